@@ -1,81 +1,171 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"syscall"
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/turnertastic1/boltq/internal/events"
 	"github.com/turnertastic1/boltq/internal/handler"
+	"github.com/turnertastic1/boltq/internal/outbox"
 	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/reconciler"
+	"github.com/turnertastic1/boltq/internal/scheduler"
 	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/webhook"
+	"github.com/turnertastic1/boltq/internal/worker"
 	"github.com/turnertastic1/boltq/pkg/queuepb"
+	bolt "go.etcd.io/bbolt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
 func main() {
+	reconcileOnce := flag.Bool("reconcile-once", false, "run a single Postgres/Redis reconciliation pass, print a summary and exit")
+	queueStats := flag.Bool("queue-stats", false, "print the length, lag and pending entries by consumer of each stream and exit (QUEUE_BACKEND=streams)")
+	embeddedPath := flag.String("embedded", "", "keep jobs and the queue in the bbolt file at this path and run without Postgres or Redis")
+	runWorker := flag.Bool("worker", false, "also run a worker for every job type in this process")
+	flag.Parse()
+
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
 
 	logger.Info("Starting BoltQ Queue Service...")
 
-	pgHost := getEnv("POSTGRES_HOST", "localhost")
-	pgPort := getEnv("POSTGRES_PORT", "5432")
-	pgUser := getEnv("POSTGRES_USER", "boltq")
-	pgPassword := getEnv("POSTGRES_PASSWORD", "boltq_dev")
-	pgDB := getEnv("POSTGRES_DB", "boltq")
+	watchConfig := events.Config{
+		BufferSize: getEnvInt("WATCH_BUFFER_SIZE", 64),
+	}
 
-	connString := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		pgHost, pgPort, pgUser, pgPassword, pgDB,
+	var (
+		jobStore   store.JobStore
+		jobQueue   queue.JobQueue
+		localHub   *events.LocalHub
+		connString string
+		err        error
 	)
-
-	logger.Info("Connecting to Postgres", "connString", connString)
-
-	// Connect to Postgres
-	db, err := sql.Open("postgres", connString)
+	if *embeddedPath != "" {
+		// Without Postgres to notify through, the store publishes job events itself
+		localHub = events.NewLocalHub(watchConfig)
+		jobStore, jobQueue, err = openEmbedded(logger, *embeddedPath, localHub.Publish)
+	} else {
+		connString = postgresConnString()
+		jobStore, jobQueue, err = openServers(logger, connString)
+	}
 	if err != nil {
-		logger.Error("Failed to open database connection", "error", err)
+		logger.Error("Failed to open the job store and queue", "error", err)
 		os.Exit(1)
 	}
-	defer db.Close()
+	// The queue goes first: in embedded mode the store owns the shared file
+	defer jobStore.Close()
+	defer jobQueue.Close()
 
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to ping database", "error", err)
-		os.Exit(1)
+	if *queueStats {
+		if err := printStreamStats(context.Background(), jobQueue, jobTypes()); err != nil {
+			logger.Error("Failed to get stream stats", "error", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	rec := reconciler.NewReconciler(logger, jobStore, jobQueue, reconciler.Config{
+		Interval:               getEnvDuration("RECONCILE_INTERVAL", 5*time.Minute),
+		StaleAfter:             getEnvDuration("RECONCILE_STALE_AFTER", 10*time.Minute),
+		BatchSize:              getEnvInt("RECONCILE_BATCH_SIZE", 500),
+		MaxAttempts:            getEnvInt("RECONCILE_MAX_ATTEMPTS", 3),
+		HeartbeatTimeout:       getEnvDuration("HEARTBEAT_TIMEOUT", 2*time.Minute),
+		HeartbeatCheckInterval: getEnvDuration("HEARTBEAT_CHECK_INTERVAL", 30*time.Second),
+	})
 
-	logger.Info("Connected to Postgres successfully")
+	if *reconcileOnce {
+		report, err := rec.ReconcileOnce(context.Background())
+		if err != nil {
+			logger.Error("Reconciliation failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("scanned=%d healthy=%d requeued=%d dead_lettered=%d skipped=%d errors=%d\n",
+			report.Scanned, report.Healthy, report.Requeued, report.DeadLettered, report.Skipped, report.Errors)
+		return
+	}
 
-	pgStore := store.NewPostgresStore(db)
-	defer pgStore.Close()
+	// Background loops run until shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// Connect to Redis
-	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
-	redisPassword := getEnv("REDIS_PASSWORD", "")
-	redisDB := 0 // Default DB
+	// Start outbox relay, unless the queue is in the jobs database and jobs
+	// are queued as they are written
+	if _, ok := jobQueue.(*queue.PostgresQueue); !ok {
+		relay := outbox.NewRelay(logger, jobStore, jobQueue, outbox.Config{
+			PollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			MinAge:       getEnvDuration("OUTBOX_MIN_AGE", 5*time.Second),
+			Retention:    getEnvDuration("OUTBOX_RETENTION", 24*time.Hour),
+		})
+		go relay.Run(ctx)
+	}
 
-	logger.Info("Connecting to Redis", "addr", redisAddr)
+	// Start reconciler
+	go rec.Run(ctx)
 
-	redisQueue, err := queue.NewRedisQueue(redisAddr, redisPassword, redisDB)
-	if err != nil {
-		logger.Error("Failed to connect to Redis", "error", err)
-		os.Exit(1)
+	// Start scheduled job promoter
+	promoter := scheduler.NewPromoter(logger, jobStore, jobQueue, scheduler.Config{
+		Interval:  getEnvDuration("PROMOTER_INTERVAL", time.Second),
+		BatchSize: getEnvInt("PROMOTER_BATCH_SIZE", 500),
+		JobTypes:  jobTypes(),
+	})
+	go promoter.Run(ctx)
+
+	// Return jobs leased by remote workers that went away to their queues
+	go reapLeases(ctx, logger, jobQueue, jobTypes(), getEnvDuration("LEASE_REAP_INTERVAL", 30*time.Second))
+
+	// Start job event hub for WatchJob and WatchJobs. Events come from Postgres
+	// notifications, or from the store itself in embedded mode.
+	var eventSource events.Source
+	if localHub != nil {
+		eventSource = localHub
+		go func() {
+			<-ctx.Done()
+			localHub.Close()
+		}()
+	} else {
+		hub, err := events.NewHub(logger, connString, watchConfig)
+		if err != nil {
+			logger.Error("Failed to start job event hub", "error", err)
+			os.Exit(1)
+		}
+		go hub.Run(ctx)
+		eventSource = hub
 	}
-	defer redisQueue.Close()
 
-	logger.Info("Connected to Redis successfully")
+	// Run jobs in this process too, stopping with the background loops
+	var workerDone chan struct{}
+	if *runWorker {
+		w, err := newWorker(logger, jobStore, jobQueue)
+		if err != nil {
+			logger.Error("Failed to set up the worker", "error", err)
+			os.Exit(1)
+		}
+
+		workerDone = make(chan struct{})
+		go func() {
+			defer close(workerDone)
+			if err := w.Run(ctx); err != nil {
+				logger.Error("Worker stopped with error", "error", err)
+			}
+		}()
+	}
 
 	// Start gRPC server
 	lis, err := net.Listen("tcp", ":50051")
@@ -86,7 +176,17 @@ func main() {
 
 	grpcServer := grpc.NewServer()
 
-	queueHandler := handler.NewQueueHandler(logger, pgStore, redisQueue)
+	leaseSecret := getEnv("LEASE_TOKEN_SECRET", "")
+	if leaseSecret == "" && *embeddedPath == "" {
+		logger.Warn("LEASE_TOKEN_SECRET is not set, so lease tokens only work on the replica that issued them")
+	}
+
+	queueHandler := handler.NewQueueHandler(logger, jobStore, jobQueue, eventSource, handler.Config{
+		IdempotencyRetention: getEnvDuration("IDEMPOTENCY_RETENTION", 24*time.Hour),
+		StreamBatchSize:      getEnvInt("ENQUEUE_STREAM_BATCH_SIZE", 100),
+		StreamFlushInterval:  getEnvDuration("ENQUEUE_STREAM_FLUSH_INTERVAL", 5*time.Millisecond),
+		LeaseSecret:          []byte(leaseSecret),
+	})
 	queuepb.RegisterQueueServiceServer(grpcServer, queueHandler)
 
 	reflection.Register(grpcServer)
@@ -100,6 +200,8 @@ func main() {
 		<-sigChan
 
 		logger.Info("Shutting down gracefully...")
+		// Stops the event hub too, which ends open watches so the server can stop
+		cancel()
 		grpcServer.GracefulStop()
 	}()
 
@@ -108,6 +210,255 @@ func main() {
 		logger.Error("Failed to serve gRPC server", "error", err)
 		os.Exit(1)
 	}
+
+	// Give in-flight jobs their shutdown timeout before the store closes
+	if workerDone != nil {
+		<-workerDone
+		logger.Info("Worker stopped")
+	}
+}
+
+// reapLeases returns messages whose lease expired to their queue every
+// interval until ctx is cancelled. Workers reap the types they run; this covers
+// types run only by remote workers, which lease jobs through FetchJobs.
+func reapLeases(ctx context.Context, logger *slog.Logger, q queue.JobQueue, types []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, jobType := range types {
+			// Messages found without a lease get the default FetchJobs lease
+			reaped, err := q.ReapExpired(ctx, jobType, handler.DefaultLeaseDuration)
+			if err != nil {
+				logger.Error("Failed to reap expired leases", "error", err, "type", jobType)
+				continue
+			}
+			if reaped > 0 {
+				logger.Warn("Returned jobs with expired leases to the queue", "type", jobType, "count", reaped)
+			}
+		}
+	}
+}
+
+// printStreamStats prints one line per stream of each job type, with the
+// entries each consumer holds, so operators can see who is holding what.
+func printStreamStats(ctx context.Context, q queue.JobQueue, types []string) error {
+	sq, ok := q.(*queue.StreamQueue)
+	if !ok {
+		return errors.New("-queue-stats needs QUEUE_BACKEND=streams")
+	}
+
+	for _, jobType := range types {
+		stats, err := sq.Stats(ctx, jobType)
+		if err != nil {
+			return err
+		}
+		for _, s := range stats {
+			consumers := make([]string, 0, len(s.Pending))
+			for consumer := range s.Pending {
+				consumers = append(consumers, consumer)
+			}
+			sort.Strings(consumers)
+
+			fmt.Printf("stream=%s length=%d lag=%d", s.Stream, s.Length, s.Lag)
+			for _, consumer := range consumers {
+				fmt.Printf(" pending[%s]=%d", consumer, s.Pending[consumer])
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+// jobTypes returns the name of every job type in the service definition.
+func jobTypes() []string {
+	var types []string
+	for value, name := range queuepb.JobType_name {
+		if value != int32(queuepb.JobType_JOB_TYPE_UNSPECIFIED) {
+			types = append(types, name)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// postgresConnString builds the Postgres connection string from POSTGRES_*.
+func postgresConnString() string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		getEnv("POSTGRES_HOST", "localhost"),
+		getEnv("POSTGRES_PORT", "5432"),
+		getEnv("POSTGRES_USER", "boltq"),
+		getEnv("POSTGRES_PASSWORD", "boltq_dev"),
+		getEnv("POSTGRES_DB", "boltq"),
+	)
+}
+
+// openServers connects to Postgres for the job store and to the queue backend
+// named by QUEUE_BACKEND.
+func openServers(logger *slog.Logger, connString string) (store.JobStore, queue.JobQueue, error) {
+	logger.Info("Connecting to Postgres", "connString", connString)
+
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	logger.Info("Connected to Postgres successfully")
+
+	// Connect to the queue: Redis lists, Redis Streams or the jobs database, per QUEUE_BACKEND
+	jobQueue, err := openQueue(logger, db, connString)
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to connect to the queue: %w", err)
+	}
+	if _, ok := jobQueue.(*queue.PostgresQueue); ok {
+		// Jobs are queued in the transaction that writes them
+		return store.NewPostgresStoreWithQueue(db, outbox.QueueTx{}), jobQueue, nil
+	}
+	return store.NewPostgresStore(db), jobQueue, nil
+}
+
+// openEmbedded keeps both jobs and the queue in the bbolt file at path,
+// creating it if needed, so the service runs as a single binary without
+// Postgres or Redis. bbolt locks the file, so one process uses it at a time.
+// The store passes its job events to publish.
+func openEmbedded(logger *slog.Logger, path string, publish func(events.Event)) (store.JobStore, queue.JobQueue, error) {
+	logger.Info("Opening embedded store", "path", path)
+
+	// Fail rather than wait if another process holds the file
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	jobStore, err := store.NewBoltStore(db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	jobStore.PublishEvents(publish)
+
+	jobQueue, err := queue.NewBoltQueue(db)
+	if err != nil {
+		jobStore.Close()
+		return nil, nil, err
+	}
+
+	logger.Info("Opened embedded store successfully")
+	return jobStore, jobQueue, nil
+}
+
+// newWorker sets up a worker for every job type, configured from the same
+// WORKER_* and WEBHOOK_* variables as cmd/worker.
+func newWorker(logger *slog.Logger, jobStore store.JobStore, jobQueue queue.JobQueue) (*worker.Worker, error) {
+	// Share of dequeues that prefer each priority level, as high:normal:low
+	weights, err := queue.ParsePriorityWeights(getEnv("WORKER_PRIORITY_WEIGHTS", "8:4:1"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid priority weights: %w", err)
+	}
+	if err := jobQueue.SetPriorityWeights(weights); err != nil {
+		return nil, fmt.Errorf("failed to set priority weights: %w", err)
+	}
+
+	w := worker.NewWorker(logger, jobStore, jobQueue, worker.Config{
+		PollTimeout:       getEnvDuration("WORKER_POLL_TIMEOUT", 2*time.Second),
+		ShutdownTimeout:   getEnvDuration("WORKER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		VisibilityTimeout: getEnvDuration("WORKER_VISIBILITY_TIMEOUT", 5*time.Minute),
+		ReapInterval:      getEnvDuration("WORKER_REAP_INTERVAL", 30*time.Second),
+		HeartbeatInterval: getEnvDuration("WORKER_HEARTBEAT_INTERVAL", 30*time.Second),
+		// Defaults to the host name and process ID
+		WorkerID: getEnv("WORKER_ID", ""),
+	})
+
+	defaultConcurrency := getEnvInt("WORKER_CONCURRENCY", 4)
+
+	standardType := queuepb.JobType_JOB_STANDARD.String()
+	w.Register(standardType, handleStandardJob(logger), getEnvInt("WORKER_CONCURRENCY_"+standardType, defaultConcurrency))
+
+	webhookPolicy, err := webhook.ParsePolicy(
+		getEnv("WEBHOOK_RETRYABLE_STATUSES", "408,425,429,500-599"),
+		getEnv("WEBHOOK_RETRY_NETWORK_ERRORS", "true") == "true",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook retry policy: %w", err)
+	}
+
+	webhookHandler := webhook.NewHandler(logger, jobStore, &http.Client{}, webhookPolicy,
+		getEnvInt("WEBHOOK_MAX_RESPONSE_BODY", webhook.DefaultMaxResponseBody))
+
+	webhookType := queuepb.JobType_JOB_WEBHOOK.String()
+	w.Register(webhookType, webhookHandler.Handle, getEnvInt("WORKER_CONCURRENCY_"+webhookType, defaultConcurrency))
+
+	return w, nil
+}
+
+// handleStandardJob processes JOB_STANDARD jobs. Standard payloads are opaque,
+// so the job is acknowledged once it has been received.
+func handleStandardJob(logger *slog.Logger) worker.HandlerFunc {
+	return func(ctx context.Context, job *store.Job) error {
+		logger.Info("Handling standard job", "job_id", job.ID.String(), "payload_size", len(job.Payload))
+		return nil
+	}
+}
+
+// openQueue connects to the queue backend named by QUEUE_BACKEND: "redis" (the
+// default), "streams" for Redis Streams with consumer groups, or "postgres" to
+// keep the queue in the jobs database and run without Redis. Every process of
+// a deployment must use the same backend.
+func openQueue(logger *slog.Logger, db *sql.DB, connString string) (queue.JobQueue, error) {
+	switch backend := getEnv("QUEUE_BACKEND", "redis"); backend {
+	case "redis":
+		redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+		redisPassword := getEnv("REDIS_PASSWORD", "")
+		redisDB := 0 // Default DB
+
+		logger.Info("Connecting to Redis", "addr", redisAddr)
+		rq, err := queue.NewRedisQueue(redisAddr, redisPassword, redisDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Info("Connected to Redis successfully")
+		return rq, nil
+
+	case "streams":
+		redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+
+		logger.Info("Connecting to Redis Streams", "addr", redisAddr)
+		sq, err := queue.NewStreamQueue(redisAddr, getEnv("REDIS_PASSWORD", ""), 0, queue.StreamConfig{
+			Group:    getEnv("STREAM_GROUP", queue.DefaultStreamGroup),
+			Consumer: getEnv("STREAM_CONSUMER", ""),
+			MaxLen:   int64(getEnvInt("STREAM_MAX_LEN", 0)),
+			MaxAge:   getEnvDuration("STREAM_MAX_AGE", 0),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Info("Connected to Redis successfully")
+		return sq, nil
+
+	case "postgres":
+		logger.Info("Using the Postgres queue")
+		return queue.NewPostgresQueue(db, connString)
+
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_BACKEND %q: want redis, streams or postgres", backend)
+	}
 }
 
 // Helper function to get environment variable with default
@@ -117,3 +468,23 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// Helper function to get an integer environment variable with default
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// Helper function to get a duration environment variable (e.g. "30s") with default
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
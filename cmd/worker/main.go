@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/turnertastic1/boltq/internal/outbox"
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/webhook"
+	"github.com/turnertastic1/boltq/internal/worker"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+
+	logger.Info("Starting BoltQ Worker...")
+
+	pgHost := getEnv("POSTGRES_HOST", "localhost")
+	pgPort := getEnv("POSTGRES_PORT", "5432")
+	pgUser := getEnv("POSTGRES_USER", "boltq")
+	pgPassword := getEnv("POSTGRES_PASSWORD", "boltq_dev")
+	pgDB := getEnv("POSTGRES_DB", "boltq")
+
+	connString := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		pgHost, pgPort, pgUser, pgPassword, pgDB,
+	)
+
+	logger.Info("Connecting to Postgres", "host", pgHost, "port", pgPort, "db", pgDB)
+
+	// Connect to Postgres
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		logger.Error("Failed to open database connection", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		logger.Error("Failed to ping database", "error", err)
+		os.Exit(1)
+	}
+
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	logger.Info("Connected to Postgres successfully")
+
+	// Connect to the queue: Redis lists, Redis Streams or the jobs database, per QUEUE_BACKEND
+	jobQueue, err := openQueue(logger, db, connString)
+	if err != nil {
+		logger.Error("Failed to connect to the queue", "error", err)
+		os.Exit(1)
+	}
+	defer jobQueue.Close()
+
+	var jobStore store.JobStore = store.NewPostgresStore(db)
+	if _, ok := jobQueue.(*queue.PostgresQueue); ok {
+		// Retries are queued in the transaction that schedules them
+		jobStore = store.NewPostgresStoreWithQueue(db, outbox.QueueTx{})
+	}
+	defer jobStore.Close()
+
+	// Share of dequeues that prefer each priority level, as high:normal:low
+	weights, err := queue.ParsePriorityWeights(getEnv("WORKER_PRIORITY_WEIGHTS", "8:4:1"))
+	if err != nil {
+		logger.Error("Invalid priority weights", "error", err)
+		os.Exit(1)
+	}
+	if err := jobQueue.SetPriorityWeights(weights); err != nil {
+		logger.Error("Failed to set priority weights", "error", err)
+		os.Exit(1)
+	}
+
+	w := worker.NewWorker(logger, jobStore, jobQueue, worker.Config{
+		PollTimeout:       getEnvDuration("WORKER_POLL_TIMEOUT", 2*time.Second),
+		ShutdownTimeout:   getEnvDuration("WORKER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		VisibilityTimeout: getEnvDuration("WORKER_VISIBILITY_TIMEOUT", 5*time.Minute),
+		ReapInterval:      getEnvDuration("WORKER_REAP_INTERVAL", 30*time.Second),
+		HeartbeatInterval: getEnvDuration("WORKER_HEARTBEAT_INTERVAL", 30*time.Second),
+		// Defaults to the host name and process ID
+		WorkerID: getEnv("WORKER_ID", ""),
+	})
+
+	defaultConcurrency := getEnvInt("WORKER_CONCURRENCY", 4)
+
+	// Concurrency can be tuned per job type, e.g. WORKER_CONCURRENCY_JOB_STANDARD=8
+	standardType := queuepb.JobType_JOB_STANDARD.String()
+	w.Register(standardType, handleStandardJob(logger), getEnvInt("WORKER_CONCURRENCY_"+standardType, defaultConcurrency))
+
+	// Webhook delivery
+	webhookPolicy, err := webhook.ParsePolicy(
+		getEnv("WEBHOOK_RETRYABLE_STATUSES", "408,425,429,500-599"),
+		getEnv("WEBHOOK_RETRY_NETWORK_ERRORS", "true") == "true",
+	)
+	if err != nil {
+		logger.Error("Invalid webhook retry policy", "error", err)
+		os.Exit(1)
+	}
+
+	webhookHandler := webhook.NewHandler(logger, jobStore, &http.Client{}, webhookPolicy,
+		getEnvInt("WEBHOOK_MAX_RESPONSE_BODY", webhook.DefaultMaxResponseBody))
+
+	webhookType := queuepb.JobType_JOB_WEBHOOK.String()
+	w.Register(webhookType, webhookHandler.Handle, getEnvInt("WORKER_CONCURRENCY_"+webhookType, defaultConcurrency))
+
+	// Graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := w.Run(ctx); err != nil {
+		logger.Error("Worker stopped with error", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Worker stopped")
+}
+
+// handleStandardJob processes JOB_STANDARD jobs. Standard payloads are opaque,
+// so the job is acknowledged once it has been received.
+func handleStandardJob(logger *slog.Logger) worker.HandlerFunc {
+	return func(ctx context.Context, job *store.Job) error {
+		logger.Info("Handling standard job", "job_id", job.ID.String(), "payload_size", len(job.Payload))
+		return nil
+	}
+}
+
+// openQueue connects to the queue backend named by QUEUE_BACKEND: "redis" (the
+// default), "streams" for Redis Streams with consumer groups, or "postgres" to
+// keep the queue in the jobs database and run without Redis. Every process of
+// a deployment must use the same backend.
+func openQueue(logger *slog.Logger, db *sql.DB, connString string) (queue.JobQueue, error) {
+	switch backend := getEnv("QUEUE_BACKEND", "redis"); backend {
+	case "redis":
+		redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+		redisPassword := getEnv("REDIS_PASSWORD", "")
+		redisDB := 0 // Default DB
+
+		logger.Info("Connecting to Redis", "addr", redisAddr)
+		rq, err := queue.NewRedisQueue(redisAddr, redisPassword, redisDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Info("Connected to Redis successfully")
+		return rq, nil
+
+	case "streams":
+		redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+
+		logger.Info("Connecting to Redis Streams", "addr", redisAddr)
+		sq, err := queue.NewStreamQueue(redisAddr, getEnv("REDIS_PASSWORD", ""), 0, queue.StreamConfig{
+			Group:    getEnv("STREAM_GROUP", queue.DefaultStreamGroup),
+			Consumer: getEnv("STREAM_CONSUMER", getEnv("WORKER_ID", "")),
+			MaxLen:   int64(getEnvInt("STREAM_MAX_LEN", 0)),
+			MaxAge:   getEnvDuration("STREAM_MAX_AGE", 0),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Info("Connected to Redis successfully")
+		return sq, nil
+
+	case "postgres":
+		logger.Info("Using the Postgres queue")
+		return queue.NewPostgresQueue(db, connString)
+
+	default:
+		return nil, fmt.Errorf("unknown QUEUE_BACKEND %q: want redis, streams or postgres", backend)
+	}
+}
+
+// Helper function to get environment variable with default
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Helper function to get an integer environment variable with default
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// Helper function to get a duration environment variable (e.g. "30s") with default
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
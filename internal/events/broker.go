@@ -0,0 +1,105 @@
+package events
+
+import "sync"
+
+// Subscription receives the events matching its filter on C until it is
+// closed, or the hub ends it; Err then reports why.
+type Subscription struct {
+	C <-chan Event
+
+	broker *broker
+	filter Filter
+	events chan Event
+	err    error
+}
+
+// Err returns why the hub ended the subscription, or nil while it is open or
+// if it was closed by its owner. Call it once C is closed.
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.err
+}
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s, nil)
+}
+
+// broker holds the subscriptions of a hub and fans events out to them.
+type broker struct {
+	bufferSize int
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func newBroker(bufferSize int) broker {
+	return broker{
+		bufferSize: bufferSize,
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+func (b *broker) subscribe(filter Filter) *Subscription {
+	events := make(chan Event, b.bufferSize)
+	sub := &Subscription{C: events, broker: b, filter: filter, events: events}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		sub.err = ErrClosed
+		close(events)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+func (b *broker) dispatch(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !sub.filter.Matches(ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			b.remove(sub, ErrSlowSubscriber)
+		}
+	}
+}
+
+func (b *broker) endAll(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		b.remove(sub, err)
+	}
+}
+
+// remove ends a subscription with err. b.mu must be held.
+func (b *broker) remove(sub *Subscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.events)
+}
+
+// close ends every subscription with ErrClosed, as well as any started later.
+func (b *broker) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	b.endAll(ErrClosed)
+}
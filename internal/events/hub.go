@@ -0,0 +1,235 @@
+// Package events streams job status changes and progress to watchers.
+//
+// Triggers on the jobs table publish every status change and progress report
+// with pg_notify, so changes made by any queue-svc replica or worker reach
+// every listener. A Hub holds one LISTEN connection per process and fans the
+// events out to its subscribers.
+//
+// Embedded mode has no Postgres to notify through, so its store publishes to
+// a LocalHub in the same process instead. Both are a Source to watchers.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres notification channel job events are published on.
+const Channel = "boltq_job_events"
+
+const (
+	defaultBufferSize           = 64
+	defaultMinReconnectInterval = 100 * time.Millisecond
+	defaultMaxReconnectInterval = 10 * time.Second
+	pingInterval                = 60 * time.Second
+)
+
+var (
+	// ErrSlowSubscriber ends a subscription that did not keep up with events.
+	ErrSlowSubscriber = errors.New("subscriber fell behind")
+	// ErrInterrupted ends subscriptions when the LISTEN connection was lost,
+	// as events published while it was down are missed.
+	ErrInterrupted = errors.New("event stream interrupted")
+	// ErrClosed ends subscriptions when the hub stops.
+	ErrClosed = errors.New("event hub closed")
+)
+
+// Event is a job changing status or reporting progress.
+type Event struct {
+	JobID  uuid.UUID
+	Type   string
+	Status string
+	Tags   map[string]string
+	// Set on progress events, which leave the status unchanged
+	Progress *Progress
+	At       time.Time
+}
+
+// Progress is what the worker running a job reported about it.
+type Progress struct {
+	Percent   int
+	Message   string
+	UpdatedAt time.Time
+}
+
+// notification is the payload published by the notify_job_event trigger.
+type notification struct {
+	JobID    uuid.UUID         `json:"job_id"`
+	Type     string            `json:"type"`
+	Status   string            `json:"status"`
+	Tags     map[string]string `json:"tags"`
+	Progress *struct {
+		Percent     int    `json:"percent"`
+		Message     string `json:"message"`
+		UpdatedAtUs int64  `json:"updated_at_us"`
+	} `json:"progress"`
+	AtUs int64 `json:"at_us"`
+}
+
+// ParseEvent decodes a notification payload.
+func ParseEvent(payload string) (Event, error) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return Event{}, fmt.Errorf("invalid job event: %w", err)
+	}
+
+	ev := Event{
+		JobID:  n.JobID,
+		Type:   n.Type,
+		Status: n.Status,
+		Tags:   n.Tags,
+		At:     time.UnixMicro(n.AtUs).UTC(),
+	}
+	if n.Progress != nil {
+		ev.Progress = &Progress{
+			Percent:   n.Progress.Percent,
+			Message:   n.Progress.Message,
+			UpdatedAt: time.UnixMicro(n.Progress.UpdatedAtUs).UTC(),
+		}
+	}
+
+	return ev, nil
+}
+
+// Source delivers job events to subscribers.
+type Source interface {
+	// Subscribe starts a subscription to the events matching filter. Events
+	// published before Subscribe returns are not delivered.
+	Subscribe(filter Filter) *Subscription
+}
+
+// Filter selects the events a subscription receives. Zero fields match every event.
+type Filter struct {
+	JobID uuid.UUID
+	Type  string
+	// Tags matches jobs carrying every one of these tags
+	Tags map[string]string
+}
+
+// Matches reports whether e passes the filter.
+func (f Filter) Matches(e Event) bool {
+	if f.JobID != uuid.Nil && f.JobID != e.JobID {
+		return false
+	}
+	if f.Type != "" && f.Type != e.Type {
+		return false
+	}
+	for k, v := range f.Tags {
+		if tag, ok := e.Tags[k]; !ok || tag != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Config controls a Hub.
+type Config struct {
+	// BufferSize is how many events a subscriber may fall behind by before
+	// its subscription is ended with ErrSlowSubscriber.
+	BufferSize int
+	// MinReconnectInterval and MaxReconnectInterval bound the backoff
+	// between attempts to re-establish a lost LISTEN connection.
+	MinReconnectInterval time.Duration
+	MaxReconnectInterval time.Duration
+}
+
+// Hub listens for job events and fans them out to subscribers.
+type Hub struct {
+	logger   *slog.Logger
+	listener *pq.Listener
+	cfg      Config
+
+	broker
+}
+
+// NewHub connects to Postgres and starts listening for job events. Zero
+// values in cfg are replaced with defaults. Call Run to deliver the events.
+func NewHub(l *slog.Logger, connString string, cfg Config) (*Hub, error) {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	if cfg.MinReconnectInterval <= 0 {
+		cfg.MinReconnectInterval = defaultMinReconnectInterval
+	}
+	if cfg.MaxReconnectInterval <= 0 {
+		cfg.MaxReconnectInterval = defaultMaxReconnectInterval
+	}
+
+	listener := pq.NewListener(connString, cfg.MinReconnectInterval, cfg.MaxReconnectInterval,
+		func(ev pq.ListenerEventType, err error) {
+			if err != nil {
+				l.Warn("Job event listener connection problem", "event", ev, "error", err)
+			}
+		})
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen for job events: %w", err)
+	}
+
+	return &Hub{
+		logger:   l,
+		listener: listener,
+		cfg:      cfg,
+		broker:   newBroker(cfg.BufferSize),
+	}, nil
+}
+
+// Run delivers events to subscribers until ctx is cancelled, then ends every
+// subscription with ErrClosed and closes the LISTEN connection.
+func (h *Hub) Run(ctx context.Context) {
+	defer h.shutdown()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Detects a silently dropped connection, which then reconnects
+			go func() {
+				if err := h.listener.Ping(); err != nil {
+					h.logger.Warn("Job event listener ping failed", "error", err)
+				}
+			}()
+		case n, ok := <-h.listener.Notify:
+			if !ok {
+				return
+			}
+			if n == nil {
+				// Sent after reconnecting; anything published meanwhile is lost
+				h.logger.Warn("Job event listener reconnected, ending subscriptions")
+				h.endAll(ErrInterrupted)
+				continue
+			}
+
+			ev, err := ParseEvent(n.Extra)
+			if err != nil {
+				h.logger.Warn("Dropping malformed job event", "error", err)
+				continue
+			}
+			h.dispatch(ev)
+		}
+	}
+}
+
+// Subscribe starts a subscription to the events matching filter. Events
+// published before Subscribe returns are not delivered.
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	return h.subscribe(filter)
+}
+
+func (h *Hub) shutdown() {
+	h.close()
+	if err := h.listener.Close(); err != nil {
+		h.logger.Warn("Failed to close job event listener", "error", err)
+	}
+}
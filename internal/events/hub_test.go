@@ -0,0 +1,175 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+// newTestHub returns a hub without a LISTEN connection, fed through dispatch.
+func newTestHub(bufferSize int) *Hub {
+	return &Hub{
+		logger: slog.Default(),
+		cfg:    Config{BufferSize: bufferSize},
+		broker: newBroker(bufferSize),
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	jobID := uuid.New()
+
+	ev, err := ParseEvent(`{"job_id":"` + jobID.String() + `","type":"JOB_WEBHOOK","status":"processing","tags":{"tenant":"acme"},"at_us":1700000000123456}`)
+	require.NoError(t, err)
+	assert.Equal(t, jobID, ev.JobID)
+	assert.Equal(t, "JOB_WEBHOOK", ev.Type)
+	assert.Equal(t, "processing", ev.Status)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, ev.Tags)
+	assert.Equal(t, time.UnixMicro(1700000000123456).UTC(), ev.At)
+	assert.Nil(t, ev.Progress)
+
+	ev, err = ParseEvent(`{"job_id":"` + jobID.String() + `","type":"JOB_WEBHOOK","status":"processing","tags":null,` +
+		`"progress":{"percent":40,"message":"halfway there","updated_at_us":1700000000000000},"at_us":1700000000123456}`)
+	require.NoError(t, err)
+	require.NotNil(t, ev.Progress)
+	assert.Equal(t, 40, ev.Progress.Percent)
+	assert.Equal(t, "halfway there", ev.Progress.Message)
+	assert.Equal(t, time.UnixMicro(1700000000000000).UTC(), ev.Progress.UpdatedAt)
+
+	_, err = ParseEvent("not json")
+	assert.Error(t, err)
+}
+
+func TestFilter_Matches(t *testing.T) {
+	ev := Event{JobID: uuid.New(), Type: "JOB_WEBHOOK", Tags: map[string]string{"tenant": "acme", "env": "prod"}}
+
+	assert.True(t, Filter{}.Matches(ev))
+	assert.True(t, Filter{JobID: ev.JobID}.Matches(ev))
+	assert.True(t, Filter{Type: "JOB_WEBHOOK", Tags: map[string]string{"tenant": "acme"}}.Matches(ev))
+
+	assert.False(t, Filter{JobID: uuid.New()}.Matches(ev))
+	assert.False(t, Filter{Type: "JOB_STANDARD"}.Matches(ev))
+	assert.False(t, Filter{Tags: map[string]string{"tenant": "globex"}}.Matches(ev))
+	assert.False(t, Filter{Tags: map[string]string{"region": "eu"}}.Matches(ev))
+}
+
+func TestHub_DispatchesToMatchingSubscribers(t *testing.T) {
+	hub := newTestHub(4)
+
+	webhooks := hub.Subscribe(Filter{Type: "JOB_WEBHOOK"})
+	defer webhooks.Close()
+	standard := hub.Subscribe(Filter{Type: "JOB_STANDARD"})
+	defer standard.Close()
+
+	ev := Event{JobID: uuid.New(), Type: "JOB_WEBHOOK", Status: "queued"}
+	hub.dispatch(ev)
+
+	select {
+	case got := <-webhooks.C:
+		assert.Equal(t, ev, got)
+	default:
+		t.Fatal("matching subscriber got no event")
+	}
+	assert.Empty(t, standard.C)
+}
+
+func TestHub_EndsSlowSubscriber(t *testing.T) {
+	hub := newTestHub(1)
+	sub := hub.Subscribe(Filter{})
+
+	hub.dispatch(Event{Status: "queued"})
+	hub.dispatch(Event{Status: "processing"})
+
+	<-sub.C
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrSlowSubscriber)
+
+	// Closing an ended subscription is a no-op
+	sub.Close()
+	assert.ErrorIs(t, sub.Err(), ErrSlowSubscriber)
+}
+
+func TestHub_EndAll(t *testing.T) {
+	hub := newTestHub(1)
+	sub := hub.Subscribe(Filter{})
+
+	hub.endAll(ErrInterrupted)
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrInterrupted)
+}
+
+func TestHub_ReceivesJobStatusChanges(t *testing.T) {
+	db, connString := testutil.StartPostgresWithConnString(t)
+
+	hub, err := NewHub(slog.Default(), connString, Config{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	jobID := uuid.New()
+	sub := hub.Subscribe(Filter{JobID: jobID})
+	defer sub.Close()
+
+	_, err = db.Exec(`INSERT INTO jobs (id, type, payload, status, tags) VALUES ($1, 'JOB_STANDARD', 'x', 'queued', '{"tenant":"acme"}')`, jobID)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE jobs SET attempts = 1 WHERE id = $1`, jobID)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE jobs SET status = 'processing' WHERE id = $1`, jobID)
+	require.NoError(t, err)
+
+	for _, want := range []string{"queued", "processing"} {
+		select {
+		case ev := <-sub.C:
+			assert.Equal(t, jobID, ev.JobID)
+			assert.Equal(t, want, ev.Status)
+			assert.Equal(t, map[string]string{"tenant": "acme"}, ev.Tags)
+			assert.WithinDuration(t, time.Now(), ev.At, time.Minute)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("never received %s event", want)
+		}
+	}
+
+	// Updates that leave the status alone publish nothing
+	select {
+	case ev := <-sub.C:
+		t.Fatalf("unexpected event %+v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	_, err = db.Exec(`UPDATE jobs SET progress_percent = 30, progress_message = 'warming up', progress_updated_at = NOW() WHERE id = $1`, jobID)
+	require.NoError(t, err)
+
+	select {
+	case ev := <-sub.C:
+		assert.Equal(t, "processing", ev.Status)
+		require.NotNil(t, ev.Progress)
+		assert.Equal(t, 30, ev.Progress.Percent)
+		assert.Equal(t, "warming up", ev.Progress.Message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("never received progress event")
+	}
+
+	cancel()
+	<-done
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrClosed)
+}
@@ -0,0 +1,33 @@
+package events
+
+// LocalHub fans out the events published in its own process, for a store no
+// other process writes to.
+type LocalHub struct {
+	broker
+}
+
+// NewLocalHub returns a hub that delivers the events passed to Publish. Only
+// the BufferSize of cfg applies; zero is replaced with the default.
+func NewLocalHub(cfg Config) *LocalHub {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	return &LocalHub{broker: newBroker(cfg.BufferSize)}
+}
+
+// Subscribe starts a subscription to the events matching filter. Events
+// published before Subscribe returns are not delivered.
+func (h *LocalHub) Subscribe(filter Filter) *Subscription {
+	return h.subscribe(filter)
+}
+
+// Publish delivers ev to the subscriptions it matches. It never blocks:
+// subscribers that fell behind are ended with ErrSlowSubscriber.
+func (h *LocalHub) Publish(ev Event) {
+	h.dispatch(ev)
+}
+
+// Close ends every subscription with ErrClosed.
+func (h *LocalHub) Close() {
+	h.close()
+}
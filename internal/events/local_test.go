@@ -0,0 +1,37 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalHub_PublishAndClose(t *testing.T) {
+	hub := NewLocalHub(Config{})
+
+	jobID := uuid.New()
+	sub := hub.Subscribe(Filter{JobID: jobID})
+	defer sub.Close()
+
+	hub.Publish(Event{JobID: uuid.New(), Status: "queued"})
+	ev := Event{JobID: jobID, Status: "processing", Progress: &Progress{Percent: 40}}
+	hub.Publish(ev)
+
+	got, ok := <-sub.C
+	require.True(t, ok)
+	assert.Equal(t, ev, got)
+	assert.Empty(t, sub.C)
+
+	hub.Close()
+	_, ok = <-sub.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrClosed)
+
+	// Subscriptions started after Close end straight away
+	late := hub.Subscribe(Filter{})
+	_, ok = <-late.C
+	assert.False(t, ok)
+	assert.ErrorIs(t, late.Err(), ErrClosed)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *QueueHandler) ListJobAttempts(ctx context.Context, req *queuepb.ListJobAttemptsRequest) (*queuepb.ListJobAttemptsResponse, error) {
+	jobID, err := uuid.Parse(req.GetJobId())
+	if err != nil {
+		h.logger.Warn("Invalid job ID", "job_id", req.GetJobId())
+		return nil, status.Error(codes.InvalidArgument, "invalid job ID")
+	}
+
+	// An empty history does not tell a missing job from one that has not run yet
+	if _, err := h.store.GetJobByID(ctx, jobID); err != nil {
+		if errors.Is(err, store.ErrJobNotFound) {
+			return nil, status.Errorf(codes.NotFound, "job %s not found", jobID)
+		}
+		h.logger.Error("Failed to get job from store", "error", err, "job_id", jobID.String())
+		return nil, status.Error(codes.Internal, "failed to list job attempts")
+	}
+
+	attempts, err := h.store.ListJobAttempts(ctx, jobID)
+	if err != nil {
+		h.logger.Error("Failed to list job attempts", "error", err, "job_id", jobID.String())
+		return nil, status.Error(codes.Internal, "failed to list job attempts")
+	}
+
+	resp := &queuepb.ListJobAttemptsResponse{}
+	for _, a := range attempts {
+		resp.Attempts = append(resp.Attempts, toProtoJobAttempt(a))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestListJobAttempts(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("flaky", ""))
+	require.NoError(t, err)
+
+	// Not run yet
+	resp, err := deps.handler.ListJobAttempts(ctx, &queuepb.ListJobAttemptsRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Attempts)
+
+	fetch := func(workerID string) *queuepb.LeasedJob {
+		fetched, err := deps.handler.FetchJobs(ctx, &queuepb.FetchJobsRequest{
+			Types:    []queuepb.JobType{queuepb.JobType_JOB_STANDARD},
+			Wait:     durationpb.New(5 * time.Second),
+			WorkerId: workerID,
+		})
+		require.NoError(t, err)
+		require.Len(t, fetched.Jobs, 1)
+		return fetched.Jobs[0]
+	}
+
+	leased := fetch("remote-a")
+	_, err = deps.handler.NackJob(ctx, &queuepb.NackJobRequest{
+		LeaseToken: leased.LeaseToken,
+		Error:      "upstream timeout",
+		ErrorCode:  "timeout",
+		RetryDelay: durationpb.New(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// What the promoter does once the retry is due
+	require.NoError(t, deps.queue.Enqueue(ctx, uuid.MustParse(enqueueResp.JobId), "JOB_STANDARD", 0))
+	leased = fetch("remote-b")
+
+	resp, err = deps.handler.ListJobAttempts(ctx, &queuepb.ListJobAttemptsRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	require.Len(t, resp.Attempts, 2)
+	assert.Equal(t, queuepb.AttemptOutcome_ATTEMPT_OUTCOME_RUNNING, resp.Attempts[1].Outcome)
+	assert.Nil(t, resp.Attempts[1].FinishedAt)
+
+	_, err = deps.handler.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken})
+	require.NoError(t, err)
+
+	resp, err = deps.handler.ListJobAttempts(ctx, &queuepb.ListJobAttemptsRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	require.Len(t, resp.Attempts, 2)
+
+	first := resp.Attempts[0]
+	assert.Equal(t, int32(1), first.Attempt)
+	assert.Equal(t, "remote-a", first.WorkerId)
+	assert.Equal(t, queuepb.AttemptOutcome_ATTEMPT_OUTCOME_FAILED, first.Outcome)
+	require.NotNil(t, first.Error)
+	assert.Equal(t, "upstream timeout", first.Error.Message)
+	assert.Equal(t, "timeout", first.Error.Code)
+	assert.NotNil(t, first.FinishedAt)
+	assert.NotNil(t, first.Latency)
+
+	second := resp.Attempts[1]
+	assert.Equal(t, int32(2), second.Attempt)
+	assert.Equal(t, "remote-b", second.WorkerId)
+	assert.Equal(t, queuepb.AttemptOutcome_ATTEMPT_OUTCOME_SUCCEEDED, second.Outcome)
+	assert.Nil(t, second.Error)
+}
+
+func TestListJobAttempts_InvalidRequests(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := deps.handler.ListJobAttempts(ctx, &queuepb.ListJobAttemptsRequest{JobId: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = deps.handler.ListJobAttempts(ctx, &queuepb.ListJobAttemptsRequest{JobId: "00000000-0000-0000-0000-000000000001"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = deps.handler.FetchJobs(ctx, &queuepb.FetchJobsRequest{
+		Types:    []queuepb.JobType{queuepb.JobType_JOB_STANDARD},
+		WorkerId: string(make([]byte, 256)),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxBatchSize = 1000
+
+// errKeyReused rolls back an atomic batch in which a job reused an
+// idempotency key held by a different job.
+var errKeyReused = errors.New("idempotency key reused for a different job")
+
+func (h *QueueHandler) EnqueueJobs(ctx context.Context, req *queuepb.EnqueueJobsRequest) (*queuepb.EnqueueJobsResponse, error) {
+	n := len(req.GetJobs())
+	if n == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no jobs to enqueue")
+	}
+	if n > maxBatchSize {
+		h.logger.Warn("Batch exceeds maximum size", "size", n)
+		return nil, status.Errorf(codes.InvalidArgument, "batch size exceeds maximum limit: %d", maxBatchSize)
+	}
+
+	h.logger.Info("Received EnqueueJobs request", "size", n, "atomic", req.GetAtomic())
+
+	results, err := h.enqueueBatch(ctx, req.GetJobs(), req.GetAtomic())
+	if err != nil {
+		return nil, err
+	}
+
+	return &queuepb.EnqueueJobsResponse{Results: results}, nil
+}
+
+// enqueueBatch creates the jobs of a batch and returns one result per request,
+// in order. Only a failure that affects the whole batch is returned as an
+// error, which is a gRPC status error.
+func (h *QueueHandler) enqueueBatch(ctx context.Context, reqs []*queuepb.EnqueueJobRequest, atomic bool) ([]*queuepb.EnqueueJobResult, error) {
+	n := len(reqs)
+	results := make([]*queuepb.EnqueueJobResult, n)
+	jobs := make([]*store.Job, n) // nil where the request was rejected
+	var valid []*store.Job
+	for i, r := range reqs {
+		job, err := h.newJob(r)
+		if err != nil {
+			results[i] = errorResult(err)
+			continue
+		}
+		jobs[i] = job
+		valid = append(valid, job)
+	}
+
+	if atomic && len(valid) < n {
+		abortRemaining(results, "not enqueued: another job in the batch was rejected")
+		return results, nil
+	}
+
+	var check func(map[string]*store.Job) error
+	reused := make(map[*store.Job]bool)
+	if atomic {
+		check = func(existing map[string]*store.Job) error {
+			for _, job := range valid {
+				if holder := keyHolder(job, existing); holder != nil && !sameJob(holder, job) {
+					reused[job] = true
+				}
+			}
+			if len(reused) > 0 {
+				return errKeyReused
+			}
+			return nil
+		}
+	}
+
+	entries, existing, err := h.store.CreateJobsWithOutbox(ctx, valid, h.cfg.IdempotencyRetention, check)
+	if errors.Is(err, errKeyReused) {
+		h.logger.Warn("Idempotency key reused in atomic batch, rejecting it", "count", len(reused))
+		for i, job := range jobs {
+			if reused[job] {
+				results[i] = errorResult(status.Error(codes.AlreadyExists, "idempotency key already used for a different job"))
+			}
+		}
+		abortRemaining(results, "not enqueued: another job in the batch was rejected")
+		return results, nil
+	}
+	if err != nil {
+		h.logger.Error("Failed to create jobs in store", "error", err, "count", len(valid))
+		return nil, status.Error(codes.Internal, "failed to enqueue jobs")
+	}
+
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		holder := keyHolder(job, existing)
+		switch {
+		case holder == nil:
+			results[i] = &queuepb.EnqueueJobResult{JobId: job.ID.String()}
+		case sameJob(holder, job):
+			results[i] = &queuepb.EnqueueJobResult{JobId: holder.ID.String()}
+		default:
+			results[i] = errorResult(status.Error(codes.AlreadyExists, "idempotency key already used for a different job"))
+		}
+	}
+
+	// The jobs are durable, so a failure here only delays them until the
+	// outbox relay publishes them
+	h.publishBatch(ctx, entries)
+
+	h.logger.Info("Job batch enqueued", "created", len(entries), "rejected", n-len(valid))
+
+	return results, nil
+}
+
+// keyHolder returns the job that already held job's idempotency key, so job
+// was not created, or nil if job was created.
+func keyHolder(job *store.Job, existing map[string]*store.Job) *store.Job {
+	if job.IdempotencyKey == nil {
+		return nil
+	}
+	holder, ok := existing[*job.IdempotencyKey]
+	if !ok || holder.ID == job.ID {
+		return nil
+	}
+	return holder
+}
+
+func errorResult(err error) *queuepb.EnqueueJobResult {
+	st := status.Convert(err)
+	return &queuepb.EnqueueJobResult{Code: int32(st.Code()), Error: st.Message()}
+}
+
+// abortRemaining fills the results not yet set with an aborted error.
+func abortRemaining(results []*queuepb.EnqueueJobResult, msg string) {
+	for i, r := range results {
+		if r == nil {
+			results[i] = errorResult(status.Error(codes.Aborted, msg))
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func standardJob(payload, key string) *queuepb.EnqueueJobRequest {
+	return &queuepb.EnqueueJobRequest{
+		Type:           queuepb.JobType_JOB_STANDARD,
+		Payload:        []byte(payload),
+		IdempotencyKey: key,
+	}
+}
+
+func TestEnqueueJobs_PartialSuccess(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{
+		Jobs: []*queuepb.EnqueueJobRequest{
+			standardJob("first", ""),
+			{Type: queuepb.JobType_JOB_STANDARD},
+			standardJob("third", ""),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 3)
+
+	assert.Equal(t, int32(codes.OK), resp.Results[0].Code)
+	assert.NotEmpty(t, resp.Results[0].JobId)
+	assert.Equal(t, int32(codes.InvalidArgument), resp.Results[1].Code)
+	assert.Empty(t, resp.Results[1].JobId)
+	assert.NotEmpty(t, resp.Results[1].Error)
+	assert.Equal(t, int32(codes.OK), resp.Results[2].Code)
+
+	for _, i := range []int{0, 2} {
+		job, err := deps.store.GetJobByID(ctx, uuid.MustParse(resp.Results[i].JobId))
+		require.NoError(t, err)
+		assert.NotNil(t, job)
+	}
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), queueLen)
+}
+
+func TestEnqueueJobs_AtomicRejectsWholeBatch(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{
+		Jobs: []*queuepb.EnqueueJobRequest{
+			standardJob("first", ""),
+			{Type: queuepb.JobType_JOB_STANDARD},
+		},
+		Atomic: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	assert.Equal(t, int32(codes.Aborted), resp.Results[0].Code)
+	assert.Equal(t, int32(codes.InvalidArgument), resp.Results[1].Code)
+
+	var count int
+	require.NoError(t, deps.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM jobs").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestEnqueueJobs_IdempotentRetry(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	req := &queuepb.EnqueueJobsRequest{
+		Jobs: []*queuepb.EnqueueJobRequest{
+			standardJob("first", "batch-1"),
+			standardJob("second", "batch-2"),
+		},
+	}
+
+	first, err := deps.handler.EnqueueJobs(ctx, req)
+	require.NoError(t, err)
+
+	// A retried batch gets the original jobs back and enqueues nothing new
+	second, err := deps.handler.EnqueueJobs(ctx, req)
+	require.NoError(t, err)
+	for i := range req.Jobs {
+		assert.Equal(t, int32(codes.OK), second.Results[i].Code)
+		assert.Equal(t, first.Results[i].JobId, second.Results[i].JobId)
+	}
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), queueLen)
+}
+
+func TestEnqueueJobs_IdempotencyKeyReused(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := deps.handler.EnqueueJob(ctx, standardJob("original", "order-7"))
+	require.NoError(t, err)
+
+	jobs := []*queuepb.EnqueueJobRequest{
+		standardJob("different", "order-7"),
+		standardJob("fresh", ""),
+	}
+
+	resp, err := deps.handler.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{Jobs: jobs})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.AlreadyExists), resp.Results[0].Code)
+	assert.Equal(t, int32(codes.OK), resp.Results[1].Code)
+
+	// In atomic mode the reuse rolls back the other job too
+	resp, err = deps.handler.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{Jobs: jobs, Atomic: true})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.AlreadyExists), resp.Results[0].Code)
+	assert.Equal(t, int32(codes.Aborted), resp.Results[1].Code)
+
+	var count int
+	require.NoError(t, deps.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM jobs").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestEnqueueJobs_DuplicateKeyInBatch(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{
+		Jobs: []*queuepb.EnqueueJobRequest{
+			standardJob("same", "dup"),
+			standardJob("same", "dup"),
+			standardJob("other", "dup"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.OK), resp.Results[0].Code)
+	assert.Equal(t, resp.Results[0].JobId, resp.Results[1].JobId)
+	assert.Equal(t, int32(codes.AlreadyExists), resp.Results[2].Code)
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queueLen)
+}
+
+func TestEnqueueJobs_InvalidBatchSize(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := deps.handler.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	jobs := make([]*queuepb.EnqueueJobRequest, maxBatchSize+1)
+	for i := range jobs {
+		jobs[i] = standardJob("payload", "")
+	}
+	_, err = deps.handler.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{Jobs: jobs})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *QueueHandler) CancelJob(ctx context.Context, req *queuepb.CancelJobRequest) (*queuepb.CancelJobResponse, error) {
+	jobID, err := uuid.Parse(req.GetJobId())
+	if err != nil {
+		h.logger.Warn("Invalid job ID", "job_id", req.GetJobId())
+		return nil, status.Error(codes.InvalidArgument, "invalid job ID")
+	}
+
+	job, err := h.store.CancelJob(ctx, jobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", jobID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to cancel job", "error", err, "job_id", jobID.String())
+		return nil, status.Error(codes.Internal, "failed to cancel job")
+	}
+
+	logger := h.logger.With("job_id", jobID.String(), "status", job.Status)
+
+	switch job.Status {
+	case store.JobStatusQueued, store.JobStatusScheduled, store.JobStatusRetrying:
+		// Workers skip cancelled jobs, so a message left behind is harmless
+		if _, err := h.queue.Remove(ctx, jobID, job.Type, job.Priority); err != nil {
+			logger.Warn("Failed to remove cancelled job from the queue", "error", err)
+		}
+		logger.Info("Job cancelled")
+		return cancelResponse(queuepb.CancelOutcome_CANCEL_OUTCOME_CANCELLED, store.JobStatusCancelled), nil
+
+	case store.JobStatusCancelled:
+		return cancelResponse(queuepb.CancelOutcome_CANCEL_OUTCOME_CANCELLED, store.JobStatusCancelled), nil
+
+	case store.JobStatusProcessing:
+		// Published on every call, so asking again reaches a worker that
+		// missed the first request
+		if err := h.queue.RequestCancel(ctx, jobID); err != nil {
+			logger.Warn("Failed to notify workers of cancellation", "error", err)
+		}
+		logger.Info("Job cancellation requested")
+		return cancelResponse(queuepb.CancelOutcome_CANCEL_OUTCOME_REQUESTED, store.JobStatusProcessing), nil
+
+	default:
+		return cancelResponse(queuepb.CancelOutcome_CANCEL_OUTCOME_TOO_LATE, job.Status), nil
+	}
+}
+
+func cancelResponse(outcome queuepb.CancelOutcome, jobStatus string) *queuepb.CancelJobResponse {
+	return &queuepb.CancelJobResponse{
+		Outcome: outcome,
+		Status:  toProtoJobStatus(jobStatus),
+	}
+}
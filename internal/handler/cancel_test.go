@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestCancelJob_Queued(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("cancel me"),
+	})
+	require.NoError(t, err)
+
+	resp, err := deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.CancelOutcome_CANCEL_OUTCOME_CANCELLED, resp.Outcome)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_CANCELLED, resp.Status)
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_CANCELLED, statusResp.JobStatus)
+	assert.NotNil(t, statusResp.CompletedAt)
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), queueLen)
+
+	// Cancelling again is a no-op
+	resp, err = deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.CancelOutcome_CANCEL_OUTCOME_CANCELLED, resp.Outcome)
+}
+
+func TestCancelJob_Scheduled(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("cancel me later"),
+		Delay:   durationpb.New(time.Hour),
+	})
+	require.NoError(t, err)
+
+	resp, err := deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.CancelOutcome_CANCEL_OUTCOME_CANCELLED, resp.Outcome)
+
+	scheduledLen, err := deps.queue.GetScheduledLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), scheduledLen)
+}
+
+func TestCancelJob_Processing(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:      jobID,
+		Type:    "JOB_STANDARD",
+		Payload: []byte("running"),
+		Status:  store.JobStatusQueued,
+	}))
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+
+	cancels, err := deps.queue.SubscribeCancels(ctx)
+	require.NoError(t, err)
+
+	resp, err := deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: jobID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.CancelOutcome_CANCEL_OUTCOME_REQUESTED, resp.Outcome)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_PROCESSING, resp.Status)
+
+	select {
+	case id := <-cancels:
+		assert.Equal(t, jobID, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("workers were never notified")
+	}
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: jobID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_PROCESSING, statusResp.JobStatus)
+	assert.NotNil(t, statusResp.CancelRequestedAt)
+}
+
+func TestCancelJob_TooLate(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:      jobID,
+		Type:    "JOB_STANDARD",
+		Payload: []byte("done"),
+		Status:  store.JobStatusQueued,
+	}))
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+	_, err := deps.store.MarkJobAsCompleted(ctx, jobID, 1, nil)
+	require.NoError(t, err)
+
+	resp, err := deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: jobID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.CancelOutcome_CANCEL_OUTCOME_TOO_LATE, resp.Outcome)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, resp.Status)
+}
+
+func TestCancelJob_InvalidOrUnknownID(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: "not-a-uuid"})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err = deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: uuid.New().String()})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
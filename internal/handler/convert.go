@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/turnertastic1/boltq/internal/retry"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// jobStatuses maps the status strings persisted in Postgres to their proto enum values.
+var jobStatuses = map[string]queuepb.JobStatus{
+	store.JobStatusQueued:     queuepb.JobStatus_JOB_STATUS_QUEUED,
+	store.JobStatusProcessing: queuepb.JobStatus_JOB_STATUS_PROCESSING,
+	store.JobStatusCompleted:  queuepb.JobStatus_JOB_STATUS_COMPLETED,
+	store.JobStatusFailed:     queuepb.JobStatus_JOB_STATUS_FAILED,
+	store.JobStatusRetrying:   queuepb.JobStatus_JOB_STATUS_RETRYING,
+	store.JobStatusDead:       queuepb.JobStatus_JOB_STATUS_DEAD,
+	store.JobStatusScheduled:  queuepb.JobStatus_JOB_STATUS_SCHEDULED,
+	store.JobStatusCancelled:  queuepb.JobStatus_JOB_STATUS_CANCELLED,
+}
+
+// attemptOutcomes maps the attempt outcomes persisted in Postgres to their proto enum values.
+var attemptOutcomes = map[string]queuepb.AttemptOutcome{
+	store.AttemptRunning:     queuepb.AttemptOutcome_ATTEMPT_OUTCOME_RUNNING,
+	store.AttemptSucceeded:   queuepb.AttemptOutcome_ATTEMPT_OUTCOME_SUCCEEDED,
+	store.AttemptFailed:      queuepb.AttemptOutcome_ATTEMPT_OUTCOME_FAILED,
+	store.AttemptCancelled:   queuepb.AttemptOutcome_ATTEMPT_OUTCOME_CANCELLED,
+	store.AttemptInterrupted: queuepb.AttemptOutcome_ATTEMPT_OUTCOME_INTERRUPTED,
+	store.AttemptAbandoned:   queuepb.AttemptOutcome_ATTEMPT_OUTCOME_ABANDONED,
+}
+
+// jitterStrategies maps proto jitter strategies to the values persisted in Postgres.
+var jitterStrategies = map[queuepb.JitterStrategy]string{
+	queuepb.JitterStrategy_JITTER_STRATEGY_NONE:  retry.JitterNone,
+	queuepb.JitterStrategy_JITTER_STRATEGY_FULL:  retry.JitterFull,
+	queuepb.JitterStrategy_JITTER_STRATEGY_EQUAL: retry.JitterEqual,
+}
+
+// fromProtoRetryPolicy converts a requested retry policy, filling unset fields
+// from the job type's defaults, and validates the result.
+func fromProtoRetryPolicy(p *queuepb.RetryPolicy, jobType string) (retry.Policy, error) {
+	policy := retry.Policy{
+		MaxAttempts: int(p.GetMaxAttempts()),
+		BaseDelay:   p.GetBaseDelay().AsDuration(),
+		MaxDelay:    p.GetMaxDelay().AsDuration(),
+		Jitter:      jitterStrategies[p.GetJitter()],
+	}
+
+	if p.GetJitter() != queuepb.JitterStrategy_JITTER_STRATEGY_UNSPECIFIED && policy.Jitter == "" {
+		return retry.Policy{}, fmt.Errorf("unknown jitter strategy %v", p.GetJitter())
+	}
+
+	policy = policy.WithDefaults(jobType)
+	if err := policy.Validate(); err != nil {
+		return retry.Policy{}, err
+	}
+
+	return policy, nil
+}
+
+func toProtoJobStatus(s string) queuepb.JobStatus {
+	return jobStatuses[s]
+}
+
+// fromProtoJobStatus returns the stored status for s, or false if it has none.
+func fromProtoJobStatus(s queuepb.JobStatus) (string, bool) {
+	for stored, status := range jobStatuses {
+		if status == s {
+			return stored, true
+		}
+	}
+	return "", false
+}
+
+// toProtoJobType reverses the JobType.String() conversion used when jobs are stored.
+func toProtoJobType(t string) queuepb.JobType {
+	return queuepb.JobType(queuepb.JobType_value[t])
+}
+
+func toProtoTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func toJobStatusResponse(job *store.Job) *queuepb.GetJobStatusResponse {
+	var heartbeatAge *durationpb.Duration
+	if job.Status == store.JobStatusProcessing && job.LastHeartbeatAt != nil {
+		heartbeatAge = durationpb.New(time.Since(*job.LastHeartbeatAt))
+	}
+
+	return &queuepb.GetJobStatusResponse{
+		JobId:             job.ID.String(),
+		JobStatus:         toProtoJobStatus(job.Status),
+		Type:              toProtoJobType(job.Type),
+		CreatedAt:         timestamppb.New(job.CreatedAt),
+		StartedAt:         toProtoTimestamp(job.StartedAt),
+		CompletedAt:       toProtoTimestamp(job.CompletedAt),
+		Attempts:          int32(job.Attempts),
+		MaxAttempts:       int32(job.MaxAttempts),
+		LastError:         derefString(job.LastError),
+		NextRunAt:         toProtoTimestamp(job.NextRunAt),
+		Priority:          int32(job.Priority),
+		CancelRequestedAt: toProtoTimestamp(job.CancelRequestedAt),
+		Tags:              job.Tags,
+		LastHeartbeatAt:   toProtoTimestamp(job.LastHeartbeatAt),
+		HeartbeatAge:      heartbeatAge,
+		Progress:          toProtoProgress(job),
+		Checkpoint:        job.Checkpoint,
+		Result:            job.Result,
+		Error:             toProtoJobError(job),
+	}
+}
+
+// toProtoJobError returns nil for a job that has not failed an attempt.
+func toProtoJobError(job *store.Job) *queuepb.JobError {
+	if job.LastError == nil {
+		return nil
+	}
+	return &queuepb.JobError{
+		Message: *job.LastError,
+		Code:    derefString(job.ErrorCode),
+		Details: derefString(job.ErrorDetails),
+	}
+}
+
+// toProtoProgress returns nil for a job that has not reported progress.
+func toProtoProgress(job *store.Job) *queuepb.JobProgress {
+	if job.ProgressUpdatedAt == nil {
+		return nil
+	}
+	p := &queuepb.JobProgress{
+		Message:   derefString(job.ProgressMessage),
+		UpdatedAt: timestamppb.New(*job.ProgressUpdatedAt),
+	}
+	if job.ProgressPercent != nil {
+		p.Percent = int32(*job.ProgressPercent)
+	}
+	return p
+}
+
+// toProtoJob converts a job listed by ListJobs; the payload is nil unless it was loaded.
+func toProtoJob(job *store.Job) *queuepb.Job {
+	return &queuepb.Job{
+		JobId:       job.ID.String(),
+		Type:        toProtoJobType(job.Type),
+		Status:      toProtoJobStatus(job.Status),
+		Payload:     job.Payload,
+		Priority:    int32(job.Priority),
+		Tags:        job.Tags,
+		CreatedAt:   timestamppb.New(job.CreatedAt),
+		StartedAt:   toProtoTimestamp(job.StartedAt),
+		CompletedAt: toProtoTimestamp(job.CompletedAt),
+		Attempts:    int32(job.Attempts),
+		MaxAttempts: int32(job.MaxAttempts),
+		LastError:   derefString(job.LastError),
+		NextRunAt:   toProtoTimestamp(job.NextRunAt),
+		Progress:    toProtoProgress(job),
+		Checkpoint:  job.Checkpoint,
+	}
+}
+
+func toProtoJobAttempt(a *store.JobAttempt) *queuepb.JobAttempt {
+	attempt := &queuepb.JobAttempt{
+		Attempt:    int32(a.Attempt),
+		WorkerId:   a.WorkerID,
+		Outcome:    attemptOutcomes[a.Outcome],
+		StartedAt:  timestamppb.New(a.StartedAt),
+		FinishedAt: toProtoTimestamp(a.FinishedAt),
+	}
+	if a.LatencyMs != nil {
+		attempt.Latency = durationpb.New(time.Duration(*a.LatencyMs) * time.Millisecond)
+	}
+	if a.Error != nil {
+		attempt.Error = &queuepb.JobError{
+			Message: *a.Error,
+			Code:    derefString(a.ErrorCode),
+			Details: derefString(a.ErrorDetails),
+		}
+	}
+	if a.ResponseStatus != nil {
+		attempt.ResponseStatus = int32(*a.ResponseStatus)
+	}
+	return attempt
+}
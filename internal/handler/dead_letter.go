@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultDeadLetterPageSize = 50
+	maxDeadLetterPageSize     = 500
+	defaultDeadLetterBatch    = 100
+	maxDeadLetterBatch        = 1000
+)
+
+func (h *QueueHandler) ListDeadLetterJobs(ctx context.Context, req *queuepb.ListDeadLetterJobsRequest) (*queuepb.ListDeadLetterJobsResponse, error) {
+	filter, err := fromProtoDeadLetterFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	pageSize := clampLimit(req.GetPageSize(), defaultDeadLetterPageSize, maxDeadLetterPageSize)
+
+	var after *store.DeadJobCursor
+	if req.GetPageToken() != "" {
+		after, err = decodeDeadJobCursor(req.GetPageToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	jobs, err := h.store.ListDeadJobs(ctx, filter, after, pageSize+1)
+	if err != nil {
+		h.logger.Error("Failed to list dead-lettered jobs", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list dead-lettered jobs")
+	}
+
+	resp := &queuepb.ListDeadLetterJobsResponse{}
+	if len(jobs) > pageSize {
+		jobs = jobs[:pageSize]
+		last := jobs[len(jobs)-1]
+		resp.NextPageToken = encodeDeadJobCursor(&store.DeadJobCursor{DeadAt: *last.DeadAt, ID: last.ID})
+	}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, toProtoDeadLetterJob(job))
+	}
+
+	return resp, nil
+}
+
+func (h *QueueHandler) GetDeadLetterJob(ctx context.Context, req *queuepb.GetDeadLetterJobRequest) (*queuepb.GetDeadLetterJobResponse, error) {
+	jobID, err := uuid.Parse(req.GetJobId())
+	if err != nil {
+		h.logger.Warn("Invalid job ID", "job_id", req.GetJobId())
+		return nil, status.Error(codes.InvalidArgument, "invalid job ID")
+	}
+
+	job, err := h.store.GetJobByID(ctx, jobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", jobID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to get job from store", "error", err, "job_id", jobID.String())
+		return nil, status.Error(codes.Internal, "failed to get dead-lettered job")
+	}
+	if job.Status != store.JobStatusDead {
+		return nil, status.Errorf(codes.FailedPrecondition, "job %s is %s, not dead-lettered", jobID, job.Status)
+	}
+
+	failures, err := h.store.ListJobFailures(ctx, jobID)
+	if err != nil {
+		h.logger.Error("Failed to list job failures", "error", err, "job_id", jobID.String())
+		return nil, status.Error(codes.Internal, "failed to get dead-lettered job")
+	}
+
+	resp := &queuepb.GetDeadLetterJobResponse{Job: toProtoDeadLetterJob(job)}
+	for _, f := range failures {
+		resp.Failures = append(resp.Failures, &queuepb.JobFailure{
+			Attempt:  int32(f.Attempt),
+			Error:    f.Error,
+			FailedAt: timestamppb.New(f.FailedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *QueueHandler) ReplayDeadLetterJobs(ctx context.Context, req *queuepb.ReplayDeadLetterJobsRequest) (*queuepb.ReplayDeadLetterJobsResponse, error) {
+	filter, err := h.deadLetterBatchFilter(req.GetFilter(), req.GetAll())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := h.store.ReplayDeadJobs(ctx, filter, clampLimit(req.GetLimit(), defaultDeadLetterBatch, maxDeadLetterBatch))
+	if err != nil {
+		h.logger.Error("Failed to replay dead-lettered jobs", "error", err)
+		return nil, status.Error(codes.Internal, "failed to replay dead-lettered jobs")
+	}
+
+	resp := &queuepb.ReplayDeadLetterJobsResponse{}
+	byType := make(map[string][]uuid.UUID)
+	for _, entry := range entries {
+		h.publish(ctx, entry)
+		byType[entry.JobType] = append(byType[entry.JobType], entry.JobID)
+		resp.JobIds = append(resp.JobIds, entry.JobID.String())
+	}
+	h.removeDeadLetters(ctx, byType)
+
+	h.logger.Info("Replayed dead-lettered jobs", "count", len(entries))
+
+	return resp, nil
+}
+
+func (h *QueueHandler) PurgeDeadLetterJobs(ctx context.Context, req *queuepb.PurgeDeadLetterJobsRequest) (*queuepb.PurgeDeadLetterJobsResponse, error) {
+	filter, err := h.deadLetterBatchFilter(req.GetFilter(), req.GetAll())
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := h.store.PurgeDeadJobs(ctx, filter, clampLimit(req.GetLimit(), defaultDeadLetterBatch, maxDeadLetterBatch))
+	if err != nil {
+		h.logger.Error("Failed to purge dead-lettered jobs", "error", err)
+		return nil, status.Error(codes.Internal, "failed to purge dead-lettered jobs")
+	}
+
+	resp := &queuepb.PurgeDeadLetterJobsResponse{}
+	byType := make(map[string][]uuid.UUID)
+	for _, job := range jobs {
+		byType[job.Type] = append(byType[job.Type], job.ID)
+		resp.JobIds = append(resp.JobIds, job.ID.String())
+	}
+	h.removeDeadLetters(ctx, byType)
+
+	h.logger.Info("Purged dead-lettered jobs", "count", len(jobs))
+
+	return resp, nil
+}
+
+// deadLetterBatchFilter converts the filter of a replay or purge request. An
+// empty filter is rejected unless all is set, so a missing field cannot act on
+// the whole dead-letter queue by accident.
+func (h *QueueHandler) deadLetterBatchFilter(f *queuepb.DeadLetterFilter, all bool) (store.DeadJobFilter, error) {
+	filter, err := fromProtoDeadLetterFilter(f)
+	if err != nil {
+		return store.DeadJobFilter{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if filter.IsEmpty() && !all {
+		h.logger.Warn("Refusing dead-letter batch without a filter")
+		return store.DeadJobFilter{}, status.Error(codes.InvalidArgument, "filter is empty; set all to act on every dead-lettered job")
+	}
+	return filter, nil
+}
+
+// removeDeadLetters drops replayed or purged jobs from the Redis dead-letter
+// queues. Postgres is already updated, so errors are only logged.
+func (h *QueueHandler) removeDeadLetters(ctx context.Context, byType map[string][]uuid.UUID) {
+	for jobType, ids := range byType {
+		if _, err := h.queue.RemoveDeadLetters(ctx, jobType, ids...); err != nil {
+			h.logger.Warn("Failed to remove jobs from dead-letter queue", "type", jobType, "error", err)
+		}
+	}
+}
+
+func fromProtoDeadLetterFilter(f *queuepb.DeadLetterFilter) (store.DeadJobFilter, error) {
+	filter := store.DeadJobFilter{ErrorContains: f.GetErrorContains()}
+
+	if f.GetType() != queuepb.JobType_JOB_TYPE_UNSPECIFIED {
+		filter.Type = f.GetType().String()
+	}
+	for _, raw := range f.GetJobIds() {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return store.DeadJobFilter{}, errors.New("invalid job ID " + strconv.Quote(raw))
+		}
+		filter.JobIDs = append(filter.JobIDs, id)
+	}
+	if f.GetDeadAfter() != nil {
+		t := f.GetDeadAfter().AsTime()
+		filter.DeadAfter = &t
+	}
+	if f.GetDeadBefore() != nil {
+		t := f.GetDeadBefore().AsTime()
+		filter.DeadBefore = &t
+	}
+
+	return filter, nil
+}
+
+func toProtoDeadLetterJob(job *store.Job) *queuepb.DeadLetterJob {
+	return &queuepb.DeadLetterJob{
+		JobId:       job.ID.String(),
+		Type:        toProtoJobType(job.Type),
+		Payload:     job.Payload,
+		Attempts:    int32(job.Attempts),
+		MaxAttempts: int32(job.MaxAttempts),
+		LastError:   derefString(job.LastError),
+		CreatedAt:   timestamppb.New(job.CreatedAt),
+		DeadAt:      toProtoTimestamp(job.DeadAt),
+	}
+}
+
+// clampLimit returns requested, or def if it is unset, capped at max.
+func clampLimit(requested int32, def, max int) int {
+	if requested <= 0 {
+		return def
+	}
+	return min(int(requested), max)
+}
+
+// encodeDeadJobCursor encodes a cursor as an opaque page token.
+func encodeDeadJobCursor(c *store.DeadJobCursor) string {
+	return encodeCursor(c.DeadAt, c.ID)
+}
+
+func decodeDeadJobCursor(token string) (*store.DeadJobCursor, error) {
+	deadAt, id, err := decodeCursor(token)
+	if err != nil {
+		return nil, err
+	}
+	return &store.DeadJobCursor{DeadAt: deadAt, ID: id}, nil
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// createDeadJob creates a job that failed its only attempt with reason and was dead-lettered.
+func createDeadJob(t *testing.T, deps *testDeps, jobType, reason string) uuid.UUID {
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:          jobID,
+		Type:        jobType,
+		Payload:     []byte("dead letter payload"),
+		Status:      store.JobStatusQueued,
+		MaxAttempts: 1,
+	}))
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+
+	dead, err := deps.store.DeadLetterJob(ctx, jobID, store.JobStatusProcessing, 1, store.JobError{Message: reason})
+	require.NoError(t, err)
+	require.True(t, dead)
+	require.NoError(t, deps.queue.DeadLetter(ctx, jobID, jobType))
+
+	return jobID
+}
+
+func TestListDeadLetterJobs_FiltersAndPages(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var standard []uuid.UUID
+	for range 3 {
+		standard = append(standard, createDeadJob(t, deps, "JOB_STANDARD", "boom"))
+		time.Sleep(5 * time.Millisecond)
+	}
+	createDeadJob(t, deps, "JOB_WEBHOOK", "webhook returned status 503")
+
+	resp, err := deps.handler.ListDeadLetterJobs(ctx, &queuepb.ListDeadLetterJobsRequest{
+		Filter:   &queuepb.DeadLetterFilter{Type: queuepb.JobType_JOB_STANDARD},
+		PageSize: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 2)
+	assert.NotEmpty(t, resp.NextPageToken)
+
+	// Most recently dead-lettered first
+	assert.Equal(t, standard[2].String(), resp.Jobs[0].JobId)
+	assert.Equal(t, standard[1].String(), resp.Jobs[1].JobId)
+	assert.Equal(t, "boom", resp.Jobs[0].LastError)
+	assert.NotNil(t, resp.Jobs[0].DeadAt)
+
+	resp, err = deps.handler.ListDeadLetterJobs(ctx, &queuepb.ListDeadLetterJobsRequest{
+		Filter:    &queuepb.DeadLetterFilter{Type: queuepb.JobType_JOB_STANDARD},
+		PageSize:  2,
+		PageToken: resp.NextPageToken,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 1)
+	assert.Equal(t, standard[0].String(), resp.Jobs[0].JobId)
+	assert.Empty(t, resp.NextPageToken)
+
+	resp, err = deps.handler.ListDeadLetterJobs(ctx, &queuepb.ListDeadLetterJobsRequest{
+		Filter: &queuepb.DeadLetterFilter{ErrorContains: "503"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 1)
+	assert.Equal(t, queuepb.JobType_JOB_WEBHOOK, resp.Jobs[0].Type)
+}
+
+func TestListDeadLetterJobs_InvalidPageToken(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp, err := deps.handler.ListDeadLetterJobs(context.Background(), &queuepb.ListDeadLetterJobsRequest{PageToken: "%%%"})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetDeadLetterJob(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	jobID := createDeadJob(t, deps, "JOB_STANDARD", "boom")
+
+	resp, err := deps.handler.GetDeadLetterJob(ctx, &queuepb.GetDeadLetterJobRequest{JobId: jobID.String()})
+	require.NoError(t, err)
+
+	assert.Equal(t, jobID.String(), resp.Job.JobId)
+	assert.Equal(t, []byte("dead letter payload"), resp.Job.Payload)
+	assert.Equal(t, int32(1), resp.Job.MaxAttempts)
+	require.Len(t, resp.Failures, 1)
+	assert.Equal(t, int32(1), resp.Failures[0].Attempt)
+	assert.Equal(t, "boom", resp.Failures[0].Error)
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: jobID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_DEAD, statusResp.JobStatus)
+}
+
+func TestGetDeadLetterJob_NotDead(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("live payload"),
+	})
+	require.NoError(t, err)
+
+	resp, err := deps.handler.GetDeadLetterJob(ctx, &queuepb.GetDeadLetterJobRequest{JobId: enqueueResp.JobId})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestReplayDeadLetterJobs(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	replayed := createDeadJob(t, deps, "JOB_STANDARD", "boom")
+	untouched := createDeadJob(t, deps, "JOB_STANDARD", "boom")
+
+	resp, err := deps.handler.ReplayDeadLetterJobs(ctx, &queuepb.ReplayDeadLetterJobsRequest{
+		Filter: &queuepb.DeadLetterFilter{JobIds: []string{replayed.String()}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{replayed.String()}, resp.JobIds)
+
+	job, err := deps.store.GetJobByID(ctx, replayed)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusQueued, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Nil(t, job.DeadAt)
+
+	// Back on the live queue and out of the dead-letter queue
+	ids, err := deps.queue.TrackedJobIDs(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Contains(t, ids, replayed)
+
+	n, err := deps.queue.GetDeadLetterLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	job, err = deps.store.GetJobByID(ctx, untouched)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusDead, job.Status)
+}
+
+func TestPurgeDeadLetterJobs(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	purged := createDeadJob(t, deps, "JOB_WEBHOOK", "webhook returned status 404")
+	kept := createDeadJob(t, deps, "JOB_STANDARD", "boom")
+
+	resp, err := deps.handler.PurgeDeadLetterJobs(ctx, &queuepb.PurgeDeadLetterJobsRequest{
+		Filter: &queuepb.DeadLetterFilter{Type: queuepb.JobType_JOB_WEBHOOK},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{purged.String()}, resp.JobIds)
+
+	_, err = deps.store.GetJobByID(ctx, purged)
+	assert.ErrorIs(t, err, store.ErrJobNotFound)
+
+	n, err := deps.queue.GetDeadLetterLength(ctx, "JOB_WEBHOOK")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	_, err = deps.store.GetJobByID(ctx, kept)
+	assert.NoError(t, err)
+}
+
+func TestPurgeDeadLetterJobs_RequiresFilterOrAll(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	createDeadJob(t, deps, "JOB_STANDARD", "boom")
+
+	resp, err := deps.handler.PurgeDeadLetterJobs(ctx, &queuepb.PurgeDeadLetterJobsRequest{})
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err = deps.handler.PurgeDeadLetterJobs(ctx, &queuepb.PurgeDeadLetterJobsRequest{All: true})
+	require.NoError(t, err)
+	assert.Len(t, resp.JobIds, 1)
+}
+
+func TestDeadJobCursor_RoundTrip(t *testing.T) {
+	cursor := &store.DeadJobCursor{DeadAt: time.Now().UTC().Truncate(time.Microsecond), ID: uuid.New()}
+
+	decoded, err := decodeDeadJobCursor(encodeDeadJobCursor(cursor))
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = decodeDeadJobCursor("bm90LWEtY3Vyc29y")
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/turnertastic1/boltq/internal/events"
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// setupEmbeddedHandler builds a handler on a bbolt store and queue, as
+// queue-svc runs in embedded mode, with the store publishing job events to a
+// local hub.
+func setupEmbeddedHandler(t *testing.T) *QueueHandler {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "boltq.db"), 0o600, nil)
+	require.NoError(t, err)
+
+	boltStore, err := store.NewBoltStore(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { boltStore.Close() })
+
+	boltQueue, err := queue.NewBoltQueue(db)
+	require.NoError(t, err)
+	t.Cleanup(func() { boltQueue.Close() })
+
+	hub := events.NewLocalHub(events.Config{})
+	t.Cleanup(hub.Close)
+	boltStore.PublishEvents(hub.Publish)
+
+	return NewQueueHandler(logger, boltStore, boltQueue, hub, Config{})
+}
+
+func TestEmbeddedHandler_WatchJob(t *testing.T) {
+	h := setupEmbeddedHandler(t)
+	ctx := context.Background()
+
+	enqueueResp, err := h.EnqueueJob(ctx, standardJob("watched", ""))
+	require.NoError(t, err)
+
+	stream := newEventStream(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- h.WatchJob(&queuepb.WatchJobRequest{JobId: enqueueResp.JobId}, stream)
+	}()
+
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, stream.next(t).Status)
+
+	leased := fetchOne(t, &testDeps{handler: h})
+	ev := stream.next(t)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_PROCESSING, ev.Status)
+	assert.NotNil(t, ev.OccurredAt)
+
+	_, err = h.ReportProgress(ctx, &queuepb.ReportProgressRequest{LeaseToken: leased.LeaseToken, Percent: 50, Message: "halfway"})
+	require.NoError(t, err)
+	ev = stream.next(t)
+	require.NotNil(t, ev.Progress)
+	assert.Equal(t, int32(50), ev.Progress.Percent)
+	assert.Equal(t, "halfway", ev.Progress.Message)
+
+	_, err = h.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, stream.next(t).Status)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not end on a terminal status")
+	}
+}
+
+func TestEmbeddedHandler_WatchJobs(t *testing.T) {
+	h := setupEmbeddedHandler(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newEventStream(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- h.WatchJobs(&queuepb.WatchJobsRequest{Tags: map[string]string{"tenant": "acme"}}, stream)
+	}()
+
+	// Give the watch time to subscribe
+	time.Sleep(100 * time.Millisecond)
+
+	var acme string
+	for _, tenant := range []string{"globex", "acme"} {
+		req := standardJob("tagged", "")
+		req.Tags = map[string]string{"tenant": tenant}
+		resp, err := h.EnqueueJob(ctx, req)
+		require.NoError(t, err)
+		acme = resp.JobId
+	}
+
+	ev := stream.next(t)
+	assert.Equal(t, acme, ev.JobId)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, ev.Status)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not end with its context")
+	}
+	assert.Empty(t, stream.events)
+}
+
+func TestEmbeddedHandler_GetJobResultWaits(t *testing.T) {
+	h := setupEmbeddedHandler(t)
+	ctx := context.Background()
+
+	enqueueResp, err := h.EnqueueJob(ctx, standardJob("awaited", ""))
+	require.NoError(t, err)
+	leased := fetchOne(t, &testDeps{handler: h})
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_, err := h.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken, Result: []byte("done")})
+		assert.NoError(t, err)
+	}()
+
+	start := time.Now()
+	resp, err := h.GetJobResult(ctx, &queuepb.GetJobResultRequest{JobId: enqueueResp.JobId, Wait: durationpb.New(10 * time.Second)})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, resp.Status)
+	assert.Equal(t, []byte("done"), resp.Result)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
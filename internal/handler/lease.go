@@ -0,0 +1,478 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/worker"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultLeaseDuration is how long FetchJobs leases jobs for when the request
+// does not say. The lease reaper of queue-svc gives messages it finds without
+// a lease the same.
+const DefaultLeaseDuration = 5 * time.Minute
+
+const (
+	maxFetchJobs     = 100
+	maxFetchWait     = 30 * time.Second
+	maxLeaseDuration = time.Hour
+	maxWorkerID      = 255
+	// How long AckJob and NackJob keep a lease they have checked while they
+	// record the job's outcome
+	settleLease = time.Minute
+	// Attempt history worker ID of remote workers that give no ID or address
+	defaultRemoteWorkerID = "remote"
+	// Size of the random lease secret used when none is configured
+	leaseSecretSize = 32
+)
+
+func (h *QueueHandler) FetchJobs(ctx context.Context, req *queuepb.FetchJobsRequest) (*queuepb.FetchJobsResponse, error) {
+	if len(req.GetTypes()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one job type is required")
+	}
+	var types []string
+	seen := make(map[queuepb.JobType]bool)
+	for _, t := range req.GetTypes() {
+		if t == queuepb.JobType_JOB_TYPE_UNSPECIFIED {
+			return nil, status.Error(codes.InvalidArgument, "invalid job type")
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t.String())
+		}
+	}
+
+	maxJobs := int(req.GetMaxJobs())
+	if maxJobs == 0 {
+		maxJobs = 1
+	}
+	if maxJobs < 0 || maxJobs > maxFetchJobs {
+		return nil, status.Errorf(codes.InvalidArgument, "max_jobs must be between 1 and %d", maxFetchJobs)
+	}
+
+	wait, err := durationArg(req.GetWait(), "wait", 0, maxFetchWait)
+	if err != nil {
+		return nil, err
+	}
+	lease, err := durationArg(req.GetLeaseDuration(), "lease_duration", DefaultLeaseDuration, maxLeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	workerID := req.GetWorkerId()
+	if len(workerID) > maxWorkerID {
+		return nil, status.Errorf(codes.InvalidArgument, "worker_id exceeds maximum length: %d", maxWorkerID)
+	}
+	if workerID == "" {
+		workerID = defaultRemoteWorkerID
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			workerID = p.Addr.String()
+		}
+	}
+
+	resp := &queuepb.FetchJobsResponse{}
+	deadline := time.Now().Add(wait)
+	for len(resp.Jobs) == 0 {
+		// Messages claimed for a client that has gone are returned to the
+		// queue by the reaper once their lease expires
+		msgs, err := h.queue.DequeueReliableBatch(ctx, types, maxJobs, time.Until(deadline), lease)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+			h.logger.Error("Failed to dequeue jobs", "error", err, "types", types)
+			return nil, status.Error(codes.Internal, "failed to fetch jobs")
+		}
+		if len(msgs) == 0 {
+			break
+		}
+
+		for _, msg := range msgs {
+			if leased := h.claim(ctx, msg, workerID, lease); leased != nil {
+				resp.Jobs = append(resp.Jobs, leased)
+			}
+		}
+	}
+
+	if len(resp.Jobs) > 0 {
+		h.logger.Info("Leased jobs to remote worker", "count", len(resp.Jobs), "lease", lease)
+	}
+
+	return resp, nil
+}
+
+// claim marks the job of a dequeued message as processing on behalf of
+// workerID, as a local worker does before running it, and returns it leased.
+// It returns nil if the job is not to be run.
+func (h *QueueHandler) claim(ctx context.Context, msg *queue.JobMessage, workerID string, lease time.Duration) *queuepb.LeasedJob {
+	logger := h.logger.With("job_id", msg.JobID.String(), "type", msg.Type)
+
+	job, err := h.store.GetJobByID(ctx, msg.JobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		logger.Warn("Dropping message for unknown job")
+		h.ackMessage(ctx, logger, msg)
+		return nil
+	}
+	if err != nil {
+		logger.Error("Failed to load job, returning it to the queue", "error", err)
+		h.nackMessage(ctx, logger, msg)
+		return nil
+	}
+
+	if isTerminal(job.Status) {
+		logger.Warn("Skipping job that is already finished", "status", job.Status)
+		h.ackMessage(ctx, logger, msg)
+		return nil
+	}
+
+	claimed, err := h.store.ClaimJob(ctx, job.ID, workerID, lease)
+	if err != nil {
+		logger.Error("Failed to mark job as processing, returning it to the queue", "error", err)
+		h.nackMessage(ctx, logger, msg)
+		return nil
+	}
+	if !claimed {
+		// As with local workers, the message stays leased until the reaper
+		// hands it back, by which time the current holder has finished or is gone
+		logger.Warn("Job is held by another worker, leaving message until its lease expires")
+		return nil
+	}
+
+	job.Attempts++
+
+	if job.CancelRequestedAt != nil {
+		logger.Info("Cancelling redelivered job")
+		if _, err := h.store.MarkJobAsCancelled(ctx, job.ID, job.Attempts); err != nil {
+			logger.Error("Failed to record job outcome", "error", err, "outcome", "cancelled")
+		}
+		h.finishAttempt(ctx, logger, job, store.AttemptEnd{Outcome: store.AttemptCancelled})
+		h.ackMessage(ctx, logger, msg)
+		return nil
+	}
+
+	now := time.Now()
+	job.Status = store.JobStatusProcessing
+	job.StartedAt = &now
+	job.NextRunAt = nil
+
+	return &queuepb.LeasedJob{
+		Job:            toProtoJob(job),
+		LeaseToken:     h.leaseToken(msg, job.Attempts),
+		LeaseExpiresAt: timestamppb.New(now.Add(lease)),
+	}
+}
+
+func (h *QueueHandler) AckJob(ctx context.Context, req *queuepb.AckJobRequest) (*queuepb.AckJobResponse, error) {
+	if len(req.GetResult()) > worker.MaxResultSize {
+		return nil, status.Errorf(codes.InvalidArgument, "result size exceeds maximum limit: %d", worker.MaxResultSize)
+	}
+
+	msg, job, err := h.leasedJob(ctx, req.GetLeaseToken())
+	if err != nil {
+		return nil, err
+	}
+	logger := h.logger.With("job_id", job.ID.String(), "type", job.Type)
+
+	if err := h.holdLease(ctx, logger, msg); err != nil {
+		return nil, err
+	}
+
+	var held bool
+	outcome := store.JobStatusCompleted
+	end := store.AttemptEnd{Outcome: store.AttemptSucceeded}
+	if req.GetCancelled() {
+		outcome = store.JobStatusCancelled
+		end.Outcome = store.AttemptCancelled
+		held, err = h.store.MarkJobAsCancelled(ctx, job.ID, job.Attempts)
+	} else {
+		var result []byte
+		if len(req.GetResult()) > 0 {
+			result = req.GetResult()
+		}
+		held, err = h.store.MarkJobAsCompleted(ctx, job.ID, job.Attempts, result)
+	}
+	if err != nil {
+		logger.Error("Failed to record job outcome", "error", err, "outcome", outcome)
+		return nil, status.Error(codes.Internal, "failed to ack job")
+	}
+	if !held {
+		return nil, errLeaseLost
+	}
+	h.finishAttempt(ctx, logger, job, end)
+	h.ackMessage(ctx, logger, msg)
+
+	logger.Info("Remote worker finished job", "outcome", outcome)
+
+	return &queuepb.AckJobResponse{Status: toProtoJobStatus(outcome)}, nil
+}
+
+func (h *QueueHandler) NackJob(ctx context.Context, req *queuepb.NackJobRequest) (*queuepb.NackJobResponse, error) {
+	delay, err := durationArg(req.GetRetryDelay(), "retry_delay", 0, maxScheduleHorizon)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, job, err := h.leasedJob(ctx, req.GetLeaseToken())
+	if err != nil {
+		return nil, err
+	}
+	logger := h.logger.With("job_id", job.ID.String(), "type", job.Type)
+
+	if err := h.holdLease(ctx, logger, msg); err != nil {
+		return nil, err
+	}
+
+	reason := req.GetError()
+	if reason == "" {
+		reason = "attempt failed on remote worker"
+	}
+	jobErr := errors.New(reason)
+	if req.GetErrorCode() != "" || req.GetErrorDetails() != "" {
+		jobErr = worker.WithCode(jobErr, req.GetErrorCode(), req.GetErrorDetails())
+	}
+	if req.GetPermanent() {
+		jobErr = worker.Permanent(jobErr)
+	}
+
+	logger.Warn("Remote worker failed job", "error", reason, "retryable", !req.GetPermanent())
+
+	outcome, err := worker.RecordFailure(ctx, logger, h.store, h.queue, job, jobErr, delay)
+	if err != nil {
+		logger.Error("Failed to record job outcome", "error", err)
+		return nil, status.Error(codes.Internal, "failed to nack job")
+	}
+	if outcome == "" {
+		return nil, errLeaseLost
+	}
+	h.finishAttempt(ctx, logger, job, store.AttemptEnd{Outcome: store.AttemptFailed, Error: worker.DescribeError(jobErr)})
+	h.ackMessage(ctx, logger, msg)
+
+	return &queuepb.NackJobResponse{Status: toProtoJobStatus(outcome)}, nil
+}
+
+func (h *QueueHandler) ExtendLease(ctx context.Context, req *queuepb.ExtendLeaseRequest) (*queuepb.ExtendLeaseResponse, error) {
+	lease, err := durationArg(req.GetLeaseDuration(), "lease_duration", DefaultLeaseDuration, maxLeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, job, err := h.leasedJob(ctx, req.GetLeaseToken())
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := h.queue.ExtendLease(ctx, msg, lease)
+	if err != nil {
+		h.logger.Error("Failed to extend lease", "error", err, "job_id", job.ID.String())
+		return nil, status.Error(codes.Internal, "failed to extend lease")
+	}
+	if !held {
+		return nil, errLeaseExpired
+	}
+
+	// Extending a lease doubles as the remote worker's heartbeat
+	held, cancelRequested, err := h.store.Heartbeat(ctx, job.ID, job.Attempts)
+	if err != nil {
+		h.logger.Error("Failed to record job heartbeat", "error", err, "job_id", job.ID.String())
+		return nil, status.Error(codes.Internal, "failed to extend lease")
+	}
+	if !held {
+		return nil, errLeaseLost
+	}
+
+	return &queuepb.ExtendLeaseResponse{
+		LeaseExpiresAt:  timestamppb.New(time.Now().Add(lease)),
+		CancelRequested: cancelRequested,
+	}, nil
+}
+
+func (h *QueueHandler) ReportProgress(ctx context.Context, req *queuepb.ReportProgressRequest) (*queuepb.ReportProgressResponse, error) {
+	progress := worker.Progress{
+		Percent: int(req.GetPercent()),
+		Message: req.GetMessage(),
+	}
+	if len(req.GetCheckpoint()) > 0 {
+		progress.Checkpoint = req.GetCheckpoint()
+	}
+	if err := progress.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// The attempt in the lease token is checked as the progress is saved, so
+	// a worker whose job was leased to another cannot report on its behalf
+	_, job, err := h.leasedJob(ctx, req.GetLeaseToken())
+	if err != nil {
+		return nil, err
+	}
+
+	held, cancelRequested, err := h.store.UpdateProgress(ctx, job.ID, job.Attempts, progress.Percent, progress.Message, progress.Checkpoint)
+	if err != nil {
+		h.logger.Error("Failed to update job progress", "error", err, "job_id", job.ID.String())
+		return nil, status.Error(codes.Internal, "failed to report progress")
+	}
+	if !held {
+		return nil, errLeaseLost
+	}
+
+	return &queuepb.ReportProgressResponse{CancelRequested: cancelRequested}, nil
+}
+
+var (
+	errLeaseExpired = status.Error(codes.FailedPrecondition, "lease expired")
+	errLeaseLost    = status.Error(codes.FailedPrecondition, "job is no longer held by this lease")
+)
+
+// leaseToken returns the token a remote worker presents for the job of msg.
+// It names the attempt as well as the message: a queue may give a redelivered
+// message the same token, and the attempt tells the runs apart. Both are
+// signed, so only tokens this service issued are accepted.
+func (h *QueueHandler) leaseToken(msg *queue.JobMessage, attempt int) string {
+	lease := msg.LeaseToken() + "." + strconv.Itoa(attempt)
+	return lease + "." + base64.RawURLEncoding.EncodeToString(h.signLease(lease))
+}
+
+// signLease returns the signature of the message and attempt part of a lease token.
+func (h *QueueHandler) signLease(lease string) []byte {
+	mac := hmac.New(sha256.New, h.cfg.LeaseSecret)
+	mac.Write([]byte(lease))
+	return mac.Sum(nil)
+}
+
+// parseLeaseToken checks the signature of a lease token and returns the
+// message and attempt it names.
+func (h *QueueHandler) parseLeaseToken(token string) (*queue.JobMessage, int, error) {
+	lease, sigText, ok := cutLast(token, ".")
+	if !ok {
+		return nil, 0, errors.New("invalid lease token: missing signature")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigText)
+	if err != nil || !hmac.Equal(sig, h.signLease(lease)) {
+		return nil, 0, errors.New("invalid lease token: bad signature")
+	}
+
+	queueToken, attemptText, ok := strings.Cut(lease, ".")
+	if !ok {
+		return nil, 0, errors.New("invalid lease token: missing attempt")
+	}
+	attempt, err := strconv.Atoi(attemptText)
+	if err != nil || attempt < 1 {
+		return nil, 0, errors.New("invalid lease token: bad attempt")
+	}
+	msg, err := queue.ParseLeaseToken(queueToken)
+	if err != nil {
+		return nil, 0, err
+	}
+	return msg, attempt, nil
+}
+
+// cutLast slices s around the last instance of sep, as strings.Cut does
+// around the first.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// leasedJob returns the message and job a lease token was issued for, with
+// the job's attempt set to the one the token holds. The job must still be
+// processing that attempt.
+func (h *QueueHandler) leasedJob(ctx context.Context, token string) (*queue.JobMessage, *store.Job, error) {
+	msg, attempt, err := h.parseLeaseToken(token)
+	if err != nil {
+		h.logger.Warn("Invalid lease token", "error", err)
+		return nil, nil, status.Error(codes.InvalidArgument, "invalid lease token")
+	}
+
+	job, err := h.store.GetJobByID(ctx, msg.JobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		return nil, nil, status.Errorf(codes.NotFound, "job %s not found", msg.JobID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to get job from store", "error", err, "job_id", msg.JobID.String())
+		return nil, nil, status.Error(codes.Internal, "failed to get job")
+	}
+	if job.Status != store.JobStatusProcessing {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "job is %s, not processing", job.Status)
+	}
+	if job.Attempts != attempt {
+		return nil, nil, errLeaseLost
+	}
+
+	return msg, job, nil
+}
+
+// holdLease checks that the queue still leases msg to the caller, and keeps
+// the lease long enough to record the job's outcome and ack it.
+func (h *QueueHandler) holdLease(ctx context.Context, logger *slog.Logger, msg *queue.JobMessage) error {
+	held, err := h.queue.ExtendLease(ctx, msg, settleLease)
+	if err != nil {
+		logger.Error("Failed to check lease", "error", err)
+		return status.Error(codes.Internal, "failed to check lease")
+	}
+	if !held {
+		return errLeaseExpired
+	}
+	return nil
+}
+
+// finishAttempt closes the current attempt of a job leased to a remote worker
+// in its attempt history.
+func (h *QueueHandler) finishAttempt(ctx context.Context, logger *slog.Logger, job *store.Job, end store.AttemptEnd) {
+	if err := h.store.FinishAttempt(ctx, job.ID, job.Attempts, end); err != nil {
+		logger.Error("Failed to record job attempt", "error", err, "outcome", end.Outcome)
+	}
+}
+
+// ackMessage releases a message's lease once its job's outcome is recorded.
+func (h *QueueHandler) ackMessage(ctx context.Context, logger *slog.Logger, msg *queue.JobMessage) {
+	held, err := h.queue.Ack(ctx, msg)
+	if err != nil {
+		logger.Error("Failed to ack job", "error", err)
+		return
+	}
+	if !held {
+		logger.Warn("Job lease expired before ack, it may be processed again")
+	}
+}
+
+// nackMessage returns a message to its queue.
+func (h *QueueHandler) nackMessage(ctx context.Context, logger *slog.Logger, msg *queue.JobMessage) {
+	if _, err := h.queue.Nack(ctx, msg); err != nil {
+		logger.Error("Failed to return job to the queue", "error", err)
+	}
+}
+
+// durationArg validates an optional duration argument, returning def when it
+// is not set or zero. Errors are gRPC status errors.
+func durationArg(d *durationpb.Duration, name string, def, limit time.Duration) (time.Duration, error) {
+	if d == nil {
+		return def, nil
+	}
+	if err := d.CheckValid(); err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid %s: %s", name, err)
+	}
+	v := d.AsDuration()
+	if v < 0 || v > limit {
+		return 0, status.Errorf(codes.InvalidArgument, "%s must be between 0 and %s", name, limit)
+	}
+	if v == 0 {
+		return def, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func fetchOne(t *testing.T, deps *testDeps) *queuepb.LeasedJob {
+	t.Helper()
+	resp, err := deps.handler.FetchJobs(context.Background(), &queuepb.FetchJobsRequest{
+		Types: []queuepb.JobType{queuepb.JobType_JOB_STANDARD},
+		Wait:  durationpb.New(5 * time.Second),
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 1)
+	return resp.Jobs[0]
+}
+
+// testLeaseTakenOver lets a lease expire and the job be leased again, then
+// checks that the first lease can no longer settle or report on the job.
+func testLeaseTakenOver(t *testing.T, h *QueueHandler) {
+	ctx := context.Background()
+
+	enqueueResp, err := h.EnqueueJob(ctx, standardJob("slow", ""))
+	require.NoError(t, err)
+
+	fetch := func() *queuepb.LeasedJob {
+		resp, err := h.FetchJobs(ctx, &queuepb.FetchJobsRequest{
+			Types:         []queuepb.JobType{queuepb.JobType_JOB_STANDARD},
+			Wait:          durationpb.New(5 * time.Second),
+			LeaseDuration: durationpb.New(200 * time.Millisecond),
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Jobs, 1)
+		return resp.Jobs[0]
+	}
+
+	first := fetch()
+	time.Sleep(300 * time.Millisecond)
+	reaped, err := h.queue.ReapExpired(ctx, "JOB_STANDARD", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 1, reaped)
+
+	second := fetch()
+	assert.Equal(t, int32(2), second.Job.Attempts)
+	assert.NotEqual(t, first.LeaseToken, second.LeaseToken)
+
+	_, err = h.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: first.LeaseToken})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = h.NackJob(ctx, &queuepb.NackJobRequest{LeaseToken: first.LeaseToken, Error: "too late"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = h.ReportProgress(ctx, &queuepb.ReportProgressRequest{LeaseToken: first.LeaseToken, Percent: 50})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = h.ExtendLease(ctx, &queuepb.ExtendLeaseRequest{LeaseToken: first.LeaseToken})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	job, err := h.store.GetJobByID(ctx, uuid.MustParse(enqueueResp.JobId))
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusProcessing, job.Status)
+	assert.Nil(t, job.ProgressPercent)
+	assert.Nil(t, job.LastError)
+
+	ackResp, err := h.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: second.LeaseToken})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, ackResp.Status)
+}
+
+func TestFetchJobs_AckCompletesJob(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("remote", ""))
+	require.NoError(t, err)
+
+	leased := fetchOne(t, deps)
+	assert.Equal(t, enqueueResp.JobId, leased.Job.JobId)
+	assert.Equal(t, []byte("remote"), leased.Job.Payload)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_PROCESSING, leased.Job.Status)
+	assert.Equal(t, int32(1), leased.Job.Attempts)
+	assert.True(t, leased.LeaseExpiresAt.AsTime().After(time.Now()))
+
+	ackResp, err := deps.handler.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, ackResp.Status)
+
+	job, err := deps.store.GetJobByID(ctx, uuid.MustParse(enqueueResp.JobId))
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusCompleted, job.Status)
+
+	processingLen, err := deps.queue.GetProcessingLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), processingLen)
+
+	// The lease is spent
+	_, err = deps.handler.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestFetchJobs_EmptyAfterWait(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	start := time.Now()
+	resp, err := deps.handler.FetchJobs(context.Background(), &queuepb.FetchJobsRequest{
+		Types:   []queuepb.JobType{queuepb.JobType_JOB_STANDARD, queuepb.JobType_JOB_WEBHOOK},
+		MaxJobs: 10,
+		Wait:    durationpb.New(300 * time.Millisecond),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Jobs)
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestNackJob_SchedulesRetry(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("flaky", ""))
+	require.NoError(t, err)
+	leased := fetchOne(t, deps)
+
+	nackResp, err := deps.handler.NackJob(ctx, &queuepb.NackJobRequest{
+		LeaseToken: leased.LeaseToken,
+		Error:      "upstream timeout",
+		RetryDelay: durationpb.New(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_RETRYING, nackResp.Status)
+
+	job, err := deps.store.GetJobByID(ctx, uuid.MustParse(enqueueResp.JobId))
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusRetrying, job.Status)
+	require.NotNil(t, job.LastError)
+	assert.Equal(t, "upstream timeout", *job.LastError)
+	require.NotNil(t, job.NextRunAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *job.NextRunAt, time.Minute)
+
+	scheduledLen, err := deps.queue.GetScheduledLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), scheduledLen)
+}
+
+func TestNackJob_Permanent(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := deps.handler.EnqueueJob(ctx, standardJob("broken", ""))
+	require.NoError(t, err)
+	leased := fetchOne(t, deps)
+
+	nackResp, err := deps.handler.NackJob(ctx, &queuepb.NackJobRequest{
+		LeaseToken: leased.LeaseToken,
+		Error:      "invalid payload",
+		Permanent:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_FAILED, nackResp.Status)
+}
+
+func TestExtendLease_ReportsCancellation(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("long", ""))
+	require.NoError(t, err)
+	leased := fetchOne(t, deps)
+
+	extendResp, err := deps.handler.ExtendLease(ctx, &queuepb.ExtendLeaseRequest{
+		LeaseToken:    leased.LeaseToken,
+		LeaseDuration: durationpb.New(30 * time.Minute),
+	})
+	require.NoError(t, err)
+	assert.False(t, extendResp.CancelRequested)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), extendResp.LeaseExpiresAt.AsTime(), time.Minute)
+
+	_, err = deps.handler.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+
+	extendResp, err = deps.handler.ExtendLease(ctx, &queuepb.ExtendLeaseRequest{LeaseToken: leased.LeaseToken})
+	require.NoError(t, err)
+	assert.True(t, extendResp.CancelRequested)
+
+	ackResp, err := deps.handler.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken, Cancelled: true})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_CANCELLED, ackResp.Status)
+}
+
+func TestGetJobStatus_ReportsHeartbeat(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("long", ""))
+	require.NoError(t, err)
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Nil(t, statusResp.LastHeartbeatAt)
+	assert.Nil(t, statusResp.HeartbeatAge)
+
+	leased := fetchOne(t, deps)
+	time.Sleep(100 * time.Millisecond)
+
+	statusResp, err = deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	require.NotNil(t, statusResp.LastHeartbeatAt)
+	require.NotNil(t, statusResp.HeartbeatAge)
+	assert.GreaterOrEqual(t, statusResp.HeartbeatAge.AsDuration(), 100*time.Millisecond)
+
+	// Extending the lease is the remote worker's heartbeat
+	_, err = deps.handler.ExtendLease(ctx, &queuepb.ExtendLeaseRequest{LeaseToken: leased.LeaseToken})
+	require.NoError(t, err)
+
+	statusResp, err = deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Less(t, statusResp.HeartbeatAge.AsDuration(), 100*time.Millisecond)
+}
+
+func TestReportProgress_CheckpointSurvivesRetry(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("resumable", ""))
+	require.NoError(t, err)
+	leased := fetchOne(t, deps)
+	assert.Nil(t, leased.Job.Checkpoint)
+
+	progressResp, err := deps.handler.ReportProgress(ctx, &queuepb.ReportProgressRequest{
+		LeaseToken: leased.LeaseToken,
+		Percent:    60,
+		Message:    "imported 600 of 1000 rows",
+		Checkpoint: []byte(`{"offset":600}`),
+	})
+	require.NoError(t, err)
+	assert.False(t, progressResp.CancelRequested)
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	require.NotNil(t, statusResp.Progress)
+	assert.Equal(t, int32(60), statusResp.Progress.Percent)
+	assert.Equal(t, "imported 600 of 1000 rows", statusResp.Progress.Message)
+	assert.Equal(t, []byte(`{"offset":600}`), statusResp.Checkpoint)
+
+	// The next attempt picks up where this one left off
+	_, err = deps.handler.NackJob(ctx, &queuepb.NackJobRequest{LeaseToken: leased.LeaseToken, Error: "connection reset"})
+	require.NoError(t, err)
+	require.NoError(t, deps.queue.Enqueue(ctx, uuid.MustParse(enqueueResp.JobId), "JOB_STANDARD", 0))
+
+	retried := fetchOne(t, deps)
+	assert.Equal(t, int32(2), retried.Job.Attempts)
+	assert.Equal(t, []byte(`{"offset":600}`), retried.Job.Checkpoint)
+
+	// Progress without a checkpoint keeps the saved one
+	_, err = deps.handler.ReportProgress(ctx, &queuepb.ReportProgressRequest{LeaseToken: retried.LeaseToken, Percent: 80})
+	require.NoError(t, err)
+	job, err := deps.store.GetJobByID(ctx, uuid.MustParse(enqueueResp.JobId))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"offset":600}`), job.Checkpoint)
+
+	_, err = deps.handler.ReportProgress(ctx, &queuepb.ReportProgressRequest{LeaseToken: retried.LeaseToken, Percent: 101})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAckJob_LeaseTakenOver(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	testLeaseTakenOver(t, deps.handler)
+}
+
+func TestRemoteWorker_InvalidRequests(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := deps.handler.FetchJobs(ctx, &queuepb.FetchJobsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = deps.handler.FetchJobs(ctx, &queuepb.FetchJobsRequest{
+		Types:   []queuepb.JobType{queuepb.JobType_JOB_STANDARD},
+		MaxJobs: maxFetchJobs + 1,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = deps.handler.FetchJobs(ctx, &queuepb.FetchJobsRequest{
+		Types: []queuepb.JobType{queuepb.JobType_JOB_STANDARD},
+		Wait:  durationpb.New(time.Minute),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = deps.handler.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: "bogus"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = deps.handler.NackJob(ctx, &queuepb.NackJobRequest{LeaseToken: "bogus"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultListPageSize = 50
+	maxListPageSize     = 500
+)
+
+func (h *QueueHandler) ListJobs(ctx context.Context, req *queuepb.ListJobsRequest) (*queuepb.ListJobsResponse, error) {
+	filter, err := fromProtoJobFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	opts := store.ListJobsOptions{
+		OldestFirst:    req.GetOrder() == queuepb.SortOrder_SORT_ORDER_OLDEST_FIRST,
+		IncludePayload: req.GetIncludePayload(),
+	}
+	pageSize := clampLimit(req.GetPageSize(), defaultListPageSize, maxListPageSize)
+
+	if req.GetPageToken() != "" {
+		createdAt, id, err := decodeCursor(req.GetPageToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		opts.After = &store.JobCursor{CreatedAt: createdAt, ID: id}
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	opts.Limit = pageSize + 1
+	jobs, err := h.store.ListJobs(ctx, filter, opts)
+	if err != nil {
+		h.logger.Error("Failed to list jobs", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list jobs")
+	}
+
+	resp := &queuepb.ListJobsResponse{}
+	if len(jobs) > pageSize {
+		jobs = jobs[:pageSize]
+		last := jobs[len(jobs)-1]
+		resp.NextPageToken = encodeCursor(last.CreatedAt, last.ID)
+	}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, toProtoJob(job))
+	}
+
+	return resp, nil
+}
+
+func fromProtoJobFilter(f *queuepb.JobFilter) (store.JobFilter, error) {
+	filter := store.JobFilter{Tags: f.GetTags()}
+
+	if f.GetType() != queuepb.JobType_JOB_TYPE_UNSPECIFIED {
+		filter.Type = f.GetType().String()
+	}
+	for _, s := range f.GetStatuses() {
+		stored, ok := fromProtoJobStatus(s)
+		if !ok {
+			return store.JobFilter{}, fmt.Errorf("invalid status %v", s)
+		}
+		filter.Statuses = append(filter.Statuses, stored)
+	}
+	if f.GetCreatedAfter() != nil {
+		t := f.GetCreatedAfter().AsTime()
+		filter.CreatedAfter = &t
+	}
+	if f.GetCreatedBefore() != nil {
+		t := f.GetCreatedBefore().AsTime()
+		filter.CreatedBefore = &t
+	}
+
+	return filter, nil
+}
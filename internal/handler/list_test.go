@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// enqueueTagged enqueues a standard job with tags and returns its ID.
+func enqueueTagged(t *testing.T, deps *testDeps, tags map[string]string) string {
+	resp, err := deps.handler.EnqueueJob(context.Background(), &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("list payload"),
+		Tags:    tags,
+	})
+	require.NoError(t, err)
+
+	// Keep created_at distinct so the expected order is unambiguous
+	time.Sleep(5 * time.Millisecond)
+	return resp.JobId
+}
+
+func TestListJobs_PagesNewestFirst(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var ids []string
+	for range 3 {
+		ids = append(ids, enqueueTagged(t, deps, nil))
+	}
+
+	resp, err := deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 2)
+	assert.Equal(t, ids[2], resp.Jobs[0].JobId)
+	assert.Equal(t, ids[1], resp.Jobs[1].JobId)
+	assert.Nil(t, resp.Jobs[0].Payload)
+	require.NotEmpty(t, resp.NextPageToken)
+
+	// A job inserted between pages does not shift the next one
+	enqueueTagged(t, deps, nil)
+
+	resp, err = deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{PageSize: 2, PageToken: resp.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 1)
+	assert.Equal(t, ids[0], resp.Jobs[0].JobId)
+	assert.Empty(t, resp.NextPageToken)
+}
+
+func TestListJobs_OldestFirstWithPayload(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	first := enqueueTagged(t, deps, nil)
+	enqueueTagged(t, deps, nil)
+
+	resp, err := deps.handler.ListJobs(context.Background(), &queuepb.ListJobsRequest{
+		Order:          queuepb.SortOrder_SORT_ORDER_OLDEST_FIRST,
+		PageSize:       1,
+		IncludePayload: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 1)
+	assert.Equal(t, first, resp.Jobs[0].JobId)
+	assert.Equal(t, []byte("list payload"), resp.Jobs[0].Payload)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, resp.Jobs[0].Status)
+}
+
+func TestListJobs_Filters(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	acme := enqueueTagged(t, deps, map[string]string{"tenant": "acme", "env": "prod"})
+	enqueueTagged(t, deps, map[string]string{"tenant": "globex"})
+
+	resp, err := deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{
+		Filter: &queuepb.JobFilter{Tags: map[string]string{"tenant": "acme"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Jobs, 1)
+	assert.Equal(t, acme, resp.Jobs[0].JobId)
+	assert.Equal(t, map[string]string{"tenant": "acme", "env": "prod"}, resp.Jobs[0].Tags)
+
+	resp, err = deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{
+		Filter: &queuepb.JobFilter{
+			Type:     queuepb.JobType_JOB_STANDARD,
+			Statuses: []queuepb.JobStatus{queuepb.JobStatus_JOB_STATUS_COMPLETED, queuepb.JobStatus_JOB_STATUS_FAILED},
+		},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Jobs)
+
+	resp, err = deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{
+		Filter: &queuepb.JobFilter{CreatedAfter: timestamppb.New(time.Now().Add(time.Hour))},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Jobs)
+
+	resp, err = deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{
+		Filter: &queuepb.JobFilter{CreatedBefore: timestamppb.New(time.Now().Add(time.Hour))},
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Jobs, 2)
+}
+
+func TestListJobs_InvalidRequest(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{PageToken: "%%%"})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err = deps.handler.ListJobs(ctx, &queuepb.ListJobsRequest{
+		Filter: &queuepb.JobFilter{Statuses: []queuepb.JobStatus{queuepb.JobStatus_JOB_STATUS_UNSPECIFIED}},
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
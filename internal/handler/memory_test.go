@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/events"
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// setupMemoryHandler builds a handler on the in-memory store and queue, so
+// tests that don't need Postgres or Redis run without containers. The memory
+// store publishes no job events, so watching is covered by the embedded tests.
+func setupMemoryHandler(t *testing.T) (*QueueHandler, *store.MemoryStore, *queue.MemoryQueue) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	memStore := store.NewMemoryStore()
+	memQueue := queue.NewMemoryQueue()
+	t.Cleanup(func() { memQueue.Close() })
+
+	hub := events.NewLocalHub(events.Config{})
+	t.Cleanup(hub.Close)
+
+	return NewQueueHandler(logger, memStore, memQueue, hub, Config{}), memStore, memQueue
+}
+
+func TestMemoryHandler_EnqueueFetchAck(t *testing.T) {
+	h, memStore, memQueue := setupMemoryHandler(t)
+	ctx := context.Background()
+
+	enqueueResp, err := h.EnqueueJob(ctx, standardJob("in memory", ""))
+	require.NoError(t, err)
+
+	queueLen, err := memQueue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queueLen)
+
+	leased := fetchOne(t, &testDeps{handler: h})
+	assert.Equal(t, enqueueResp.JobId, leased.Job.JobId)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_PROCESSING, leased.Job.Status)
+
+	ackResp, err := h.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, ackResp.Status)
+
+	job, err := memStore.GetJobByID(ctx, uuid.MustParse(enqueueResp.JobId))
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusCompleted, job.Status)
+
+	statusResp, err := h.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, statusResp.JobStatus)
+}
+
+func TestMemoryHandler_IdempotentEnqueue(t *testing.T) {
+	h, _, memQueue := setupMemoryHandler(t)
+	ctx := context.Background()
+
+	first, err := h.EnqueueJob(ctx, standardJob("once", "memory-key"))
+	require.NoError(t, err)
+
+	second, err := h.EnqueueJob(ctx, standardJob("once", "memory-key"))
+	require.NoError(t, err)
+	assert.Equal(t, first.JobId, second.JobId)
+
+	queueLen, err := memQueue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queueLen)
+}
+
+func TestMemoryHandler_IdempotencyKeyCoversJobFields(t *testing.T) {
+	h, _, _ := setupMemoryHandler(t)
+	ctx := context.Background()
+
+	original := func() *queuepb.EnqueueJobRequest {
+		req := standardJob("covered", "covered-key")
+		req.Priority = 5
+		req.Tags = map[string]string{"tenant": "acme"}
+		req.RetryPolicy = &queuepb.RetryPolicy{MaxAttempts: 4}
+		return req
+	}
+	first, err := h.EnqueueJob(ctx, original())
+	require.NoError(t, err)
+
+	changes := map[string]func(*queuepb.EnqueueJobRequest){
+		"priority":     func(r *queuepb.EnqueueJobRequest) { r.Priority = 6 },
+		"tags":         func(r *queuepb.EnqueueJobRequest) { r.Tags["tenant"] = "other" },
+		"no tags":      func(r *queuepb.EnqueueJobRequest) { r.Tags = nil },
+		"max attempts": func(r *queuepb.EnqueueJobRequest) { r.RetryPolicy.MaxAttempts = 5 },
+		"base delay":   func(r *queuepb.EnqueueJobRequest) { r.RetryPolicy.BaseDelay = durationpb.New(2 * time.Second) },
+		"jitter":       func(r *queuepb.EnqueueJobRequest) { r.RetryPolicy.Jitter = queuepb.JitterStrategy_JITTER_STRATEGY_NONE },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			req := original()
+			change(req)
+
+			_, err := h.EnqueueJob(ctx, req)
+			assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+			resp, err := h.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{Jobs: []*queuepb.EnqueueJobRequest{req}})
+			require.NoError(t, err)
+			assert.Equal(t, int32(codes.AlreadyExists), resp.Results[0].Code)
+
+			resp, err = h.EnqueueJobs(ctx, &queuepb.EnqueueJobsRequest{Jobs: []*queuepb.EnqueueJobRequest{req}, Atomic: true})
+			require.NoError(t, err)
+			assert.Equal(t, int32(codes.AlreadyExists), resp.Results[0].Code)
+		})
+	}
+
+	// The schedule is not covered, as a delay differs on every retry
+	req := original()
+	req.Delay = durationpb.New(time.Minute)
+	second, err := h.EnqueueJob(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.JobId, second.JobId)
+}
+
+func TestMemoryHandler_CancelQueuedJob(t *testing.T) {
+	h, _, memQueue := setupMemoryHandler(t)
+	ctx := context.Background()
+
+	enqueueResp, err := h.EnqueueJob(ctx, standardJob("cancel me", ""))
+	require.NoError(t, err)
+
+	cancelResp, err := h.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.CancelOutcome_CANCEL_OUTCOME_CANCELLED, cancelResp.Outcome)
+
+	queueLen, err := memQueue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), queueLen)
+
+	_, err = h.CancelJob(ctx, &queuepb.CancelJobRequest{JobId: uuid.NewString()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestMemoryHandler_LeaseTakenOver(t *testing.T) {
+	h, _, _ := setupMemoryHandler(t)
+	testLeaseTakenOver(t, h)
+}
+
+func TestMemoryHandler_ForgedLeaseToken(t *testing.T) {
+	h, memStore, memQueue := setupMemoryHandler(t)
+	ctx := context.Background()
+
+	_, err := h.EnqueueJob(ctx, standardJob("leased", ""))
+	require.NoError(t, err)
+	leased := fetchOne(t, &testDeps{handler: h})
+
+	// Without the signature, a token is only the job's message and attempt
+	lease, _, ok := cutLast(leased.LeaseToken, ".")
+	require.True(t, ok)
+	forger := NewQueueHandler(h.logger, memStore, memQueue, h.events, Config{LeaseSecret: []byte("guessed")})
+	for _, token := range []string{lease, lease + ".", forger.leaseToken(mustParseLease(t, h, leased.LeaseToken), 1)} {
+		_, err = h.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: token})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), token)
+		_, err = h.ExtendLease(ctx, &queuepb.ExtendLeaseRequest{LeaseToken: token})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), token)
+	}
+
+	// A replica sharing the secret accepts the token
+	replica := NewQueueHandler(h.logger, memStore, memQueue, h.events, Config{LeaseSecret: h.cfg.LeaseSecret})
+	ackResp, err := replica.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, ackResp.Status)
+}
+
+// mustParseLease returns the message of a lease token h issued.
+func mustParseLease(t *testing.T, h *QueueHandler, token string) *queue.JobMessage {
+	t.Helper()
+	msg, _, err := h.parseLeaseToken(token)
+	require.NoError(t, err)
+	return msg
+}
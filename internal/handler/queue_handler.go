@@ -1,35 +1,142 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/events"
+	"github.com/turnertastic1/boltq/internal/outbox"
 	"github.com/turnertastic1/boltq/internal/queue"
 	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/webhook"
 	"github.com/turnertastic1/boltq/pkg/queuepb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	defaultIdempotencyRetention = 24 * time.Hour
+	defaultStreamBatchSize      = 100
+	defaultStreamFlushInterval  = 5 * time.Millisecond
+)
+
+// Config controls request handling.
+type Config struct {
+	// IdempotencyRetention is how long an idempotency key stays tied to the
+	// job created with it. After that the key can create a new job.
+	IdempotencyRetention time.Duration
+	// StreamBatchSize is the most jobs EnqueueStream writes at once, up to
+	// the EnqueueJobs limit. It also bounds how many jobs a stream buffers
+	// while a write is in progress.
+	StreamBatchSize int
+	// StreamFlushInterval is how long EnqueueStream waits for more jobs to
+	// fill a batch before writing it.
+	StreamFlushInterval time.Duration
+	// LeaseSecret signs the lease tokens given to remote workers, so they
+	// cannot be forged from a job's ID and attempt. Replicas must share it.
+	// If empty, a random secret is used, and tokens are only accepted by the
+	// process that issued them.
+	LeaseSecret []byte
+}
+
 type QueueHandler struct {
 	queuepb.UnimplementedQueueServiceServer
 	logger *slog.Logger
-	store  *store.PostgresStore
-	queue  *queue.RedisQueue
+	store  store.JobStore
+	queue  queue.JobQueue
+	events events.Source
+	cfg    Config
 }
 
-func NewQueueHandler(l *slog.Logger, s *store.PostgresStore, q *queue.RedisQueue) *QueueHandler {
+// NewQueueHandler creates a QueueHandler. Zero values in cfg are replaced with
+// defaults. e must deliver the job events of s, which the watch RPCs and
+// waiting GetJobResult calls are built on.
+func NewQueueHandler(l *slog.Logger, s store.JobStore, q queue.JobQueue, e events.Source, cfg Config) *QueueHandler {
+	if cfg.IdempotencyRetention <= 0 {
+		cfg.IdempotencyRetention = defaultIdempotencyRetention
+	}
+	if cfg.StreamBatchSize <= 0 {
+		cfg.StreamBatchSize = defaultStreamBatchSize
+	}
+	cfg.StreamBatchSize = min(cfg.StreamBatchSize, maxBatchSize)
+	if cfg.StreamFlushInterval <= 0 {
+		cfg.StreamFlushInterval = defaultStreamFlushInterval
+	}
+	if len(cfg.LeaseSecret) == 0 {
+		cfg.LeaseSecret = make([]byte, leaseSecretSize)
+		rand.Read(cfg.LeaseSecret)
+	}
+
 	return &QueueHandler{
 		logger: l,
 		store:  s,
 		queue:  q,
+		events: e,
+		cfg:    cfg,
 	}
 }
 
-const maxPayloadSize = 1024 * 1024 // 1 MB
+const (
+	maxPayloadSize     = 1024 * 1024 // 1 MB
+	maxScheduleHorizon = 365 * 24 * time.Hour
+	minPriority        = -100
+	maxPriority        = 100
+	maxIdempotencyKey  = 255
+	maxTags            = 16
+	maxTagKey          = 64
+	maxTagValue        = 256
+	// Job events carry tags only up to 4000 bytes of JSON (migration 013), so
+	// a larger set would never match a WatchJobs tag filter
+	maxTagsJSON = 4000
+)
 
 func (h *QueueHandler) EnqueueJob(ctx context.Context, req *queuepb.EnqueueJobRequest) (*queuepb.EnqueueJobResponse, error) {
+	job, err := h.newJob(req)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("Received EnqueueJob request", "type", req.GetType(), "payload_size", len(req.GetPayload()))
+
+	// 1. Save job and its outbox entry to Postgres in one transaction
+	var entry *store.OutboxEntry
+	if job.IdempotencyKey != nil {
+		var existing *store.Job
+		existing, entry, err = h.store.CreateIdempotentJob(ctx, job, h.cfg.IdempotencyRetention)
+		if err == nil && existing != nil {
+			return h.duplicateEnqueue(existing, job)
+		}
+	} else {
+		entry, err = h.store.CreateJobWithOutbox(ctx, job)
+	}
+	if err != nil {
+		h.logger.Error("Failed to create job in store", "error", err)
+		return nil, status.Error(codes.Internal, "failed to enqueue job")
+	}
+
+	// 2. Add job reference to Redis, onto its queue or into the scheduled set.
+	// The job is already durable, so a failure here only delays it until the
+	// outbox relay publishes it.
+	h.publish(ctx, entry)
+
+	h.logger.Info("Job enqueued successfully", "job_id", job.ID.String())
+
+	return &queuepb.EnqueueJobResponse{
+		JobId: job.ID.String(),
+	}, nil
+}
+
+// newJob validates an enqueue request and builds the job it creates. Errors
+// are gRPC status errors.
+func (h *QueueHandler) newJob(req *queuepb.EnqueueJobRequest) (*store.Job, error) {
 	if req.GetType() == queuepb.JobType_JOB_TYPE_UNSPECIFIED {
 		h.logger.Warn("Invalid job type", "type", req.GetType())
 		return nil, status.Error(codes.InvalidArgument, "invalid job type")
@@ -45,38 +152,234 @@ func (h *QueueHandler) EnqueueJob(ctx context.Context, req *queuepb.EnqueueJobRe
 		return nil, status.Errorf(codes.InvalidArgument, "payload size exceeds maximum limit: %d", maxPayloadSize)
 	}
 
-	h.logger.Info("Received EnqueueJob request", "type", req.GetType(), "payload_size", len(req.GetPayload()))
+	if req.GetPriority() < minPriority || req.GetPriority() > maxPriority {
+		h.logger.Warn("Priority out of range", "priority", req.GetPriority())
+		return nil, status.Errorf(codes.InvalidArgument, "priority must be between %d and %d", minPriority, maxPriority)
+	}
+
+	if len(req.GetIdempotencyKey()) > maxIdempotencyKey {
+		h.logger.Warn("Idempotency key too long", "size", len(req.GetIdempotencyKey()))
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key exceeds maximum length: %d", maxIdempotencyKey)
+	}
+
+	if err := validateTags(req.GetTags()); err != nil {
+		h.logger.Warn("Invalid tags", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.GetType() == queuepb.JobType_JOB_WEBHOOK {
+		if _, err := webhook.ParsePayload(req.GetPayload()); err != nil {
+			h.logger.Warn("Invalid webhook payload", "error", err)
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
 
-	jobId := uuid.New()
 	jobType := req.GetType().String()
 
-	// 1. Save job to Postgres (persistent store)
+	retryPolicy, err := fromProtoRetryPolicy(req.GetRetryPolicy(), jobType)
+	if err != nil {
+		h.logger.Warn("Invalid retry policy", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid retry policy: %s", err)
+	}
+
+	runAt, err := scheduledRunAt(req)
+	if err != nil {
+		h.logger.Warn("Invalid schedule", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	job := &store.Job{
-		ID:      jobId,
-		Type:    jobType,
-		Payload: req.GetPayload(),
-		Status:  store.JobStatusQueued,
+		ID:       uuid.New(),
+		Type:     jobType,
+		Payload:  req.GetPayload(),
+		Status:   store.JobStatusQueued,
+		Priority: int(req.GetPriority()),
+		Tags:     req.GetTags(),
+
+		MaxAttempts:    retryPolicy.MaxAttempts,
+		RetryBaseDelay: retryPolicy.BaseDelay,
+		RetryMaxDelay:  retryPolicy.MaxDelay,
+		RetryJitter:    retryPolicy.Jitter,
+	}
+	if runAt != nil {
+		job.Status = store.JobStatusScheduled
+		job.NextRunAt = runAt
+	}
+	if key := req.GetIdempotencyKey(); key != "" {
+		job.IdempotencyKey = &key
 	}
 
-	if err := h.store.CreateJob(ctx, job); err != nil {
-		h.logger.Error("Failed to create job in store", "error", err)
-		return nil, status.Error(codes.Internal, "failed to enqueue job")
+	return job, nil
+}
+
+func (h *QueueHandler) GetJobStatus(ctx context.Context, req *queuepb.GetJobStatusRequest) (*queuepb.GetJobStatusResponse, error) {
+	jobID, err := uuid.Parse(req.GetJobId())
+	if err != nil {
+		h.logger.Warn("Invalid job ID", "job_id", req.GetJobId())
+		return nil, status.Error(codes.InvalidArgument, "invalid job ID")
 	}
 
-	// 2. Add job reference to Redis queue
-	if err := h.queue.Enqueue(ctx, jobId, jobType); err != nil {
-		h.logger.Error("Failed to enqueue job to Redis", "error", err, "job_id", jobId.String())
-		// TODO: Consider rolling back the job creation in Postgres here.
-		return nil, status.Error(codes.Internal, "failed to enqueue job")
+	job, err := h.store.GetJobByID(ctx, jobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", jobID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to get job from store", "error", err, "job_id", jobID.String())
+		return nil, status.Error(codes.Internal, "failed to get job status")
 	}
 
-	h.logger.Info("Job enqueued successfully", "job_id", jobId.String())
+	return toJobStatusResponse(job), nil
+}
+
+// duplicateEnqueue answers a request whose idempotency key is held by
+// existing: with the existing job's ID if the request is for the same job,
+// or an error if the key was used for a different one.
+func (h *QueueHandler) duplicateEnqueue(existing, requested *store.Job) (*queuepb.EnqueueJobResponse, error) {
+	if !sameJob(existing, requested) {
+		h.logger.Warn("Idempotency key reused for a different job", "job_id", existing.ID.String())
+		return nil, status.Error(codes.AlreadyExists, "idempotency key already used for a different job")
+	}
+
+	h.logger.Info("Duplicate enqueue, returning existing job", "job_id", existing.ID.String())
 
 	return &queuepb.EnqueueJobResponse{
-		JobId: jobId.String(),
+		JobId: existing.ID.String(),
 	}, nil
 }
 
-func (h *QueueHandler) GetJobStatus(ctx context.Context, req *queuepb.GetJobStatusRequest) (*queuepb.GetJobStatusResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "method GetJobStatus not implemented")
+// validateTags checks the number and size of a job's tags.
+func validateTags(tags map[string]string) error {
+	if len(tags) > maxTags {
+		return fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	for k, v := range tags {
+		if k == "" {
+			return errors.New("tag keys cannot be empty")
+		}
+		if len(k) > maxTagKey {
+			return fmt.Errorf("tag key %q exceeds maximum length: %d", k, maxTagKey)
+		}
+		if len(v) > maxTagValue {
+			return fmt.Errorf("value of tag %q exceeds maximum length: %d", k, maxTagValue)
+		}
+	}
+	if tagsJSONSize(tags) > maxTagsJSON {
+		return fmt.Errorf("tags exceed maximum size: %d bytes of JSON", maxTagsJSON)
+	}
+	return nil
+}
+
+// tagsJSONSize returns an upper bound on the size of tags as Postgres prints
+// them. Postgres adds a space after each colon and comma that encoding/json
+// leaves out, and never escapes more than it does.
+func tagsJSONSize(tags map[string]string) int {
+	if len(tags) == 0 {
+		return 0
+	}
+	b, _ := json.Marshal(tags)
+	return len(b) + 2*len(tags)
+}
+
+// sameJob reports whether requested asks for the same job as existing, which
+// holds its idempotency key. The key covers the type, payload, priority, tags
+// and retry policy. The schedule is left out: a delay resolves against the
+// time of each request, so retries of one request would never agree on it.
+// Retry delays are compared in whole milliseconds, as Postgres stores them.
+func sameJob(existing, requested *store.Job) bool {
+	return existing.Type == requested.Type &&
+		bytes.Equal(existing.Payload, requested.Payload) &&
+		existing.Priority == requested.Priority &&
+		maps.Equal(existing.Tags, requested.Tags) &&
+		existing.MaxAttempts == requested.MaxAttempts &&
+		existing.RetryBaseDelay.Milliseconds() == requested.RetryBaseDelay.Milliseconds() &&
+		existing.RetryMaxDelay.Milliseconds() == requested.RetryMaxDelay.Milliseconds() &&
+		existing.RetryJitter == requested.RetryJitter
+}
+
+// scheduledRunAt returns when a job requested with run_at or delay should run,
+// or nil if it should run straight away.
+func scheduledRunAt(req *queuepb.EnqueueJobRequest) (*time.Time, error) {
+	if req.GetRunAt() != nil && req.GetDelay() != nil {
+		return nil, errors.New("run_at and delay are mutually exclusive")
+	}
+
+	now := time.Now()
+	var runAt time.Time
+	switch {
+	case req.GetRunAt() != nil:
+		if err := req.GetRunAt().CheckValid(); err != nil {
+			return nil, fmt.Errorf("invalid run_at: %w", err)
+		}
+		runAt = req.GetRunAt().AsTime()
+	case req.GetDelay() != nil:
+		if err := req.GetDelay().CheckValid(); err != nil {
+			return nil, fmt.Errorf("invalid delay: %w", err)
+		}
+		if req.GetDelay().AsDuration() < 0 {
+			return nil, errors.New("delay cannot be negative")
+		}
+		runAt = now.Add(req.GetDelay().AsDuration())
+	default:
+		return nil, nil
+	}
+
+	if runAt.Sub(now) > maxScheduleHorizon {
+		return nil, fmt.Errorf("jobs cannot be scheduled more than %s ahead", maxScheduleHorizon)
+	}
+	if !runAt.After(now) {
+		// A time in the past means run now
+		return nil, nil
+	}
+
+	// Stored in a TIMESTAMP column, which drops the zone
+	runAt = runAt.UTC()
+	return &runAt, nil
+}
+
+// publishBatch is publish for many entries, in one Redis round trip.
+func (h *QueueHandler) publishBatch(ctx context.Context, entries []*store.OutboxEntry) {
+	// Entries already queued in the transaction that wrote their jobs are skipped
+	var unsent []*store.OutboxEntry
+	for _, entry := range entries {
+		if entry.SentAt == nil {
+			unsent = append(unsent, entry)
+		}
+	}
+	entries = unsent
+	if len(entries) == 0 {
+		return
+	}
+
+	if err := outbox.PublishBatch(ctx, h.queue, entries); err != nil {
+		h.logger.Warn("Failed to enqueue batch to Redis, leaving it to the outbox relay", "count", len(entries), "error", err)
+		return
+	}
+
+	ids := make([]int64, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	if err := h.store.MarkOutboxSentBatch(ctx, ids); err != nil {
+		h.logger.Warn("Failed to mark outbox entries as sent", "count", len(entries), "error", err)
+	}
+}
+
+// publish hands an outbox entry to Redis and marks it sent. Errors
+// are logged rather than returned: the outbox relay retries unsent entries.
+func (h *QueueHandler) publish(ctx context.Context, entry *store.OutboxEntry) {
+	if entry.SentAt != nil {
+		// Already queued in the transaction that wrote the job
+		return
+	}
+	logger := h.logger.With("job_id", entry.JobID.String())
+
+	if err := outbox.Publish(ctx, h.queue, entry); err != nil {
+		logger.Warn("Failed to enqueue job to Redis, leaving it to the outbox relay", "error", err)
+		return
+	}
+
+	if err := h.store.MarkOutboxSent(ctx, entry.ID); err != nil {
+		// The relay will publish it again; workers skip jobs that already ran.
+		logger.Warn("Failed to mark outbox entry as sent", "error", err)
+	}
 }
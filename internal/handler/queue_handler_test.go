@@ -3,95 +3,50 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	"github.com/testcontainers/testcontainers-go/modules/redis"
-	"github.com/testcontainers/testcontainers-go/wait"
 
+	"github.com/turnertastic1/boltq/internal/events"
 	"github.com/turnertastic1/boltq/internal/queue"
 	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/testutil"
 	"github.com/turnertastic1/boltq/pkg/queuepb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type testDeps struct {
-	handler        *QueueHandler
-	store          *store.PostgresStore
-	queue          *queue.RedisQueue
-	pgContainer    *postgres.PostgresContainer
-	redisContainer *redis.RedisContainer
-	db             *sql.DB
+	handler *QueueHandler
+	store   *store.PostgresStore
+	queue   *queue.RedisQueue
+	db      *sql.DB
 }
 
 func setupTestHandler(t *testing.T) (*testDeps, func()) {
-	ctx := context.Background()
-
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
 
-	pgContainer, err := postgres.Run(ctx,
-		"postgres:17",
-		postgres.WithDatabase("boltq"),
-		postgres.WithUsername("test"),
-		postgres.WithPassword("test"),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("database system is ready to accept connections").
-				WithOccurrence(2).
-				WithStartupTimeout(30*time.Second),
-		),
-	)
-	require.NoError(t, err)
-
-	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
-	require.NoError(t, err)
-
-	db, err := sql.Open("postgres", connStr)
-	require.NoError(t, err)
-	require.NoError(t, db.Ping())
+	db, connString := testutil.StartPostgresWithConnString(t)
+	redisAddr := testutil.StartRedis(t)
 
-	// Run migrations
-	_, err = db.Exec(`
-        CREATE TABLE IF NOT EXISTS jobs (
-            id UUID PRIMARY KEY,
-            type VARCHAR(50) NOT NULL,
-            payload BYTEA NOT NULL,
-            status VARCHAR(20) NOT NULL DEFAULT 'queued',
-            created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-            started_at TIMESTAMP,
-            completed_at TIMESTAMP
-        );
-        CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
-        CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs(created_at DESC);
-    `)
-	require.NoError(t, err)
-
-	// Start Redis container
-	redisContainer, err := redis.Run(ctx,
-		"redis:8.4",
-		redis.WithSnapshotting(10, 1),
-		redis.WithLogLevel(redis.LogLevelVerbose),
-	)
-	require.NoError(t, err)
+	logger.Info("Redis address for tests", "addr", redisAddr)
 
-	// Get Redis connection string
-	redisAddr, err := redisContainer.ConnectionString(ctx)
+	hub, err := events.NewHub(logger, connString, events.Config{})
 	require.NoError(t, err)
-
-	// Remove "redis://" prefix if present
-	redisAddr = strings.TrimPrefix(redisAddr, "redis://")
-
-	logger.Info("Redis address for tests", "addr", redisAddr)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	go hub.Run(hubCtx)
 
 	// Create Redis queue
 	redisQueue, err := queue.NewRedisQueue(redisAddr, "", 0)
@@ -99,26 +54,18 @@ func setupTestHandler(t *testing.T) (*testDeps, func()) {
 
 	// Create store and handler
 	pgStore := store.NewPostgresStore(db)
-	handler := NewQueueHandler(logger, pgStore, redisQueue)
+	handler := NewQueueHandler(logger, pgStore, redisQueue, hub, Config{})
 
 	deps := &testDeps{
-		handler:        handler,
-		store:          pgStore,
-		queue:          redisQueue,
-		pgContainer:    pgContainer,
-		redisContainer: redisContainer,
-		db:             db,
+		handler: handler,
+		store:   pgStore,
+		queue:   redisQueue,
+		db:      db,
 	}
 
 	cleanup := func() {
+		stopHub()
 		redisQueue.Close()
-		db.Close()
-		if err := redisContainer.Terminate(ctx); err != nil {
-			t.Logf("failed to terminate redis container: %s", err)
-		}
-		if err := pgContainer.Terminate(ctx); err != nil {
-			t.Logf("failed to terminate postgres container: %s", err)
-		}
 	}
 
 	return deps, cleanup
@@ -143,6 +90,11 @@ func TestEnqueueJob_Integration(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, count)
 
+	// Verify the outbox entry was published straight away
+	pending, err := deps.store.CountPendingOutbox(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pending)
+
 	// Verify job in Redis queue
 	queueLen, err := deps.queue.GetQueueLength(context.Background(), "JOB_STANDARD")
 	require.NoError(t, err)
@@ -156,6 +108,27 @@ func TestEnqueueJob_Integration(t *testing.T) {
 	assert.Equal(t, "JOB_STANDARD", msg.Type)
 }
 
+func TestEnqueueJob_RedisUnavailable(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// With Redis unreachable the job is still accepted and left for the outbox relay
+	require.NoError(t, deps.queue.Close())
+
+	resp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("integration test payload"),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.JobId)
+
+	pending, err := deps.store.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pending)
+}
+
 func TestEnqueueJob_UnspecifiedType(t *testing.T) {
 	deps, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -217,6 +190,340 @@ func TestEnqueueJob_OversizedPayload(t *testing.T) {
 	assert.Contains(t, st.Message(), "exceeds maximum limit")
 }
 
+func TestEnqueueJob_Webhook(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp, err := deps.handler.EnqueueJob(context.Background(), &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_WEBHOOK,
+		Payload: []byte(`{"url": "https://example.com/hook", "method": "POST", "body": "{}"}`),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.JobId)
+
+	queueLen, err := deps.queue.GetQueueLength(context.Background(), "JOB_WEBHOOK")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queueLen)
+}
+
+func TestEnqueueJob_InvalidWebhookPayload(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp, err := deps.handler.EnqueueJob(context.Background(), &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_WEBHOOK,
+		Payload: []byte(`{"url": "ftp://example.com/hook"}`),
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Contains(t, st.Message(), "scheme")
+}
+
+func TestEnqueueJob_RetryPolicy(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("retry policy payload"),
+		RetryPolicy: &queuepb.RetryPolicy{
+			MaxAttempts: 5,
+			BaseDelay:   durationpb.New(2 * time.Second),
+			Jitter:      queuepb.JitterStrategy_JITTER_STRATEGY_EQUAL,
+		},
+	})
+	require.NoError(t, err)
+
+	job, err := deps.store.GetJobByID(ctx, uuid.MustParse(resp.JobId))
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, job.MaxAttempts)
+	assert.Equal(t, 2*time.Second, job.RetryBaseDelay)
+	assert.Equal(t, time.Minute, job.RetryMaxDelay) // from the JOB_STANDARD default
+	assert.Equal(t, "equal", job.RetryJitter)
+}
+
+func TestEnqueueJob_InvalidRetryPolicy(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp, err := deps.handler.EnqueueJob(context.Background(), &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("retry policy payload"),
+		RetryPolicy: &queuepb.RetryPolicy{
+			BaseDelay: durationpb.New(time.Hour),
+			MaxDelay:  durationpb.New(time.Minute),
+		},
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Contains(t, st.Message(), "invalid retry policy")
+}
+
+func TestEnqueueJob_Priority(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:     queuepb.JobType_JOB_STANDARD,
+		Payload:  []byte("urgent payload"),
+		Priority: 10,
+	})
+	require.NoError(t, err)
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: resp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), statusResp.Priority)
+
+	highLen, err := deps.queue.GetPriorityQueueLength(ctx, "JOB_STANDARD", queue.PriorityHigh)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), highLen)
+}
+
+func TestEnqueueJob_PriorityOutOfRange(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp, err := deps.handler.EnqueueJob(context.Background(), &queuepb.EnqueueJobRequest{
+		Type:     queuepb.JobType_JOB_STANDARD,
+		Payload:  []byte("urgent payload"),
+		Priority: 1000,
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestEnqueueJob_InvalidTags(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tooMany := make(map[string]string)
+	for i := range maxTags + 1 {
+		tooMany[fmt.Sprintf("tag-%d", i)] = "x"
+	}
+	// Each tag fits, but together they are too large for job events
+	tooLarge := make(map[string]string)
+	for i := range maxTags {
+		tooLarge[fmt.Sprintf("tag-%d", i)] = strings.Repeat("v", maxTagValue)
+	}
+
+	for name, tags := range map[string]map[string]string{
+		"too many":   tooMany,
+		"empty key":  {"": "x"},
+		"long key":   {strings.Repeat("k", maxTagKey+1): "x"},
+		"long value": {"tenant": strings.Repeat("v", maxTagValue+1)},
+		"too large":  tooLarge,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := deps.handler.EnqueueJob(context.Background(), &queuepb.EnqueueJobRequest{
+				Type:    queuepb.JobType_JOB_STANDARD,
+				Payload: []byte("tagged payload"),
+				Tags:    tags,
+			})
+
+			assert.Error(t, err)
+			assert.Nil(t, resp)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestEnqueueJob_IdempotencyKey(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	req := &queuepb.EnqueueJobRequest{
+		Type:           queuepb.JobType_JOB_STANDARD,
+		Payload:        []byte("idempotent payload"),
+		IdempotencyKey: "order-42",
+	}
+
+	first, err := deps.handler.EnqueueJob(ctx, req)
+	require.NoError(t, err)
+
+	// A retried request gets the original job back
+	second, err := deps.handler.EnqueueJob(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.JobId, second.JobId)
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queueLen)
+
+	// The same key for a different payload is refused
+	resp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:           queuepb.JobType_JOB_STANDARD,
+		Payload:        []byte("another payload"),
+		IdempotencyKey: "order-42",
+	})
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestEnqueueJob_IdempotencyKeyConcurrent(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	req := &queuepb.EnqueueJobRequest{
+		Type:           queuepb.JobType_JOB_STANDARD,
+		Payload:        []byte("idempotent payload"),
+		IdempotencyKey: "order-43",
+	}
+
+	const callers = 10
+	ids := make(chan string, callers)
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := deps.handler.EnqueueJob(ctx, req)
+			if assert.NoError(t, err) {
+				ids <- resp.JobId
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	unique := make(map[string]struct{})
+	for id := range ids {
+		unique[id] = struct{}{}
+	}
+	assert.Len(t, unique, 1)
+}
+
+func TestEnqueueJob_IdempotencyKeyExpires(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	handler := NewQueueHandler(slog.Default(), deps.store, deps.queue, deps.handler.events, Config{IdempotencyRetention: 50 * time.Millisecond})
+	req := &queuepb.EnqueueJobRequest{
+		Type:           queuepb.JobType_JOB_STANDARD,
+		Payload:        []byte("idempotent payload"),
+		IdempotencyKey: "order-44",
+	}
+
+	first, err := handler.EnqueueJob(ctx, req)
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	second, err := handler.EnqueueJob(ctx, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.JobId, second.JobId)
+}
+
+func TestEnqueueJob_Delayed(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("delayed payload"),
+		Delay:   durationpb.New(time.Hour),
+	})
+	require.NoError(t, err)
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: resp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_SCHEDULED, statusResp.JobStatus)
+	require.NotNil(t, statusResp.NextRunAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), statusResp.NextRunAt.AsTime(), time.Minute)
+
+	// Held in the scheduled set, not the queue
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), queueLen)
+
+	scheduledLen, err := deps.queue.GetScheduledLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), scheduledLen)
+}
+
+func TestEnqueueJob_RunAtInPastEnqueuesImmediately(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("past payload"),
+		RunAt:   timestamppb.New(time.Now().Add(-time.Minute)),
+	})
+	require.NoError(t, err)
+
+	job, err := deps.store.GetJobByID(ctx, uuid.MustParse(resp.JobId))
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusQueued, job.Status)
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queueLen)
+}
+
+func TestEnqueueJob_InvalidSchedule(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		req  *queuepb.EnqueueJobRequest
+	}{
+		{
+			name: "run_at and delay",
+			req: &queuepb.EnqueueJobRequest{
+				RunAt: timestamppb.New(time.Now().Add(time.Hour)),
+				Delay: durationpb.New(time.Hour),
+			},
+		},
+		{
+			name: "negative delay",
+			req:  &queuepb.EnqueueJobRequest{Delay: durationpb.New(-time.Hour)},
+		},
+		{
+			name: "beyond horizon",
+			req:  &queuepb.EnqueueJobRequest{Delay: durationpb.New(2 * maxScheduleHorizon)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Type = queuepb.JobType_JOB_STANDARD
+			tt.req.Payload = []byte("scheduled payload")
+
+			resp, err := deps.handler.EnqueueJob(context.Background(), tt.req)
+
+			assert.Error(t, err)
+			assert.Nil(t, resp)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
 func TestEnqueueJob_MultipleJobs(t *testing.T) {
 	deps, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -262,3 +569,71 @@ func TestEnqueueJob_MultipleJobs(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), queueLen)
 }
+
+func TestGetJobStatus_Integration(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("status test payload"),
+	})
+	require.NoError(t, err)
+
+	resp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+
+	assert.Equal(t, enqueueResp.JobId, resp.JobId)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, resp.JobStatus)
+	assert.Equal(t, queuepb.JobType_JOB_STANDARD, resp.Type)
+	assert.NotNil(t, resp.CreatedAt)
+	assert.Nil(t, resp.StartedAt)
+	assert.Nil(t, resp.CompletedAt)
+	assert.Equal(t, int32(0), resp.Attempts)
+	assert.Equal(t, int32(3), resp.MaxAttempts)
+
+	// Simulate a worker picking up and finishing the job
+	jobID := uuid.MustParse(enqueueResp.JobId)
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+	_, err = deps.store.MarkJobAsCompleted(ctx, jobID, 1, nil)
+	require.NoError(t, err)
+
+	resp, err = deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, resp.JobStatus)
+	assert.NotNil(t, resp.StartedAt)
+	assert.NotNil(t, resp.CompletedAt)
+	assert.Equal(t, int32(1), resp.Attempts)
+}
+
+func TestGetJobStatus_InvalidJobID(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp, err := deps.handler.GetJobStatus(context.Background(), &queuepb.GetJobStatusRequest{JobId: "not-a-uuid"})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Contains(t, st.Message(), "invalid job ID")
+}
+
+func TestGetJobStatus_NotFound(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp, err := deps.handler.GetJobStatus(context.Background(), &queuepb.GetJobStatusRequest{JobId: uuid.NewString()})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.NotFound, st.Code())
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/events"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxResultWait = 30 * time.Second
+
+func (h *QueueHandler) GetJobResult(ctx context.Context, req *queuepb.GetJobResultRequest) (*queuepb.GetJobResultResponse, error) {
+	jobID, err := uuid.Parse(req.GetJobId())
+	if err != nil {
+		h.logger.Warn("Invalid job ID", "job_id", req.GetJobId())
+		return nil, status.Error(codes.InvalidArgument, "invalid job ID")
+	}
+
+	wait, err := durationArg(req.GetWait(), "wait", 0, maxResultWait)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub *events.Subscription
+	if wait > 0 {
+		// Subscribe before reading the job, so it cannot finish unnoticed in between
+		sub = h.events.Subscribe(events.Filter{JobID: jobID})
+		defer sub.Close()
+	}
+
+	job, err := h.store.GetJobByID(ctx, jobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		return nil, status.Errorf(codes.NotFound, "job %s not found", jobID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to get job from store", "error", err, "job_id", jobID.String())
+		return nil, status.Error(codes.Internal, "failed to get job result")
+	}
+
+	if sub != nil && !isTerminal(job.Status) {
+		if err := awaitFinish(ctx, sub, wait); err != nil {
+			return nil, err
+		}
+		job, err = h.store.GetJobByID(ctx, jobID)
+		if err != nil {
+			h.logger.Error("Failed to get job from store", "error", err, "job_id", jobID.String())
+			return nil, status.Error(codes.Internal, "failed to get job result")
+		}
+	}
+
+	return &queuepb.GetJobResultResponse{
+		JobId:       job.ID.String(),
+		Status:      toProtoJobStatus(job.Status),
+		Result:      job.Result,
+		Error:       toProtoJobError(job),
+		CompletedAt: toProtoTimestamp(job.CompletedAt),
+	}, nil
+}
+
+// awaitFinish returns once sub delivers a terminal status or wait elapses.
+// If the hub ends the subscription it returns early, leaving the caller to
+// report the job as it then is.
+func awaitFinish(ctx context.Context, sub *events.Subscription, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+			return nil
+		case ev, ok := <-sub.C:
+			if !ok || isTerminal(ev.Status) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestGetJobResult_WaitsForCompletion(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("report", ""))
+	require.NoError(t, err)
+
+	// Not finished and not waiting: the current state comes straight back
+	resp, err := deps.handler.GetJobResult(ctx, &queuepb.GetJobResultRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, resp.Status)
+	assert.Nil(t, resp.Result)
+
+	leased := fetchOne(t, deps)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_, err := deps.handler.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: leased.LeaseToken, Result: []byte("42 rows")})
+		assert.NoError(t, err)
+	}()
+
+	resp, err = deps.handler.GetJobResult(ctx, &queuepb.GetJobResultRequest{
+		JobId: enqueueResp.JobId,
+		Wait:  durationpb.New(10 * time.Second),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, resp.Status)
+	assert.Equal(t, []byte("42 rows"), resp.Result)
+	assert.Nil(t, resp.Error)
+	assert.NotNil(t, resp.CompletedAt)
+
+	statusResp, err := deps.handler.GetJobStatus(ctx, &queuepb.GetJobStatusRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("42 rows"), statusResp.Result)
+}
+
+func TestGetJobResult_ReportsError(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("doomed", ""))
+	require.NoError(t, err)
+	leased := fetchOne(t, deps)
+
+	_, err = deps.handler.NackJob(ctx, &queuepb.NackJobRequest{
+		LeaseToken:   leased.LeaseToken,
+		Error:        "schema mismatch",
+		ErrorCode:    "bad_schema",
+		ErrorDetails: "column amount is text, want numeric",
+		Permanent:    true,
+	})
+	require.NoError(t, err)
+
+	resp, err := deps.handler.GetJobResult(ctx, &queuepb.GetJobResultRequest{JobId: enqueueResp.JobId})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_FAILED, resp.Status)
+	assert.Nil(t, resp.Result)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "schema mismatch", resp.Error.Message)
+	assert.Equal(t, "bad_schema", resp.Error.Code)
+	assert.Equal(t, "column amount is text, want numeric", resp.Error.Details)
+}
+
+func TestGetJobResult_TimesOut(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, standardJob("slow", ""))
+	require.NoError(t, err)
+
+	start := time.Now()
+	resp, err := deps.handler.GetJobResult(ctx, &queuepb.GetJobResultRequest{
+		JobId: enqueueResp.JobId,
+		Wait:  durationpb.New(300 * time.Millisecond),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, resp.Status)
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestGetJobResult_InvalidRequests(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	_, err := deps.handler.GetJobResult(ctx, &queuepb.GetJobResultRequest{JobId: "not-a-uuid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = deps.handler.GetJobResult(ctx, &queuepb.GetJobResultRequest{
+		JobId: "00000000-0000-0000-0000-000000000001",
+		Wait:  durationpb.New(time.Minute),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = deps.handler.GetJobResult(ctx, &queuepb.GetJobResultRequest{JobId: "00000000-0000-0000-0000-000000000001"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = deps.handler.AckJob(ctx, &queuepb.AckJobRequest{LeaseToken: "bogus", Result: make([]byte, 1024*1024+1)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc"
+)
+
+type enqueueStream = grpc.BidiStreamingServer[queuepb.EnqueueStreamRequest, queuepb.EnqueueStreamResponse]
+
+func (h *QueueHandler) EnqueueStream(stream enqueueStream) error {
+	ctx := stream.Context()
+
+	h.logger.Info("EnqueueStream opened")
+
+	// Receiving stops while the buffer is full, so a producer that outpaces
+	// the writes is held back by gRPC flow control
+	incoming := make(chan *queuepb.EnqueueStreamRequest, h.cfg.StreamBatchSize)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(incoming)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case incoming <- req:
+			case <-ctx.Done():
+				recvErr <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	batch := make([]*queuepb.EnqueueStreamRequest, 0, h.cfg.StreamBatchSize)
+	for req := range incoming {
+		batch = append(batch[:0], req)
+
+		// Wait briefly for more jobs, so jobs sent together share a write
+		timer := time.NewTimer(h.cfg.StreamFlushInterval)
+	fill:
+		for len(batch) < h.cfg.StreamBatchSize {
+			select {
+			case req, ok := <-incoming:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, req)
+			case <-timer.C:
+				break fill
+			}
+		}
+		timer.Stop()
+
+		if err := h.flushStream(ctx, stream, batch); err != nil {
+			return err
+		}
+	}
+
+	// Every job received has been acknowledged
+	err := <-recvErr
+	if errors.Is(err, io.EOF) {
+		h.logger.Info("EnqueueStream closed by client")
+		return nil
+	}
+	return err
+}
+
+// flushStream enqueues a batch of streamed jobs and acknowledges each of them.
+func (h *QueueHandler) flushStream(ctx context.Context, stream enqueueStream, batch []*queuepb.EnqueueStreamRequest) error {
+	jobs := make([]*queuepb.EnqueueJobRequest, len(batch))
+	for i, req := range batch {
+		jobs[i] = req.GetJob()
+	}
+
+	results, err := h.enqueueBatch(ctx, jobs, false)
+	if err != nil {
+		// Fail just these jobs; the client can resend them on the same stream
+		results = make([]*queuepb.EnqueueJobResult, len(batch))
+		for i := range results {
+			results[i] = errorResult(err)
+		}
+	}
+
+	for i, req := range batch {
+		if err := stream.Send(&queuepb.EnqueueStreamResponse{Sequence: req.GetSequence(), Result: results[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// producerStream feeds requests to EnqueueStream and collects its acknowledgements.
+type producerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	reqs chan *queuepb.EnqueueStreamRequest
+	acks chan *queuepb.EnqueueStreamResponse
+}
+
+func newProducerStream(ctx context.Context) *producerStream {
+	return &producerStream{
+		ctx:  ctx,
+		reqs: make(chan *queuepb.EnqueueStreamRequest),
+		acks: make(chan *queuepb.EnqueueStreamResponse, 64),
+	}
+}
+
+func (s *producerStream) Context() context.Context { return s.ctx }
+
+func (s *producerStream) Recv() (*queuepb.EnqueueStreamRequest, error) {
+	select {
+	case req, ok := <-s.reqs:
+		if !ok {
+			return nil, io.EOF
+		}
+		return req, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func (s *producerStream) Send(ack *queuepb.EnqueueStreamResponse) error {
+	s.acks <- ack
+	return nil
+}
+
+func TestEnqueueStream_AcknowledgesEveryJob(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	stream := newProducerStream(ctx)
+
+	done := make(chan error, 1)
+	go func() { done <- deps.handler.EnqueueStream(stream) }()
+
+	const jobs = 25
+	for i := range jobs {
+		req := standardJob("streamed", "")
+		if i == 10 {
+			req.Payload = nil
+		}
+		stream.reqs <- &queuepb.EnqueueStreamRequest{Sequence: uint64(i), Job: req}
+	}
+	close(stream.reqs)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("stream did not end after the client closed it")
+	}
+
+	require.Len(t, stream.acks, jobs)
+	for i := range jobs {
+		ack := <-stream.acks
+		assert.Equal(t, uint64(i), ack.Sequence)
+		if i == 10 {
+			assert.Equal(t, int32(codes.InvalidArgument), ack.Result.Code)
+			continue
+		}
+		assert.Equal(t, int32(codes.OK), ack.Result.Code)
+		assert.NotEmpty(t, ack.Result.JobId)
+	}
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(jobs-1), queueLen)
+}
+
+func TestEnqueueStream_EndsWhenClientCancels(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newProducerStream(ctx)
+
+	done := make(chan error, 1)
+	go func() { done <- deps.handler.EnqueueStream(stream) }()
+
+	stream.reqs <- &queuepb.EnqueueStreamRequest{Sequence: 1, Job: standardJob("streamed", "")}
+	ack := <-stream.acks
+	assert.Equal(t, uint64(1), ack.Sequence)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after the client cancelled")
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/events"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *QueueHandler) WatchJob(req *queuepb.WatchJobRequest, stream grpc.ServerStreamingServer[queuepb.JobEvent]) error {
+	jobID, err := uuid.Parse(req.GetJobId())
+	if err != nil {
+		h.logger.Warn("Invalid job ID", "job_id", req.GetJobId())
+		return status.Error(codes.InvalidArgument, "invalid job ID")
+	}
+
+	ctx := stream.Context()
+
+	// Subscribe before reading the current status, so no change in between is missed
+	sub := h.events.Subscribe(events.Filter{JobID: jobID})
+	defer sub.Close()
+
+	job, err := h.store.GetJobByID(ctx, jobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		return status.Errorf(codes.NotFound, "job %s not found", jobID)
+	}
+	if err != nil {
+		h.logger.Error("Failed to get job from store", "error", err, "job_id", jobID.String())
+		return status.Error(codes.Internal, "failed to watch job")
+	}
+
+	current := &queuepb.JobEvent{
+		JobId:    job.ID.String(),
+		Type:     toProtoJobType(job.Type),
+		Status:   toProtoJobStatus(job.Status),
+		Progress: toProtoProgress(job),
+	}
+	if err := stream.Send(current); err != nil {
+		return err
+	}
+
+	last := job.Status
+	for !isTerminal(last) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-sub.C:
+			if !ok {
+				return h.watchEnded(sub, jobID.String())
+			}
+			if ev.Status == last && ev.Progress == nil {
+				// Already reported with the current status
+				continue
+			}
+			if err := stream.Send(toProtoJobEvent(ev)); err != nil {
+				return err
+			}
+			last = ev.Status
+		}
+	}
+
+	return nil
+}
+
+func (h *QueueHandler) WatchJobs(req *queuepb.WatchJobsRequest, stream grpc.ServerStreamingServer[queuepb.JobEvent]) error {
+	filter := events.Filter{Tags: req.GetTags()}
+	if req.GetType() != queuepb.JobType_JOB_TYPE_UNSPECIFIED {
+		filter.Type = req.GetType().String()
+	}
+
+	ctx := stream.Context()
+
+	sub := h.events.Subscribe(filter)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-sub.C:
+			if !ok {
+				return h.watchEnded(sub, "")
+			}
+			if err := stream.Send(toProtoJobEvent(ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchEnded returns the error for a watch whose subscription the hub ended.
+// Clients can watch again to resume from the current status.
+func (h *QueueHandler) watchEnded(sub *events.Subscription, jobID string) error {
+	err := sub.Err()
+	h.logger.Warn("Watch ended by event hub", "error", err, "job_id", jobID)
+
+	if errors.Is(err, events.ErrSlowSubscriber) {
+		return status.Error(codes.ResourceExhausted, "watch fell behind job events; watch again to resume")
+	}
+	return status.Error(codes.Unavailable, "job event stream interrupted; watch again to resume")
+}
+
+// isTerminal reports whether a job in status s will not change status again
+// without being replayed.
+func isTerminal(s string) bool {
+	switch s {
+	case store.JobStatusCompleted, store.JobStatusFailed, store.JobStatusDead, store.JobStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+func toProtoJobEvent(ev events.Event) *queuepb.JobEvent {
+	pe := &queuepb.JobEvent{
+		JobId:      ev.JobID.String(),
+		Type:       toProtoJobType(ev.Type),
+		Status:     toProtoJobStatus(ev.Status),
+		OccurredAt: timestamppb.New(ev.At),
+	}
+	if ev.Progress != nil {
+		pe.Progress = &queuepb.JobProgress{
+			Percent:   int32(ev.Progress.Percent),
+			Message:   ev.Progress.Message,
+			UpdatedAt: timestamppb.New(ev.Progress.UpdatedAt),
+		}
+	}
+	return pe
+}
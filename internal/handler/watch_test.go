@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/pkg/queuepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// eventStream collects the events a watch sends.
+type eventStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *queuepb.JobEvent
+}
+
+func newEventStream(ctx context.Context) *eventStream {
+	return &eventStream{ctx: ctx, events: make(chan *queuepb.JobEvent, 16)}
+}
+
+func (s *eventStream) Context() context.Context { return s.ctx }
+
+func (s *eventStream) Send(ev *queuepb.JobEvent) error {
+	s.events <- ev
+	return nil
+}
+
+func (s *eventStream) next(t *testing.T) *queuepb.JobEvent {
+	t.Helper()
+	select {
+	case ev := <-s.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+func TestWatchJob_StreamsUntilTerminal(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	enqueueResp, err := deps.handler.EnqueueJob(ctx, &queuepb.EnqueueJobRequest{
+		Type:    queuepb.JobType_JOB_STANDARD,
+		Payload: []byte("watched payload"),
+	})
+	require.NoError(t, err)
+	jobID := uuid.MustParse(enqueueResp.JobId)
+
+	stream := newEventStream(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- deps.handler.WatchJob(&queuepb.WatchJobRequest{JobId: enqueueResp.JobId}, stream)
+	}()
+
+	ev := stream.next(t)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, ev.Status)
+	assert.Nil(t, ev.OccurredAt)
+
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+	ev = stream.next(t)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_PROCESSING, ev.Status)
+	assert.NotNil(t, ev.OccurredAt)
+
+	_, _, err = deps.store.UpdateProgress(ctx, jobID, 1, 50, "halfway", nil)
+	require.NoError(t, err)
+	ev = stream.next(t)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_PROCESSING, ev.Status)
+	require.NotNil(t, ev.Progress)
+	assert.Equal(t, int32(50), ev.Progress.Percent)
+	assert.Equal(t, "halfway", ev.Progress.Message)
+
+	_, err = deps.store.MarkJobAsCompleted(ctx, jobID, 1, nil)
+	require.NoError(t, err)
+	ev = stream.next(t)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_COMPLETED, ev.Status)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not end on a terminal status")
+	}
+}
+
+func TestWatchJob_AlreadyFinished(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:      jobID,
+		Type:    "JOB_STANDARD",
+		Payload: []byte("done"),
+		Status:  store.JobStatusQueued,
+	}))
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+	require.NoError(t, deps.store.MarkJobAsFailed(ctx, jobID, store.JobError{Message: "boom"}))
+
+	stream := newEventStream(ctx)
+	err := deps.handler.WatchJob(&queuepb.WatchJobRequest{JobId: jobID.String()}, stream)
+	require.NoError(t, err)
+
+	require.Len(t, stream.events, 1)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_FAILED, (<-stream.events).Status)
+}
+
+func TestWatchJob_InvalidOrUnknownID(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	err := deps.handler.WatchJob(&queuepb.WatchJobRequest{JobId: "not-a-uuid"}, newEventStream(ctx))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	err = deps.handler.WatchJob(&queuepb.WatchJobRequest{JobId: uuid.New().String()}, newEventStream(ctx))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestWatchJobs_FiltersByTag(t *testing.T) {
+	deps, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := newEventStream(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- deps.handler.WatchJobs(&queuepb.WatchJobsRequest{
+			Type: queuepb.JobType_JOB_STANDARD,
+			Tags: map[string]string{"tenant": "acme"},
+		}, stream)
+	}()
+
+	// Give the watch time to subscribe
+	time.Sleep(100 * time.Millisecond)
+
+	enqueueTagged(t, deps, map[string]string{"tenant": "globex"})
+	acme := enqueueTagged(t, deps, map[string]string{"tenant": "acme"})
+
+	ev := stream.next(t)
+	assert.Equal(t, acme, ev.JobId)
+	assert.Equal(t, queuepb.JobStatus_JOB_STATUS_QUEUED, ev.Status)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not end with its context")
+	}
+	assert.Empty(t, stream.events)
+}
@@ -0,0 +1,154 @@
+// Package outbox relays job references from the Postgres outbox table to the
+// Redis queue.
+//
+// EnqueueJob writes a job and its outbox row in one transaction, then tries
+// to publish the reference straight away. The relay is the safety net: it
+// publishes every row that is still pending once it is older than MinAge, so
+// a Redis failure at enqueue time delays a job instead of losing it.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+const (
+	defaultPollInterval    = time.Second
+	defaultBatchSize       = 100
+	defaultMinAge          = 5 * time.Second
+	defaultRetention       = 24 * time.Hour
+	defaultCleanupInterval = time.Hour
+)
+
+// Config controls how often and how much the relay publishes.
+type Config struct {
+	// PollInterval is how often pending rows are checked for.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of rows published per poll.
+	BatchSize int
+	// MinAge is how long a row must have been pending before the relay
+	// publishes it, leaving room for the enqueue fast path to do so first.
+	MinAge time.Duration
+	// Retention is how long sent rows are kept before being deleted.
+	Retention time.Duration
+}
+
+// Relay publishes pending outbox rows to the Redis queue.
+type Relay struct {
+	logger *slog.Logger
+	store  store.JobStore
+	queue  queue.JobQueue
+	cfg    Config
+}
+
+// NewRelay creates a Relay. Zero values in cfg are replaced with defaults.
+func NewRelay(l *slog.Logger, s store.JobStore, q queue.JobQueue, cfg Config) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.MinAge <= 0 {
+		cfg.MinAge = defaultMinAge
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultRetention
+	}
+
+	return &Relay{
+		logger: l,
+		store:  s,
+		queue:  q,
+		cfg:    cfg,
+	}
+}
+
+// Run relays pending rows every PollInterval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Drain the backlog in full batches before waiting for the next tick.
+		for {
+			sent, err := r.RelayOnce(ctx)
+			if err != nil {
+				r.logger.Error("Failed to relay outbox", "error", err)
+				break
+			}
+			if sent > 0 {
+				r.logger.Info("Relayed pending jobs from outbox", "count", sent)
+			}
+			if sent < r.cfg.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		if time.Since(lastCleanup) >= defaultCleanupInterval {
+			lastCleanup = time.Now()
+			deleted, err := r.store.DeleteSentOutbox(ctx, r.cfg.Retention)
+			if err != nil {
+				r.logger.Error("Failed to delete sent outbox entries", "error", err)
+			} else if deleted > 0 {
+				r.logger.Info("Deleted sent outbox entries", "count", deleted)
+			}
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending rows and returns how many were sent.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	return r.store.RelayOutbox(ctx, r.cfg.BatchSize, r.cfg.MinAge, func(ctx context.Context, entry *store.OutboxEntry) error {
+		if err := Publish(ctx, r.queue, entry); err != nil {
+			r.logger.Warn("Failed to publish outbox entry, will retry", "error", err, "job_id", entry.JobID.String(), "attempts", entry.Attempts+1)
+			return err
+		}
+		return nil
+	})
+}
+
+// Publish hands an outbox entry to Redis: onto its queue, or into the
+// scheduled set if the job runs at a later time.
+func Publish(ctx context.Context, q queue.JobQueue, entry *store.OutboxEntry) error {
+	if entry.RunAt != nil {
+		return q.Schedule(ctx, entry.JobID, entry.JobType, entry.Priority, *entry.RunAt)
+	}
+	return q.Enqueue(ctx, entry.JobID, entry.JobType, entry.Priority)
+}
+
+// PublishBatch hands many outbox entries to Redis in one round trip.
+func PublishBatch(ctx context.Context, q queue.JobQueue, entries []*store.OutboxEntry) error {
+	return q.EnqueueBatch(ctx, batchItems(entries))
+}
+
+// QueueTx is the store.TxEnqueuer for queue.PostgresQueue. It writes the
+// job_queue rows of entries in the store's transaction, so a PostgresStore
+// given it needs no relay.
+type QueueTx struct{}
+
+// EnqueueTx implements store.TxEnqueuer.
+func (QueueTx) EnqueueTx(ctx context.Context, tx *sql.Tx, entries []*store.OutboxEntry) error {
+	return queue.EnqueueTx(ctx, tx, batchItems(entries))
+}
+
+func batchItems(entries []*store.OutboxEntry) []queue.BatchItem {
+	items := make([]queue.BatchItem, len(entries))
+	for i, entry := range entries {
+		items[i] = queue.BatchItem{JobID: entry.JobID, Type: entry.JobType, Priority: entry.Priority, RunAt: entry.RunAt}
+	}
+	return items
+}
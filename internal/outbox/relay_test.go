@@ -0,0 +1,118 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+const testJobType = "JOB_STANDARD"
+
+func setupTestRelay(t *testing.T) (*Relay, *store.PostgresStore, *queue.RedisQueue) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	pgStore := store.NewPostgresStore(testutil.StartPostgres(t))
+
+	redisQueue, err := queue.NewRedisQueue(testutil.StartRedis(t), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { redisQueue.Close() })
+
+	relay := NewRelay(logger, pgStore, redisQueue, Config{MinAge: time.Millisecond})
+	return relay, pgStore, redisQueue
+}
+
+func createPendingJob(t *testing.T, s *store.PostgresStore) uuid.UUID {
+	jobID := uuid.New()
+	_, err := s.CreateJobWithOutbox(context.Background(), &store.Job{
+		ID:      jobID,
+		Type:    testJobType,
+		Payload: []byte("outbox test payload"),
+		Status:  store.JobStatusQueued,
+	})
+	require.NoError(t, err)
+	return jobID
+}
+
+func TestRelay_PublishesPendingEntries(t *testing.T) {
+	relay, pgStore, redisQueue := setupTestRelay(t)
+	ctx := context.Background()
+
+	jobID := createPendingJob(t, pgStore)
+	time.Sleep(10 * time.Millisecond)
+
+	sent, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	pending, err := pgStore.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pending)
+
+	msg, err := redisQueue.Dequeue(ctx, testJobType, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, jobID, msg.JobID)
+
+	// Sent entries are not published again
+	sent, err = relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestRelay_KeepsEntriesPendingWhenRedisFails(t *testing.T) {
+	relay, pgStore, redisQueue := setupTestRelay(t)
+	ctx := context.Background()
+
+	createPendingJob(t, pgStore)
+	time.Sleep(10 * time.Millisecond)
+
+	require.NoError(t, redisQueue.Close())
+
+	sent, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	pending, err := pgStore.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pending)
+}
+
+func TestRelay_SchedulesDelayedEntries(t *testing.T) {
+	relay, pgStore, redisQueue := setupTestRelay(t)
+	ctx := context.Background()
+
+	runAt := time.Now().Add(time.Hour).UTC()
+	_, err := pgStore.CreateJobWithOutbox(ctx, &store.Job{
+		ID:        uuid.New(),
+		Type:      testJobType,
+		Payload:   []byte("outbox test payload"),
+		Status:    store.JobStatusScheduled,
+		NextRunAt: &runAt,
+	})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	sent, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	queueLen, err := redisQueue.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), queueLen)
+
+	scheduledLen, err := redisQueue.GetScheduledLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), scheduledLen)
+}
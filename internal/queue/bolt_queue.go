@@ -0,0 +1,811 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of a BoltQueue. Each job type has a bucket under queueBucket
+// holding the rest, which mirror the type's Redis keys.
+var (
+	queueBucket     = []byte("queue")
+	readyBuckets    = [3][]byte{[]byte("ready:high"), []byte("ready"), []byte("ready:low")} // by priority level
+	leasedBucket    = []byte("leased")
+	scheduledBucket = []byte("scheduled")
+	deadBucket      = []byte("dead")
+)
+
+// BoltQueue is a JobQueue kept in a bbolt file, for the embedded
+// single-binary mode of queue-svc. It behaves like PostgresQueue: ready
+// messages are ordered by position, with returned ones put ahead of the rest,
+// and leases are named by an ID in the lease token. Queued, scheduled and
+// leased messages survive restarts; leases that ran out while the process was
+// down are returned by the next ReapExpired.
+//
+// bbolt lets only one process open the file, so dequeues wake in process as
+// soon as a message is added, and cancellation requests reach subscribers of
+// the same queue only.
+type BoltQueue struct {
+	db *bolt.DB
+
+	mu      sync.Mutex
+	weights PriorityWeights
+	turns   map[string]int
+	// ready is closed and replaced whenever messages are added to a queue
+	ready   chan struct{}
+	cancels map[chan uuid.UUID]struct{}
+}
+
+// boltLease is the stored value of a leased message.
+type boltLease struct {
+	JobMessage
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var _ JobQueue = (*BoltQueue)(nil)
+
+// NewBoltQueue creates a BoltQueue on db. Close leaves db open, for the
+// BoltStore sharing it to close.
+func NewBoltQueue(db *bolt.DB) (*BoltQueue, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(queueBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+
+	return &BoltQueue{
+		db:      db,
+		weights: DefaultPriorityWeights,
+		turns:   make(map[string]int),
+		ready:   make(chan struct{}),
+		cancels: make(map[chan uuid.UUID]struct{}),
+	}, nil
+}
+
+// SetPriorityWeights changes how dequeues share out the priority levels.
+func (bq *BoltQueue) SetPriorityWeights(w PriorityWeights) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	bq.weights = w
+	return nil
+}
+
+// nextOrder advances a type's turn and returns the order to try its priority
+// levels in.
+func (bq *BoltQueue) nextOrder(jobType string) []int {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	bq.turns[jobType]++
+	return dequeueOrder(bq.weights, bq.turns[jobType])
+}
+
+// wake releases every dequeue waiting for a message.
+func (bq *BoltQueue) wake() {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	close(bq.ready)
+	bq.ready = make(chan struct{})
+}
+
+// wait calls try until it reports success or an error, or timeout passes.
+// Adding a message to any queue makes it try again.
+func (bq *BoltQueue) wait(ctx context.Context, timeout time.Duration, try func() (bool, error)) (bool, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		// Taken before trying, so a message added meanwhile is not missed
+		bq.mu.Lock()
+		ready := bq.ready
+		bq.mu.Unlock()
+
+		ok, err := try()
+		if ok || err != nil {
+			return ok, err
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return false, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-ready:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// typeBucket returns a job type's bucket, creating it and its buckets in a
+// writable transaction. In a read-only one it returns nil for a type never
+// used, which readers treat as empty.
+func typeBucket(tx *bolt.Tx, jobType string) (*bolt.Bucket, error) {
+	root := tx.Bucket(queueBucket)
+	if !tx.Writable() {
+		return root.Bucket([]byte(jobType)), nil
+	}
+
+	b, err := root.CreateBucketIfNotExists([]byte(jobType))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range append(readyBuckets[:], leasedBucket, scheduledBucket, deadBucket) {
+		if _, err := b.CreateBucketIfNotExists(name); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// positionKey encodes a position so keys sort in position order, negative
+// positions first.
+func positionKey(pos int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(pos)^(1<<63))
+	return key
+}
+
+// scheduledKey orders scheduled messages like a sorted set: by due time, then
+// by message.
+func scheduledKey(runAt time.Time, raw []byte) []byte {
+	key := make([]byte, 8, 8+len(raw))
+	binary.BigEndian.PutUint64(key, uint64(runAt.UnixNano()))
+	return append(key, raw...)
+}
+
+// push adds a message to its ready queue: at the tail, or at the head for a
+// message returned to the queue.
+func push(tx *bolt.Tx, msg *JobMessage, head bool) error {
+	b, err := typeBucket(tx, msg.Type)
+	if err != nil {
+		return err
+	}
+	seq, err := tx.Bucket(queueBucket).NextSequence()
+	if err != nil {
+		return err
+	}
+	pos := int64(seq)
+	if head {
+		pos = -pos
+	}
+
+	data, err := json.Marshal(JobMessage{JobID: msg.JobID, Type: msg.Type, Priority: msg.Priority})
+	if err != nil {
+		return err
+	}
+	return b.Bucket(readyBuckets[PriorityLevel(msg.Priority)]).Put(positionKey(pos), data)
+}
+
+// schedule adds a message to its type's scheduled messages.
+func schedule(tx *bolt.Tx, msg *JobMessage, runAt time.Time) error {
+	b, err := typeBucket(tx, msg.Type)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.Bucket(scheduledBucket).Put(scheduledKey(runAt, data), nil)
+}
+
+// Enqueue adds a job reference to the queue for its priority level.
+func (bq *BoltQueue) Enqueue(ctx context.Context, jobID uuid.UUID, jobType string, priority int) error {
+	return bq.EnqueueBatch(ctx, []BatchItem{{JobID: jobID, Type: jobType, Priority: priority}})
+}
+
+// Schedule adds a job reference to be moved to the queue by PromoteDue once
+// runAt has passed. A job that is already due is enqueued straight away.
+func (bq *BoltQueue) Schedule(ctx context.Context, jobID uuid.UUID, jobType string, priority int, runAt time.Time) error {
+	return bq.EnqueueBatch(ctx, []BatchItem{{JobID: jobID, Type: jobType, Priority: priority, RunAt: &runAt}})
+}
+
+// EnqueueBatch enqueues or schedules many jobs in one transaction.
+func (bq *BoltQueue) EnqueueBatch(ctx context.Context, items []BatchItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	queued := false
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		for _, item := range items {
+			msg := &JobMessage{JobID: item.JobID, Type: item.Type, Priority: item.Priority}
+			if item.RunAt != nil && item.RunAt.After(now) {
+				if err := schedule(tx, msg, *item.RunAt); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := push(tx, msg, false); err != nil {
+				return err
+			}
+			queued = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue jobs: %w", err)
+	}
+
+	if queued {
+		bq.wake()
+	}
+	return nil
+}
+
+// PromoteDue moves up to limit scheduled jobs of a type whose due time has
+// passed to the queue, earliest due first, and returns the promoted messages.
+func (bq *BoltQueue) PromoteDue(ctx context.Context, jobType string, limit int) ([]*JobMessage, error) {
+	var msgs []*JobMessage
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		msgs = nil
+		b, err := typeBucket(tx, jobType)
+		if err != nil {
+			return err
+		}
+
+		now := uint64(time.Now().UnixNano())
+		var due [][]byte
+		c := b.Bucket(scheduledBucket).Cursor()
+		for k, _ := c.First(); k != nil && len(due) < limit; k, _ = c.Next() {
+			if binary.BigEndian.Uint64(k[:8]) > now {
+				break
+			}
+			due = append(due, slices.Clone(k))
+		}
+
+		for _, k := range due {
+			if err := b.Bucket(scheduledBucket).Delete(k); err != nil {
+				return err
+			}
+			var msg JobMessage
+			if err := json.Unmarshal(k[8:], &msg); err != nil {
+				// Skip malformed entries; they were dropped all the same
+				continue
+			}
+			if err := push(tx, &msg, false); err != nil {
+				return err
+			}
+			msgs = append(msgs, &msg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to promote due jobs: %w", err)
+	}
+
+	if len(msgs) > 0 {
+		bq.wake()
+	}
+	return msgs, nil
+}
+
+// deleteMatching deletes every entry of a bucket whose key and value match,
+// reporting whether any did.
+func deleteMatching(b *bolt.Bucket, match func(k, v []byte) bool) (bool, error) {
+	var keys [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		if match(k, v) {
+			keys = append(keys, slices.Clone(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return false, err
+		}
+	}
+	return len(keys) > 0, nil
+}
+
+// hasJobID reports whether an encoded message is for jobID.
+func hasJobID(data []byte, jobID uuid.UUID) bool {
+	var msg JobMessage
+	return json.Unmarshal(data, &msg) == nil && msg.JobID == jobID
+}
+
+// Remove takes a job that has not been dequeued yet off its queue and out of
+// the scheduled messages, and reports whether it was found in either.
+func (bq *BoltQueue) Remove(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	var removed bool
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		b, err := typeBucket(tx, jobType)
+		if err != nil {
+			return err
+		}
+
+		queued, err := deleteMatching(b.Bucket(readyBuckets[PriorityLevel(priority)]), func(k, v []byte) bool {
+			return hasJobID(v, jobID)
+		})
+		if err != nil {
+			return err
+		}
+		scheduled, err := deleteMatching(b.Bucket(scheduledBucket), func(k, v []byte) bool {
+			return hasJobID(k[8:], jobID)
+		})
+		removed = queued || scheduled
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to remove job: %w", err)
+	}
+	return removed, nil
+}
+
+// take removes the next ready message of a type, in weighted priority order,
+// and returns it, or nil if none is ready.
+func (bq *BoltQueue) take(tx *bolt.Tx, jobType string) (*JobMessage, error) {
+	b, err := typeBucket(tx, jobType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, level := range bq.nextOrder(jobType) {
+		ready := b.Bucket(readyBuckets[level])
+		k, v := ready.Cursor().First()
+		if k == nil {
+			continue
+		}
+
+		var msg JobMessage
+		err := json.Unmarshal(v, &msg)
+		if delErr := ready.Delete(k); delErr != nil {
+			return nil, delErr
+		}
+		if err != nil {
+			// Dropped rather than left to block the queue
+			continue
+		}
+		return &msg, nil
+	}
+	return nil, nil
+}
+
+// Dequeue takes the next job reference of a type, in weighted priority order.
+// Returns nil if no job is available within the timeout.
+func (bq *BoltQueue) Dequeue(ctx context.Context, jobType string, timeout time.Duration) (*JobMessage, error) {
+	var msg *JobMessage
+	_, err := bq.wait(ctx, timeout, func() (bool, error) {
+		err := bq.db.Update(func(tx *bolt.Tx) (err error) {
+			msg, err = bq.take(tx, jobType)
+			return err
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to dequeue job: %w", err)
+		}
+		return msg != nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// claim leases the next ready message of a type, in weighted priority order,
+// for visibilityTimeout. It returns nil if none is ready.
+func (bq *BoltQueue) claim(jobType string, visibilityTimeout time.Duration) (*JobMessage, error) {
+	lease := uuid.New()
+
+	var msg *JobMessage
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		msg, err = bq.take(tx, jobType)
+		if err != nil || msg == nil {
+			return err
+		}
+
+		b, err := typeBucket(tx, jobType)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(boltLease{JobMessage: *msg, ExpiresAt: time.Now().Add(visibilityTimeout)})
+		if err != nil {
+			return err
+		}
+		return b.Bucket(leasedBucket).Put(lease[:], data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if msg == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(leasedMessage{JobMessage: *msg, Lease: lease})
+	if err != nil {
+		return nil, err
+	}
+	msg.raw = string(raw)
+	return msg, nil
+}
+
+// DequeueReliable leases the next job reference of a type, in weighted
+// priority order, for visibilityTimeout. The caller must Ack the message once
+// the job is finished or Nack it to return it to the queue; otherwise it is
+// requeued by ReapExpired after the lease expires.
+// Returns nil if no job is available within the timeout.
+func (bq *BoltQueue) DequeueReliable(ctx context.Context, jobType string, timeout, visibilityTimeout time.Duration) (*JobMessage, error) {
+	var msg *JobMessage
+	_, err := bq.wait(ctx, timeout, func() (bool, error) {
+		var err error
+		msg, err = bq.claim(jobType, visibilityTimeout)
+		return msg != nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// DequeueReliableBatch is DequeueReliable for up to limit messages of any of
+// jobTypes, taken from the types in turn. It waits up to timeout for a message
+// to be ready, then returns every one ready up to limit. If bbolt fails once
+// some messages are claimed, those are returned without the error.
+func (bq *BoltQueue) DequeueReliableBatch(ctx context.Context, jobTypes []string, limit int, timeout, visibilityTimeout time.Duration) ([]*JobMessage, error) {
+	var msgs []*JobMessage
+	_, err := bq.wait(ctx, timeout, func() (bool, error) {
+		drained := make(map[string]bool, len(jobTypes))
+		for len(msgs) < limit && len(drained) < len(jobTypes) {
+			for _, jobType := range jobTypes {
+				if drained[jobType] || len(msgs) == limit {
+					continue
+				}
+				msg, err := bq.claim(jobType, visibilityTimeout)
+				if err != nil {
+					return len(msgs) > 0, err
+				}
+				if msg == nil {
+					drained[jobType] = true
+					continue
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+		return len(msgs) > 0, nil
+	})
+	if len(msgs) > 0 {
+		return msgs, nil
+	}
+	return nil, err
+}
+
+// updateLease runs fn on the stored lease a message obtained from
+// DequeueReliable was claimed with, reporting false if the message was from
+// elsewhere or no longer held.
+func (bq *BoltQueue) updateLease(msg *JobMessage, fn func(tx *bolt.Tx, leased *bolt.Bucket, key []byte, lease *boltLease) error) (bool, error) {
+	id, ok := leaseOf(msg)
+	if !ok {
+		return false, nil
+	}
+
+	held := false
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		b, err := typeBucket(tx, msg.Type)
+		if err != nil {
+			return err
+		}
+		leased := b.Bucket(leasedBucket)
+		data := leased.Get(id[:])
+		if data == nil {
+			return nil
+		}
+
+		var lease boltLease
+		if err := json.Unmarshal(data, &lease); err != nil {
+			return err
+		}
+		held = true
+		return fn(tx, leased, id[:], &lease)
+	})
+	return held, err
+}
+
+// Ack removes a message obtained from DequeueReliable from the queue. It
+// reports false if the message was no longer held, which happens when its
+// lease expired and it was reaped back to the queue.
+func (bq *BoltQueue) Ack(ctx context.Context, msg *JobMessage) (bool, error) {
+	held, err := bq.updateLease(msg, func(tx *bolt.Tx, leased *bolt.Bucket, key []byte, lease *boltLease) error {
+		return leased.Delete(key)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to ack job: %w", err)
+	}
+	return held, nil
+}
+
+// Nack returns a message obtained from DequeueReliable to the head of its queue
+// so another consumer can pick it up. It reports false if the message was no
+// longer held.
+func (bq *BoltQueue) Nack(ctx context.Context, msg *JobMessage) (bool, error) {
+	held, err := bq.updateLease(msg, func(tx *bolt.Tx, leased *bolt.Bucket, key []byte, lease *boltLease) error {
+		if err := leased.Delete(key); err != nil {
+			return err
+		}
+		return push(tx, &lease.JobMessage, true)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to nack job: %w", err)
+	}
+	if held {
+		bq.wake()
+	}
+	return held, nil
+}
+
+// ExtendLease moves the lease deadline of a message obtained from
+// DequeueReliable to visibilityTimeout from now. It reports false if the
+// message was no longer held.
+func (bq *BoltQueue) ExtendLease(ctx context.Context, msg *JobMessage, visibilityTimeout time.Duration) (bool, error) {
+	held, err := bq.updateLease(msg, func(tx *bolt.Tx, leased *bolt.Bucket, key []byte, lease *boltLease) error {
+		lease.ExpiresAt = time.Now().Add(visibilityTimeout)
+		data, err := json.Marshal(lease)
+		if err != nil {
+			return err
+		}
+		return leased.Put(key, data)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to extend lease: %w", err)
+	}
+	return held, nil
+}
+
+// DropLease removes a job's leased message without returning it to the
+// queue. It reports whether the message was found.
+func (bq *BoltQueue) DropLease(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	var dropped bool
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		b, err := typeBucket(tx, jobType)
+		if err != nil {
+			return err
+		}
+		dropped, err = deleteMatching(b.Bucket(leasedBucket), func(k, v []byte) bool {
+			return hasJobID(v, jobID)
+		})
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to drop lease: %w", err)
+	}
+	return dropped, nil
+}
+
+// ReapExpired returns every message of a job type whose lease has expired to
+// the head of its queue and reports how many were returned. Every message is
+// leased when claimed, so visibilityTimeout is not needed.
+func (bq *BoltQueue) ReapExpired(ctx context.Context, jobType string, visibilityTimeout time.Duration) (int, error) {
+	reaped := 0
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		reaped = 0
+		b, err := typeBucket(tx, jobType)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var expired []*boltLease
+		leased := b.Bucket(leasedBucket)
+		found, err := deleteMatching(leased, func(k, v []byte) bool {
+			var lease boltLease
+			if json.Unmarshal(v, &lease) != nil || lease.ExpiresAt.After(now) {
+				return false
+			}
+			expired = append(expired, &lease)
+			return true
+		})
+		if err != nil || !found {
+			return err
+		}
+
+		for _, lease := range expired {
+			if err := push(tx, &lease.JobMessage, true); err != nil {
+				return err
+			}
+		}
+		reaped = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reap expired leases: %w", err)
+	}
+
+	if reaped > 0 {
+		bq.wake()
+	}
+	return reaped, nil
+}
+
+// RequestCancel asks whichever worker is running a job to cancel it. The
+// request reaches only current subscribers, and is dropped for a subscriber
+// that has fallen cancelBufferSize requests behind.
+func (bq *BoltQueue) RequestCancel(ctx context.Context, jobID uuid.UUID) error {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	for ids := range bq.cancels {
+		select {
+		case ids <- jobID:
+		default:
+		}
+	}
+	return nil
+}
+
+// SubscribeCancels delivers the IDs of jobs whose cancellation is requested
+// until ctx is cancelled, when the returned channel is closed.
+func (bq *BoltQueue) SubscribeCancels(ctx context.Context) (<-chan uuid.UUID, error) {
+	ids := make(chan uuid.UUID, cancelBufferSize)
+
+	bq.mu.Lock()
+	bq.cancels[ids] = struct{}{}
+	bq.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		bq.mu.Lock()
+		defer bq.mu.Unlock()
+		delete(bq.cancels, ids)
+		close(ids)
+	}()
+
+	return ids, nil
+}
+
+// DeadLetter adds a job to its type's dead-letter queue.
+func (bq *BoltQueue) DeadLetter(ctx context.Context, jobID uuid.UUID, jobType string) error {
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		b, err := typeBucket(tx, jobType)
+		if err != nil {
+			return err
+		}
+		deadAt, err := time.Now().MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return b.Bucket(deadBucket).Put(jobID[:], deadAt)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+// RemoveDeadLetters removes jobs from their type's dead-letter queue and
+// reports how many were removed.
+func (bq *BoltQueue) RemoveDeadLetters(ctx context.Context, jobType string, jobIDs ...uuid.UUID) (int64, error) {
+	var removed int64
+	err := bq.db.Update(func(tx *bolt.Tx) error {
+		removed = 0
+		b, err := typeBucket(tx, jobType)
+		if err != nil {
+			return err
+		}
+		dead := b.Bucket(deadBucket)
+		for _, id := range jobIDs {
+			if dead.Get(id[:]) == nil {
+				continue
+			}
+			if err := dead.Delete(id[:]); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove dead letters: %w", err)
+	}
+	return removed, nil
+}
+
+// length counts the entries of some of a type's buckets.
+func (bq *BoltQueue) length(what, jobType string, buckets ...[]byte) (int64, error) {
+	var n int64
+	err := bq.db.View(func(tx *bolt.Tx) error {
+		b, err := typeBucket(tx, jobType)
+		if err != nil || b == nil {
+			return err
+		}
+		for _, name := range buckets {
+			n += int64(b.Bucket(name).Stats().KeyN)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s length: %w", what, err)
+	}
+	return n, nil
+}
+
+// GetQueueLength returns the number of jobs of a type waiting at any priority.
+func (bq *BoltQueue) GetQueueLength(ctx context.Context, jobType string) (int64, error) {
+	return bq.length("queue", jobType, readyBuckets[:]...)
+}
+
+// GetPriorityQueueLength returns the number of jobs of a type waiting at a priority level.
+func (bq *BoltQueue) GetPriorityQueueLength(ctx context.Context, jobType string, level int) (int64, error) {
+	if level < PriorityHigh || level > PriorityLow {
+		// The normal queue, as QueueKey picks for unknown levels
+		level = PriorityNormal
+	}
+	return bq.length("queue", jobType, readyBuckets[level])
+}
+
+// GetScheduledLength returns the number of jobs of a type waiting for their due time.
+func (bq *BoltQueue) GetScheduledLength(ctx context.Context, jobType string) (int64, error) {
+	return bq.length("scheduled", jobType, scheduledBucket)
+}
+
+// GetProcessingLength returns the number of jobs of a type currently leased to consumers.
+func (bq *BoltQueue) GetProcessingLength(ctx context.Context, jobType string) (int64, error) {
+	return bq.length("processing", jobType, leasedBucket)
+}
+
+// GetDeadLetterLength returns the number of jobs of a type in the dead-letter queue.
+func (bq *BoltQueue) GetDeadLetterLength(ctx context.Context, jobType string) (int64, error) {
+	return bq.length("dead-letter", jobType, deadBucket)
+}
+
+// TrackedJobIDs returns the IDs of every job of a type the queue knows about,
+// whether ready, leased or scheduled.
+func (bq *BoltQueue) TrackedJobIDs(ctx context.Context, jobType string) (map[uuid.UUID]struct{}, error) {
+	ids := make(map[uuid.UUID]struct{})
+	track := func(data []byte) {
+		var msg JobMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			// Skip malformed entries rather than abort the scan
+			return
+		}
+		ids[msg.JobID] = struct{}{}
+	}
+
+	err := bq.db.View(func(tx *bolt.Tx) error {
+		b, err := typeBucket(tx, jobType)
+		if err != nil || b == nil {
+			return err
+		}
+		for _, name := range append(readyBuckets[:], leasedBucket) {
+			err := b.Bucket(name).ForEach(func(k, v []byte) error {
+				track(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return b.Bucket(scheduledBucket).ForEach(func(k, v []byte) error {
+			track(k[8:])
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tracked jobs: %w", err)
+	}
+	return ids, nil
+}
+
+// Close releases nothing; the bbolt file is closed with the store sharing it.
+func (bq *BoltQueue) Close() error {
+	return nil
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openBoltQueue(t *testing.T, path string) (*BoltQueue, *bolt.DB) {
+	db, err := bolt.Open(path, 0o600, nil)
+	require.NoError(t, err)
+
+	bq, err := NewBoltQueue(db)
+	require.NoError(t, err)
+	return bq, db
+}
+
+func TestBoltQueue_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "boltq.db")
+	ctx := context.Background()
+	queued, leased, scheduled := uuid.New(), uuid.New(), uuid.New()
+
+	bq, db := openBoltQueue(t, path)
+	require.NoError(t, bq.Enqueue(ctx, leased, "JOB_STANDARD", 0))
+	require.NoError(t, bq.Enqueue(ctx, queued, "JOB_STANDARD", 0))
+	require.NoError(t, bq.Schedule(ctx, scheduled, "JOB_STANDARD", 0, time.Now().Add(time.Hour)))
+
+	msg, err := bq.DequeueReliable(ctx, "JOB_STANDARD", time.Second, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	require.Equal(t, leased, msg.JobID)
+	require.NoError(t, bq.Close())
+	require.NoError(t, db.Close())
+
+	bq, db = openBoltQueue(t, path)
+	defer db.Close()
+
+	ids, err := bq.TrackedJobIDs(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Len(t, ids, 3)
+
+	// The lease ran out while the queue was closed, so it is reaped to the head
+	time.Sleep(100 * time.Millisecond)
+	reaped, err := bq.ReapExpired(ctx, "JOB_STANDARD", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reaped)
+
+	for _, want := range []uuid.UUID{leased, queued} {
+		msg, err := bq.Dequeue(ctx, "JOB_STANDARD", time.Second)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		assert.Equal(t, want, msg.JobID)
+	}
+
+	scheduledLen, err := bq.GetScheduledLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), scheduledLen)
+}
@@ -0,0 +1,77 @@
+package queue_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/queue/queuetest"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+func TestMemoryQueue_Conformance(t *testing.T) {
+	queuetest.Run(t, func(t *testing.T) queue.JobQueue {
+		return queue.NewMemoryQueue()
+	})
+}
+
+func TestBoltQueue_Conformance(t *testing.T) {
+	queuetest.Run(t, func(t *testing.T) queue.JobQueue {
+		db, err := bolt.Open(filepath.Join(t.TempDir(), "boltq.db"), 0o600, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		bq, err := queue.NewBoltQueue(db)
+		require.NoError(t, err)
+		return bq
+	})
+}
+
+func TestRedisQueue_Conformance(t *testing.T) {
+	addr := testutil.StartRedis(t)
+	admin := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { admin.Close() })
+
+	queuetest.Run(t, func(t *testing.T) queue.JobQueue {
+		require.NoError(t, admin.FlushDB(context.Background()).Err())
+
+		rq, err := queue.NewRedisQueue(addr, "", 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { rq.Close() })
+		return rq
+	})
+}
+
+func TestPostgresQueue_Conformance(t *testing.T) {
+	db, connString := testutil.StartPostgresWithConnString(t)
+
+	queuetest.Run(t, func(t *testing.T) queue.JobQueue {
+		_, err := db.Exec("TRUNCATE job_queue, job_queue_dead")
+		require.NoError(t, err)
+
+		pgq, err := queue.NewPostgresQueue(db, connString)
+		require.NoError(t, err)
+		t.Cleanup(func() { pgq.Close() })
+		return pgq
+	})
+}
+
+func TestStreamQueue_Conformance(t *testing.T) {
+	addr := testutil.StartRedis(t)
+	admin := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { admin.Close() })
+
+	queuetest.Run(t, func(t *testing.T) queue.JobQueue {
+		require.NoError(t, admin.FlushDB(context.Background()).Err())
+
+		sq, err := queue.NewStreamQueue(addr, "", 0, queue.StreamConfig{})
+		require.NoError(t, err)
+		t.Cleanup(func() { sq.Close() })
+		return sq
+	})
+}
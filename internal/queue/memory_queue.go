@@ -0,0 +1,593 @@
+package queue
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cancelBufferSize is how many cancellation requests a MemoryQueue subscriber
+// can fall behind by before further requests to it are dropped.
+const cancelBufferSize = 100
+
+// MemoryQueue is a JobQueue that keeps its queues in process memory. It
+// behaves like RedisQueue, keeping messages in the same encoding, and is
+// meant for unit tests and single-process deployments. Dequeues wake as soon
+// as a message is added rather than polling.
+type MemoryQueue struct {
+	mu      sync.Mutex
+	weights PriorityWeights
+	types   map[string]*memoryQueues
+	// ready is closed and replaced whenever messages are added to a queue
+	ready   chan struct{}
+	cancels map[chan uuid.UUID]struct{}
+}
+
+// memoryQueues holds one job type's messages, mirroring the type's Redis keys.
+type memoryQueues struct {
+	queues     [3][]string // by priority level
+	turn       int
+	processing []string
+	leases     map[string]time.Time
+	scheduled  map[string]time.Time
+	dead       map[uuid.UUID]time.Time
+}
+
+var _ JobQueue = (*MemoryQueue)(nil)
+
+// NewMemoryQueue creates an empty MemoryQueue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		weights: DefaultPriorityWeights,
+		types:   make(map[string]*memoryQueues),
+		ready:   make(chan struct{}),
+		cancels: make(map[chan uuid.UUID]struct{}),
+	}
+}
+
+// SetPriorityWeights changes how dequeues share out the priority levels.
+func (mq *MemoryQueue) SetPriorityWeights(w PriorityWeights) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.weights = w
+	return nil
+}
+
+// of returns a job type's queues, creating them on first use. mq.mu must be held.
+func (mq *MemoryQueue) of(jobType string) *memoryQueues {
+	q, ok := mq.types[jobType]
+	if !ok {
+		q = &memoryQueues{
+			leases:    make(map[string]time.Time),
+			scheduled: make(map[string]time.Time),
+			dead:      make(map[uuid.UUID]time.Time),
+		}
+		mq.types[jobType] = q
+	}
+	return q
+}
+
+// wake releases every dequeue waiting for a message. mq.mu must be held.
+func (mq *MemoryQueue) wake() {
+	close(mq.ready)
+	mq.ready = make(chan struct{})
+}
+
+func encodeMessage(jobID uuid.UUID, jobType string, priority int) (string, error) {
+	data, err := json.Marshal(JobMessage{JobID: jobID, Type: jobType, Priority: priority})
+	return string(data), err
+}
+
+func decodeMessage(raw string) (*JobMessage, error) {
+	var msg JobMessage
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, err
+	}
+	msg.raw = raw
+	return &msg, nil
+}
+
+// levelOf returns the priority level of a raw message, like queueKeyLua.
+func levelOf(raw string) int {
+	var msg JobMessage
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return PriorityNormal
+	}
+	return PriorityLevel(msg.Priority)
+}
+
+// push adds a raw message to the tail of its queue. mq.mu must be held.
+func (mq *MemoryQueue) push(jobType, raw string) {
+	q := mq.of(jobType)
+	level := levelOf(raw)
+	q.queues[level] = append(q.queues[level], raw)
+	mq.wake()
+}
+
+// pushFront adds a raw message to the head of its queue. mq.mu must be held.
+func (mq *MemoryQueue) pushFront(jobType, raw string) {
+	q := mq.of(jobType)
+	level := levelOf(raw)
+	q.queues[level] = append([]string{raw}, q.queues[level]...)
+	mq.wake()
+}
+
+// pop takes the next message of a type, trying the levels in the order
+// dequeueOrderLua picks. mq.mu must be held.
+func (mq *MemoryQueue) pop(jobType string) (string, bool) {
+	q := mq.of(jobType)
+	q.turn++
+
+	for _, level := range dequeueOrder(mq.weights, q.turn) {
+		if len(q.queues[level]) > 0 {
+			raw := q.queues[level][0]
+			q.queues[level] = q.queues[level][1:]
+			return raw, true
+		}
+	}
+	return "", false
+}
+
+// claim pops the next message of a type into its processing list and leases
+// it. mq.mu must be held.
+func (mq *MemoryQueue) claim(jobType string, visibilityTimeout time.Duration) (string, bool) {
+	raw, ok := mq.pop(jobType)
+	if !ok {
+		return "", false
+	}
+	q := mq.of(jobType)
+	q.processing = append(q.processing, raw)
+	q.leases[raw] = time.Now().Add(visibilityTimeout)
+	return raw, true
+}
+
+// wait calls try, with mq.mu held, until it reports success or timeout
+// passes. Adding a message to any queue makes it try again.
+func (mq *MemoryQueue) wait(ctx context.Context, timeout time.Duration, try func() bool) (bool, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		mq.mu.Lock()
+		ok := try()
+		ready := mq.ready
+		mq.mu.Unlock()
+		if ok {
+			return true, nil
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return false, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-ready:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Enqueue adds a job reference to the queue for its priority level.
+func (mq *MemoryQueue) Enqueue(ctx context.Context, jobID uuid.UUID, jobType string, priority int) error {
+	raw, err := encodeMessage(jobID, jobType, priority)
+	if err != nil {
+		return err
+	}
+
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.push(jobType, raw)
+	return nil
+}
+
+// Schedule adds a job reference to the type's scheduled set, to be moved to the
+// queue by PromoteDue once runAt has passed. A job that is already due is
+// enqueued straight away.
+func (mq *MemoryQueue) Schedule(ctx context.Context, jobID uuid.UUID, jobType string, priority int, runAt time.Time) error {
+	if !runAt.After(time.Now()) {
+		return mq.Enqueue(ctx, jobID, jobType, priority)
+	}
+
+	raw, err := encodeMessage(jobID, jobType, priority)
+	if err != nil {
+		return err
+	}
+
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.of(jobType).scheduled[raw] = runAt
+	return nil
+}
+
+// EnqueueBatch enqueues or schedules many jobs at once.
+func (mq *MemoryQueue) EnqueueBatch(ctx context.Context, items []BatchItem) error {
+	raws := make([]string, len(items))
+	for i, item := range items {
+		raw, err := encodeMessage(item.JobID, item.Type, item.Priority)
+		if err != nil {
+			return err
+		}
+		raws[i] = raw
+	}
+
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	now := time.Now()
+	for i, item := range items {
+		if item.RunAt != nil && item.RunAt.After(now) {
+			mq.of(item.Type).scheduled[raws[i]] = *item.RunAt
+		} else {
+			mq.push(item.Type, raws[i])
+		}
+	}
+	return nil
+}
+
+// PromoteDue moves up to limit scheduled jobs of a type whose due time has
+// passed to the queue, earliest due first, and returns the promoted messages.
+func (mq *MemoryQueue) PromoteDue(ctx context.Context, jobType string, limit int) ([]*JobMessage, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	q := mq.of(jobType)
+	now := time.Now()
+	var due []string
+	for raw, runAt := range q.scheduled {
+		if !runAt.After(now) {
+			due = append(due, raw)
+		}
+	}
+	// Ordered like a sorted set: by score, then member
+	slices.SortFunc(due, func(a, b string) int {
+		if c := q.scheduled[a].Compare(q.scheduled[b]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	msgs := make([]*JobMessage, 0, len(due))
+	for _, raw := range due {
+		delete(q.scheduled, raw)
+		mq.push(jobType, raw)
+		msg, err := decodeMessage(raw)
+		if err != nil {
+			// Skip malformed entries; they were promoted all the same
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// Remove takes a job that has not been dequeued yet off its queue and out of
+// the scheduled set, and reports whether it was found in either.
+func (mq *MemoryQueue) Remove(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	raw, err := encodeMessage(jobID, jobType, priority)
+	if err != nil {
+		return false, err
+	}
+
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	q := mq.of(jobType)
+	level := PriorityLevel(priority)
+	before := len(q.queues[level])
+	q.queues[level] = slices.DeleteFunc(q.queues[level], func(item string) bool { return item == raw })
+	removed := len(q.queues[level]) < before
+
+	if _, ok := q.scheduled[raw]; ok {
+		delete(q.scheduled, raw)
+		removed = true
+	}
+	return removed, nil
+}
+
+// Dequeue takes the next job reference of a type, in weighted priority order.
+// Returns nil if no job is available within the timeout.
+func (mq *MemoryQueue) Dequeue(ctx context.Context, jobType string, timeout time.Duration) (*JobMessage, error) {
+	var raw string
+	ok, err := mq.wait(ctx, timeout, func() (ok bool) {
+		raw, ok = mq.pop(jobType)
+		return ok
+	})
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	msg, err := decodeMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+	// Only reliable-mode messages keep their encoding
+	msg.raw = ""
+	return msg, nil
+}
+
+// DequeueReliable moves the next job reference of a type, in weighted priority
+// order, into the type's processing list and leases it for visibilityTimeout,
+// like RedisQueue.DequeueReliable.
+// Returns nil if no job is available within the timeout.
+func (mq *MemoryQueue) DequeueReliable(ctx context.Context, jobType string, timeout, visibilityTimeout time.Duration) (*JobMessage, error) {
+	var raw string
+	ok, err := mq.wait(ctx, timeout, func() (ok bool) {
+		raw, ok = mq.claim(jobType, visibilityTimeout)
+		return ok
+	})
+	if !ok || err != nil {
+		return nil, err
+	}
+
+	return decodeMessage(raw)
+}
+
+// DequeueReliableBatch is DequeueReliable for up to limit messages of any of
+// jobTypes, taken from the types in turn. It waits up to timeout for a message
+// to be ready, then returns every one ready up to limit.
+func (mq *MemoryQueue) DequeueReliableBatch(ctx context.Context, jobTypes []string, limit int, timeout, visibilityTimeout time.Duration) ([]*JobMessage, error) {
+	var msgs []*JobMessage
+	_, err := mq.wait(ctx, timeout, func() bool {
+		drained := make(map[string]bool, len(jobTypes))
+		for len(msgs) < limit && len(drained) < len(jobTypes) {
+			for _, jobType := range jobTypes {
+				if drained[jobType] || len(msgs) == limit {
+					continue
+				}
+				raw, ok := mq.claim(jobType, visibilityTimeout)
+				if !ok {
+					drained[jobType] = true
+					continue
+				}
+				msg, err := decodeMessage(raw)
+				if err != nil {
+					// Left leased, so the reaper returns it to the queue
+					continue
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+		return len(msgs) > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// release removes a raw message from a type's processing list and drops its
+// lease, reporting whether it was in the list. mq.mu must be held.
+func (mq *MemoryQueue) release(jobType, raw string) bool {
+	q := mq.of(jobType)
+	delete(q.leases, raw)
+	i := slices.Index(q.processing, raw)
+	if i < 0 {
+		return false
+	}
+	q.processing = slices.Delete(q.processing, i, i+1)
+	return true
+}
+
+// Ack removes a message obtained from DequeueReliable from the processing list.
+// It reports false if the message was no longer held.
+func (mq *MemoryQueue) Ack(ctx context.Context, msg *JobMessage) (bool, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return mq.release(msg.Type, msg.raw), nil
+}
+
+// Nack returns a message obtained from DequeueReliable to the head of its queue
+// so another consumer can pick it up. It reports false if the message was no
+// longer held.
+func (mq *MemoryQueue) Nack(ctx context.Context, msg *JobMessage) (bool, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	if !mq.release(msg.Type, msg.raw) {
+		return false, nil
+	}
+	mq.pushFront(msg.Type, msg.raw)
+	return true, nil
+}
+
+// ExtendLease moves the lease deadline of a message obtained from
+// DequeueReliable to visibilityTimeout from now. It reports false if the
+// message was no longer held.
+func (mq *MemoryQueue) ExtendLease(ctx context.Context, msg *JobMessage, visibilityTimeout time.Duration) (bool, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	q := mq.of(msg.Type)
+	if _, ok := q.leases[msg.raw]; !ok {
+		return false, nil
+	}
+	q.leases[msg.raw] = time.Now().Add(visibilityTimeout)
+	return true, nil
+}
+
+// DropLease removes a job's message from the processing list without returning
+// it to the queue. It reports whether the message was found.
+func (mq *MemoryQueue) DropLease(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	raw, err := encodeMessage(jobID, jobType, priority)
+	if err != nil {
+		return false, err
+	}
+
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return mq.release(jobType, raw), nil
+}
+
+// ReapExpired returns every message of a job type whose lease has expired to
+// the head of its queue and reports how many were returned. Messages found
+// without a lease are leased for visibilityTimeout.
+func (mq *MemoryQueue) ReapExpired(ctx context.Context, jobType string, visibilityTimeout time.Duration) (int, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	q := mq.of(jobType)
+	now := time.Now()
+	reaped := 0
+	for _, raw := range slices.Clone(q.processing) {
+		deadline, ok := q.leases[raw]
+		if !ok {
+			q.leases[raw] = now.Add(visibilityTimeout)
+		} else if !deadline.After(now) {
+			mq.release(jobType, raw)
+			mq.pushFront(jobType, raw)
+			reaped++
+		}
+	}
+	return reaped, nil
+}
+
+// RequestCancel asks whichever worker is running a job to cancel it. The
+// request reaches only current subscribers, and is dropped for a subscriber
+// that has fallen cancelBufferSize requests behind.
+func (mq *MemoryQueue) RequestCancel(ctx context.Context, jobID uuid.UUID) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	for ids := range mq.cancels {
+		select {
+		case ids <- jobID:
+		default:
+		}
+	}
+	return nil
+}
+
+// SubscribeCancels delivers the IDs of jobs whose cancellation is requested
+// until ctx is cancelled, when the returned channel is closed.
+func (mq *MemoryQueue) SubscribeCancels(ctx context.Context) (<-chan uuid.UUID, error) {
+	ids := make(chan uuid.UUID, cancelBufferSize)
+
+	mq.mu.Lock()
+	mq.cancels[ids] = struct{}{}
+	mq.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		mq.mu.Lock()
+		defer mq.mu.Unlock()
+		delete(mq.cancels, ids)
+		close(ids)
+	}()
+
+	return ids, nil
+}
+
+// DeadLetter adds a job to its type's dead-letter queue.
+func (mq *MemoryQueue) DeadLetter(ctx context.Context, jobID uuid.UUID, jobType string) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.of(jobType).dead[jobID] = time.Now()
+	return nil
+}
+
+// RemoveDeadLetters removes jobs from their type's dead-letter queue and
+// reports how many were removed.
+func (mq *MemoryQueue) RemoveDeadLetters(ctx context.Context, jobType string, jobIDs ...uuid.UUID) (int64, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	q := mq.of(jobType)
+	var removed int64
+	for _, id := range jobIDs {
+		if _, ok := q.dead[id]; ok {
+			delete(q.dead, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// GetQueueLength returns the number of jobs of a type waiting at any priority.
+func (mq *MemoryQueue) GetQueueLength(ctx context.Context, jobType string) (int64, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	var total int64
+	for _, queue := range mq.of(jobType).queues {
+		total += int64(len(queue))
+	}
+	return total, nil
+}
+
+// GetPriorityQueueLength returns the number of jobs of a type waiting at a priority level.
+func (mq *MemoryQueue) GetPriorityQueueLength(ctx context.Context, jobType string, level int) (int64, error) {
+	if level < PriorityHigh || level > PriorityLow {
+		// The normal queue, as QueueKey picks for unknown levels
+		level = PriorityNormal
+	}
+
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return int64(len(mq.of(jobType).queues[level])), nil
+}
+
+// GetScheduledLength returns the number of jobs of a type waiting for their due time.
+func (mq *MemoryQueue) GetScheduledLength(ctx context.Context, jobType string) (int64, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return int64(len(mq.of(jobType).scheduled)), nil
+}
+
+// GetProcessingLength returns the number of jobs of a type currently leased to consumers.
+func (mq *MemoryQueue) GetProcessingLength(ctx context.Context, jobType string) (int64, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return int64(len(mq.of(jobType).processing)), nil
+}
+
+// GetDeadLetterLength returns the number of jobs of a type in the dead-letter queue.
+func (mq *MemoryQueue) GetDeadLetterLength(ctx context.Context, jobType string) (int64, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return int64(len(mq.of(jobType).dead)), nil
+}
+
+// TrackedJobIDs returns the IDs of every job of a type the queue knows about,
+// whether waiting in a queue, leased in the processing list or scheduled.
+func (mq *MemoryQueue) TrackedJobIDs(ctx context.Context, jobType string) (map[uuid.UUID]struct{}, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	q := mq.of(jobType)
+	raws := slices.Concat(q.queues[PriorityHigh], q.queues[PriorityNormal], q.queues[PriorityLow], q.processing)
+	for raw := range q.scheduled {
+		raws = append(raws, raw)
+	}
+
+	ids := make(map[uuid.UUID]struct{}, len(raws))
+	for _, raw := range raws {
+		msg, err := decodeMessage(raw)
+		if err != nil {
+			// Skip malformed entries rather than abort the scan
+			continue
+		}
+		ids[msg.JobID] = struct{}{}
+	}
+	return ids, nil
+}
+
+// Close releases nothing; it exists to satisfy JobQueue.
+func (mq *MemoryQueue) Close() error {
+	return nil
+}
@@ -0,0 +1,727 @@
+package queue
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// QueueNotifyChannel carries the type of every message that becomes ready
+	// in the job_queue table, sent by the table's trigger.
+	QueueNotifyChannel = "boltq_queue"
+	// CancelNotifyChannel carries cancellation requests between processes
+	// using PostgresQueue.
+	CancelNotifyChannel = "boltq_cancel"
+
+	listenerMinReconnectInterval = 100 * time.Millisecond
+	listenerMaxReconnectInterval = 10 * time.Second
+	listenerPingInterval         = 60 * time.Second
+)
+
+// States of a row in job_queue.
+const (
+	stateReady     = "ready"
+	stateScheduled = "scheduled"
+	stateLeased    = "leased"
+)
+
+// PostgresQueue is a JobQueue that keeps its messages in the job_queue table
+// of the jobs database, for deployments without Redis. Consumers take ready
+// rows with SELECT ... FOR UPDATE SKIP LOCKED, so they never wait on each
+// other, and idle dequeues sleep until a LISTEN notification says a message
+// of some type became ready.
+//
+// Priority levels, scheduling, leases and dead letters behave as in
+// RedisQueue. The weighted rotation between priority levels is kept per
+// process rather than shared, which gives the same proportions overall.
+type PostgresQueue struct {
+	db       *sql.DB
+	listener *pq.Listener
+
+	mu      sync.Mutex
+	weights PriorityWeights
+	turns   map[string]int
+	// ready is closed and replaced whenever a message becomes ready
+	ready   chan struct{}
+	cancels map[chan uuid.UUID]struct{}
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// leasedMessage is the encoding of a message obtained from DequeueReliable.
+// The lease identifies the claim, so a consumer whose lease expired and was
+// reclaimed by another can no longer ack or nack it.
+type leasedMessage struct {
+	JobMessage
+	Lease uuid.UUID `json:"lease"`
+}
+
+var _ JobQueue = (*PostgresQueue)(nil)
+
+// NewPostgresQueue creates a PostgresQueue on db, whose schema must include
+// the job_queue tables, and starts listening for notifications on a separate
+// connection opened with connString. Close stops listening; db is left open.
+func NewPostgresQueue(db *sql.DB, connString string) (*PostgresQueue, error) {
+	listener := pq.NewListener(connString, listenerMinReconnectInterval, listenerMaxReconnectInterval, nil)
+	for _, channel := range []string{QueueNotifyChannel, CancelNotifyChannel} {
+		if err := listener.Listen(channel); err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("failed to listen on %s: %w", channel, err)
+		}
+	}
+
+	pgq := &PostgresQueue{
+		db:       db,
+		listener: listener,
+		weights:  DefaultPriorityWeights,
+		turns:    make(map[string]int),
+		ready:    make(chan struct{}),
+		cancels:  make(map[chan uuid.UUID]struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go pgq.listen()
+
+	return pgq, nil
+}
+
+// listen wakes waiting dequeues and delivers cancellation requests until Close.
+func (pgq *PostgresQueue) listen() {
+	defer close(pgq.done)
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pgq.stop:
+			return
+		case <-ticker.C:
+			// Detects a silently dropped connection, which then reconnects
+			go pgq.listener.Ping()
+		case n, ok := <-pgq.listener.Notify:
+			if !ok {
+				return
+			}
+			switch {
+			case n == nil:
+				// Sent after reconnecting; messages may have become ready meanwhile
+				pgq.wake()
+			case n.Channel == QueueNotifyChannel:
+				pgq.wake()
+			case n.Channel == CancelNotifyChannel:
+				id, err := uuid.Parse(n.Extra)
+				if err != nil {
+					// Skip malformed requests
+					continue
+				}
+				pgq.deliverCancel(id)
+			}
+		}
+	}
+}
+
+// wake releases every dequeue waiting for a message.
+func (pgq *PostgresQueue) wake() {
+	pgq.mu.Lock()
+	defer pgq.mu.Unlock()
+	close(pgq.ready)
+	pgq.ready = make(chan struct{})
+}
+
+func (pgq *PostgresQueue) deliverCancel(jobID uuid.UUID) {
+	pgq.mu.Lock()
+	defer pgq.mu.Unlock()
+
+	for ids := range pgq.cancels {
+		select {
+		case ids <- jobID:
+		default:
+		}
+	}
+}
+
+// SetPriorityWeights changes how dequeues share out the priority levels.
+func (pgq *PostgresQueue) SetPriorityWeights(w PriorityWeights) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	pgq.mu.Lock()
+	defer pgq.mu.Unlock()
+	pgq.weights = w
+	return nil
+}
+
+// nextOrder advances a type's turn and returns the order to try its priority
+// levels in.
+func (pgq *PostgresQueue) nextOrder(jobType string) []int {
+	pgq.mu.Lock()
+	defer pgq.mu.Unlock()
+	pgq.turns[jobType]++
+	return dequeueOrder(pgq.weights, pgq.turns[jobType])
+}
+
+// wait calls try until it reports success or an error, or timeout passes. A
+// notification that a message became ready makes it try again.
+func (pgq *PostgresQueue) wait(ctx context.Context, timeout time.Duration, try func() (bool, error)) (bool, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		// Taken before trying, so a message added meanwhile is not missed
+		pgq.mu.Lock()
+		ready := pgq.ready
+		pgq.mu.Unlock()
+
+		ok, err := try()
+		if ok || err != nil {
+			return ok, err
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return false, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-ready:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Enqueue adds a job reference to the queue for its priority level.
+func (pgq *PostgresQueue) Enqueue(ctx context.Context, jobID uuid.UUID, jobType string, priority int) error {
+	return pgq.EnqueueBatch(ctx, []BatchItem{{JobID: jobID, Type: jobType, Priority: priority}})
+}
+
+// Schedule adds a job reference to be moved to the queue by PromoteDue once
+// runAt has passed. A job that is already due is enqueued straight away.
+func (pgq *PostgresQueue) Schedule(ctx context.Context, jobID uuid.UUID, jobType string, priority int, runAt time.Time) error {
+	return pgq.EnqueueBatch(ctx, []BatchItem{{JobID: jobID, Type: jobType, Priority: priority, RunAt: &runAt}})
+}
+
+// EnqueueBatch enqueues or schedules many jobs with one multi-row insert.
+func (pgq *PostgresQueue) EnqueueBatch(ctx context.Context, items []BatchItem) error {
+	return insertMessages(ctx, pgq.db, items)
+}
+
+// EnqueueTx is EnqueueBatch within tx, for a store that writes jobs and their
+// messages in one transaction. Consumers are woken once tx commits.
+func EnqueueTx(ctx context.Context, tx *sql.Tx, items []BatchItem) error {
+	return insertMessages(ctx, tx, items)
+}
+
+// execer is implemented by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// insertMessages adds a ready or scheduled row to job_queue for each item.
+func insertMessages(ctx context.Context, db execer, items []BatchItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var args []any
+	rows := make([]string, len(items))
+	for i, item := range items {
+		state, runAt := stateReady, (*time.Time)(nil)
+		if item.RunAt != nil && item.RunAt.After(now) {
+			state, runAt = stateScheduled, item.RunAt
+		}
+
+		n := len(args)
+		rows[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, nextval('job_queue_position_seq'), $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args, item.JobID, item.Type, item.Priority, PriorityLevel(item.Priority), state, runAt)
+	}
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO job_queue (job_id, type, priority, level, state, position, run_at)
+		VALUES `+strings.Join(rows, ", "), args...)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue jobs: %w", err)
+	}
+	return nil
+}
+
+// PromoteDue moves up to limit scheduled jobs of a type whose due time has
+// passed to the queue, earliest due first, and returns the promoted messages.
+// Rows are locked as they are picked, so concurrent promoters never move the
+// same message twice.
+func (pgq *PostgresQueue) PromoteDue(ctx context.Context, jobType string, limit int) ([]*JobMessage, error) {
+	rows, err := pgq.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT id, run_at
+			FROM job_queue
+			WHERE type = $1 AND state = 'scheduled' AND run_at <= NOW()
+			ORDER BY run_at, job_id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE job_queue q
+		SET state = 'ready', run_at = NULL, position = nextval('job_queue_position_seq')
+		FROM due
+		WHERE q.id = due.id
+		RETURNING q.job_id, q.type, q.priority, due.run_at
+	`, jobType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to promote due jobs: %w", err)
+	}
+	defer rows.Close()
+
+	type promoted struct {
+		msg   *JobMessage
+		runAt time.Time
+	}
+	var due []promoted
+	for rows.Next() {
+		var p promoted
+		p.msg = &JobMessage{}
+		if err := rows.Scan(&p.msg.JobID, &p.msg.Type, &p.msg.Priority, &p.runAt); err != nil {
+			return nil, fmt.Errorf("failed to scan promoted job: %w", err)
+		}
+		due = append(due, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to promote due jobs: %w", err)
+	}
+
+	// RETURNING has no order
+	slices.SortFunc(due, func(a, b promoted) int {
+		if c := a.runAt.Compare(b.runAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.msg.JobID.String(), b.msg.JobID.String())
+	})
+
+	msgs := make([]*JobMessage, len(due))
+	for i, p := range due {
+		msgs[i] = p.msg
+	}
+	return msgs, nil
+}
+
+// Remove takes a job that has not been dequeued yet off its queue and out of
+// the scheduled messages, and reports whether it was found in either.
+func (pgq *PostgresQueue) Remove(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	result, err := pgq.db.ExecContext(ctx, `
+		DELETE FROM job_queue
+		WHERE job_id = $1 AND type = $2 AND priority = $3 AND state IN ('ready', 'scheduled')
+	`, jobID, jobType, priority)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove job: %w", err)
+	}
+	return affected(result, "failed to remove job")
+}
+
+// Dequeue takes the next job reference of a type, in weighted priority order.
+// Returns nil if no job is available within the timeout.
+func (pgq *PostgresQueue) Dequeue(ctx context.Context, jobType string, timeout time.Duration) (*JobMessage, error) {
+	var msg *JobMessage
+	_, err := pgq.wait(ctx, timeout, func() (bool, error) {
+		for _, level := range pgq.nextOrder(jobType) {
+			var m JobMessage
+			err := pgq.db.QueryRowContext(ctx, `
+				DELETE FROM job_queue
+				WHERE id = (
+					SELECT id
+					FROM job_queue
+					WHERE type = $1 AND state = 'ready' AND level = $2
+					ORDER BY position
+					LIMIT 1
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING job_id, type, priority
+			`, jobType, level).Scan(&m.JobID, &m.Type, &m.Priority)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return false, fmt.Errorf("failed to dequeue job: %w", err)
+			}
+			msg = &m
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// claim leases the next ready message of a type, in weighted priority order,
+// for visibilityTimeout. It returns nil if none is ready.
+func (pgq *PostgresQueue) claim(ctx context.Context, jobType string, visibilityTimeout time.Duration) (*JobMessage, error) {
+	lease := uuid.New()
+
+	for _, level := range pgq.nextOrder(jobType) {
+		var msg JobMessage
+		err := pgq.db.QueryRowContext(ctx, `
+			UPDATE job_queue
+			SET state = 'leased', lease_id = $3, lease_expires_at = NOW() + make_interval(secs => $4)
+			WHERE id = (
+				SELECT id
+				FROM job_queue
+				WHERE type = $1 AND state = 'ready' AND level = $2
+				ORDER BY position
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING job_id, type, priority
+		`, jobType, level, lease, visibilityTimeout.Seconds()).Scan(&msg.JobID, &msg.Type, &msg.Priority)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim job: %w", err)
+		}
+
+		raw, err := json.Marshal(leasedMessage{JobMessage: msg, Lease: lease})
+		if err != nil {
+			return nil, err
+		}
+		msg.raw = string(raw)
+		return &msg, nil
+	}
+
+	return nil, nil
+}
+
+// DequeueReliable leases the next job reference of a type, in weighted
+// priority order, for visibilityTimeout. The caller must Ack the message once
+// the job is finished or Nack it to return it to the queue; otherwise it is
+// requeued by ReapExpired after the lease expires.
+// Returns nil if no job is available within the timeout.
+func (pgq *PostgresQueue) DequeueReliable(ctx context.Context, jobType string, timeout, visibilityTimeout time.Duration) (*JobMessage, error) {
+	var msg *JobMessage
+	_, err := pgq.wait(ctx, timeout, func() (bool, error) {
+		var err error
+		msg, err = pgq.claim(ctx, jobType, visibilityTimeout)
+		return msg != nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// DequeueReliableBatch is DequeueReliable for up to limit messages of any of
+// jobTypes, taken from the types in turn. It waits up to timeout for a message
+// to be ready, then returns every one ready up to limit. If Postgres fails once
+// some messages are claimed, those are returned without the error.
+func (pgq *PostgresQueue) DequeueReliableBatch(ctx context.Context, jobTypes []string, limit int, timeout, visibilityTimeout time.Duration) ([]*JobMessage, error) {
+	var msgs []*JobMessage
+	_, err := pgq.wait(ctx, timeout, func() (bool, error) {
+		drained := make(map[string]bool, len(jobTypes))
+		for len(msgs) < limit && len(drained) < len(jobTypes) {
+			for _, jobType := range jobTypes {
+				if drained[jobType] || len(msgs) == limit {
+					continue
+				}
+				msg, err := pgq.claim(ctx, jobType, visibilityTimeout)
+				if err != nil {
+					return len(msgs) > 0, err
+				}
+				if msg == nil {
+					drained[jobType] = true
+					continue
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+		return len(msgs) > 0, nil
+	})
+	if len(msgs) > 0 {
+		return msgs, nil
+	}
+	return nil, err
+}
+
+// leaseOf returns the lease a message obtained from DequeueReliable was
+// claimed with, or false for a message from elsewhere.
+func leaseOf(msg *JobMessage) (uuid.UUID, bool) {
+	var leased leasedMessage
+	if err := json.Unmarshal([]byte(msg.raw), &leased); err != nil {
+		return uuid.Nil, false
+	}
+	return leased.Lease, leased.Lease != uuid.Nil
+}
+
+// Ack removes a message obtained from DequeueReliable from the queue. It
+// reports false if the message was no longer held, which happens when its
+// lease expired and it was reaped back to the queue.
+func (pgq *PostgresQueue) Ack(ctx context.Context, msg *JobMessage) (bool, error) {
+	lease, ok := leaseOf(msg)
+	if !ok {
+		return false, nil
+	}
+
+	result, err := pgq.db.ExecContext(ctx, `
+		DELETE FROM job_queue
+		WHERE lease_id = $1 AND state = 'leased'
+	`, lease)
+	if err != nil {
+		return false, fmt.Errorf("failed to ack job: %w", err)
+	}
+	return affected(result, "failed to ack job")
+}
+
+// Nack returns a message obtained from DequeueReliable to the head of its queue
+// so another consumer can pick it up. It reports false if the message was no
+// longer held.
+func (pgq *PostgresQueue) Nack(ctx context.Context, msg *JobMessage) (bool, error) {
+	lease, ok := leaseOf(msg)
+	if !ok {
+		return false, nil
+	}
+
+	result, err := pgq.db.ExecContext(ctx, `
+		UPDATE job_queue
+		SET state = 'ready', position = -nextval('job_queue_position_seq'), lease_id = NULL, lease_expires_at = NULL
+		WHERE lease_id = $1 AND state = 'leased'
+	`, lease)
+	if err != nil {
+		return false, fmt.Errorf("failed to nack job: %w", err)
+	}
+	return affected(result, "failed to nack job")
+}
+
+// ExtendLease moves the lease deadline of a message obtained from
+// DequeueReliable to visibilityTimeout from now. It reports false if the
+// message was no longer held.
+func (pgq *PostgresQueue) ExtendLease(ctx context.Context, msg *JobMessage, visibilityTimeout time.Duration) (bool, error) {
+	lease, ok := leaseOf(msg)
+	if !ok {
+		return false, nil
+	}
+
+	result, err := pgq.db.ExecContext(ctx, `
+		UPDATE job_queue
+		SET lease_expires_at = NOW() + make_interval(secs => $2)
+		WHERE lease_id = $1 AND state = 'leased'
+	`, lease, visibilityTimeout.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to extend lease: %w", err)
+	}
+	return affected(result, "failed to extend lease")
+}
+
+// DropLease removes a job's leased message without returning it to the queue,
+// once the job was taken from a worker that stopped responding. It reports
+// whether the message was found.
+func (pgq *PostgresQueue) DropLease(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	result, err := pgq.db.ExecContext(ctx, `
+		DELETE FROM job_queue
+		WHERE id = (
+			SELECT id
+			FROM job_queue
+			WHERE job_id = $1 AND type = $2 AND priority = $3 AND state = 'leased'
+			LIMIT 1
+		)
+	`, jobID, jobType, priority)
+	if err != nil {
+		return false, fmt.Errorf("failed to drop lease: %w", err)
+	}
+	return affected(result, "failed to drop lease")
+}
+
+// ReapExpired returns every message of a job type whose lease has expired to
+// the head of its queue and reports how many were returned. Every leased row
+// has a deadline, so visibilityTimeout is not needed.
+func (pgq *PostgresQueue) ReapExpired(ctx context.Context, jobType string, visibilityTimeout time.Duration) (int, error) {
+	result, err := pgq.db.ExecContext(ctx, `
+		UPDATE job_queue
+		SET state = 'ready', position = -nextval('job_queue_position_seq'), lease_id = NULL, lease_expires_at = NULL
+		WHERE type = $1 AND state = 'leased' AND lease_expires_at <= NOW()
+	`, jobType)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reap expired leases: %w", err)
+	}
+
+	reaped, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reap expired leases: %w", err)
+	}
+	return int(reaped), nil
+}
+
+// RequestCancel asks whichever worker is running a job to cancel it. The
+// request is fire-and-forget: it reaches only workers subscribed right now.
+func (pgq *PostgresQueue) RequestCancel(ctx context.Context, jobID uuid.UUID) error {
+	if _, err := pgq.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, CancelNotifyChannel, jobID.String()); err != nil {
+		return fmt.Errorf("failed to publish cancellation: %w", err)
+	}
+	return nil
+}
+
+// SubscribeCancels delivers the IDs of jobs whose cancellation is requested
+// until ctx is cancelled, when the returned channel is closed. Requests are
+// dropped for a subscriber that has fallen cancelBufferSize behind.
+func (pgq *PostgresQueue) SubscribeCancels(ctx context.Context) (<-chan uuid.UUID, error) {
+	ids := make(chan uuid.UUID, cancelBufferSize)
+
+	pgq.mu.Lock()
+	pgq.cancels[ids] = struct{}{}
+	pgq.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		pgq.mu.Lock()
+		defer pgq.mu.Unlock()
+		delete(pgq.cancels, ids)
+		close(ids)
+	}()
+
+	return ids, nil
+}
+
+// DeadLetter adds a job to its type's dead-letter queue.
+func (pgq *PostgresQueue) DeadLetter(ctx context.Context, jobID uuid.UUID, jobType string) error {
+	_, err := pgq.db.ExecContext(ctx, `
+		INSERT INTO job_queue_dead (type, job_id)
+		VALUES ($1, $2)
+		ON CONFLICT (type, job_id) DO UPDATE SET dead_at = NOW()
+	`, jobType, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+// RemoveDeadLetters removes jobs from their type's dead-letter queue, after
+// they were replayed or purged, and reports how many were removed.
+func (pgq *PostgresQueue) RemoveDeadLetters(ctx context.Context, jobType string, jobIDs ...uuid.UUID) (int64, error) {
+	if len(jobIDs) == 0 {
+		return 0, nil
+	}
+
+	args := []any{jobType}
+	in := make([]string, len(jobIDs))
+	for i, id := range jobIDs {
+		args = append(args, id)
+		in[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	result, err := pgq.db.ExecContext(ctx, `
+		DELETE FROM job_queue_dead
+		WHERE type = $1 AND job_id IN (`+strings.Join(in, ", ")+`)
+	`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove dead letters: %w", err)
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove dead letters: %w", err)
+	}
+	return removed, nil
+}
+
+// count runs a COUNT(*) query.
+func (pgq *PostgresQueue) count(ctx context.Context, what, query string, args ...any) (int64, error) {
+	var n int64
+	if err := pgq.db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to get %s length: %w", what, err)
+	}
+	return n, nil
+}
+
+// GetQueueLength returns the number of jobs of a type waiting at any priority.
+func (pgq *PostgresQueue) GetQueueLength(ctx context.Context, jobType string) (int64, error) {
+	return pgq.count(ctx, "queue", `
+		SELECT COUNT(*) FROM job_queue WHERE type = $1 AND state = 'ready'
+	`, jobType)
+}
+
+// GetPriorityQueueLength returns the number of jobs of a type waiting at a priority level.
+func (pgq *PostgresQueue) GetPriorityQueueLength(ctx context.Context, jobType string, level int) (int64, error) {
+	if level < PriorityHigh || level > PriorityLow {
+		// The normal queue, as QueueKey picks for unknown levels
+		level = PriorityNormal
+	}
+	return pgq.count(ctx, "queue", `
+		SELECT COUNT(*) FROM job_queue WHERE type = $1 AND state = 'ready' AND level = $2
+	`, jobType, level)
+}
+
+// GetScheduledLength returns the number of jobs of a type waiting for their due time.
+func (pgq *PostgresQueue) GetScheduledLength(ctx context.Context, jobType string) (int64, error) {
+	return pgq.count(ctx, "scheduled", `
+		SELECT COUNT(*) FROM job_queue WHERE type = $1 AND state = 'scheduled'
+	`, jobType)
+}
+
+// GetProcessingLength returns the number of jobs of a type currently leased to consumers.
+func (pgq *PostgresQueue) GetProcessingLength(ctx context.Context, jobType string) (int64, error) {
+	return pgq.count(ctx, "processing", `
+		SELECT COUNT(*) FROM job_queue WHERE type = $1 AND state = 'leased'
+	`, jobType)
+}
+
+// GetDeadLetterLength returns the number of jobs of a type in the dead-letter queue.
+func (pgq *PostgresQueue) GetDeadLetterLength(ctx context.Context, jobType string) (int64, error) {
+	return pgq.count(ctx, "dead-letter", `
+		SELECT COUNT(*) FROM job_queue_dead WHERE type = $1
+	`, jobType)
+}
+
+// TrackedJobIDs returns the IDs of every job of a type the queue knows about,
+// whether ready, leased or scheduled.
+func (pgq *PostgresQueue) TrackedJobIDs(ctx context.Context, jobType string) (map[uuid.UUID]struct{}, error) {
+	rows, err := pgq.db.QueryContext(ctx, `
+		SELECT DISTINCT job_id FROM job_queue WHERE type = $1
+	`, jobType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tracked jobs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[uuid.UUID]struct{})
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan tracked job: %w", err)
+		}
+		ids[id] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tracked jobs: %w", err)
+	}
+	return ids, nil
+}
+
+// Close stops listening for notifications. The database handle is left open.
+func (pgq *PostgresQueue) Close() error {
+	close(pgq.stop)
+	<-pgq.done
+	return pgq.listener.Close()
+}
+
+// affected reports whether a statement changed any row.
+func affected(result sql.Result, failure string) (bool, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", failure, err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,202 @@
+package queue_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/outbox"
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+func startPostgresQueue(t *testing.T) (*queue.PostgresQueue, *sql.DB, string) {
+	db, connString := testutil.StartPostgresWithConnString(t)
+
+	pgq, err := queue.NewPostgresQueue(db, connString)
+	require.NoError(t, err)
+	t.Cleanup(func() { pgq.Close() })
+	return pgq, db, connString
+}
+
+func TestPostgresQueue_ClaimOrder(t *testing.T) {
+	pgq, _, _ := startPostgresQueue(t)
+	ctx := context.Background()
+
+	// Always prefer high priority, then fall back from high to low
+	require.NoError(t, pgq.SetPriorityWeights(queue.PriorityWeights{High: 1}))
+
+	first, second, urgent := uuid.New(), uuid.New(), uuid.New()
+	require.NoError(t, pgq.Enqueue(ctx, first, "JOB_STANDARD", 0))
+	require.NoError(t, pgq.Enqueue(ctx, second, "JOB_STANDARD", 0))
+	require.NoError(t, pgq.Enqueue(ctx, urgent, "JOB_STANDARD", 5))
+
+	claim := func() *queue.JobMessage {
+		msg, err := pgq.DequeueReliable(ctx, "JOB_STANDARD", 0, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		return msg
+	}
+
+	assert.Equal(t, urgent, claim().JobID)
+
+	// A nacked message goes back to the head of its level
+	msg := claim()
+	assert.Equal(t, first, msg.JobID)
+	ok, err := pgq.Nack(ctx, msg)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, first, claim().JobID)
+	assert.Equal(t, second, claim().JobID)
+}
+
+func TestPostgresQueue_ConcurrentClaimsSkipLockedRows(t *testing.T) {
+	pgq, _, _ := startPostgresQueue(t)
+	ctx := context.Background()
+
+	const n = 20
+	for range n {
+		require.NoError(t, pgq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+	}
+
+	var mu sync.Mutex
+	seen := make(map[uuid.UUID]int)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg, err := pgq.DequeueReliable(ctx, "JOB_STANDARD", time.Second, time.Minute)
+			assert.NoError(t, err)
+			if msg != nil {
+				mu.Lock()
+				seen[msg.JobID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, n)
+	for id, claims := range seen {
+		assert.Equal(t, 1, claims, id)
+	}
+}
+
+func TestPostgresQueue_LeaseExpiry(t *testing.T) {
+	pgq, _, _ := startPostgresQueue(t)
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	require.NoError(t, pgq.Enqueue(ctx, jobID, "JOB_STANDARD", 0))
+
+	stale, err := pgq.DequeueReliable(ctx, "JOB_STANDARD", 0, 100*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, stale)
+
+	// Nothing is reaped while the lease holds
+	reaped, err := pgq.ReapExpired(ctx, "JOB_STANDARD", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reaped)
+
+	time.Sleep(200 * time.Millisecond)
+	reaped, err = pgq.ReapExpired(ctx, "JOB_STANDARD", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reaped)
+
+	fresh, err := pgq.DequeueReliable(ctx, "JOB_STANDARD", 0, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, fresh)
+	assert.Equal(t, jobID, fresh.JobID)
+
+	// The expired lease no longer settles the message
+	for _, settle := range []func(context.Context, *queue.JobMessage) (bool, error){pgq.Ack, pgq.Nack} {
+		ok, err := settle(ctx, stale)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	ok, err := pgq.ExtendLease(ctx, stale, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = pgq.Ack(ctx, fresh)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestPostgresQueue_NotifyWakesWaitingDequeue(t *testing.T) {
+	pgq, db, connString := startPostgresQueue(t)
+	ctx := context.Background()
+
+	// Another process enqueues through its own connection
+	producer, err := queue.NewPostgresQueue(db, connString)
+	require.NoError(t, err)
+	t.Cleanup(func() { producer.Close() })
+
+	jobID := uuid.New()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		assert.NoError(t, producer.Enqueue(ctx, jobID, "JOB_STANDARD", 0))
+	}()
+
+	// The dequeue sleeps until notified rather than polling, so it returns
+	// long before its timeout
+	start := time.Now()
+	msg, err := pgq.DequeueReliable(ctx, "JOB_STANDARD", 10*time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, jobID, msg.JobID)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestPostgresQueue_JobsQueuedWithTheirTransaction(t *testing.T) {
+	pgq, db, _ := startPostgresQueue(t)
+	ctx := context.Background()
+	s := store.NewPostgresStoreWithQueue(db, outbox.QueueTx{})
+
+	job := &store.Job{
+		ID:          uuid.New(),
+		Type:        "JOB_STANDARD",
+		Payload:     []byte("payload"),
+		Status:      store.JobStatusQueued,
+		MaxAttempts: 3,
+		RetryJitter: "full",
+	}
+	entry, err := s.CreateJobWithOutbox(ctx, job)
+	require.NoError(t, err)
+	assert.NotNil(t, entry.SentAt)
+
+	// Nothing is left for the relay
+	pending, err := s.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+
+	msg, err := pgq.DequeueReliable(ctx, "JOB_STANDARD", 0, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, job.ID, msg.JobID)
+
+	// A batch that rolls back leaves no messages behind
+	refused := errors.New("refused")
+	_, _, err = s.CreateJobsWithOutbox(ctx, []*store.Job{{
+		ID:          uuid.New(),
+		Type:        "JOB_STANDARD",
+		Status:      store.JobStatusQueued,
+		MaxAttempts: 3,
+		RetryJitter: "full",
+	}}, time.Hour, func(map[string]*store.Job) error { return refused })
+	require.ErrorIs(t, err, refused)
+
+	queued, err := pgq.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Zero(t, queued)
+}
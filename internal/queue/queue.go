@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobQueue hands job references to workers. RedisQueue is the default
+// implementation; StreamQueue uses Redis Streams consumer groups,
+// PostgresQueue keeps the queue in the jobs database, BoltQueue in a local
+// bbolt file for embedded mode, and MemoryQueue in process. All pass the conformance suite in package
+// queuetest, which documents the behavior every implementation must share.
+type JobQueue interface {
+	// SetPriorityWeights must be called before the queue is used to dequeue.
+	SetPriorityWeights(w PriorityWeights) error
+
+	// Adding jobs
+	Enqueue(ctx context.Context, jobID uuid.UUID, jobType string, priority int) error
+	Schedule(ctx context.Context, jobID uuid.UUID, jobType string, priority int, runAt time.Time) error
+	EnqueueBatch(ctx context.Context, items []BatchItem) error
+	PromoteDue(ctx context.Context, jobType string, limit int) ([]*JobMessage, error)
+	Remove(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error)
+
+	// Taking jobs
+	Dequeue(ctx context.Context, jobType string, timeout time.Duration) (*JobMessage, error)
+	DequeueReliable(ctx context.Context, jobType string, timeout, visibilityTimeout time.Duration) (*JobMessage, error)
+	DequeueReliableBatch(ctx context.Context, jobTypes []string, limit int, timeout, visibilityTimeout time.Duration) ([]*JobMessage, error)
+
+	// Leases
+	Ack(ctx context.Context, msg *JobMessage) (bool, error)
+	Nack(ctx context.Context, msg *JobMessage) (bool, error)
+	ExtendLease(ctx context.Context, msg *JobMessage, visibilityTimeout time.Duration) (bool, error)
+	DropLease(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error)
+	ReapExpired(ctx context.Context, jobType string, visibilityTimeout time.Duration) (int, error)
+
+	// Cancellation
+	RequestCancel(ctx context.Context, jobID uuid.UUID) error
+	SubscribeCancels(ctx context.Context) (<-chan uuid.UUID, error)
+
+	// Dead letters
+	DeadLetter(ctx context.Context, jobID uuid.UUID, jobType string) error
+	RemoveDeadLetters(ctx context.Context, jobType string, jobIDs ...uuid.UUID) (int64, error)
+
+	// Inspection
+	GetQueueLength(ctx context.Context, jobType string) (int64, error)
+	GetPriorityQueueLength(ctx context.Context, jobType string, level int) (int64, error)
+	GetScheduledLength(ctx context.Context, jobType string) (int64, error)
+	GetProcessingLength(ctx context.Context, jobType string) (int64, error)
+	GetDeadLetterLength(ctx context.Context, jobType string) (int64, error)
+	TrackedJobIDs(ctx context.Context, jobType string) (map[uuid.UUID]struct{}, error)
+
+	Close() error
+}
+
+var _ JobQueue = (*RedisQueue)(nil)
+
+// dequeueOrder returns the priority levels in the order the dequeue on a
+// type's given turn tries them, as dequeueOrderLua does: the weights pick the
+// preferred level and the rest follow from high to low.
+func dequeueOrder(w PriorityWeights, turn int) []int {
+	first := PriorityHigh
+	slot := turn % (w.High + w.Normal + w.Low)
+	if slot >= w.High+w.Normal {
+		first = PriorityLow
+	} else if slot >= w.High {
+		first = PriorityNormal
+	}
+
+	order := []int{first}
+	for level := PriorityHigh; level <= PriorityLow; level++ {
+		if level != first {
+			order = append(order, level)
+		}
+	}
+	return order
+}
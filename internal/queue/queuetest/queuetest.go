@@ -0,0 +1,475 @@
+// Package queuetest is the conformance suite for queue.JobQueue
+// implementations. Every implementation runs it from its own tests, so they
+// all behave like RedisQueue, the reference implementation.
+package queuetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/turnertastic1/boltq/internal/queue"
+)
+
+const testJobType = "JOB_STANDARD"
+
+// Run runs the suite. newQueue must return a queue holding no messages.
+func Run(t *testing.T, newQueue func(t *testing.T) queue.JobQueue) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, q queue.JobQueue)
+	}{
+		{"DequeueReliable_Ack", testDequeueReliableAck},
+		{"DequeueReliable_Empty", testDequeueReliableEmpty},
+		{"Nack_ReturnsJobToHeadOfQueue", testNackReturnsJobToHeadOfQueue},
+		{"ReapExpired", testReapExpired},
+		{"Schedule_PromoteDue", testSchedulePromoteDue},
+		{"Dequeue_PriorityOrder", testDequeuePriorityOrder},
+		{"Dequeue_WeightsPreventStarvation", testDequeueWeightsPreventStarvation},
+		{"NackAndPromote_KeepPriority", testNackAndPromoteKeepPriority},
+		{"Remove", testRemove},
+		{"RequestCancel_ReachesSubscribers", testRequestCancelReachesSubscribers},
+		{"EnqueueBatch", testEnqueueBatch},
+		{"DequeueReliableBatch", testDequeueReliableBatch},
+		{"LeaseToken_ExtendAndAck", testLeaseTokenExtendAndAck},
+		{"DequeueReliable_WakesOnEnqueue", testDequeueReliableWakesOnEnqueue},
+		{"DropLease", testDropLease},
+		{"DeadLetters", testDeadLetters},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newQueue(t))
+		})
+	}
+}
+
+func testDequeueReliableAck(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	require.NoError(t, q.Enqueue(ctx, jobID, testJobType, 0))
+
+	msg, err := q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, jobID, msg.JobID)
+
+	queueLen, err := q.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), queueLen)
+
+	processingLen, err := q.GetProcessingLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), processingLen)
+
+	held, err := q.Ack(ctx, msg)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	processingLen, err = q.GetProcessingLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), processingLen)
+
+	// A second ack is a no-op
+	held, err = q.Ack(ctx, msg)
+	require.NoError(t, err)
+	assert.False(t, held)
+}
+
+func testDequeueReliableEmpty(t *testing.T, q queue.JobQueue) {
+	msg, err := q.DequeueReliable(context.Background(), testJobType, 100*time.Millisecond, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+}
+
+func testNackReturnsJobToHeadOfQueue(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	first, second := uuid.New(), uuid.New()
+	require.NoError(t, q.Enqueue(ctx, first, testJobType, 0))
+	require.NoError(t, q.Enqueue(ctx, second, testJobType, 0))
+
+	msg, err := q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+
+	held, err := q.Nack(ctx, msg)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	processingLen, err := q.GetProcessingLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), processingLen)
+
+	// The nacked job is retried before later jobs
+	msg, err = q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, first, msg.JobID)
+}
+
+func testReapExpired(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	expired, live := uuid.New(), uuid.New()
+	require.NoError(t, q.Enqueue(ctx, expired, testJobType, 0))
+	require.NoError(t, q.Enqueue(ctx, live, testJobType, 0))
+
+	_, err := q.DequeueReliable(ctx, testJobType, time.Second, 50*time.Millisecond)
+	require.NoError(t, err)
+	liveMsg, err := q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	reaped, err := q.ReapExpired(ctx, testJobType, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reaped)
+
+	msg, err := q.Dequeue(ctx, testJobType, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, expired, msg.JobID)
+
+	// The job with a live lease is still held
+	held, err := q.Ack(ctx, liveMsg)
+	require.NoError(t, err)
+	assert.True(t, held)
+}
+
+func testSchedulePromoteDue(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	soon, later, now := uuid.New(), uuid.New(), uuid.New()
+	require.NoError(t, q.Schedule(ctx, soon, testJobType, 0, time.Now().Add(50*time.Millisecond)))
+	require.NoError(t, q.Schedule(ctx, later, testJobType, 0, time.Now().Add(time.Hour)))
+
+	// A job that is already due goes straight to the queue
+	require.NoError(t, q.Schedule(ctx, now, testJobType, 0, time.Now().Add(-time.Second)))
+
+	scheduledLen, err := q.GetScheduledLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), scheduledLen)
+
+	ids, err := q.TrackedJobIDs(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Len(t, ids, 3)
+
+	time.Sleep(100 * time.Millisecond)
+
+	promoted, err := q.PromoteDue(ctx, testJobType, 10)
+	require.NoError(t, err)
+	require.Len(t, promoted, 1)
+	assert.Equal(t, soon, promoted[0].JobID)
+
+	// Promoting again finds nothing due
+	promoted, err = q.PromoteDue(ctx, testJobType, 10)
+	require.NoError(t, err)
+	assert.Empty(t, promoted)
+
+	queueLen, err := q.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), queueLen)
+
+	scheduledLen, err = q.GetScheduledLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), scheduledLen)
+}
+
+func testDequeuePriorityOrder(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	// Only high priority is preferred, so levels are taken strictly in order
+	require.NoError(t, q.SetPriorityWeights(queue.PriorityWeights{High: 1}))
+
+	low, normal, high := uuid.New(), uuid.New(), uuid.New()
+	require.NoError(t, q.Enqueue(ctx, low, testJobType, -5))
+	require.NoError(t, q.Enqueue(ctx, normal, testJobType, 0))
+	require.NoError(t, q.Enqueue(ctx, high, testJobType, 10))
+
+	for _, want := range []uuid.UUID{high, normal, low} {
+		msg, err := q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		assert.Equal(t, want, msg.JobID)
+	}
+}
+
+func testDequeueWeightsPreventStarvation(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	require.NoError(t, q.SetPriorityWeights(queue.PriorityWeights{High: 3, Normal: 0, Low: 1}))
+
+	for range 12 {
+		require.NoError(t, q.Enqueue(ctx, uuid.New(), testJobType, 1))
+	}
+	for range 4 {
+		require.NoError(t, q.Enqueue(ctx, uuid.New(), testJobType, -1))
+	}
+
+	// Of the first 8 dequeues, 2 go to the low priority queue
+	lowTaken := 0
+	for range 8 {
+		msg, err := q.Dequeue(ctx, testJobType, time.Second)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		if msg.Priority < 0 {
+			lowTaken++
+		}
+	}
+	assert.Equal(t, 2, lowTaken)
+}
+
+func testNackAndPromoteKeepPriority(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	urgent := uuid.New()
+	require.NoError(t, q.Enqueue(ctx, urgent, testJobType, 5))
+
+	msg, err := q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+
+	held, err := q.Nack(ctx, msg)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	require.NoError(t, q.Schedule(ctx, uuid.New(), testJobType, -5, time.Now().Add(20*time.Millisecond)))
+	time.Sleep(50 * time.Millisecond)
+	_, err = q.PromoteDue(ctx, testJobType, 10)
+	require.NoError(t, err)
+
+	highLen, err := q.GetPriorityQueueLength(ctx, testJobType, queue.PriorityHigh)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), highLen)
+
+	lowLen, err := q.GetPriorityQueueLength(ctx, testJobType, queue.PriorityLow)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lowLen)
+}
+
+func testRemove(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	queued, scheduled := uuid.New(), uuid.New()
+	require.NoError(t, q.Enqueue(ctx, queued, testJobType, 3))
+	require.NoError(t, q.Schedule(ctx, scheduled, testJobType, 0, time.Now().Add(time.Hour)))
+
+	removed, err := q.Remove(ctx, queued, testJobType, 3)
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = q.Remove(ctx, scheduled, testJobType, 0)
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = q.Remove(ctx, uuid.New(), testJobType, 0)
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	queueLen, err := q.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), queueLen)
+
+	scheduledLen, err := q.GetScheduledLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), scheduledLen)
+}
+
+func testRequestCancelReachesSubscribers(t *testing.T, q queue.JobQueue) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cancels, err := q.SubscribeCancels(ctx)
+	require.NoError(t, err)
+
+	jobID := uuid.New()
+	require.NoError(t, q.RequestCancel(ctx, jobID))
+
+	select {
+	case id := <-cancels:
+		assert.Equal(t, jobID, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel request was never delivered")
+	}
+
+	// The channel is closed once ctx is done
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-cancels
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func testEnqueueBatch(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	later := time.Now().Add(time.Hour)
+	high, low, scheduled := uuid.New(), uuid.New(), uuid.New()
+	require.NoError(t, q.EnqueueBatch(ctx, []queue.BatchItem{
+		{JobID: low, Type: testJobType, Priority: -5},
+		{JobID: scheduled, Type: testJobType, RunAt: &later},
+		{JobID: high, Type: testJobType, Priority: 5},
+	}))
+
+	queueLen, err := q.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), queueLen)
+
+	scheduledLen, err := q.GetScheduledLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), scheduledLen)
+
+	msg, err := q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, high, msg.JobID)
+}
+
+func testDequeueReliableBatch(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	const otherType = "JOB_WEBHOOK"
+	for range 3 {
+		require.NoError(t, q.Enqueue(ctx, uuid.New(), testJobType, 0))
+	}
+	require.NoError(t, q.Enqueue(ctx, uuid.New(), otherType, 0))
+
+	msgs, err := q.DequeueReliableBatch(ctx, []string{testJobType, otherType}, 3, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	// Types are taken in turn
+	assert.Equal(t, testJobType, msgs[0].Type)
+	assert.Equal(t, otherType, msgs[1].Type)
+	assert.Equal(t, testJobType, msgs[2].Type)
+
+	processingLen, err := q.GetProcessingLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), processingLen)
+
+	msgs, err = q.DequeueReliableBatch(ctx, []string{testJobType, otherType}, 10, time.Second, time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 1)
+
+	start := time.Now()
+	msgs, err = q.DequeueReliableBatch(ctx, []string{testJobType, otherType}, 10, 200*time.Millisecond, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func testLeaseTokenExtendAndAck(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	require.NoError(t, q.Enqueue(ctx, jobID, testJobType, 2))
+
+	msg, err := q.DequeueReliable(ctx, testJobType, time.Second, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+
+	leased, err := queue.ParseLeaseToken(msg.LeaseToken())
+	require.NoError(t, err)
+	assert.Equal(t, jobID, leased.JobID)
+	assert.Equal(t, 2, leased.Priority)
+
+	held, err := q.ExtendLease(ctx, leased, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	// The extended lease outlives the original deadline
+	time.Sleep(100 * time.Millisecond)
+	reaped, err := q.ReapExpired(ctx, testJobType, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reaped)
+
+	held, err = q.Ack(ctx, leased)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	held, err = q.ExtendLease(ctx, leased, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, held)
+}
+
+func testDequeueReliableWakesOnEnqueue(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		assert.NoError(t, q.Enqueue(ctx, jobID, testJobType, 0))
+	}()
+
+	// A dequeue waiting on an empty queue takes a job added meanwhile
+	msg, err := q.DequeueReliable(ctx, testJobType, 5*time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, jobID, msg.JobID)
+}
+
+func testDropLease(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	require.NoError(t, q.Enqueue(ctx, jobID, testJobType, 3))
+
+	msg, err := q.DequeueReliable(ctx, testJobType, time.Second, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+
+	ids, err := q.TrackedJobIDs(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Contains(t, ids, jobID)
+
+	dropped, err := q.DropLease(ctx, jobID, testJobType, 3)
+	require.NoError(t, err)
+	assert.True(t, dropped)
+
+	// Dropped, not returned to the queue
+	queueLen, err := q.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), queueLen)
+
+	ids, err = q.TrackedJobIDs(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	held, err := q.Ack(ctx, msg)
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	dropped, err = q.DropLease(ctx, jobID, testJobType, 3)
+	require.NoError(t, err)
+	assert.False(t, dropped)
+}
+
+func testDeadLetters(t *testing.T, q queue.JobQueue) {
+	ctx := context.Background()
+
+	first, second := uuid.New(), uuid.New()
+	require.NoError(t, q.DeadLetter(ctx, first, testJobType))
+	require.NoError(t, q.DeadLetter(ctx, second, testJobType))
+	// Dead-lettering a job twice keeps one entry
+	require.NoError(t, q.DeadLetter(ctx, first, testJobType))
+
+	deadLen, err := q.GetDeadLetterLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deadLen)
+
+	removed, err := q.RemoveDeadLetters(ctx, testJobType, first, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	removed, err = q.RemoveDeadLetters(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), removed)
+
+	deadLen, err = q.GetDeadLetterLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deadLen)
+}
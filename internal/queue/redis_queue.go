@@ -1,11 +1,41 @@
 // Package queue provides a Redis-based job queue management for BoltQ.
 // It handles enqueung, dequeuing, and queue monitoring using Redis.
+//
+// Besides the simple Dequeue, the queue offers a reliable mode: DequeueReliable
+// atomically moves a message into a per-type processing list and records a lease
+// deadline for it. The consumer must Ack or Nack the message; ReapExpired returns
+// messages whose lease has expired (e.g. because the worker crashed) to the queue.
+//
+// Each job type has three queue lists, one per priority level. Dequeues take
+// from the levels in a weighted rotation (see PriorityWeights) so urgent jobs
+// go first without starving the rest. No blocking Redis command takes from
+// several lists in weighted order, so consumers instead block on a per-type
+// ready list, which holds a token whenever messages may be waiting, and take
+// the next message with a script once it wakes them.
+//
+// Jobs that should run later (scheduled jobs and retries) wait in a per-type
+// sorted set scored by their due time; PromoteDue moves them to the queue.
+//
+// Jobs that exhaust their retries are moved to a per-type dead-letter queue,
+// a sorted set of job IDs, until they are replayed or purged.
+//
+// Cancellation requests for running jobs are broadcast to workers over the
+// CancelChannel pub/sub channel.
+//
+// Consumers depend on the JobQueue interface, which MemoryQueue also
+// implements with the same semantics for tests and single-process use,
+// StreamQueue on Redis Streams for deployments that want consumer groups, and
+// PostgresQueue for deployments that keep the queue in the jobs database.
 package queue
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,22 +43,310 @@ import (
 )
 
 const (
-	QueueKeyPrefix   = "boltq:queue:"
-	JobKeyPrefix     = "boltq:job:"
-	redisPingTimeout = 5 * time.Second
+	QueueKeyPrefix      = "boltq:queue:"
+	JobKeyPrefix        = "boltq:job:"
+	ProcessingKeyPrefix = "boltq:processing:"
+	LeaseKeyPrefix      = "boltq:leases:"
+	DeadLetterKeyPrefix = "boltq:dead:"
+	ScheduledKeyPrefix  = "boltq:scheduled:"
+	TurnKeyPrefix       = "boltq:turn:"
+	ReadyKeyPrefix      = "boltq:ready:"
+	CancelChannel       = "boltq:cancel"
+	redisPingTimeout    = 5 * time.Second
+	scanBatchSize       = 1000
+	reapBatchSize       = 100
 )
 
+// Priority levels. A job's numeric priority maps to one of them: above zero is
+// high, zero is normal and below zero is low.
+const (
+	PriorityHigh = iota
+	PriorityNormal
+	PriorityLow
+)
+
+// PriorityWeights sets how often each priority level is tried first when jobs
+// are waiting at several levels. With 8:4:1, of every 13 dequeues 8 prefer
+// high, 4 normal and 1 low; if the preferred level is empty the others are
+// tried from high to low, so no dequeue is wasted.
+type PriorityWeights struct {
+	High   int
+	Normal int
+	Low    int
+}
+
+// DefaultPriorityWeights are the weights a RedisQueue starts with.
+var DefaultPriorityWeights = PriorityWeights{High: 8, Normal: 4, Low: 1}
+
+// ParsePriorityWeights parses weights written as "high:normal:low", e.g. "8:4:1".
+func ParsePriorityWeights(s string) (PriorityWeights, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return PriorityWeights{}, fmt.Errorf("invalid priority weights %q: want high:normal:low", s)
+	}
+
+	var values [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return PriorityWeights{}, fmt.Errorf("invalid priority weights %q: %w", s, err)
+		}
+		values[i] = v
+	}
+
+	w := PriorityWeights{High: values[0], Normal: values[1], Low: values[2]}
+	return w, w.Validate()
+}
+
+// Validate reports an error if a weight is negative or all are zero.
+func (w PriorityWeights) Validate() error {
+	if w.High < 0 || w.Normal < 0 || w.Low < 0 {
+		return errors.New("priority weights cannot be negative")
+	}
+	if w.High+w.Normal+w.Low == 0 {
+		return errors.New("at least one priority weight must be positive")
+	}
+	return nil
+}
+
+// PriorityLevel returns the level a job with the given priority is queued at.
+func PriorityLevel(priority int) int {
+	switch {
+	case priority > 0:
+		return PriorityHigh
+	case priority < 0:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// QueueKey returns the list holding a type's jobs at a priority level. Normal
+// priority uses the plain QueueKeyPrefix key, so queues written before
+// priorities existed are still consumed.
+func QueueKey(jobType string, level int) string {
+	switch level {
+	case PriorityHigh:
+		return QueueKeyPrefix + jobType + ":high"
+	case PriorityLow:
+		return QueueKeyPrefix + jobType + ":low"
+	default:
+		return QueueKeyPrefix + jobType
+	}
+}
+
+// queueKeys returns a type's queue lists from high to low priority.
+func queueKeys(jobType string) []string {
+	return []string{
+		QueueKey(jobType, PriorityHigh),
+		QueueKey(jobType, PriorityNormal),
+		QueueKey(jobType, PriorityLow),
+	}
+}
+
 // RedisQueue manages job queues using Redis as the backing store.
 type RedisQueue struct {
-	client *redis.Client
+	client  *redis.Client
+	weights PriorityWeights
 }
 
 // JobMessage represents a lightweight job reference in the queue.
 type JobMessage struct {
-	JobID uuid.UUID `json:"job_id"`
-	Type  string    `json:"type"`
+	JobID    uuid.UUID `json:"job_id"`
+	Type     string    `json:"type"`
+	Priority int       `json:"priority,omitempty"`
+
+	// raw is the exact encoding stored in Redis. Reliable-mode messages are
+	// identified by it when they are acked, nacked or reaped.
+	raw string
+}
+
+// LeaseToken identifies a message obtained from DequeueReliable, so a consumer
+// in another process can ack, nack or extend it after ParseLeaseToken.
+func (m *JobMessage) LeaseToken() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(m.raw))
+}
+
+// ParseLeaseToken returns the message a lease token was issued for.
+func ParseLeaseToken(token string) (*JobMessage, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lease token: %w", err)
+	}
+
+	var msg JobMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("invalid lease token: %w", err)
+	}
+	if msg.JobID == uuid.Nil || msg.Type == "" {
+		return nil, errors.New("invalid lease token: missing job")
+	}
+	msg.raw = string(raw)
+
+	return &msg, nil
 }
 
+// queueKeyLua picks the queue a raw message belongs to from its priority,
+// given the index of the high priority queue in KEYS; the normal and low
+// queues must follow it.
+const queueKeyLua = `
+local function queue_key(item, first)
+  local ok, p = pcall(function() return tonumber(cjson.decode(item).priority) end)
+  if ok and p and p > 0 then
+    return KEYS[first]
+  elseif ok and p and p < 0 then
+    return KEYS[first + 2]
+  end
+  return KEYS[first + 1]
+end
+`
+
+// signalReadyLua leaves the single token in a ready list that wakes one
+// consumer blocked on it.
+const signalReadyLua = `
+local function signal_ready(ready)
+  redis.call('LPUSH', ready, 1)
+  redis.call('LTRIM', ready, 0, 0)
+end
+`
+
+// takeNextLua takes the next message from the queues in KEYS[1..3] in
+// weighted priority order, using move to take it from a queue, and wakes
+// another consumer through the ready list in KEYS[ready] if more are waiting.
+const takeNextLua = signalReadyLua + dequeueOrderLua + `
+local function take_next(move, ready)
+  for _, i in ipairs(dequeue_order()) do
+    local item = move(KEYS[i])
+    if item then
+      if redis.call('LLEN', KEYS[1]) + redis.call('LLEN', KEYS[2]) + redis.call('LLEN', KEYS[3]) > 0 then
+        signal_ready(KEYS[ready])
+      end
+      return item
+    end
+  end
+  return false
+end
+`
+
+// dequeueOrderLua returns the indexes of the queues in KEYS[1..3] (high,
+// normal, low) in the order to try them. A per-type counter in KEYS[4] picks
+// the preferred level by the weights in ARGV[1..3]; the rest follow from high
+// to low.
+const dequeueOrderLua = `
+local function dequeue_order()
+  local high, normal, low = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+  local first = 1
+  local slot = redis.call('INCR', KEYS[4]) % (high + normal + low)
+  if slot >= high + normal then
+    first = 3
+  elseif slot >= high then
+    first = 2
+  end
+  local order = {first}
+  for i = 1, 3 do
+    if i ~= first then
+      table.insert(order, i)
+    end
+  end
+  return order
+end
+`
+
+// popScript pops the next message from the queues in weighted priority order.
+// KEYS[1..3] queues, KEYS[4] turn counter, KEYS[5] ready list; ARGV[1..3] weights.
+var popScript = redis.NewScript(takeNextLua + `
+return take_next(function(queue)
+  return redis.call('LPOP', queue)
+end, 5)
+`)
+
+// claimScript moves the next message from the queues, in weighted priority
+// order, to the processing list and leases it.
+// KEYS[1..3] queues, KEYS[4] turn counter, KEYS[5] ready list, KEYS[6]
+// processing list, KEYS[7] lease set; ARGV[1..3] weights, ARGV[4] lease
+// deadline in ms.
+var claimScript = redis.NewScript(takeNextLua + `
+local item = take_next(function(queue)
+  return redis.call('LMOVE', queue, KEYS[6], 'LEFT', 'RIGHT')
+end, 5)
+if item then
+  redis.call('ZADD', KEYS[7], ARGV[4], item)
+end
+return item
+`)
+
+// ackScript removes a message from the processing list and drops its lease.
+// KEYS[1] processing list, KEYS[2] lease set; ARGV[1] raw message.
+var ackScript = redis.NewScript(`
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+return removed
+`)
+
+// nackScript moves a message from the processing list back to the head of the
+// queue. It is a no-op if the message is no longer being processed.
+// KEYS[1] processing list, KEYS[2] lease set, KEYS[3] queue, KEYS[4] ready
+// list; ARGV[1] raw message.
+var nackScript = redis.NewScript(signalReadyLua + `
+local removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+if removed > 0 then
+  redis.call('LPUSH', KEYS[3], ARGV[1])
+  signal_ready(KEYS[4])
+end
+return removed
+`)
+
+// extendScript moves a held message's lease deadline. It is a no-op if the
+// message is no longer leased.
+// KEYS[1] lease set; ARGV[1] raw message, ARGV[2] deadline in ms.
+var extendScript = redis.NewScript(`
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+  redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+  return 1
+end
+return 0
+`)
+
+// reapScript returns up to ARGV[2] messages whose lease expired at or before
+// ARGV[1] to the head of their queue, taking them from the lease set so only
+// expired leases are read. Leases of messages no longer in the processing list
+// are dropped. Returns how many leases were read and how many messages reaped.
+// KEYS[1] processing list, KEYS[2] lease set, KEYS[3..5] queues, KEYS[6]
+// ready list; ARGV[1] now in ms, ARGV[2] limit.
+var reapScript = redis.NewScript(queueKeyLua + signalReadyLua + `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local reaped = 0
+for _, item in ipairs(expired) do
+  redis.call('ZREM', KEYS[2], item)
+  if redis.call('LREM', KEYS[1], 1, item) > 0 then
+    redis.call('LPUSH', queue_key(item, 3), item)
+    reaped = reaped + 1
+  end
+end
+if reaped > 0 then
+  signal_ready(KEYS[6])
+end
+return {#expired, reaped}
+`)
+
+// promoteScript moves up to ARGV[2] messages due at or before ARGV[1] from the
+// scheduled set to the tail of their queue and returns them. Running it as one
+// script means concurrent promoters never move the same message twice.
+// KEYS[1] scheduled set, KEYS[2..4] queues, KEYS[5] ready list; ARGV[1] now in
+// ms, ARGV[2] limit.
+var promoteScript = redis.NewScript(queueKeyLua + signalReadyLua + `
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, item in ipairs(due) do
+  redis.call('ZREM', KEYS[1], item)
+  redis.call('RPUSH', queue_key(item, 2), item)
+end
+if #due > 0 then
+  signal_ready(KEYS[5])
+end
+return due
+`)
+
 // NewRedisQueue initializes a new RedisQueue with the given Redis connection parameters.
 func NewRedisQueue(addr, password string, db int) (*RedisQueue, error) {
 	client := redis.NewClient(&redis.Options{
@@ -44,14 +362,25 @@ func NewRedisQueue(addr, password string, db int) (*RedisQueue, error) {
 		return nil, err
 	}
 
-	return &RedisQueue{client: client}, nil
+	return &RedisQueue{client: client, weights: DefaultPriorityWeights}, nil
+}
+
+// SetPriorityWeights changes how dequeues share out the priority levels. It
+// must be called before the queue is used to dequeue.
+func (rq *RedisQueue) SetPriorityWeights(w PriorityWeights) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	rq.weights = w
+	return nil
 }
 
-// Enqueue adds a job reference to the Redis queue.
-func (rq *RedisQueue) Enqueue(ctx context.Context, jobID uuid.UUID, jobType string) error {
+// Enqueue adds a job reference to the Redis queue for its priority level.
+func (rq *RedisQueue) Enqueue(ctx context.Context, jobID uuid.UUID, jobType string, priority int) error {
 	msg := JobMessage{
-		JobID: jobID,
-		Type:  jobType,
+		JobID:    jobID,
+		Type:     jobType,
+		Priority: priority,
 	}
 
 	data, err := json.Marshal(msg)
@@ -59,17 +388,202 @@ func (rq *RedisQueue) Enqueue(ctx context.Context, jobID uuid.UUID, jobType stri
 		return err
 	}
 
-	queueKey := QueueKeyPrefix + jobType
-	return rq.client.RPush(ctx, queueKey, data).Err()
+	pipe := rq.client.TxPipeline()
+	pipe.RPush(ctx, QueueKey(jobType, PriorityLevel(priority)), data)
+	signalReady(ctx, pipe, jobType)
+	_, err = pipe.Exec(ctx)
+	return err
 }
 
-// Dequeue retrieves the next job reference from the Redis queue.
-// Workers should call this with their specific job type.
-// Returns nil if no job is available within the timeout.
-func (rq *RedisQueue) Dequeue(ctx context.Context, jobType string, timeout time.Duration) (*JobMessage, error) {
-	queueKey := QueueKeyPrefix + jobType
+// signalReady queues the commands that wake a consumer waiting for jobType,
+// as signalReadyLua does in scripts.
+func signalReady(ctx context.Context, pipe redis.Pipeliner, jobType string) {
+	pipe.LPush(ctx, ReadyKeyPrefix+jobType, 1)
+	pipe.LTrim(ctx, ReadyKeyPrefix+jobType, 0, 0)
+}
+
+// Schedule adds a job reference to the type's scheduled set, to be moved to the
+// queue by PromoteDue once runAt has passed. A job that is already due is
+// enqueued straight away.
+func (rq *RedisQueue) Schedule(ctx context.Context, jobID uuid.UUID, jobType string, priority int, runAt time.Time) error {
+	if !runAt.After(time.Now()) {
+		return rq.Enqueue(ctx, jobID, jobType, priority)
+	}
 
-	result, err := rq.client.BLPop(ctx, timeout, queueKey).Result()
+	data, err := json.Marshal(JobMessage{JobID: jobID, Type: jobType, Priority: priority})
+	if err != nil {
+		return err
+	}
+
+	err = rq.client.ZAdd(ctx, ScheduledKeyPrefix+jobType, redis.Z{
+		Score:  float64(runAt.UnixMilli()),
+		Member: data,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule job: %w", err)
+	}
+	return nil
+}
+
+// BatchItem is a job reference handed to the queue by EnqueueBatch.
+type BatchItem struct {
+	JobID    uuid.UUID
+	Type     string
+	Priority int
+	// RunAt, if in the future, schedules the job instead of enqueuing it
+	RunAt *time.Time
+}
+
+// EnqueueBatch enqueues or schedules many jobs in one round trip. It is not
+// atomic: on error some of the items may have been added.
+func (rq *RedisQueue) EnqueueBatch(ctx context.Context, items []BatchItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	pipe := rq.client.Pipeline()
+	queued := make(map[string]bool)
+	for _, item := range items {
+		data, err := json.Marshal(JobMessage{JobID: item.JobID, Type: item.Type, Priority: item.Priority})
+		if err != nil {
+			return err
+		}
+		if item.RunAt != nil && item.RunAt.After(now) {
+			pipe.ZAdd(ctx, ScheduledKeyPrefix+item.Type, redis.Z{Score: float64(item.RunAt.UnixMilli()), Member: data})
+		} else {
+			pipe.RPush(ctx, QueueKey(item.Type, PriorityLevel(item.Priority)), data)
+			queued[item.Type] = true
+		}
+	}
+	for jobType := range queued {
+		signalReady(ctx, pipe, jobType)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to enqueue batch: %w", err)
+	}
+	return nil
+}
+
+// PromoteDue moves up to limit scheduled jobs of a type whose due time has
+// passed to the queue, and returns the promoted messages.
+func (rq *RedisQueue) PromoteDue(ctx context.Context, jobType string, limit int) ([]*JobMessage, error) {
+	items, err := promoteScript.Run(ctx, rq.client,
+		append(append([]string{ScheduledKeyPrefix + jobType}, queueKeys(jobType)...), ReadyKeyPrefix+jobType),
+		time.Now().UnixMilli(), limit,
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to promote scheduled jobs: %w", err)
+	}
+
+	msgs := make([]*JobMessage, 0, len(items))
+	for _, item := range items {
+		var msg JobMessage
+		if err := json.Unmarshal([]byte(item), &msg); err != nil {
+			// Skip malformed entries; they were promoted all the same
+			continue
+		}
+		msg.raw = item
+		msgs = append(msgs, &msg)
+	}
+	return msgs, nil
+}
+
+// Remove takes a job that has not been dequeued yet off its queue and out of
+// the scheduled set, and reports whether it was found in either.
+func (rq *RedisQueue) Remove(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	data, err := json.Marshal(JobMessage{JobID: jobID, Type: jobType, Priority: priority})
+	if err != nil {
+		return false, err
+	}
+
+	pipe := rq.client.TxPipeline()
+	fromQueue := pipe.LRem(ctx, QueueKey(jobType, PriorityLevel(priority)), 0, data)
+	fromScheduled := pipe.ZRem(ctx, ScheduledKeyPrefix+jobType, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to remove job: %w", err)
+	}
+
+	return fromQueue.Val()+fromScheduled.Val() > 0, nil
+}
+
+// RequestCancel asks whichever worker is running a job to cancel it. The
+// request is fire-and-forget: it reaches only workers subscribed right now.
+func (rq *RedisQueue) RequestCancel(ctx context.Context, jobID uuid.UUID) error {
+	return publishCancel(ctx, rq.client, jobID)
+}
+
+// SubscribeCancels delivers the IDs of jobs whose cancellation is requested
+// until ctx is cancelled, when the returned channel is closed. It returns once
+// the subscription is active.
+func (rq *RedisQueue) SubscribeCancels(ctx context.Context) (<-chan uuid.UUID, error) {
+	return subscribeCancels(ctx, rq.client)
+}
+
+// publishCancel and subscribeCancels carry cancellation requests over
+// CancelChannel for both Redis backends.
+func publishCancel(ctx context.Context, client *redis.Client, jobID uuid.UUID) error {
+	if err := client.Publish(ctx, CancelChannel, jobID.String()).Err(); err != nil {
+		return fmt.Errorf("failed to publish cancellation: %w", err)
+	}
+	return nil
+}
+
+func subscribeCancels(ctx context.Context, client *redis.Client) (<-chan uuid.UUID, error) {
+	sub := client.Subscribe(ctx, CancelChannel)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to cancellations: %w", err)
+	}
+
+	ids := make(chan uuid.UUID)
+	go func() {
+		defer close(ids)
+		defer sub.Close()
+
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				id, err := uuid.Parse(msg.Payload)
+				if err != nil {
+					// Skip malformed requests
+					continue
+				}
+				select {
+				case ids <- id:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ids, nil
+}
+
+// GetScheduledLength returns the number of jobs of a type waiting for their due time.
+func (rq *RedisQueue) GetScheduledLength(ctx context.Context, jobType string) (int64, error) {
+	length, err := rq.client.ZCard(ctx, ScheduledKeyPrefix+jobType).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get scheduled length: %w", err)
+	}
+	return length, nil
+}
+
+// Dequeue retrieves the next job reference from the Redis queue, taking the
+// priority levels in weighted order. Workers should call this with their
+// specific job type. Returns nil if no job is available within the timeout.
+func (rq *RedisQueue) Dequeue(ctx context.Context, jobType string, timeout time.Duration) (*JobMessage, error) {
+	result, err := rq.awaitScript(ctx, jobType, timeout, func() *redis.Cmd {
+		return popScript.Run(ctx, rq.client, rq.turnKeys(jobType), rq.weightArgs()...)
+	})
 	if err == redis.Nil {
 		// No job available within the timeout
 		return nil, nil
@@ -78,22 +592,417 @@ func (rq *RedisQueue) Dequeue(ctx context.Context, jobType string, timeout time.
 		return nil, err
 	}
 
-	if len(result) < 2 {
-		return nil, fmt.Errorf("unexpected result format from Redis with length: %d", len(result))
+	var msg JobMessage
+	if err := json.Unmarshal([]byte(result), &msg); err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+// DequeueReliable atomically moves the next job reference from the queue, in
+// weighted priority order, into the type's processing list and leases it for
+// visibilityTimeout. The caller must Ack the message once the job is finished
+// or Nack it to return it to the queue; otherwise it is requeued by
+// ReapExpired after the lease expires.
+// Returns nil if no job is available within the timeout.
+func (rq *RedisQueue) DequeueReliable(ctx context.Context, jobType string, timeout, visibilityTimeout time.Duration) (*JobMessage, error) {
+	keys := append(rq.turnKeys(jobType), ProcessingKeyPrefix+jobType, LeaseKeyPrefix+jobType)
+
+	result, err := rq.awaitScript(ctx, jobType, timeout, func() *redis.Cmd {
+		deadline := time.Now().Add(visibilityTimeout).UnixMilli()
+		return claimScript.Run(ctx, rq.client, keys, append(rq.weightArgs(), deadline)...)
+	})
+	if err == redis.Nil {
+		// No job available within the timeout
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	var msg JobMessage
-	if err := json.Unmarshal([]byte(result[1]), &msg); err != nil {
+	if err := json.Unmarshal([]byte(result), &msg); err != nil {
 		return nil, err
 	}
+	msg.raw = result
 
 	return &msg, nil
 }
 
-// GetQueueLength returns the number of jobs waiting in a specific queue.
+// DequeueReliableBatch is DequeueReliable for up to limit messages of any of
+// jobTypes, taken from the types in turn. It waits up to timeout for a message
+// to be ready, then returns every one ready up to limit. Returns no messages if
+// none is available within the timeout. If Redis fails once some messages are
+// claimed, those are returned without the error.
+func (rq *RedisQueue) DequeueReliableBatch(ctx context.Context, jobTypes []string, limit int, timeout, visibilityTimeout time.Duration) ([]*JobMessage, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		msgs, err := rq.claimBatch(ctx, jobTypes, limit, visibilityTimeout)
+		if len(msgs) > 0 {
+			return msgs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ready, err := rq.waitReady(ctx, jobTypes, deadline)
+		if err != nil || !ready {
+			return nil, err
+		}
+	}
+}
+
+// claimBatch claims up to limit ready messages, one type at a time in turn
+// until each is drained.
+func (rq *RedisQueue) claimBatch(ctx context.Context, jobTypes []string, limit int, visibilityTimeout time.Duration) ([]*JobMessage, error) {
+	var msgs []*JobMessage
+	deadline := time.Now().Add(visibilityTimeout).UnixMilli()
+	drained := make(map[string]bool, len(jobTypes))
+
+	for len(msgs) < limit && len(drained) < len(jobTypes) {
+		for _, jobType := range jobTypes {
+			if drained[jobType] || len(msgs) == limit {
+				continue
+			}
+
+			keys := append(rq.turnKeys(jobType), ProcessingKeyPrefix+jobType, LeaseKeyPrefix+jobType)
+			result, err := claimScript.Run(ctx, rq.client, keys, append(rq.weightArgs(), deadline)...).Text()
+			if err == redis.Nil {
+				drained[jobType] = true
+				continue
+			}
+			if err != nil {
+				return msgs, err
+			}
+
+			var msg JobMessage
+			if err := json.Unmarshal([]byte(result), &msg); err != nil {
+				// Left leased, so the reaper returns it to the queue
+				continue
+			}
+			msg.raw = result
+			msgs = append(msgs, &msg)
+		}
+	}
+
+	return msgs, nil
+}
+
+// awaitScript runs a dequeue script for jobType, waiting for the type's ready
+// list between runs, until it returns a message or timeout passes, in which
+// case it returns redis.Nil.
+func (rq *RedisQueue) awaitScript(ctx context.Context, jobType string, timeout time.Duration, run func() *redis.Cmd) (string, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		result, err := run().Text()
+		if err != redis.Nil {
+			return result, err
+		}
+
+		ready, err := rq.waitReady(ctx, []string{jobType}, deadline)
+		if err != nil {
+			return "", err
+		}
+		if !ready {
+			return "", redis.Nil
+		}
+	}
+}
+
+// waitReady blocks until the ready list of one of jobTypes holds a token,
+// which it takes, and reports false if deadline passes first. Blocking
+// commands time out in whole seconds, so it may wait up to a second past
+// deadline. A token taken for a caller that has gone is put back, so another
+// consumer is woken instead.
+func (rq *RedisQueue) waitReady(ctx context.Context, jobTypes []string, deadline time.Time) (bool, error) {
+	wait := time.Until(deadline)
+	if wait <= 0 {
+		return false, nil
+	}
+	wait = (wait + time.Second - 1).Truncate(time.Second)
+
+	keys := make([]string, len(jobTypes))
+	for i, jobType := range jobTypes {
+		keys[i] = ReadyKeyPrefix + jobType
+	}
+
+	popped, err := rq.client.BLPop(ctx, wait, keys...).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, fmt.Errorf("failed to wait for jobs: %w", err)
+	}
+
+	if ctx.Err() != nil {
+		pipe := rq.client.Pipeline()
+		signalReady(context.WithoutCancel(ctx), pipe, strings.TrimPrefix(popped[0], ReadyKeyPrefix))
+		_, _ = pipe.Exec(context.WithoutCancel(ctx))
+		return false, ctx.Err()
+	}
+	return true, nil
+}
+
+// turnKeys returns the keys the dequeue scripts start with: the type's queues
+// from high to low priority, its turn counter, then its ready list.
+func (rq *RedisQueue) turnKeys(jobType string) []string {
+	return append(queueKeys(jobType), TurnKeyPrefix+jobType, ReadyKeyPrefix+jobType)
+}
+
+func (rq *RedisQueue) weightArgs() []any {
+	return []any{rq.weights.High, rq.weights.Normal, rq.weights.Low}
+}
+
+// Ack removes a message obtained from DequeueReliable from the processing list.
+// It reports false if the message was no longer held, which happens when its
+// lease expired and it was reaped back to the queue.
+func (rq *RedisQueue) Ack(ctx context.Context, msg *JobMessage) (bool, error) {
+	jobType := msg.Type
+	removed, err := ackScript.Run(ctx, rq.client,
+		[]string{ProcessingKeyPrefix + jobType, LeaseKeyPrefix + jobType},
+		msg.raw,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to ack job: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// Nack returns a message obtained from DequeueReliable to the head of its queue
+// so another consumer can pick it up. It reports false if the message was no
+// longer held.
+func (rq *RedisQueue) Nack(ctx context.Context, msg *JobMessage) (bool, error) {
+	jobType := msg.Type
+	removed, err := nackScript.Run(ctx, rq.client,
+		[]string{ProcessingKeyPrefix + jobType, LeaseKeyPrefix + jobType, QueueKey(jobType, PriorityLevel(msg.Priority)), ReadyKeyPrefix + jobType},
+		msg.raw,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to nack job: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// ExtendLease moves the lease deadline of a message obtained from
+// DequeueReliable to visibilityTimeout from now. It reports false if the
+// message was no longer held.
+func (rq *RedisQueue) ExtendLease(ctx context.Context, msg *JobMessage, visibilityTimeout time.Duration) (bool, error) {
+	extended, err := extendScript.Run(ctx, rq.client,
+		[]string{LeaseKeyPrefix + msg.Type},
+		msg.raw, time.Now().Add(visibilityTimeout).UnixMilli(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to extend lease: %w", err)
+	}
+	return extended > 0, nil
+}
+
+// DropLease removes a job's message from the processing list without returning
+// it to the queue, once the job was taken from a worker that stopped
+// responding. It reports whether the message was found.
+func (rq *RedisQueue) DropLease(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	data, err := json.Marshal(JobMessage{JobID: jobID, Type: jobType, Priority: priority})
+	if err != nil {
+		return false, err
+	}
+
+	removed, err := ackScript.Run(ctx, rq.client,
+		[]string{ProcessingKeyPrefix + jobType, LeaseKeyPrefix + jobType},
+		string(data),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to drop lease: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// ReapExpired returns every message of a job type whose lease has expired to
+// the queue and reports how many were returned. Messages found without a lease
+// are leased for visibilityTimeout. Expired leases are reaped reapBatchSize at
+// a time, so Redis is never blocked by a long processing list.
+func (rq *RedisQueue) ReapExpired(ctx context.Context, jobType string, visibilityTimeout time.Duration) (int, error) {
+	now := time.Now()
+	keys := append(append([]string{ProcessingKeyPrefix + jobType, LeaseKeyPrefix + jobType}, queueKeys(jobType)...), ReadyKeyPrefix+jobType)
+
+	total := 0
+	for {
+		counts, err := reapScript.Run(ctx, rq.client, keys, now.UnixMilli(), reapBatchSize).Int64Slice()
+		if err != nil {
+			return total, fmt.Errorf("failed to reap expired leases: %w", err)
+		}
+		total += int(counts[1])
+		if counts[0] < reapBatchSize {
+			break
+		}
+	}
+
+	if err := rq.leaseStrays(ctx, jobType, now.Add(visibilityTimeout)); err != nil {
+		return total, err
+	}
+	return total, nil
+}
+
+// leaseStrays leases the messages of a job type found in the processing list
+// without a lease until deadline, so they are reaped if abandoned. Every claim
+// leases its message, so the list is only read when it holds more messages
+// than there are leases.
+func (rq *RedisQueue) leaseStrays(ctx context.Context, jobType string, deadline time.Time) error {
+	processingKey, leaseKey := ProcessingKeyPrefix+jobType, LeaseKeyPrefix+jobType
+
+	pipe := rq.client.Pipeline()
+	processing := pipe.LLen(ctx, processingKey)
+	leased := pipe.ZCard(ctx, leaseKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to count leases: %w", err)
+	}
+	if processing.Val() <= leased.Val() {
+		return nil
+	}
+
+	for start := int64(0); ; start += scanBatchSize {
+		items, err := rq.client.LRange(ctx, processingKey, start, start+scanBatchSize-1).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", processingKey, err)
+		}
+
+		pipe := rq.client.Pipeline()
+		for _, item := range items {
+			pipe.ZAddNX(ctx, leaseKey, redis.Z{Score: float64(deadline.UnixMilli()), Member: item})
+		}
+		if len(items) > 0 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return fmt.Errorf("failed to lease stray messages: %w", err)
+			}
+		}
+
+		if len(items) < scanBatchSize {
+			return nil
+		}
+	}
+}
+
+// GetProcessingLength returns the number of jobs of a type currently leased to consumers.
+func (rq *RedisQueue) GetProcessingLength(ctx context.Context, jobType string) (int64, error) {
+	length, err := rq.client.LLen(ctx, ProcessingKeyPrefix+jobType).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get processing length: %w", err)
+	}
+	return length, nil
+}
+
+// TrackedJobIDs returns the IDs of every job of a type that Redis knows about,
+// whether waiting in a queue, leased in the processing list or scheduled.
+func (rq *RedisQueue) TrackedJobIDs(ctx context.Context, jobType string) (map[uuid.UUID]struct{}, error) {
+	ids := make(map[uuid.UUID]struct{})
+
+	for _, key := range append(queueKeys(jobType), ProcessingKeyPrefix+jobType, ScheduledKeyPrefix+jobType) {
+		// Both read a range by index; the scheduled set is ordered by due time
+		read := rq.client.LRange
+		if key == ScheduledKeyPrefix+jobType {
+			read = rq.client.ZRange
+		}
+
+		for start := int64(0); ; start += scanBatchSize {
+			items, err := read(ctx, key, start, start+scanBatchSize-1).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", key, err)
+			}
+
+			for _, item := range items {
+				var msg JobMessage
+				if err := json.Unmarshal([]byte(item), &msg); err != nil {
+					// Skip malformed entries rather than abort the scan
+					continue
+				}
+				ids[msg.JobID] = struct{}{}
+			}
+
+			if len(items) < scanBatchSize {
+				break
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// DeadLetter adds a job to its type's dead-letter queue, a sorted set of job IDs
+// scored by the time they were dead-lettered.
+func (rq *RedisQueue) DeadLetter(ctx context.Context, jobID uuid.UUID, jobType string) error {
+	return addDeadLetter(ctx, rq.client, jobID, jobType)
+}
+
+// RemoveDeadLetters removes jobs from their type's dead-letter queue, after
+// they were replayed or purged, and reports how many were removed.
+func (rq *RedisQueue) RemoveDeadLetters(ctx context.Context, jobType string, jobIDs ...uuid.UUID) (int64, error) {
+	return removeDeadLetters(ctx, rq.client, jobType, jobIDs...)
+}
+
+// GetDeadLetterLength returns the number of jobs of a type in the dead-letter queue.
+func (rq *RedisQueue) GetDeadLetterLength(ctx context.Context, jobType string) (int64, error) {
+	return deadLetterLength(ctx, rq.client, jobType)
+}
+
+// addDeadLetter, removeDeadLetters and deadLetterLength keep the dead-letter
+// sets for both Redis backends.
+func addDeadLetter(ctx context.Context, client *redis.Client, jobID uuid.UUID, jobType string) error {
+	err := client.ZAdd(ctx, DeadLetterKeyPrefix+jobType, redis.Z{
+		Score:  float64(time.Now().UnixMilli()),
+		Member: jobID.String(),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+func removeDeadLetters(ctx context.Context, client *redis.Client, jobType string, jobIDs ...uuid.UUID) (int64, error) {
+	if len(jobIDs) == 0 {
+		return 0, nil
+	}
+
+	members := make([]any, len(jobIDs))
+	for i, id := range jobIDs {
+		members[i] = id.String()
+	}
+
+	removed, err := client.ZRem(ctx, DeadLetterKeyPrefix+jobType, members...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove dead letters: %w", err)
+	}
+	return removed, nil
+}
+
+func deadLetterLength(ctx context.Context, client *redis.Client, jobType string) (int64, error) {
+	length, err := client.ZCard(ctx, DeadLetterKeyPrefix+jobType).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get dead-letter length: %w", err)
+	}
+	return length, nil
+}
+
+// GetQueueLength returns the number of jobs of a type waiting at any priority.
 func (rq *RedisQueue) GetQueueLength(ctx context.Context, jobType string) (int64, error) {
-	queueKey := QueueKeyPrefix + jobType
-	length, err := rq.client.LLen(ctx, queueKey).Result()
+	var total int64
+	for level := PriorityHigh; level <= PriorityLow; level++ {
+		length, err := rq.GetPriorityQueueLength(ctx, jobType, level)
+		if err != nil {
+			return 0, err
+		}
+		total += length
+	}
+	return total, nil
+}
+
+// GetPriorityQueueLength returns the number of jobs of a type waiting at a priority level.
+func (rq *RedisQueue) GetPriorityQueueLength(ctx context.Context, jobType string, level int) (int64, error) {
+	length, err := rq.client.LLen(ctx, QueueKey(jobType, level)).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get queue length: %w", err)
 	}
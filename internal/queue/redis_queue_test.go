@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+func startRedisQueue(t *testing.T) *RedisQueue {
+	rq, err := NewRedisQueue(testutil.StartRedis(t), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { rq.Close() })
+	return rq
+}
+
+func TestParseLeaseToken_Invalid(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "e30"} {
+		_, err := ParseLeaseToken(token)
+		assert.Error(t, err, token)
+	}
+}
+
+func TestParsePriorityWeights(t *testing.T) {
+	w, err := ParsePriorityWeights("8:4:1")
+	require.NoError(t, err)
+	assert.Equal(t, PriorityWeights{High: 8, Normal: 4, Low: 1}, w)
+
+	for _, s := range []string{"8:4", "a:b:c", "1:-1:1", "0:0:0"} {
+		_, err := ParsePriorityWeights(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRedisQueue_EnqueueWakesWaitingDequeues(t *testing.T) {
+	rq := startRedisQueue(t)
+	ctx := context.Background()
+
+	// Both waiters block on the ready list; the first to claim wakes the other
+	var wg sync.WaitGroup
+	got := make(chan *JobMessage, 2)
+	start := time.Now()
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg, err := rq.DequeueReliable(ctx, "JOB_STANDARD", 10*time.Second, time.Minute)
+			assert.NoError(t, err)
+			got <- msg
+		}()
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, rq.EnqueueBatch(ctx, []BatchItem{
+		{JobID: uuid.New(), Type: "JOB_STANDARD"},
+		{JobID: uuid.New(), Type: "JOB_STANDARD"},
+	}))
+	wg.Wait()
+	close(got)
+
+	for msg := range got {
+		assert.NotNil(t, msg)
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// With nothing queued, a dequeue waits out its timeout
+	msg, err := rq.DequeueReliable(ctx, "JOB_STANDARD", time.Second, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+}
+
+func TestRedisQueue_ReapExpiredInBatches(t *testing.T) {
+	rq := startRedisQueue(t)
+	ctx := context.Background()
+
+	const n = 2*reapBatchSize + 10
+	items := make([]BatchItem, n)
+	for i := range items {
+		items[i] = BatchItem{JobID: uuid.New(), Type: "JOB_STANDARD"}
+	}
+	require.NoError(t, rq.EnqueueBatch(ctx, items))
+
+	msgs, err := rq.DequeueReliableBatch(ctx, []string{"JOB_STANDARD"}, n, 0, time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, msgs, n)
+	time.Sleep(10 * time.Millisecond)
+
+	reaped, err := rq.ReapExpired(ctx, "JOB_STANDARD", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, n, reaped)
+
+	queued, err := rq.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(n), queued)
+	processing, err := rq.GetProcessingLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Zero(t, processing)
+}
+
+func TestRedisQueue_ReapLeasesStrayMessages(t *testing.T) {
+	rq := startRedisQueue(t)
+	ctx := context.Background()
+
+	// A message left in the processing list without a lease
+	stray := `{"job_id":"` + uuid.NewString() + `","type":"JOB_STANDARD"}`
+	require.NoError(t, rq.client.RPush(ctx, ProcessingKeyPrefix+"JOB_STANDARD", stray).Err())
+
+	reaped, err := rq.ReapExpired(ctx, "JOB_STANDARD", time.Minute)
+	require.NoError(t, err)
+	assert.Zero(t, reaped)
+
+	deadline, err := rq.client.ZScore(ctx, LeaseKeyPrefix+"JOB_STANDARD", stray).Result()
+	require.NoError(t, err)
+	assert.Greater(t, deadline, float64(time.Now().UnixMilli()))
+
+	// Once that lease runs out the message is reaped
+	require.NoError(t, rq.client.ZAdd(ctx, LeaseKeyPrefix+"JOB_STANDARD", redis.Z{Score: 1, Member: stray}).Err())
+	reaped, err = rq.ReapExpired(ctx, "JOB_STANDARD", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reaped)
+}
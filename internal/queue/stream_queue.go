@@ -0,0 +1,1109 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	StreamKeyPrefix          = "boltq:stream:"
+	StreamScheduledKeyPrefix = "boltq:stream-scheduled:"
+	StreamTurnKeyPrefix      = "boltq:stream-turn:"
+
+	// DefaultStreamGroup is the consumer group a StreamQueue reads through
+	// unless configured otherwise.
+	DefaultStreamGroup = "boltq"
+
+	// ReturnedConsumer owns pending entries that were nacked or whose lease
+	// expired and was reaped, until a consumer claims them again.
+	ReturnedConsumer = "boltq:returned"
+
+	// leaseHorizon is the idle time at which a pending entry's lease has run
+	// out. Leasing an entry for d sets its idle time to leaseHorizon - d, so
+	// XAUTOCLAIM with a minimum idle time of leaseHorizon claims exactly the
+	// entries whose lease has expired, each at its own deadline.
+	leaseHorizon = 7 * 24 * time.Hour
+
+	// dequeuePollInterval is how often empty streams are checked again until
+	// a dequeue times out, as entries are claimed by a script that weighs
+	// the priority streams and expired leases rather than by XREADGROUP.
+	dequeuePollInterval = 100 * time.Millisecond
+)
+
+// StreamKey returns the stream holding a type's jobs at a priority level,
+// named like the list QueueKey returns.
+func StreamKey(jobType string, level int) string {
+	switch level {
+	case PriorityHigh:
+		return StreamKeyPrefix + jobType + ":high"
+	case PriorityLow:
+		return StreamKeyPrefix + jobType + ":low"
+	default:
+		return StreamKeyPrefix + jobType
+	}
+}
+
+// streamKeys returns a type's streams from high to low priority.
+func streamKeys(jobType string) []string {
+	return []string{
+		StreamKey(jobType, PriorityHigh),
+		StreamKey(jobType, PriorityNormal),
+		StreamKey(jobType, PriorityLow),
+	}
+}
+
+// StreamConfig controls a StreamQueue.
+type StreamConfig struct {
+	// Group is the consumer group every process reads through. Defaults to
+	// DefaultStreamGroup.
+	Group string
+	// Consumer names this process within the group, so pending entries show
+	// who holds them. Defaults to "<host name>-<pid>".
+	Consumer string
+	// MaxLen trims each stream to this many entries and MaxAge drops entries
+	// older than it, as jobs are added; zero disables either. Only entries the
+	// group has read and acked are trimmed, so a stream whose group lags keeps
+	// its backlog past the limits.
+	MaxLen int64
+	MaxAge time.Duration
+}
+
+// StreamQueue is a JobQueue on Redis Streams. Each job type has a stream per
+// priority level, read through one consumer group: XREADGROUP delivers new
+// entries, XACK finishes them, and entries whose lease expired are taken over
+// with XAUTOCLAIM by the next consumer to dequeue, so jobs held by a crashed
+// worker are picked up again. Unlike RedisQueue, every pending entry records
+// the consumer holding it; see Stats.
+//
+// Scheduled jobs wait in a sorted set as with RedisQueue, and dead letters and
+// cancellation requests use the same keys and channel.
+type StreamQueue struct {
+	client  *redis.Client
+	weights PriorityWeights
+	cfg     StreamConfig
+}
+
+// streamLease is the encoding of a message obtained from DequeueReliable. It
+// names the delivery the lease was issued for, so a consumer whose lease
+// expired and was claimed by another can no longer ack or nack it.
+type streamLease struct {
+	JobMessage
+	EntryID    string `json:"entry_id"`
+	Consumer   string `json:"consumer"`
+	Deliveries int64  `json:"deliveries"`
+}
+
+// StreamStats describes the stream of one job type and priority level.
+type StreamStats struct {
+	Stream string
+	// Length is the number of entries kept, acked ones included until trimmed
+	Length int64
+	// Lag is the number of entries not yet delivered to the group
+	Lag int64
+	// Pending counts the entries delivered but not acked, by consumer
+	Pending map[string]int64
+}
+
+var _ JobQueue = (*StreamQueue)(nil)
+
+// fieldLua reads a field of a stream entry.
+const fieldLua = `
+local function field(fields, name)
+  for j = 1, #fields, 2 do
+    if fields[j] == name then
+      return fields[j + 1]
+    end
+  end
+end
+`
+
+// heldLua reports whether an entry is still leased by the delivery a lease
+// token was issued for: pending, owned by the same consumer, delivered as many
+// times and not expired.
+const heldLua = `
+local function held(stream, group, id, consumer, deliveries, horizon)
+  local pending = redis.pcall('XPENDING', stream, group, id, id, 1)
+  if type(pending) ~= 'table' or pending.err or not pending[1] then
+    return false
+  end
+  local p = pending[1]
+  return p[2] == consumer and p[4] == tonumber(deliveries) and p[3] < tonumber(horizon)
+end
+`
+
+// streamClaimScript takes the next entry from the streams in weighted priority
+// order. In each stream, expired and returned entries are claimed before new
+// ones are read. The entry is leased to the consumer, or acked at once for a
+// plain dequeue. Returns the entry ID, its fields and its delivery count.
+// KEYS[1..3] streams, KEYS[4] turn counter; ARGV[1..3] weights, ARGV[4] group,
+// ARGV[5] consumer, ARGV[6] lease horizon in ms, ARGV[7] idle time in ms that
+// leaves the lease to run, ARGV[8] "1" to ack instead of leasing.
+var streamClaimScript = redis.NewScript(dequeueOrderLua + `
+local group, consumer, horizon = ARGV[4], ARGV[5], ARGV[6]
+for _, i in ipairs(dequeue_order()) do
+  local stream = KEYS[i]
+  redis.pcall('XGROUP', 'CREATE', stream, group, '0', 'MKSTREAM')
+
+  local entry
+  local cursor = '0-0'
+  repeat
+    local claimed = redis.call('XAUTOCLAIM', stream, group, consumer, horizon, cursor, 'COUNT', 1)
+    cursor = claimed[1]
+    entry = claimed[2][1]
+  until entry or cursor == '0-0'
+
+  if not entry then
+    local read = redis.call('XREADGROUP', 'GROUP', group, consumer, 'COUNT', 1, 'STREAMS', stream, '>')
+    if read and read[1] then
+      entry = read[1][2][1]
+    end
+  end
+
+  if entry then
+    if ARGV[8] == '1' then
+      redis.call('XACK', stream, group, entry[1])
+      return {entry[1], entry[2], 0}
+    end
+    redis.call('XCLAIM', stream, group, consumer, 0, entry[1], 'IDLE', ARGV[7], 'JUSTID')
+    local pending = redis.call('XPENDING', stream, group, entry[1], entry[1], 1)
+    return {entry[1], entry[2], pending[1][4]}
+  end
+end
+return false
+`)
+
+// streamAckScript acks a held entry.
+// KEYS[1] stream; ARGV[1] group, ARGV[2] entry ID, ARGV[3] consumer,
+// ARGV[4] delivery count, ARGV[5] lease horizon in ms.
+var streamAckScript = redis.NewScript(heldLua + `
+if not held(KEYS[1], ARGV[1], ARGV[2], ARGV[3], ARGV[4], ARGV[5]) then
+  return 0
+end
+redis.call('XACK', KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// streamNackScript hands a held entry to ReturnedConsumer with its lease
+// expired, so the next dequeue claims it before reading new entries.
+// KEYS[1] stream; ARGV[1..5] as streamAckScript, ARGV[6] ReturnedConsumer.
+var streamNackScript = redis.NewScript(heldLua + `
+if not held(KEYS[1], ARGV[1], ARGV[2], ARGV[3], ARGV[4], ARGV[5]) then
+  return 0
+end
+redis.call('XCLAIM', KEYS[1], ARGV[1], ARGV[6], 0, ARGV[2], 'IDLE', ARGV[5], 'JUSTID')
+return 1
+`)
+
+// streamExtendScript moves a held entry's lease deadline.
+// KEYS[1] stream; ARGV[1..5] as streamAckScript, ARGV[6] idle time in ms that
+// leaves the new lease to run.
+var streamExtendScript = redis.NewScript(heldLua + `
+if not held(KEYS[1], ARGV[1], ARGV[2], ARGV[3], ARGV[4], ARGV[5]) then
+  return 0
+end
+redis.call('XCLAIM', KEYS[1], ARGV[1], ARGV[3], 0, ARGV[2], 'IDLE', ARGV[6], 'RETRYCOUNT', ARGV[4], 'JUSTID')
+return 1
+`)
+
+// streamReapScript hands every entry whose lease expired to ReturnedConsumer
+// and returns how many it moved.
+// KEYS[1..3] streams; ARGV[1] group, ARGV[2] ReturnedConsumer, ARGV[3] lease
+// horizon in ms, ARGV[4] scan batch size.
+var streamReapScript = redis.NewScript(`
+local reaped = 0
+for i = 1, 3 do
+  local start = '-'
+  while true do
+    local pending = redis.pcall('XPENDING', KEYS[i], ARGV[1], 'IDLE', ARGV[3], start, '+', ARGV[4])
+    if type(pending) ~= 'table' or pending.err then
+      break
+    end
+    for _, p in ipairs(pending) do
+      if p[2] ~= ARGV[2] then
+        redis.call('XCLAIM', KEYS[i], ARGV[1], ARGV[2], ARGV[3], p[1], 'IDLE', ARGV[3], 'JUSTID')
+        reaped = reaped + 1
+      end
+    end
+    if #pending < tonumber(ARGV[4]) then
+      break
+    end
+    start = '(' .. pending[#pending][1]
+  end
+end
+return reaped
+`)
+
+// streamDropScript acks a job's leased entry without returning it.
+// KEYS[1] stream; ARGV[1] group, ARGV[2] job ID, ARGV[3] lease horizon in ms,
+// ARGV[4] scan batch size.
+var streamDropScript = redis.NewScript(fieldLua + `
+local start = '-'
+while true do
+  local pending = redis.pcall('XPENDING', KEYS[1], ARGV[1], start, '+', ARGV[4])
+  if type(pending) ~= 'table' or pending.err then
+    return 0
+  end
+  for _, p in ipairs(pending) do
+    if p[3] < tonumber(ARGV[3]) then
+      local entry = redis.call('XRANGE', KEYS[1], p[1], p[1])[1]
+      if entry and field(entry[2], 'job_id') == ARGV[2] then
+        redis.call('XACK', KEYS[1], ARGV[1], p[1])
+        return 1
+      end
+    end
+  end
+  if #pending < tonumber(ARGV[4]) then
+    return 0
+  end
+  start = '(' .. pending[#pending][1]
+end
+`)
+
+// streamRemoveScript deletes a job's entries that were not delivered yet or
+// were returned, and its scheduled message, and returns how many it removed.
+// The undelivered entries are scanned, so this is slow for long backlogs.
+// KEYS[1] stream, KEYS[2] scheduled set; ARGV[1] group, ARGV[2] job ID,
+// ARGV[3] scheduled message, ARGV[4] lease horizon in ms, ARGV[5] scan batch size.
+var streamRemoveScript = redis.NewScript(fieldLua + `
+local batch = tonumber(ARGV[5])
+local removed = redis.call('ZREM', KEYS[2], ARGV[3])
+
+local start = '-'
+local groups = redis.pcall('XINFO', 'GROUPS', KEYS[1])
+if type(groups) ~= 'table' or groups.err then
+  return removed
+end
+for _, g in ipairs(groups) do
+  local info = {}
+  for j = 1, #g, 2 do
+    info[g[j]] = g[j + 1]
+  end
+  if info['name'] == ARGV[1] then
+    start = '(' .. info['last-delivered-id']
+  end
+end
+
+while true do
+  local entries = redis.call('XRANGE', KEYS[1], start, '+', 'COUNT', batch)
+  for _, entry in ipairs(entries) do
+    if field(entry[2], 'job_id') == ARGV[2] then
+      removed = removed + redis.call('XDEL', KEYS[1], entry[1])
+    end
+  end
+  if #entries < batch then
+    break
+  end
+  start = '(' .. entries[#entries][1]
+end
+
+start = '-'
+while true do
+  local pending = redis.pcall('XPENDING', KEYS[1], ARGV[1], 'IDLE', ARGV[4], start, '+', batch)
+  if type(pending) ~= 'table' or pending.err then
+    break
+  end
+  for _, p in ipairs(pending) do
+    local entry = redis.call('XRANGE', KEYS[1], p[1], p[1])[1]
+    if entry and field(entry[2], 'job_id') == ARGV[2] then
+      redis.call('XACK', KEYS[1], ARGV[1], p[1])
+      removed = removed + redis.call('XDEL', KEYS[1], p[1])
+    end
+  end
+  if #pending < batch then
+    break
+  end
+  start = '(' .. pending[#pending][1]
+end
+return removed
+`)
+
+// streamTrimScript trims a stream to a length or a minimum entry ID, but
+// never past the entries the group still needs: those not delivered yet, and
+// those pending from the oldest on. Returns how many entries it removed.
+// KEYS[1] stream; ARGV[1] group, ARGV[2] maximum length or 0, ARGV[3] minimum
+// entry time in ms or "".
+var streamTrimScript = redis.NewScript(`
+local function parse(id)
+  local ms, seq = string.match(id, '^(%d+)-(%d+)$')
+  return tonumber(ms), tonumber(seq)
+end
+local function less(a, b)
+  local a_ms, a_seq = parse(a)
+  local b_ms, b_seq = parse(b)
+  return a_ms < b_ms or (a_ms == b_ms and a_seq < b_seq)
+end
+local function after(id)
+  local ms, seq = string.match(id, '^(%d+)-(%d+)$')
+  return ms .. '-' .. (tonumber(seq) + 1)
+end
+
+-- Entries below floor were delivered and acked
+local floor
+local groups = redis.pcall('XINFO', 'GROUPS', KEYS[1])
+if type(groups) ~= 'table' or groups.err then
+  return 0
+end
+for _, g in ipairs(groups) do
+  local info = {}
+  for j = 1, #g, 2 do
+    info[g[j]] = g[j + 1]
+  end
+  if info['name'] == ARGV[1] then
+    floor = after(info['last-delivered-id'])
+  end
+end
+if not floor then
+  return 0
+end
+local pending = redis.call('XPENDING', KEYS[1], ARGV[1])
+if pending[1] > 0 and less(pending[2], floor) then
+  floor = pending[2]
+end
+
+local min_id
+local excess = redis.call('XLEN', KEYS[1]) - tonumber(ARGV[2])
+if tonumber(ARGV[2]) > 0 and excess > 0 then
+  local old = redis.call('XRANGE', KEYS[1], '-', '(' .. floor, 'COUNT', excess)
+  if #old > 0 then
+    min_id = after(old[#old][1])
+  end
+end
+if ARGV[3] ~= '' then
+  local by_age = ARGV[3] .. '-0'
+  if not min_id or less(min_id, by_age) then
+    min_id = by_age
+  end
+end
+if not min_id then
+  return 0
+end
+if less(floor, min_id) then
+  min_id = floor
+end
+return redis.call('XTRIM', KEYS[1], 'MINID', min_id)
+`)
+
+// streamPromoteScript moves up to ARGV[2] messages due at or before ARGV[1]
+// from the scheduled set to their stream and returns them.
+// KEYS[1] scheduled set, KEYS[2..4] streams; ARGV[1] now in ms, ARGV[2] limit.
+var streamPromoteScript = redis.NewScript(queueKeyLua + `
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, item in ipairs(due) do
+  redis.call('ZREM', KEYS[1], item)
+  local ok, msg = pcall(cjson.decode, item)
+  if ok then
+    redis.call('XADD', queue_key(item, 2), '*', 'job_id', msg.job_id, 'type', msg.type, 'priority', msg.priority or 0)
+  end
+end
+return due
+`)
+
+// NewStreamQueue connects to Redis and returns a StreamQueue. Zero values in
+// cfg are replaced with defaults.
+func NewStreamQueue(addr, password string, db int, cfg StreamConfig) (*StreamQueue, error) {
+	if cfg.Group == "" {
+		cfg.Group = DefaultStreamGroup
+	}
+	if cfg.Consumer == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "consumer"
+		}
+		cfg.Consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &StreamQueue{client: client, weights: DefaultPriorityWeights, cfg: cfg}, nil
+}
+
+// SetPriorityWeights changes how dequeues share out the priority levels. It
+// must be called before the queue is used to dequeue.
+func (sq *StreamQueue) SetPriorityWeights(w PriorityWeights) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	sq.weights = w
+	return nil
+}
+
+// addEntry queues an XADD of a job reference to its stream on pipe.
+func addEntry(ctx context.Context, pipe redis.Pipeliner, jobID uuid.UUID, jobType string, priority int) {
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: StreamKey(jobType, PriorityLevel(priority)),
+		Values: []any{"job_id", jobID.String(), "type", jobType, "priority", priority},
+	})
+}
+
+// trim queues the configured trimming of a stream on pipe. The script is sent
+// whole, as a pipeline cannot fall back from EVALSHA.
+func (sq *StreamQueue) trim(ctx context.Context, pipe redis.Pipeliner, stream string) {
+	if sq.cfg.MaxLen <= 0 && sq.cfg.MaxAge <= 0 {
+		return
+	}
+	minTime := ""
+	if sq.cfg.MaxAge > 0 {
+		minTime = strconv.FormatInt(time.Now().Add(-sq.cfg.MaxAge).UnixMilli(), 10)
+	}
+	streamTrimScript.Eval(ctx, pipe, []string{stream}, sq.cfg.Group, sq.cfg.MaxLen, minTime)
+}
+
+// Enqueue adds a job reference to the stream for its priority level.
+func (sq *StreamQueue) Enqueue(ctx context.Context, jobID uuid.UUID, jobType string, priority int) error {
+	pipe := sq.client.TxPipeline()
+	addEntry(ctx, pipe, jobID, jobType, priority)
+	sq.trim(ctx, pipe, StreamKey(jobType, PriorityLevel(priority)))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// Schedule adds a job reference to the type's scheduled set, to be moved to
+// its stream by PromoteDue once runAt has passed. A job that is already due is
+// enqueued straight away.
+func (sq *StreamQueue) Schedule(ctx context.Context, jobID uuid.UUID, jobType string, priority int, runAt time.Time) error {
+	if !runAt.After(time.Now()) {
+		return sq.Enqueue(ctx, jobID, jobType, priority)
+	}
+
+	data, err := json.Marshal(JobMessage{JobID: jobID, Type: jobType, Priority: priority})
+	if err != nil {
+		return err
+	}
+
+	err = sq.client.ZAdd(ctx, StreamScheduledKeyPrefix+jobType, redis.Z{
+		Score:  float64(runAt.UnixMilli()),
+		Member: data,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule job: %w", err)
+	}
+	return nil
+}
+
+// EnqueueBatch enqueues or schedules many jobs in one transaction.
+func (sq *StreamQueue) EnqueueBatch(ctx context.Context, items []BatchItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	pipe := sq.client.TxPipeline()
+	streams := make(map[string]bool)
+	for _, item := range items {
+		if item.RunAt != nil && item.RunAt.After(now) {
+			data, err := json.Marshal(JobMessage{JobID: item.JobID, Type: item.Type, Priority: item.Priority})
+			if err != nil {
+				return err
+			}
+			pipe.ZAdd(ctx, StreamScheduledKeyPrefix+item.Type, redis.Z{
+				Score:  float64(item.RunAt.UnixMilli()),
+				Member: data,
+			})
+			continue
+		}
+		addEntry(ctx, pipe, item.JobID, item.Type, item.Priority)
+		streams[StreamKey(item.Type, PriorityLevel(item.Priority))] = true
+	}
+	for stream := range streams {
+		sq.trim(ctx, pipe, stream)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to enqueue batch: %w", err)
+	}
+	return nil
+}
+
+// PromoteDue moves up to limit scheduled jobs of a type whose due time has
+// passed to their stream, earliest due first, and returns the promoted messages.
+func (sq *StreamQueue) PromoteDue(ctx context.Context, jobType string, limit int) ([]*JobMessage, error) {
+	keys := append([]string{StreamScheduledKeyPrefix + jobType}, streamKeys(jobType)...)
+	items, err := streamPromoteScript.Run(ctx, sq.client, keys, time.Now().UnixMilli(), limit).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to promote due jobs: %w", err)
+	}
+
+	msgs := make([]*JobMessage, 0, len(items))
+	for _, item := range items {
+		var msg JobMessage
+		if err := json.Unmarshal([]byte(item), &msg); err != nil {
+			// Skip malformed entries; they were dropped all the same
+			continue
+		}
+		msgs = append(msgs, &msg)
+	}
+
+	if len(msgs) > 0 {
+		pipe := sq.client.Pipeline()
+		for _, stream := range streamKeys(jobType) {
+			sq.trim(ctx, pipe, stream)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to trim streams: %w", err)
+		}
+	}
+	return msgs, nil
+}
+
+// Remove deletes a job's entries that were not delivered yet or were
+// returned, and its scheduled message, and reports whether any was found.
+func (sq *StreamQueue) Remove(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	data, err := json.Marshal(JobMessage{JobID: jobID, Type: jobType, Priority: priority})
+	if err != nil {
+		return false, err
+	}
+
+	removed, err := streamRemoveScript.Run(ctx, sq.client,
+		[]string{StreamKey(jobType, PriorityLevel(priority)), StreamScheduledKeyPrefix + jobType},
+		sq.cfg.Group, jobID.String(), data, leaseHorizon.Milliseconds(), scanBatchSize,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to remove job: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// claim takes the next entry of a type, leasing it for visibilityTimeout or,
+// if visibilityTimeout is zero, acking it at once. It returns nil if none is
+// ready.
+func (sq *StreamQueue) claim(ctx context.Context, jobType string, visibilityTimeout time.Duration) (*JobMessage, error) {
+	ackAtOnce := "0"
+	if visibilityTimeout <= 0 {
+		ackAtOnce = "1"
+	}
+	leaseIdle := max(leaseHorizon-visibilityTimeout, 0)
+
+	keys := append(streamKeys(jobType), StreamTurnKeyPrefix+jobType)
+	args := []any{
+		sq.weights.High, sq.weights.Normal, sq.weights.Low,
+		sq.cfg.Group, sq.cfg.Consumer, leaseHorizon.Milliseconds(), leaseIdle.Milliseconds(), ackAtOnce,
+	}
+
+	result, err := streamClaimScript.Run(ctx, sq.client, keys, args...).Slice()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if len(result) != 3 {
+		return nil, fmt.Errorf("failed to claim job: unexpected reply %v", result)
+	}
+
+	entryID, _ := result[0].(string)
+	fields, _ := result[1].([]any)
+	deliveries, _ := result[2].(int64)
+
+	msg, err := entryMessage(fields)
+	if err != nil {
+		return nil, err
+	}
+	if ackAtOnce == "1" {
+		return msg, nil
+	}
+
+	raw, err := json.Marshal(streamLease{JobMessage: *msg, EntryID: entryID, Consumer: sq.cfg.Consumer, Deliveries: deliveries})
+	if err != nil {
+		return nil, err
+	}
+	msg.raw = string(raw)
+	return msg, nil
+}
+
+// entryMessage reads a job reference from a stream entry's fields.
+func entryMessage(fields []any) (*JobMessage, error) {
+	values := make(map[string]string, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		name, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		values[name] = value
+	}
+
+	jobID, err := uuid.Parse(values["job_id"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream entry: %w", err)
+	}
+	priority, err := strconv.Atoi(values["priority"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream entry: %w", err)
+	}
+	return &JobMessage{JobID: jobID, Type: values["type"], Priority: priority}, nil
+}
+
+// poll calls claim until it returns a message or timeout passes.
+func (sq *StreamQueue) poll(ctx context.Context, jobType string, timeout, visibilityTimeout time.Duration) (*JobMessage, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		msg, err := sq.claim(ctx, jobType, visibilityTimeout)
+		if msg != nil || err != nil {
+			return msg, err
+		}
+
+		wait := min(dequeuePollInterval, time.Until(deadline))
+		if wait <= 0 {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Dequeue takes the next job reference of a type, in weighted priority order,
+// and acks it straight away. Returns nil if no job is available within the
+// timeout.
+func (sq *StreamQueue) Dequeue(ctx context.Context, jobType string, timeout time.Duration) (*JobMessage, error) {
+	return sq.poll(ctx, jobType, timeout, 0)
+}
+
+// DequeueReliable delivers the next job reference of a type, in weighted
+// priority order, to this queue's consumer and leases it for
+// visibilityTimeout. Entries whose lease expired are claimed before new ones.
+// The caller must Ack the message once the job is finished or Nack it to
+// return it; otherwise another consumer claims it after the lease expires.
+// Returns nil if no job is available within the timeout.
+func (sq *StreamQueue) DequeueReliable(ctx context.Context, jobType string, timeout, visibilityTimeout time.Duration) (*JobMessage, error) {
+	return sq.poll(ctx, jobType, timeout, max(visibilityTimeout, time.Millisecond))
+}
+
+// DequeueReliableBatch is DequeueReliable for up to limit messages of any of
+// jobTypes, taken from the types in turn. It waits up to timeout for a message
+// to be ready, then returns every one ready up to limit. If Redis fails once
+// some messages are claimed, those are returned without the error.
+func (sq *StreamQueue) DequeueReliableBatch(ctx context.Context, jobTypes []string, limit int, timeout, visibilityTimeout time.Duration) ([]*JobMessage, error) {
+	deadline := time.Now().Add(timeout)
+	visibilityTimeout = max(visibilityTimeout, time.Millisecond)
+
+	for {
+		var msgs []*JobMessage
+		drained := make(map[string]bool, len(jobTypes))
+		for len(msgs) < limit && len(drained) < len(jobTypes) {
+			for _, jobType := range jobTypes {
+				if drained[jobType] || len(msgs) == limit {
+					continue
+				}
+				msg, err := sq.claim(ctx, jobType, visibilityTimeout)
+				if err != nil {
+					if len(msgs) > 0 {
+						return msgs, nil
+					}
+					return nil, err
+				}
+				if msg == nil {
+					drained[jobType] = true
+					continue
+				}
+				msgs = append(msgs, msg)
+			}
+		}
+		if len(msgs) > 0 {
+			return msgs, nil
+		}
+
+		wait := min(dequeuePollInterval, time.Until(deadline))
+		if wait <= 0 {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// runLeaseScript runs an ack, nack or extend script for the delivery a
+// message was leased by, reporting false for a message from elsewhere.
+func (sq *StreamQueue) runLeaseScript(ctx context.Context, script *redis.Script, msg *JobMessage, extra ...any) (bool, error) {
+	var lease streamLease
+	if err := json.Unmarshal([]byte(msg.raw), &lease); err != nil || lease.EntryID == "" {
+		return false, nil
+	}
+
+	args := append([]any{sq.cfg.Group, lease.EntryID, lease.Consumer, lease.Deliveries, leaseHorizon.Milliseconds()}, extra...)
+	held, err := script.Run(ctx, sq.client, []string{StreamKey(msg.Type, PriorityLevel(msg.Priority))}, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return held > 0, nil
+}
+
+// Ack acks a message obtained from DequeueReliable. It reports false if the
+// message was no longer held, which happens when its lease expired and it was
+// reaped or claimed by another consumer.
+func (sq *StreamQueue) Ack(ctx context.Context, msg *JobMessage) (bool, error) {
+	held, err := sq.runLeaseScript(ctx, streamAckScript, msg)
+	if err != nil {
+		return false, fmt.Errorf("failed to ack job: %w", err)
+	}
+	return held, nil
+}
+
+// Nack returns a message obtained from DequeueReliable, so the next dequeue of
+// its priority level takes it before any new entry. It reports false if the
+// message was no longer held.
+func (sq *StreamQueue) Nack(ctx context.Context, msg *JobMessage) (bool, error) {
+	held, err := sq.runLeaseScript(ctx, streamNackScript, msg, ReturnedConsumer)
+	if err != nil {
+		return false, fmt.Errorf("failed to nack job: %w", err)
+	}
+	return held, nil
+}
+
+// ExtendLease moves the lease deadline of a message obtained from
+// DequeueReliable to visibilityTimeout from now. It reports false if the
+// message was no longer held.
+func (sq *StreamQueue) ExtendLease(ctx context.Context, msg *JobMessage, visibilityTimeout time.Duration) (bool, error) {
+	leaseIdle := max(leaseHorizon-visibilityTimeout, 0)
+	held, err := sq.runLeaseScript(ctx, streamExtendScript, msg, leaseIdle.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to extend lease: %w", err)
+	}
+	return held, nil
+}
+
+// DropLease acks a job's leased entry without returning it, once the job was
+// taken from a worker that stopped responding. It reports whether the entry
+// was found.
+func (sq *StreamQueue) DropLease(ctx context.Context, jobID uuid.UUID, jobType string, priority int) (bool, error) {
+	dropped, err := streamDropScript.Run(ctx, sq.client,
+		[]string{StreamKey(jobType, PriorityLevel(priority))},
+		sq.cfg.Group, jobID.String(), leaseHorizon.Milliseconds(), scanBatchSize,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to drop lease: %w", err)
+	}
+	return dropped > 0, nil
+}
+
+// ReapExpired hands every entry of a job type whose lease has expired to
+// ReturnedConsumer and reports how many it moved. The next dequeue claims
+// them, as it would any expired entry; reaping shows them as returned rather
+// than held by the consumer that let them expire. Every entry is leased when
+// delivered, so visibilityTimeout is not needed.
+func (sq *StreamQueue) ReapExpired(ctx context.Context, jobType string, visibilityTimeout time.Duration) (int, error) {
+	reaped, err := streamReapScript.Run(ctx, sq.client, streamKeys(jobType),
+		sq.cfg.Group, ReturnedConsumer, leaseHorizon.Milliseconds(), scanBatchSize,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reap expired leases: %w", err)
+	}
+	return reaped, nil
+}
+
+// RequestCancel asks whichever worker is running a job to cancel it. The
+// request is fire-and-forget: it reaches only workers subscribed right now.
+func (sq *StreamQueue) RequestCancel(ctx context.Context, jobID uuid.UUID) error {
+	return publishCancel(ctx, sq.client, jobID)
+}
+
+// SubscribeCancels delivers the IDs of jobs whose cancellation is requested
+// until ctx is cancelled, when the returned channel is closed. It returns once
+// the subscription is active.
+func (sq *StreamQueue) SubscribeCancels(ctx context.Context) (<-chan uuid.UUID, error) {
+	return subscribeCancels(ctx, sq.client)
+}
+
+// DeadLetter adds a job to its type's dead-letter queue.
+func (sq *StreamQueue) DeadLetter(ctx context.Context, jobID uuid.UUID, jobType string) error {
+	return addDeadLetter(ctx, sq.client, jobID, jobType)
+}
+
+// RemoveDeadLetters removes jobs from their type's dead-letter queue and
+// reports how many were removed.
+func (sq *StreamQueue) RemoveDeadLetters(ctx context.Context, jobType string, jobIDs ...uuid.UUID) (int64, error) {
+	return removeDeadLetters(ctx, sq.client, jobType, jobIDs...)
+}
+
+// GetDeadLetterLength returns the number of jobs of a type in the dead-letter queue.
+func (sq *StreamQueue) GetDeadLetterLength(ctx context.Context, jobType string) (int64, error) {
+	return deadLetterLength(ctx, sq.client, jobType)
+}
+
+// isMissing reports whether err says a stream or its consumer group does not
+// exist yet, which the read commands treat as empty.
+func isMissing(err error) bool {
+	return err != nil && (strings.HasPrefix(err.Error(), "NOGROUP") || strings.Contains(err.Error(), "no such key"))
+}
+
+// group returns the consumer group's view of a stream, or nil if the group
+// does not exist yet.
+func (sq *StreamQueue) group(ctx context.Context, stream string) (*redis.XInfoGroup, error) {
+	groups, err := sq.client.XInfoGroups(ctx, stream).Result()
+	if isMissing(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		if groups[i].Name == sq.cfg.Group {
+			return &groups[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// scanUndelivered calls fn with every entry of a stream not yet delivered to
+// the group, oldest first.
+func (sq *StreamQueue) scanUndelivered(ctx context.Context, stream string, fn func(redis.XMessage)) error {
+	g, err := sq.group(ctx, stream)
+	if err != nil {
+		return err
+	}
+	start := "-"
+	if g != nil {
+		start = "(" + g.LastDeliveredID
+	}
+
+	for {
+		entries, err := sq.client.XRangeN(ctx, stream, start, "+", scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			fn(entry)
+		}
+		if len(entries) < scanBatchSize {
+			return nil
+		}
+		start = "(" + entries[len(entries)-1].ID
+	}
+}
+
+// scanPending calls fn with every entry of a stream delivered to the group
+// and not acked whose idle time is at least minIdle.
+func (sq *StreamQueue) scanPending(ctx context.Context, stream string, minIdle time.Duration, fn func(redis.XPendingExt)) error {
+	start := "-"
+	for {
+		pending, err := sq.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  sq.cfg.Group,
+			Idle:   minIdle,
+			Start:  start,
+			End:    "+",
+			Count:  scanBatchSize,
+		}).Result()
+		if isMissing(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, p := range pending {
+			fn(p)
+		}
+		if len(pending) < scanBatchSize {
+			return nil
+		}
+		start = "(" + pending[len(pending)-1].ID
+	}
+}
+
+// levelLengths returns how many entries of a stream are waiting, either not
+// delivered yet or returned, and how many are leased.
+func (sq *StreamQueue) levelLengths(ctx context.Context, stream string) (waiting, leased int64, err error) {
+	g, err := sq.group(ctx, stream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var lag int64
+	switch {
+	case g == nil:
+		// Nothing was read yet
+		lag, err = sq.client.XLen(ctx, stream).Result()
+		if err != nil {
+			return 0, 0, err
+		}
+	case g.Lag >= 0:
+		lag = g.Lag
+	default:
+		// Redis cannot tell after entries were deleted, so count them
+		err = sq.scanUndelivered(ctx, stream, func(redis.XMessage) { lag++ })
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var returned int64
+	err = sq.scanPending(ctx, stream, leaseHorizon, func(redis.XPendingExt) { returned++ })
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var pending int64
+	if g != nil {
+		pending = g.Pending
+	}
+	return lag + returned, pending - returned, nil
+}
+
+// GetQueueLength returns the number of jobs of a type waiting at any priority,
+// counting entries not yet delivered and entries returned to be claimed again.
+func (sq *StreamQueue) GetQueueLength(ctx context.Context, jobType string) (int64, error) {
+	var total int64
+	for level := PriorityHigh; level <= PriorityLow; level++ {
+		length, err := sq.GetPriorityQueueLength(ctx, jobType, level)
+		if err != nil {
+			return 0, err
+		}
+		total += length
+	}
+	return total, nil
+}
+
+// GetPriorityQueueLength returns the number of jobs of a type waiting at a priority level.
+func (sq *StreamQueue) GetPriorityQueueLength(ctx context.Context, jobType string, level int) (int64, error) {
+	waiting, _, err := sq.levelLengths(ctx, StreamKey(jobType, level))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue length: %w", err)
+	}
+	return waiting, nil
+}
+
+// GetScheduledLength returns the number of jobs of a type waiting for their due time.
+func (sq *StreamQueue) GetScheduledLength(ctx context.Context, jobType string) (int64, error) {
+	length, err := sq.client.ZCard(ctx, StreamScheduledKeyPrefix+jobType).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get scheduled length: %w", err)
+	}
+	return length, nil
+}
+
+// GetProcessingLength returns the number of jobs of a type currently leased to consumers.
+func (sq *StreamQueue) GetProcessingLength(ctx context.Context, jobType string) (int64, error) {
+	var total int64
+	for _, stream := range streamKeys(jobType) {
+		_, leased, err := sq.levelLengths(ctx, stream)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get processing length: %w", err)
+		}
+		total += leased
+	}
+	return total, nil
+}
+
+// TrackedJobIDs returns the IDs of every job of a type the queue knows about,
+// whether not yet delivered, pending or scheduled. Acked entries kept until
+// the stream is trimmed are not included.
+func (sq *StreamQueue) TrackedJobIDs(ctx context.Context, jobType string) (map[uuid.UUID]struct{}, error) {
+	ids := make(map[uuid.UUID]struct{})
+	track := func(fields map[string]any) {
+		if id, err := uuid.Parse(fmt.Sprint(fields["job_id"])); err == nil {
+			ids[id] = struct{}{}
+		}
+	}
+
+	for _, stream := range streamKeys(jobType) {
+		err := sq.scanUndelivered(ctx, stream, func(entry redis.XMessage) { track(entry.Values) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", stream, err)
+		}
+
+		var pendingIDs []string
+		err = sq.scanPending(ctx, stream, 0, func(p redis.XPendingExt) { pendingIDs = append(pendingIDs, p.ID) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", stream, err)
+		}
+
+		pipe := sq.client.Pipeline()
+		reads := make([]*redis.XMessageSliceCmd, len(pendingIDs))
+		for i, id := range pendingIDs {
+			reads[i] = pipe.XRange(ctx, stream, id, id)
+		}
+		if len(reads) > 0 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", stream, err)
+			}
+		}
+		for _, read := range reads {
+			for _, entry := range read.Val() {
+				track(entry.Values)
+			}
+		}
+	}
+
+	scheduled, err := sq.client.ZRange(ctx, StreamScheduledKeyPrefix+jobType, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduled jobs: %w", err)
+	}
+	for _, item := range scheduled {
+		var msg JobMessage
+		if err := json.Unmarshal([]byte(item), &msg); err != nil {
+			// Skip malformed entries rather than abort the scan
+			continue
+		}
+		ids[msg.JobID] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// Stats reports the length, lag and pending entries by consumer of each of a
+// type's streams, from high to low priority, so operators can see how far
+// behind the group is and which consumer holds what.
+func (sq *StreamQueue) Stats(ctx context.Context, jobType string) ([]StreamStats, error) {
+	var stats []StreamStats
+	for _, stream := range streamKeys(jobType) {
+		s := StreamStats{Stream: stream, Pending: make(map[string]int64)}
+
+		length, err := sq.client.XLen(ctx, stream).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stream length: %w", err)
+		}
+		s.Length = length
+
+		waiting, _, err := sq.levelLengths(ctx, stream)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stream lag: %w", err)
+		}
+
+		pending, err := sq.client.XPending(ctx, stream, sq.cfg.Group).Result()
+		if err != nil && !isMissing(err) {
+			return nil, fmt.Errorf("failed to get pending entries: %w", err)
+		}
+		if pending != nil {
+			for consumer, count := range pending.Consumers {
+				s.Pending[consumer] = count
+			}
+		}
+		// Returned entries are waiting too, but already delivered once
+		s.Lag = waiting - s.Pending[ReturnedConsumer]
+
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// Close closes the Redis client connection.
+func (sq *StreamQueue) Close() error {
+	return sq.client.Close()
+}
@@ -0,0 +1,142 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+func startStreamQueue(t *testing.T, cfg queue.StreamConfig) *queue.StreamQueue {
+	addr := testutil.StartRedis(t)
+
+	sq, err := queue.NewStreamQueue(addr, "", 0, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { sq.Close() })
+	return sq
+}
+
+// normalStats returns the stats of the normal priority stream of JOB_STANDARD.
+func normalStats(t *testing.T, sq *queue.StreamQueue) queue.StreamStats {
+	t.Helper()
+	stats, err := sq.Stats(context.Background(), "JOB_STANDARD")
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, queue.StreamKey("JOB_STANDARD", queue.PriorityNormal), stats[1].Stream)
+	return stats[1]
+}
+
+func TestStreamQueue_TrimKeepsUndeliveredAndPending(t *testing.T) {
+	sq := startStreamQueue(t, queue.StreamConfig{Consumer: "worker-a", MaxLen: 2})
+	ctx := context.Background()
+
+	for range 5 {
+		require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+	}
+
+	// Nothing was delivered, so nothing is trimmed
+	assert.Equal(t, int64(5), normalStats(t, sq).Length)
+
+	leased, err := sq.DequeueReliable(ctx, "JOB_STANDARD", 0, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, leased)
+	done, err := sq.DequeueReliable(ctx, "JOB_STANDARD", 0, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, done)
+	ok, err := sq.Ack(ctx, done)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The acked entry comes after the oldest pending one, so both stay
+	require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+	assert.Equal(t, int64(6), normalStats(t, sq).Length)
+
+	// Once both are acked they are trimmed, but the backlog is kept
+	ok, err = sq.Ack(ctx, leased)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+
+	stats := normalStats(t, sq)
+	assert.Equal(t, int64(5), stats.Length)
+	assert.Equal(t, int64(5), stats.Lag)
+
+	queued, err := sq.GetQueueLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), queued)
+}
+
+func TestStreamQueue_TrimByAge(t *testing.T) {
+	sq := startStreamQueue(t, queue.StreamConfig{Consumer: "worker-a", MaxAge: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	for range 3 {
+		require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Old entries that were never delivered are kept
+	require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+	assert.Equal(t, int64(4), normalStats(t, sq).Length)
+
+	for range 4 {
+		msg, err := sq.Dequeue(ctx, "JOB_STANDARD", 0)
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+	stats := normalStats(t, sq)
+	assert.Equal(t, int64(1), stats.Length)
+	assert.Equal(t, int64(1), stats.Lag)
+}
+
+func TestStreamQueue_Stats(t *testing.T) {
+	sq := startStreamQueue(t, queue.StreamConfig{Consumer: "worker-a"})
+	ctx := context.Background()
+
+	// Prefer the normal stream, so the high priority job stays queued
+	require.NoError(t, sq.SetPriorityWeights(queue.PriorityWeights{Normal: 1}))
+
+	for range 4 {
+		require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 0))
+	}
+	require.NoError(t, sq.Enqueue(ctx, uuid.New(), "JOB_STANDARD", 5))
+
+	stats := normalStats(t, sq)
+	assert.Equal(t, int64(4), stats.Length)
+	assert.Equal(t, int64(4), stats.Lag)
+	assert.Empty(t, stats.Pending)
+
+	held, err := sq.DequeueReliable(ctx, "JOB_STANDARD", 0, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	assert.Equal(t, 0, held.Priority)
+
+	returned, err := sq.DequeueReliable(ctx, "JOB_STANDARD", 0, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, returned)
+	ok, err := sq.Nack(ctx, returned)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Delivered entries leave the lag; the nacked one waits with the returned consumer
+	stats = normalStats(t, sq)
+	assert.Equal(t, int64(4), stats.Length)
+	assert.Equal(t, int64(2), stats.Lag)
+	assert.Equal(t, map[string]int64{"worker-a": 1, queue.ReturnedConsumer: 1}, stats.Pending)
+
+	queued, err := sq.GetPriorityQueueLength(ctx, "JOB_STANDARD", queue.PriorityNormal)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), queued)
+	processing, err := sq.GetProcessingLength(ctx, "JOB_STANDARD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), processing)
+}
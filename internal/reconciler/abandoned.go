@@ -0,0 +1,62 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/worker"
+)
+
+// ReapAbandoned fails the current attempt of every processing job whose worker
+// has sent no heartbeat for HeartbeatTimeout, as that worker is presumed dead.
+// The attempt counts against the job, which is retried per its retry policy or
+// dead-lettered once out of attempts. The attempt is recorded as abandoned
+// in the job's attempt history. It returns how many jobs were reaped.
+//
+// Should the worker turn out to be alive, its next heartbeat finds the job no
+// longer processing and it stops running it.
+func (r *Reconciler) ReapAbandoned(ctx context.Context) (int, error) {
+	jobs, err := r.store.ListAbandonedJobs(ctx, r.cfg.HeartbeatTimeout, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	jobErr := worker.WithCode(fmt.Errorf("reconciler: no heartbeat for %s", r.cfg.HeartbeatTimeout), "heartbeat_timeout", "")
+
+	reaped := 0
+	for _, job := range jobs {
+		logger := r.logger.With("job_id", job.ID.String(), "type", job.Type, "attempts", job.Attempts)
+
+		// Its message stays leased by the dead worker. Left alone, the lease
+		// would expire and run the job again ahead of its retry. It goes
+		// before the retry is published, which may reuse the same message.
+		if _, err := r.queue.DropLease(ctx, job.ID, job.Type, job.Priority); err != nil {
+			logger.Warn("Failed to drop abandoned job's lease", "error", err)
+		}
+
+		outcome, err := worker.RecordFailure(ctx, logger, r.store, r.queue, job, jobErr, 0)
+		if err != nil {
+			logger.Error("Failed to reap abandoned job", "error", err)
+			continue
+		}
+		if outcome == "" {
+			// Finished while being reaped
+			continue
+		}
+
+		end := store.AttemptEnd{Outcome: store.AttemptAbandoned, Error: worker.DescribeError(jobErr)}
+		if err := r.store.FinishAttempt(ctx, job.ID, job.Attempts, end); err != nil {
+			logger.Warn("Failed to record abandoned attempt", "error", err)
+		}
+
+		logger.Warn("Reaped abandoned job", "outcome", outcome)
+		reaped++
+	}
+
+	if len(jobs) > 0 {
+		r.logger.Info("Reaped abandoned jobs", "found", len(jobs), "reaped", reaped)
+	}
+
+	return reaped, nil
+}
@@ -0,0 +1,89 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+// startAbandonedJob creates a job and leases it to a worker that never comes back.
+func startAbandonedJob(t *testing.T, deps *testDeps, maxAttempts int) uuid.UUID {
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:          jobID,
+		Type:        testJobType,
+		Payload:     []byte("reconciler test payload"),
+		Status:      store.JobStatusQueued,
+		MaxAttempts: maxAttempts,
+	}))
+	require.NoError(t, deps.queue.Enqueue(ctx, jobID, testJobType, 0))
+
+	msg, err := deps.queue.DequeueReliable(ctx, testJobType, time.Second, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	claimed, err := deps.store.ClaimJob(ctx, jobID, "lost-worker", time.Hour)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	return jobID
+}
+
+func TestReapAbandoned(t *testing.T) {
+	deps := setupTestReconciler(t)
+	ctx := context.Background()
+
+	retried := startAbandonedJob(t, deps, 3)
+	exhausted := startAbandonedJob(t, deps, 1)
+	alive := startAbandonedJob(t, deps, 3)
+
+	time.Sleep(300 * time.Millisecond)
+
+	held, _, err := deps.store.Heartbeat(ctx, alive, 1)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	reaped, err := deps.reconciler.ReapAbandoned(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reaped)
+
+	job, err := deps.store.GetJobByID(ctx, retried)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusRetrying, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	require.NotNil(t, job.LastError)
+	assert.Contains(t, *job.LastError, "no heartbeat")
+
+	attempts, err := deps.store.ListJobAttempts(ctx, retried)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "lost-worker", attempts[0].WorkerID)
+	assert.Equal(t, store.AttemptAbandoned, attempts[0].Outcome)
+	assert.NotNil(t, attempts[0].FinishedAt)
+	require.NotNil(t, attempts[0].ErrorCode)
+	assert.Equal(t, "heartbeat_timeout", *attempts[0].ErrorCode)
+
+	job, err = deps.store.GetJobByID(ctx, exhausted)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusDead, job.Status)
+
+	job, err = deps.store.GetJobByID(ctx, alive)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusProcessing, job.Status)
+
+	// Only the live job's message is still leased
+	n, err := deps.queue.GetProcessingLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	reaped, err = deps.reconciler.ReapAbandoned(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, reaped)
+}
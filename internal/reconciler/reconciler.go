@@ -0,0 +1,217 @@
+// Package reconciler repairs drift between the jobs table and the Redis queues.
+//
+// Redis can lose data (a flush, a restore from an old AOF, a failover) and
+// workers can die without their lease ever being reaped. Either way Postgres
+// is left with queued or processing jobs that nothing will ever run. The
+// reconciler looks for such jobs once they are older than a threshold, checks
+// whether Redis still tracks them, and re-enqueues or dead-letters the ones it
+// does not. It also reaps processing jobs whose worker has stopped sending
+// heartbeats, even though Redis still tracks their lease.
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+const (
+	defaultInterval               = 5 * time.Minute
+	defaultStaleAfter             = 10 * time.Minute
+	defaultBatchSize              = 500
+	defaultMaxAttempts            = 3
+	defaultHeartbeatTimeout       = 2 * time.Minute
+	defaultHeartbeatCheckInterval = 30 * time.Second
+)
+
+// Config controls what counts as stale and how orphaned jobs are repaired.
+type Config struct {
+	// Interval is how often Run performs a pass.
+	Interval time.Duration
+	// StaleAfter is how long a job must have been queued or processing before
+	// it is checked against Redis.
+	StaleAfter time.Duration
+	// BatchSize is the maximum number of stale jobs examined per pass.
+	BatchSize int
+	// MaxAttempts is how many times a job may be started before an orphaned
+	// processing job is dead-lettered instead of re-enqueued. A job's own max_attempts
+	// takes precedence.
+	MaxAttempts int
+	// HeartbeatTimeout is how long a processing job may go without a
+	// heartbeat before its worker is presumed dead.
+	HeartbeatTimeout time.Duration
+	// HeartbeatCheckInterval is how often Run looks for such jobs.
+	HeartbeatCheckInterval time.Duration
+}
+
+// Report summarizes a reconciliation pass.
+type Report struct {
+	Scanned      int // stale jobs examined
+	Healthy      int // stale jobs still tracked by Redis
+	Requeued     int // orphaned jobs pushed back onto their queue
+	DeadLettered int // orphaned jobs out of attempts, moved to the dead-letter queue
+	Skipped      int // jobs whose status changed while being reconciled
+	Errors       int // jobs that could not be repaired this pass
+}
+
+// Reconciler finds jobs that Postgres expects to run but Redis no longer tracks.
+type Reconciler struct {
+	logger *slog.Logger
+	store  store.JobStore
+	queue  queue.JobQueue
+	cfg    Config
+}
+
+// NewReconciler creates a Reconciler. Zero values in cfg are replaced with defaults.
+func NewReconciler(l *slog.Logger, s store.JobStore, q queue.JobQueue, cfg Config) *Reconciler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = defaultStaleAfter
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.HeartbeatTimeout <= 0 {
+		cfg.HeartbeatTimeout = defaultHeartbeatTimeout
+	}
+	if cfg.HeartbeatCheckInterval <= 0 {
+		cfg.HeartbeatCheckInterval = defaultHeartbeatCheckInterval
+	}
+
+	return &Reconciler{
+		logger: l,
+		store:  s,
+		queue:  q,
+		cfg:    cfg,
+	}
+}
+
+// Run performs a pass every Interval and reaps abandoned jobs every
+// HeartbeatCheckInterval until ctx is cancelled.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	heartbeatTicker := time.NewTicker(r.cfg.HeartbeatCheckInterval)
+	defer heartbeatTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.ReconcileOnce(ctx); err != nil {
+				r.logger.Error("Reconciliation failed", "error", err)
+			}
+		case <-heartbeatTicker.C:
+			if _, err := r.ReapAbandoned(ctx); err != nil {
+				r.logger.Error("Failed to reap abandoned jobs", "error", err)
+			}
+		}
+	}
+}
+
+// ReconcileOnce performs a single pass and returns a summary of what it repaired.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) (*Report, error) {
+	jobs, err := r.store.ListStaleJobs(ctx, r.cfg.StaleAfter, r.cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Scanned: len(jobs)}
+	tracked := make(map[string]map[uuid.UUID]struct{})
+
+	for _, job := range jobs {
+		// Snapshot Redis once per job type per pass
+		ids, ok := tracked[job.Type]
+		if !ok {
+			ids, err = r.queue.TrackedJobIDs(ctx, job.Type)
+			if err != nil {
+				return nil, err
+			}
+			tracked[job.Type] = ids
+		}
+
+		if _, ok := ids[job.ID]; ok {
+			report.Healthy++
+			continue
+		}
+
+		r.repair(ctx, job, report)
+	}
+
+	r.logger.Info("Reconciliation complete",
+		"scanned", report.Scanned,
+		"healthy", report.Healthy,
+		"requeued", report.Requeued,
+		"dead_lettered", report.DeadLettered,
+		"skipped", report.Skipped,
+		"errors", report.Errors,
+	)
+
+	return report, nil
+}
+
+// repair re-enqueues or dead-letters a job that Redis no longer tracks.
+func (r *Reconciler) repair(ctx context.Context, job *store.Job, report *Report) {
+	logger := r.logger.With("job_id", job.ID.String(), "type", job.Type, "status", job.Status, "attempts", job.Attempts)
+
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = r.cfg.MaxAttempts
+	}
+
+	if job.Status == store.JobStatusProcessing && job.AttemptsSinceReplay() >= maxAttempts {
+		reason := fmt.Sprintf("reconciler: worker lost after %d attempts", job.Attempts)
+		dead, err := r.store.DeadLetterJob(ctx, job.ID, job.Status, job.Attempts, store.JobError{Message: reason, Code: "worker_lost"})
+		switch {
+		case err != nil:
+			logger.Error("Failed to dead-letter orphaned job", "error", err)
+			report.Errors++
+		case !dead:
+			report.Skipped++
+		default:
+			if err := r.queue.DeadLetter(ctx, job.ID, job.Type); err != nil {
+				logger.Warn("Failed to add orphaned job to dead-letter queue", "error", err)
+			}
+			logger.Warn("Dead-lettered orphaned job", "reason", reason)
+			report.DeadLettered++
+		}
+		return
+	}
+
+	reason := "reconciler: job missing from queue"
+	if job.Status == store.JobStatusProcessing {
+		reason = "reconciler: worker lost"
+	}
+
+	requeued, err := r.store.RequeueJob(ctx, job.ID, job.Status, reason)
+	if err != nil {
+		logger.Error("Failed to requeue orphaned job", "error", err)
+		report.Errors++
+		return
+	}
+	if !requeued {
+		report.Skipped++
+		return
+	}
+
+	if err := r.queue.Enqueue(ctx, job.ID, job.Type, job.Priority); err != nil {
+		// The job is queued in Postgres, so the next pass retries the push.
+		logger.Error("Failed to re-enqueue orphaned job", "error", err)
+		report.Errors++
+		return
+	}
+
+	logger.Warn("Re-enqueued orphaned job", "reason", reason)
+	report.Requeued++
+}
@@ -0,0 +1,134 @@
+package reconciler
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+const testJobType = "JOB_STANDARD"
+
+type testDeps struct {
+	reconciler *Reconciler
+	store      *store.PostgresStore
+	queue      *queue.RedisQueue
+}
+
+func setupTestReconciler(t *testing.T) *testDeps {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	pgStore := store.NewPostgresStore(testutil.StartPostgres(t))
+
+	redisQueue, err := queue.NewRedisQueue(testutil.StartRedis(t), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { redisQueue.Close() })
+
+	rec := NewReconciler(logger, pgStore, redisQueue, Config{
+		StaleAfter:       10 * time.Millisecond,
+		MaxAttempts:      2,
+		HeartbeatTimeout: 200 * time.Millisecond,
+	})
+
+	return &testDeps{
+		reconciler: rec,
+		store:      pgStore,
+		queue:      redisQueue,
+	}
+}
+
+func createJob(t *testing.T, deps *testDeps) uuid.UUID {
+	jobID := uuid.New()
+	require.NoError(t, deps.store.CreateJob(context.Background(), &store.Job{
+		ID:      jobID,
+		Type:    testJobType,
+		Payload: []byte("reconciler test payload"),
+		Status:  store.JobStatusQueued,
+	}))
+	return jobID
+}
+
+func TestReconcileOnce(t *testing.T) {
+	deps := setupTestReconciler(t)
+	ctx := context.Background()
+
+	// Queued and still in Redis
+	healthy := createJob(t, deps)
+	require.NoError(t, deps.queue.Enqueue(ctx, healthy, testJobType, 0))
+
+	// Queued but lost from Redis
+	lost := createJob(t, deps)
+
+	// Processing, worker died, attempts left
+	orphaned := createJob(t, deps)
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, orphaned))
+
+	// Processing, worker died, out of attempts
+	exhausted := createJob(t, deps)
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, exhausted))
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, exhausted))
+
+	time.Sleep(50 * time.Millisecond)
+
+	report, err := deps.reconciler.ReconcileOnce(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Report{Scanned: 4, Healthy: 1, Requeued: 2, DeadLettered: 1}, report)
+
+	ids, err := deps.queue.TrackedJobIDs(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Contains(t, ids, lost)
+	assert.Contains(t, ids, orphaned)
+	assert.NotContains(t, ids, exhausted)
+
+	job, err := deps.store.GetJobByID(ctx, orphaned)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusQueued, job.Status)
+	require.NotNil(t, job.LastError)
+	assert.Contains(t, *job.LastError, "worker lost")
+
+	job, err = deps.store.GetJobByID(ctx, exhausted)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusDead, job.Status)
+	require.NotNil(t, job.LastError)
+
+	n, err := deps.queue.GetDeadLetterLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// A second pass finds nothing left to repair
+	report, err = deps.reconciler.ReconcileOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Requeued)
+	assert.Equal(t, 0, report.DeadLettered)
+}
+
+func TestReconcileOnce_SkipsJobsAwaitingOutboxRelay(t *testing.T) {
+	deps := setupTestReconciler(t)
+	ctx := context.Background()
+
+	_, err := deps.store.CreateJobWithOutbox(ctx, &store.Job{
+		ID:      uuid.New(),
+		Type:    testJobType,
+		Payload: []byte("reconciler test payload"),
+		Status:  store.JobStatusQueued,
+	})
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+
+	report, err := deps.reconciler.ReconcileOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Scanned)
+}
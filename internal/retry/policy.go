@@ -0,0 +1,114 @@
+// Package retry computes when a failed job should be attempted again.
+package retry
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter strategies, as persisted in the jobs table.
+const (
+	JitterNone  = "none"
+	JitterFull  = "full"
+	JitterEqual = "equal"
+)
+
+// MaxAttemptsLimit caps the number of attempts a caller may request.
+const MaxAttemptsLimit = 100
+
+// Policy describes how many times a job is attempted and how long to wait
+// between attempts. Delays grow exponentially from BaseDelay, doubling after
+// every attempt, up to MaxDelay, with Jitter applied on top.
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      string
+}
+
+// defaults holds the retry policy for each job type.
+var defaults = map[string]Policy{
+	"JOB_STANDARD": {MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterFull},
+	"JOB_WEBHOOK":  {MaxAttempts: 8, BaseDelay: 5 * time.Second, MaxDelay: time.Hour, Jitter: JitterFull},
+}
+
+// fallback is used for job types without an entry in defaults.
+var fallback = Policy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterFull}
+
+// DefaultFor returns the default policy for a job type.
+func DefaultFor(jobType string) Policy {
+	if p, ok := defaults[jobType]; ok {
+		return p
+	}
+	return fallback
+}
+
+// WithDefaults fills the zero fields of p from the job type's default policy.
+func (p Policy) WithDefaults(jobType string) Policy {
+	d := DefaultFor(jobType)
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = d.MaxAttempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = d.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = max(d.MaxDelay, p.BaseDelay)
+	}
+	if p.Jitter == "" {
+		p.Jitter = d.Jitter
+	}
+	return p
+}
+
+// Validate checks a fully populated policy.
+func (p Policy) Validate() error {
+	if p.MaxAttempts < 1 || p.MaxAttempts > MaxAttemptsLimit {
+		return fmt.Errorf("max_attempts must be between 1 and %d", MaxAttemptsLimit)
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("retry delays cannot be negative")
+	}
+	if p.BaseDelay > p.MaxDelay {
+		return fmt.Errorf("base_delay cannot exceed max_delay")
+	}
+	switch p.Jitter {
+	case JitterNone, JitterFull, JitterEqual:
+	default:
+		return fmt.Errorf("unknown jitter strategy %q", p.Jitter)
+	}
+	return nil
+}
+
+// Backoff returns how long to wait before the next attempt, given the number
+// of attempts already made (1 after the first failure).
+func (p Policy) Backoff(attempts int) time.Duration {
+	delay := p.MaxDelay
+	if attempts < 1 {
+		attempts = 1
+	}
+	// Stop doubling once the cap is reached so the shift cannot overflow
+	if shift := attempts - 1; shift < 63 && p.BaseDelay <= p.MaxDelay>>shift {
+		delay = p.BaseDelay << shift
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	switch p.Jitter {
+	case JitterFull:
+		return rand.N(delay + 1)
+	case JitterEqual:
+		half := delay / 2
+		return half + rand.N(delay-half+1)
+	default:
+		return delay
+	}
+}
+
+// CanRetry reports whether another attempt is allowed after attempts have been made.
+func (p Policy) CanRetry(attempts int) bool {
+	return attempts < p.MaxAttempts
+}
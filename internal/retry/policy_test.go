@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDefaults(t *testing.T) {
+	p := Policy{MaxAttempts: 5}.WithDefaults("JOB_WEBHOOK")
+
+	assert.Equal(t, 5, p.MaxAttempts)
+	assert.Equal(t, 5*time.Second, p.BaseDelay)
+	assert.Equal(t, time.Hour, p.MaxDelay)
+	assert.Equal(t, JitterFull, p.Jitter)
+
+	// An unknown type falls back to the standard policy
+	assert.Equal(t, DefaultFor("JOB_STANDARD"), Policy{}.WithDefaults("JOB_UNKNOWN"))
+
+	// A base delay above the default cap raises the cap with it
+	p = Policy{BaseDelay: 2 * time.Minute}.WithDefaults("JOB_STANDARD")
+	assert.Equal(t, 2*time.Minute, p.MaxDelay)
+	require.NoError(t, p.Validate())
+}
+
+func TestValidate(t *testing.T) {
+	valid := DefaultFor("JOB_STANDARD")
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name   string
+		mutate func(p *Policy)
+		errMsg string
+	}{
+		{"zero attempts", func(p *Policy) { p.MaxAttempts = 0 }, "max_attempts"},
+		{"too many attempts", func(p *Policy) { p.MaxAttempts = MaxAttemptsLimit + 1 }, "max_attempts"},
+		{"negative delay", func(p *Policy) { p.BaseDelay = -time.Second }, "cannot be negative"},
+		{"base above max", func(p *Policy) { p.BaseDelay = 2 * p.MaxDelay }, "cannot exceed"},
+		{"bad jitter", func(p *Policy) { p.Jitter = "random" }, "unknown jitter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.mutate(&p)
+			err := p.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestBackoff_NoJitter(t *testing.T) {
+	p := Policy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: JitterNone}
+
+	assert.Equal(t, time.Second, p.Backoff(0))
+	assert.Equal(t, time.Second, p.Backoff(1))
+	assert.Equal(t, 2*time.Second, p.Backoff(2))
+	assert.Equal(t, 8*time.Second, p.Backoff(4))
+	assert.Equal(t, 10*time.Second, p.Backoff(5))
+	assert.Equal(t, 10*time.Second, p.Backoff(1000))
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	full := Policy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterFull}
+	equal := Policy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: JitterEqual}
+
+	for range 100 {
+		d := full.Backoff(3)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, 4*time.Second)
+
+		d = equal.Backoff(3)
+		assert.GreaterOrEqual(t, d, 2*time.Second)
+		assert.LessOrEqual(t, d, 4*time.Second)
+	}
+}
+
+func TestCanRetry(t *testing.T) {
+	p := Policy{MaxAttempts: 3}
+
+	assert.True(t, p.CanRetry(1))
+	assert.True(t, p.CanRetry(2))
+	assert.False(t, p.CanRetry(3))
+}
@@ -0,0 +1,126 @@
+// Package scheduler promotes delayed jobs to their queue once they are due.
+//
+// Scheduled jobs and retries wait in a per-type Redis sorted set scored by
+// their due time. The promoter moves due entries to the queue with a Lua
+// script, so any number of queue-svc replicas can run it without promoting a
+// job twice, then marks promoted scheduled jobs as queued in Postgres.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+const (
+	defaultInterval  = time.Second
+	defaultBatchSize = 500
+)
+
+// Config controls how often and how much the promoter moves.
+type Config struct {
+	// Interval is how often due jobs are checked for.
+	Interval time.Duration
+	// BatchSize is the maximum number of jobs moved per script call.
+	BatchSize int
+	// JobTypes are the job types whose scheduled sets are promoted.
+	JobTypes []string
+}
+
+// Promoter moves due jobs from the scheduled sets to their queues.
+type Promoter struct {
+	logger *slog.Logger
+	store  store.JobStore
+	queue  queue.JobQueue
+	cfg    Config
+}
+
+// NewPromoter creates a Promoter. Zero values in cfg are replaced with defaults.
+func NewPromoter(l *slog.Logger, s store.JobStore, q queue.JobQueue, cfg Config) *Promoter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+
+	return &Promoter{
+		logger: l,
+		store:  s,
+		queue:  q,
+		cfg:    cfg,
+	}
+}
+
+// Run promotes due jobs every Interval until ctx is cancelled.
+func (p *Promoter) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		promoted, err := p.PromoteOnce(ctx)
+		if err != nil {
+			p.logger.Error("Failed to promote scheduled jobs", "error", err)
+		}
+		if promoted > 0 {
+			p.logger.Info("Promoted scheduled jobs", "count", promoted)
+		}
+	}
+}
+
+// PromoteOnce moves every job that is due to its queue and returns how many
+// were moved. Types are promoted independently; the first error is returned
+// after all types have been tried.
+func (p *Promoter) PromoteOnce(ctx context.Context) (int, error) {
+	total := 0
+	var firstErr error
+
+	for _, jobType := range p.cfg.JobTypes {
+		promoted, err := p.promoteType(ctx, jobType)
+		total += promoted
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return total, firstErr
+}
+
+// promoteType drains the due jobs of one type in batches.
+func (p *Promoter) promoteType(ctx context.Context, jobType string) (int, error) {
+	total := 0
+
+	for {
+		msgs, err := p.queue.PromoteDue(ctx, jobType, p.cfg.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += len(msgs)
+
+		if len(msgs) > 0 {
+			ids := make([]uuid.UUID, len(msgs))
+			for i, msg := range msgs {
+				ids[i] = msg.JobID
+			}
+			// The jobs are already on the queue and workers claim scheduled
+			// jobs directly, so a failure here only leaves the status stale.
+			if _, err := p.store.PromoteScheduledJobs(ctx, ids); err != nil {
+				p.logger.Warn("Failed to mark promoted jobs as queued", "type", jobType, "count", len(ids), "error", err)
+			}
+		}
+
+		if len(msgs) < p.cfg.BatchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
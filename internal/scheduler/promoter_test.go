@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+const testJobType = "JOB_STANDARD"
+
+type testDeps struct {
+	logger *slog.Logger
+	store  *store.PostgresStore
+	queue  *queue.RedisQueue
+}
+
+func setupTestPromoter(t *testing.T) *testDeps {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	pgStore := store.NewPostgresStore(testutil.StartPostgres(t))
+
+	redisQueue, err := queue.NewRedisQueue(testutil.StartRedis(t), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { redisQueue.Close() })
+
+	return &testDeps{logger: logger, store: pgStore, queue: redisQueue}
+}
+
+// scheduleJob creates a job scheduled to run at runAt and adds it to the scheduled set.
+func scheduleJob(t *testing.T, deps *testDeps, runAt time.Time) uuid.UUID {
+	ctx := context.Background()
+	jobID := uuid.New()
+	runAt = runAt.UTC()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:        jobID,
+		Type:      testJobType,
+		Payload:   []byte("scheduled payload"),
+		Status:    store.JobStatusScheduled,
+		NextRunAt: &runAt,
+	}))
+	require.NoError(t, deps.queue.Schedule(ctx, jobID, testJobType, 0, runAt))
+
+	return jobID
+}
+
+func TestPromoteOnce(t *testing.T) {
+	deps := setupTestPromoter(t)
+	ctx := context.Background()
+
+	due := scheduleJob(t, deps, time.Now().Add(50*time.Millisecond))
+	notDue := scheduleJob(t, deps, time.Now().Add(time.Hour))
+
+	promoter := NewPromoter(deps.logger, deps.store, deps.queue, Config{JobTypes: []string{testJobType}})
+
+	time.Sleep(100 * time.Millisecond)
+
+	promoted, err := promoter.PromoteOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, promoted)
+
+	msg, err := deps.queue.Dequeue(ctx, testJobType, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, due, msg.JobID)
+
+	job, err := deps.store.GetJobByID(ctx, due)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusQueued, job.Status)
+	assert.Nil(t, job.NextRunAt)
+
+	job, err = deps.store.GetJobByID(ctx, notDue)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusScheduled, job.Status)
+}
+
+func TestPromoteOnce_ConcurrentPromotersDoNotDoublePromote(t *testing.T) {
+	deps := setupTestPromoter(t)
+	ctx := context.Background()
+
+	const jobs = 50
+	for range jobs {
+		scheduleJob(t, deps, time.Now().Add(20*time.Millisecond))
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			promoter := NewPromoter(deps.logger, deps.store, deps.queue, Config{BatchSize: 5, JobTypes: []string{testJobType}})
+			promoted, err := promoter.PromoteOnce(ctx)
+			assert.NoError(t, err)
+
+			mu.Lock()
+			total += promoted
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, jobs, total)
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(jobs), queueLen)
+}
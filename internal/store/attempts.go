@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// FinishAttempt closes a job's running attempt with how it ended, recording
+// its latency. The attempt is matched by number so that a worker finishing
+// late cannot close the attempt of the worker that took the job over. An
+// attempt that is no longer running is left alone.
+func (ps *PostgresStore) FinishAttempt(ctx context.Context, id uuid.UUID, attempt int, end AttemptEnd) error {
+	query := `
+		UPDATE job_attempts
+		SET outcome = $1, finished_at = NOW(),
+		  latency_ms = (EXTRACT(EPOCH FROM NOW() - started_at) * 1000)::BIGINT,
+		  error = $2, error_code = $3, error_details = $4
+		WHERE job_id = $5 AND attempt = $6 AND outcome = $7
+	`
+
+	_, err := ps.db.ExecContext(ctx, query,
+		end.Outcome, nullString(end.Error.Message), nullString(end.Error.Code), nullString(end.Error.Details),
+		id, attempt, AttemptRunning,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListJobAttempts returns every attempt of a job, oldest first.
+func (ps *PostgresStore) ListJobAttempts(ctx context.Context, id uuid.UUID) ([]*JobAttempt, error) {
+	query := `
+		SELECT id, job_id, attempt, worker_id, outcome, started_at, finished_at,
+		  latency_ms, error, error_code, error_details, response_status
+		FROM job_attempts
+		WHERE job_id = $1
+		ORDER BY id
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*JobAttempt
+	for rows.Next() {
+		a := &JobAttempt{}
+		if err := rows.Scan(
+			&a.ID,
+			&a.JobID,
+			&a.Attempt,
+			&a.WorkerID,
+			&a.Outcome,
+			&a.StartedAt,
+			&a.FinishedAt,
+			&a.LatencyMs,
+			&a.Error,
+			&a.ErrorCode,
+			&a.ErrorDetails,
+			&a.ResponseStatus,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan job attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list job attempts: %w", err)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_FinishAttempt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectExec(`UPDATE job_attempts SET outcome = \$1, finished_at = NOW\(\), (.+) WHERE job_id = \$5 AND attempt = \$6 AND outcome = \$7`).
+		WithArgs(AttemptFailed, "webhook returned status 503", "http_503", nil, jobID, 2, AttemptRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.FinishAttempt(ctx, jobID, 2, AttemptEnd{
+		Outcome: AttemptFailed,
+		Error:   JobError{Message: "webhook returned status 503", Code: "http_503"},
+	})
+	require.NoError(t, err)
+
+	// A successful attempt records no error
+	mock.ExpectExec("UPDATE job_attempts SET outcome").
+		WithArgs(AttemptSucceeded, nil, nil, nil, jobID, 3, AttemptRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.FinishAttempt(ctx, jobID, 3, AttemptEnd{Outcome: AttemptSucceeded})
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ListJobAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM job_attempts WHERE job_id = \\$1 ORDER BY id").
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "job_id", "attempt", "worker_id", "outcome", "started_at", "finished_at",
+			"latency_ms", "error", "error_code", "error_details", "response_status",
+		}).
+			AddRow(1, jobID, 1, "worker-a", AttemptFailed, now.Add(-time.Minute), now.Add(-50*time.Second),
+				10000, "webhook returned status 503", "http_503", nil, 503).
+			AddRow(2, jobID, 2, "worker-b", AttemptRunning, now, nil, nil, nil, nil, nil, nil))
+
+	attempts, err := store.ListJobAttempts(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+
+	assert.Equal(t, "worker-a", attempts[0].WorkerID)
+	assert.Equal(t, AttemptFailed, attempts[0].Outcome)
+	require.NotNil(t, attempts[0].LatencyMs)
+	assert.Equal(t, int64(10000), *attempts[0].LatencyMs)
+	require.NotNil(t, attempts[0].ResponseStatus)
+	assert.Equal(t, 503, *attempts[0].ResponseStatus)
+
+	assert.Equal(t, 2, attempts[1].Attempt)
+	assert.Nil(t, attempts[1].FinishedAt)
+	assert.Nil(t, attempts[1].Error)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
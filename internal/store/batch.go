@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateJobsWithOutbox is CreateJobWithOutbox and CreateIdempotentJob for
+// many jobs at once: the jobs, then their outbox rows, are written with one
+// multi-row statement each, all in a single transaction.
+//
+// Jobs whose IdempotencyKey is held by a job created less than retention ago,
+// or by an earlier job of the batch, are not written. The holders of their
+// keys are returned keyed by idempotency key, and check, if not nil, is called
+// with them before committing; an error from check rolls the whole batch back
+// and is returned as is. The caller decides whether each skipped job matches
+// its key's holder. The outbox entries of the written jobs are returned in the
+// order of jobs.
+func (ps *PostgresStore) CreateJobsWithOutbox(ctx context.Context, jobs []*Job, retention time.Duration, check func(existing map[string]*Job) error) ([]*OutboxEntry, map[string]*Job, error) {
+	if len(jobs) == 0 {
+		return nil, nil, nil
+	}
+
+	var keys []any
+	for _, job := range jobs {
+		if job.IdempotencyKey != nil {
+			keys = append(keys, *job.IdempotencyKey)
+		}
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(keys) > 0 {
+		args, in := appendPlaceholders([]any{retention.Seconds()}, keys...)
+		_, err = tx.ExecContext(ctx, `
+			UPDATE jobs
+			SET idempotency_key = NULL
+			WHERE idempotency_key IN (`+in+`) AND created_at < NOW() - make_interval(secs => $1)
+		`, args...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to release expired idempotency keys: %w", err)
+		}
+	}
+
+	var args []any
+	rows := make([]string, len(jobs))
+	for i, job := range jobs {
+		var row string
+		args, row = appendPlaceholders(args, insertJobArgs(job)...)
+		rows[i] = "(" + row + ")"
+	}
+
+	// A job whose key is taken, including by an earlier job of the batch, is skipped
+	inserted, err := queryIDs(ctx, tx, insertJobPrefix+strings.Join(rows, ", ")+`
+		ON CONFLICT (idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+		RETURNING id
+	`, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create jobs: %w", err)
+	}
+
+	existing := make(map[string]*Job)
+	var skipped []any
+	for _, job := range jobs {
+		if _, ok := inserted[job.ID]; !ok && job.IdempotencyKey != nil {
+			skipped = append(skipped, *job.IdempotencyKey)
+		}
+	}
+	if len(skipped) > 0 {
+		args, in := appendPlaceholders(nil, skipped...)
+		holders, err := tx.QueryContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE idempotency_key IN (`+in+`)`, args...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get jobs by idempotency key: %w", err)
+		}
+		for holders.Next() {
+			job, err := scanJob(holders)
+			if err != nil {
+				holders.Close()
+				return nil, nil, fmt.Errorf("failed to scan job: %w", err)
+			}
+			existing[*job.IdempotencyKey] = job
+		}
+		holders.Close()
+		if err := holders.Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to get jobs by idempotency key: %w", err)
+		}
+	}
+
+	if check != nil {
+		if err := check(existing); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entries, err := ps.handOffInserted(ctx, tx, jobs, inserted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit jobs: %w", err)
+	}
+
+	return entries, existing, nil
+}
+
+// handOffInserted writes the hand-offs of the jobs that were inserted, in one
+// statement, and returns their entries in the order of jobs.
+func (ps *PostgresStore) handOffInserted(ctx context.Context, tx *sql.Tx, jobs []*Job, inserted map[uuid.UUID]struct{}) ([]*OutboxEntry, error) {
+	var entries []*OutboxEntry
+	for _, job := range jobs {
+		if _, ok := inserted[job.ID]; ok {
+			entries = append(entries, &OutboxEntry{JobID: job.ID, JobType: job.Type, RunAt: job.NextRunAt, Priority: job.Priority})
+		}
+	}
+	if err := ps.handOff(ctx, tx, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// MarkOutboxSentBatch is MarkOutboxSent for many entries at once.
+func (ps *PostgresStore) MarkOutboxSentBatch(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	args, in := appendPlaceholders(nil, values...)
+
+	_, err := ps.db.ExecContext(ctx, `
+		UPDATE outbox
+		SET sent_at = NOW()
+		WHERE id IN (`+in+`) AND sent_at IS NULL
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entries as sent: %w", err)
+	}
+
+	return nil
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryIDs runs a query returning a single UUID column and collects the IDs.
+func queryIDs(ctx context.Context, q queryer, query string, args ...any) (map[uuid.UUID]struct{}, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[uuid.UUID]struct{})
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+	}
+	return ids, rows.Err()
+}
+
+// appendPlaceholders appends values to args and returns the extended list
+// with the values' placeholders, comma separated.
+func appendPlaceholders(args []any, values ...any) ([]any, string) {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		args = append(args, v)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	return args, strings.Join(placeholders, ", ")
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendPlaceholders(t *testing.T) {
+	args, in := appendPlaceholders([]any{"first"}, "a", "b")
+	assert.Equal(t, "$2, $3", in)
+	assert.Equal(t, []any{"first", "a", "b"}, args)
+}
+
+func TestPostgresStore_CreateJobsWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	now := time.Now()
+	key := "order-42"
+
+	fresh := &Job{ID: uuid.New(), Type: "job.standard", Payload: []byte("a"), Status: JobStatusQueued}
+	duplicate := &Job{ID: uuid.New(), Type: "job.standard", Payload: []byte("b"), Status: JobStatusQueued, IdempotencyKey: &key}
+	holderID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE jobs SET idempotency_key = NULL WHERE idempotency_key IN \(\$2\)`).
+		WithArgs(float64(86400), key).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO jobs (.+) VALUES \(\$1, (.+), \$12\), \(\$13, (.+), \$24\) ON CONFLICT`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(fresh.ID))
+	mock.ExpectQuery(`SELECT (.+) FROM jobs WHERE idempotency_key IN \(\$1\)`).
+		WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "type", "payload", "status", "created_at", "started_at", "completed_at", "attempts", "last_error",
+			"response_status", "response_latency_ms", "response_body",
+			"max_attempts", "retry_base_delay_ms", "retry_max_delay_ms", "retry_jitter", "next_run_at",
+			"dead_at", "priority", "idempotency_key", "cancel_requested_at", "tags", "last_heartbeat_at",
+			"progress_percent", "progress_message", "progress_updated_at", "checkpoint",
+			"result", "error_code", "error_details", "attempts_before_replay",
+		}).AddRow(holderID, "job.standard", []byte("b"), JobStatusQueued, now, nil, nil, 0, nil,
+			nil, nil, nil, 3, 1000, 60000, "full", nil, nil, 0, key, nil, []byte("{}"), nil, nil, nil, nil, nil, nil, nil, nil, 0))
+	mock.ExpectQuery(`INSERT INTO outbox (.+) VALUES \(\$1, \$2, \$3, \$4\) RETURNING`).
+		WithArgs(fresh.ID, "job.standard", nil, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "created_at", "available_at"}).AddRow(7, fresh.ID, now, now))
+	mock.ExpectCommit()
+
+	entries, existing, err := store.CreateJobsWithOutbox(ctx, []*Job{fresh, duplicate}, 24*time.Hour, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(7), entries[0].ID)
+	assert.Equal(t, fresh.ID, entries[0].JobID)
+	require.Contains(t, existing, key)
+	assert.Equal(t, holderID, existing[key].ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CreateJobsWithOutbox_CheckRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	job := &Job{ID: uuid.New(), Type: "job.standard", Payload: []byte("a"), Status: JobStatusQueued}
+	rejected := errors.New("rejected")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(job.ID))
+	mock.ExpectRollback()
+
+	_, _, err = store.CreateJobsWithOutbox(ctx, []*Job{job}, time.Hour, func(map[string]*Job) error {
+		return rejected
+	})
+	assert.ErrorIs(t, err, rejected)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_MarkOutboxSentBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+
+	mock.ExpectExec(`UPDATE outbox SET sent_at = NOW\(\) WHERE id IN \(\$1, \$2\)`).
+		WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, store.MarkOutboxSentBatch(context.Background(), []int64{1, 2}))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
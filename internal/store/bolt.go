@@ -0,0 +1,1179 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/turnertastic1/boltq/internal/events"
+)
+
+// Buckets of a BoltStore. Failures and attempts are keyed by job ID followed
+// by their own ID, so a job's history is one prefix scan.
+var (
+	jobsBucket            = []byte("jobs")
+	idempotencyKeysBucket = []byte("idempotency_keys")
+	outboxBucket          = []byte("outbox")
+	failuresBucket        = []byte("job_failures")
+	attemptsBucket        = []byte("job_attempts")
+)
+
+// BoltStore is a JobStore kept in a bbolt file, for the embedded single-binary
+// mode of queue-svc. It behaves like MemoryStore, with every method one bbolt
+// transaction, but survives restarts. Listing, dead-letter and reconciliation
+// methods scan every stored job, so it suits local development and edge
+// deployments rather than large job histories.
+type BoltStore struct {
+	db *bolt.DB
+
+	mu       sync.Mutex
+	relaying map[int64]bool // outbox entries being published by RelayOutbox
+
+	publish func(events.Event) // set by PublishEvents
+}
+
+var _ JobStore = (*BoltStore)(nil)
+
+// NewBoltStore creates the store's buckets in db if they do not exist yet.
+// Closing the store closes db.
+func NewBoltStore(db *bolt.DB) (*BoltStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{jobsBucket, idempotencyKeysBucket, outboxBucket, failuresBucket, attemptsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+
+	return &BoltStore{db: db, relaying: make(map[int64]bool)}, nil
+}
+
+// PublishEvents has the store pass publish the event of every job it creates,
+// moves to another status or records progress for, once the change commits.
+// These are the events the triggers on the Postgres jobs table publish, so
+// watches work in embedded mode. Call it before using the store.
+func (bs *BoltStore) PublishEvents(publish func(events.Event)) {
+	bs.publish = publish
+}
+
+func (bs *BoltStore) Close() error {
+	return bs.db.Close()
+}
+
+// idKey encodes a sequence ID so keys sort in ID order.
+func idKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// historyKey is the key of a failure or attempt: its job's ID, then its own.
+func historyKey(jobID uuid.UUID, id int64) []byte {
+	return append(slices.Clone(jobID[:]), idKey(uint64(id))...)
+}
+
+func putJSON(b *bolt.Bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// getJob reads a stored job, or returns nil if there is none.
+func getJob(tx *bolt.Tx, id uuid.UUID) (*Job, error) {
+	data := tx.Bucket(jobsBucket).Get(id[:])
+	if data == nil {
+		return nil, nil
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+// putJob saves a job, publishing its event once tx commits if the change
+// warrants one.
+func (bs *BoltStore) putJob(tx *bolt.Tx, job *Job) error {
+	if bs.publish != nil {
+		old, err := getJob(tx, job.ID)
+		if err != nil {
+			return err
+		}
+		if ev, ok := jobEvent(old, job); ok {
+			tx.OnCommit(func() { bs.publish(ev) })
+		}
+	}
+	return putJSON(tx.Bucket(jobsBucket), job.ID[:], job)
+}
+
+// jobEvent returns the event of a job saved over old, which is nil for a new
+// job. Like the Postgres triggers, it publishes new jobs, status changes, and
+// progress reports that leave the status unchanged.
+func jobEvent(old, job *Job) (events.Event, bool) {
+	ev := events.Event{
+		JobID:  job.ID,
+		Type:   job.Type,
+		Status: job.Status,
+		Tags:   maps.Clone(job.Tags),
+		At:     time.Now().UTC(),
+	}
+
+	switch {
+	case old == nil || old.Status != job.Status:
+		return ev, true
+	case job.ProgressUpdatedAt != nil && (old.ProgressUpdatedAt == nil || !old.ProgressUpdatedAt.Equal(*job.ProgressUpdatedAt)):
+		ev.Progress = &events.Progress{UpdatedAt: job.ProgressUpdatedAt.UTC()}
+		if job.ProgressPercent != nil {
+			ev.Progress.Percent = *job.ProgressPercent
+		}
+		if job.ProgressMessage != nil {
+			ev.Progress.Message = *job.ProgressMessage
+		}
+		return ev, true
+	}
+	return events.Event{}, false
+}
+
+// updateJob applies fn to a stored job and saves it if fn reports a change.
+// It reports false for a missing job.
+func (bs *BoltStore) updateJob(tx *bolt.Tx, id uuid.UUID, fn func(job *Job) bool) (bool, error) {
+	job, err := getJob(tx, id)
+	if err != nil || job == nil {
+		return false, err
+	}
+	if !fn(job) {
+		return false, nil
+	}
+	return true, bs.putJob(tx, job)
+}
+
+// scanJobs returns the stored jobs matching keep, in no particular order.
+func scanJobs(tx *bolt.Tx, keep func(*Job) bool) ([]*Job, error) {
+	var jobs []*Job
+	err := tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+		var job Job
+		if err := json.Unmarshal(v, &job); err != nil {
+			return fmt.Errorf("failed to decode job: %w", err)
+		}
+		if keep(&job) {
+			jobs = append(jobs, &job)
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// jobsByCreation returns the stored jobs matching keep, oldest first with ties
+// broken by ID.
+func jobsByCreation(tx *bolt.Tx, keep func(*Job) bool) ([]*Job, error) {
+	jobs, err := scanJobs(tx, keep)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(jobs, func(a, b *Job) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	return jobs, nil
+}
+
+// keyHolder returns the job holding an idempotency key, or nil.
+func keyHolder(tx *bolt.Tx, key string) (*Job, error) {
+	id := tx.Bucket(idempotencyKeysBucket).Get([]byte(key))
+	if id == nil {
+		return nil, nil
+	}
+	return getJob(tx, uuid.UUID(id))
+}
+
+// releaseExpiredKey frees an idempotency key held by a job created before cutoff.
+func (bs *BoltStore) releaseExpiredKey(tx *bolt.Tx, key string, cutoff time.Time) error {
+	holder, err := keyHolder(tx, key)
+	if err != nil || holder == nil || !holder.CreatedAt.Before(cutoff) {
+		return err
+	}
+	holder.IdempotencyKey = nil
+	if err := bs.putJob(tx, holder); err != nil {
+		return err
+	}
+	return tx.Bucket(idempotencyKeysBucket).Delete([]byte(key))
+}
+
+// insertJob stores a new job.
+func (bs *BoltStore) insertJob(tx *bolt.Tx, job *Job, now time.Time) error {
+	if tx.Bucket(jobsBucket).Get(job.ID[:]) != nil {
+		return fmt.Errorf("%w: ID %s is taken", errDuplicateJob, job.ID)
+	}
+	if job.IdempotencyKey != nil {
+		key := []byte(*job.IdempotencyKey)
+		keys := tx.Bucket(idempotencyKeysBucket)
+		if keys.Get(key) != nil {
+			return fmt.Errorf("%w: idempotency key %q is taken", errDuplicateJob, *job.IdempotencyKey)
+		}
+		if err := keys.Put(key, job.ID[:]); err != nil {
+			return err
+		}
+	}
+	return bs.putJob(tx, newStoredJob(job, now))
+}
+
+// addOutboxEntry writes the outbox entry that hands a job off to the queue.
+func addOutboxEntry(tx *bolt.Tx, job *Job, runAt *time.Time, now time.Time) (*OutboxEntry, error) {
+	b := tx.Bucket(outboxBucket)
+	id, err := b.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &OutboxEntry{
+		ID:          int64(id),
+		JobID:       job.ID,
+		JobType:     job.Type,
+		CreatedAt:   now,
+		AvailableAt: now,
+		RunAt:       runAt,
+		Priority:    job.Priority,
+	}
+	return entry, putJSON(b, idKey(id), entry)
+}
+
+// addFailure records a failed attempt in a job's failure history.
+func addFailure(tx *bolt.Tx, job *Job, now time.Time) error {
+	b := tx.Bucket(failuresBucket)
+	id, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	return putJSON(b, historyKey(job.ID, int64(id)), &JobFailure{
+		ID:       int64(id),
+		JobID:    job.ID,
+		Attempt:  job.Attempts,
+		Error:    *job.LastError,
+		FailedAt: now,
+	})
+}
+
+// jobHistory returns a job's failures or attempts, oldest first.
+func jobHistory[T any](tx *bolt.Tx, bucket []byte, jobID uuid.UUID) ([]*T, error) {
+	var records []*T
+	c := tx.Bucket(bucket).Cursor()
+	for k, v := c.Seek(jobID[:]); k != nil && bytes.HasPrefix(k, jobID[:]); k, v = c.Next() {
+		var record T
+		if err := json.Unmarshal(v, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", bucket, err)
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// updateRunningAttempts applies fn to a job's running attempts and saves them.
+func updateRunningAttempts(tx *bolt.Tx, jobID uuid.UUID, fn func(a *JobAttempt)) error {
+	attempts, err := jobHistory[JobAttempt](tx, attemptsBucket, jobID)
+	if err != nil {
+		return err
+	}
+	b := tx.Bucket(attemptsBucket)
+	for _, a := range attempts {
+		if a.Outcome != AttemptRunning {
+			continue
+		}
+		fn(a)
+		if err := putJSON(b, historyKey(jobID, a.ID), a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteByPrefix deletes every key of a bucket starting with prefix.
+func deleteByPrefix(b *bolt.Bucket, prefix []byte) error {
+	var keys [][]byte
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, slices.Clone(k))
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outboxEntries returns every outbox entry matching keep, in ID order.
+func outboxEntries(tx *bolt.Tx, keep func(*OutboxEntry) bool) ([]*OutboxEntry, error) {
+	var entries []*OutboxEntry
+	err := tx.Bucket(outboxBucket).ForEach(func(k, v []byte) error {
+		var entry OutboxEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return fmt.Errorf("failed to decode outbox entry: %w", err)
+		}
+		if keep(&entry) {
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func putOutboxEntry(tx *bolt.Tx, entry *OutboxEntry) error {
+	return putJSON(tx.Bucket(outboxBucket), idKey(uint64(entry.ID)), entry)
+}
+
+func (bs *BoltStore) CreateJob(ctx context.Context, job *Job) error {
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		return bs.insertJob(tx, job, time.Now())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+func (bs *BoltStore) GetJobByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	var job *Job
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		job, err = getJob(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return job, nil
+}
+
+func (bs *BoltStore) ListJobs(ctx context.Context, filter JobFilter, opts ListJobsOptions) ([]*Job, error) {
+	var jobs []*Job
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		jobs, err = jobsByCreation(tx, filter.matches)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	if !opts.OldestFirst {
+		slices.Reverse(jobs)
+	}
+
+	var page []*Job
+	for _, job := range jobs {
+		if len(page) == opts.Limit {
+			break
+		}
+		if opts.After != nil {
+			c := job.CreatedAt.Compare(opts.After.CreatedAt)
+			if c == 0 {
+				c = bytes.Compare(job.ID[:], opts.After.ID[:])
+			}
+			if (opts.OldestFirst && c <= 0) || (!opts.OldestFirst && c >= 0) {
+				continue
+			}
+		}
+		if !opts.IncludePayload {
+			job.Payload, job.Checkpoint, job.Result = nil, nil, nil
+		}
+		page = append(page, job)
+	}
+
+	return page, nil
+}
+
+func (bs *BoltStore) CreateJobWithOutbox(ctx context.Context, job *Job) (*OutboxEntry, error) {
+	var entry *OutboxEntry
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		if err := bs.insertJob(tx, job, now); err != nil {
+			return err
+		}
+		var err error
+		entry, err = addOutboxEntry(tx, job, job.NextRunAt, now)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return entry, nil
+}
+
+func (bs *BoltStore) CreateIdempotentJob(ctx context.Context, job *Job, retention time.Duration) (*Job, *OutboxEntry, error) {
+	if job.IdempotencyKey == nil {
+		return nil, nil, errors.New("job has no idempotency key")
+	}
+
+	var existing *Job
+	var entry *OutboxEntry
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		if err := bs.releaseExpiredKey(tx, *job.IdempotencyKey, now.Add(-retention)); err != nil {
+			return err
+		}
+		holder, err := keyHolder(tx, *job.IdempotencyKey)
+		if err != nil || holder != nil {
+			existing = holder
+			return err
+		}
+
+		if err := bs.insertJob(tx, job, now); err != nil {
+			return err
+		}
+		entry, err = addOutboxEntry(tx, job, job.NextRunAt, now)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return existing, entry, nil
+}
+
+func (bs *BoltStore) CreateJobsWithOutbox(ctx context.Context, jobs []*Job, retention time.Duration, check func(existing map[string]*Job) error) ([]*OutboxEntry, map[string]*Job, error) {
+	if len(jobs) == 0 {
+		return nil, nil, nil
+	}
+
+	// check's error is returned as is; the rest are wrapped
+	var checkErr error
+	var entries []*OutboxEntry
+	existing := make(map[string]*Job)
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		cutoff := now.Add(-retention)
+
+		// Work out the whole batch before changing anything, so check can
+		// still reject it
+		holders := make(map[string]*Job)
+		for _, job := range jobs {
+			if job.IdempotencyKey == nil {
+				continue
+			}
+			key := *job.IdempotencyKey
+			holder, err := keyHolder(tx, key)
+			if err != nil {
+				return err
+			}
+			if holder != nil && !holder.CreatedAt.Before(cutoff) {
+				holders[key] = holder
+			}
+		}
+
+		var inserted []*Job
+		batchIDs := make(map[uuid.UUID]bool, len(jobs))
+		for _, job := range jobs {
+			if tx.Bucket(jobsBucket).Get(job.ID[:]) != nil || batchIDs[job.ID] {
+				return fmt.Errorf("%w: ID %s is taken", errDuplicateJob, job.ID)
+			}
+			if job.IdempotencyKey != nil {
+				key := *job.IdempotencyKey
+				if holder, ok := holders[key]; ok {
+					existing[key] = cloneJob(holder)
+					continue
+				}
+				holders[key] = newStoredJob(job, now)
+			}
+			batchIDs[job.ID] = true
+			inserted = append(inserted, job)
+		}
+
+		if check != nil {
+			if checkErr = check(existing); checkErr != nil {
+				return checkErr
+			}
+		}
+
+		for _, job := range jobs {
+			if job.IdempotencyKey != nil {
+				if err := bs.releaseExpiredKey(tx, *job.IdempotencyKey, cutoff); err != nil {
+					return err
+				}
+			}
+		}
+		for _, job := range inserted {
+			if err := bs.insertJob(tx, job, now); err != nil {
+				return err
+			}
+			entry, err := addOutboxEntry(tx, job, job.NextRunAt, now)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if checkErr != nil {
+		return nil, nil, checkErr
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create jobs: %w", err)
+	}
+	return entries, existing, nil
+}
+
+// markSent marks an outbox entry sent.
+func markSent(tx *bolt.Tx, id int64, now time.Time) error {
+	data := tx.Bucket(outboxBucket).Get(idKey(uint64(id)))
+	if data == nil {
+		return nil
+	}
+	var entry OutboxEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return fmt.Errorf("failed to decode outbox entry: %w", err)
+	}
+	if entry.SentAt != nil {
+		return nil
+	}
+	entry.SentAt = timePtr(now)
+	return putOutboxEntry(tx, &entry)
+}
+
+func (bs *BoltStore) MarkOutboxSent(ctx context.Context, id int64) error {
+	return bs.MarkOutboxSentBatch(ctx, []int64{id})
+}
+
+func (bs *BoltStore) MarkOutboxSentBatch(ctx context.Context, ids []int64) error {
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		for _, id := range ids {
+			if err := markSent(tx, id, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entries sent: %w", err)
+	}
+	return nil
+}
+
+// RelayOutbox is PostgresStore.RelayOutbox. Entries being published are
+// skipped by concurrent calls, as locked rows are; no transaction is open
+// while publish runs.
+func (bs *BoltStore) RelayOutbox(ctx context.Context, limit int, minAge time.Duration, publish func(context.Context, *OutboxEntry) error) (int, error) {
+	bs.mu.Lock()
+	var batch []*OutboxEntry
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		cutoff := time.Now().Add(-minAge)
+		batch, err = outboxEntries(tx, func(entry *OutboxEntry) bool {
+			return entry.SentAt == nil && !entry.AvailableAt.After(cutoff) && !bs.relaying[entry.ID]
+		})
+		return err
+	})
+	if err != nil {
+		bs.mu.Unlock()
+		return 0, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	slices.SortStableFunc(batch, func(a, b *OutboxEntry) int {
+		return a.AvailableAt.Compare(b.AvailableAt)
+	})
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	for _, entry := range batch {
+		bs.relaying[entry.ID] = true
+	}
+	bs.mu.Unlock()
+
+	defer func() {
+		bs.mu.Lock()
+		defer bs.mu.Unlock()
+		for _, entry := range batch {
+			delete(bs.relaying, entry.ID)
+		}
+	}()
+
+	errs := make([]error, len(batch))
+	for i, entry := range batch {
+		c := *entry
+		errs[i] = publish(ctx, &c)
+	}
+
+	sent := 0
+	err = bs.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		sent = 0
+		for i, entry := range batch {
+			entry.Attempts++
+			if errs[i] != nil {
+				entry.LastError = stringPtr(errs[i].Error())
+			} else {
+				sent++
+				entry.SentAt = timePtr(now)
+				entry.LastError = nil
+			}
+			if err := putOutboxEntry(tx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update outbox: %w", err)
+	}
+
+	return sent, nil
+}
+
+func (bs *BoltStore) DeleteSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	var deleted int64
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		cutoff := time.Now().Add(-olderThan)
+		entries, err := outboxEntries(tx, func(entry *OutboxEntry) bool {
+			return entry.SentAt != nil && entry.SentAt.Before(cutoff)
+		})
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := tx.Bucket(outboxBucket).Delete(idKey(uint64(entry.ID))); err != nil {
+				return err
+			}
+		}
+		deleted = int64(len(entries))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox entries: %w", err)
+	}
+	return deleted, nil
+}
+
+func (bs *BoltStore) CountPendingOutbox(ctx context.Context) (int64, error) {
+	var pending []*OutboxEntry
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		pending, err = outboxEntries(tx, func(entry *OutboxEntry) bool { return entry.SentAt == nil })
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending outbox entries: %w", err)
+	}
+	return int64(len(pending)), nil
+}
+
+func (bs *BoltStore) PromoteScheduledJobs(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	var promoted int64
+	err := bs.db.Update(func(tx *bolt.Tx) error {
+		promoted = 0
+		for _, id := range ids {
+			ok, err := bs.updateJob(tx, id, func(job *Job) bool {
+				if job.Status != JobStatusScheduled {
+					return false
+				}
+				job.Status = JobStatusQueued
+				job.NextRunAt = nil
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			if ok {
+				promoted++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote scheduled jobs: %w", err)
+	}
+	return promoted, nil
+}
+
+// update runs fn in a read-write transaction, wrapping its error with what
+// failed.
+func (bs *BoltStore) update(failure string, fn func(tx *bolt.Tx) error) error {
+	if err := bs.db.Update(fn); err != nil {
+		return fmt.Errorf("failed to %s: %w", failure, err)
+	}
+	return nil
+}
+
+// updateOne applies fn to a stored job in its own transaction, like updateJob.
+func (bs *BoltStore) updateOne(failure string, id uuid.UUID, fn func(job *Job) bool) (bool, error) {
+	var ok bool
+	err := bs.update(failure, func(tx *bolt.Tx) (err error) {
+		ok, err = bs.updateJob(tx, id, fn)
+		return err
+	})
+	return ok, err
+}
+
+func (bs *BoltStore) MarkJobAsQueued(ctx context.Context, id uuid.UUID, attempt int) (bool, error) {
+	return bs.updateOne("mark job as queued", id, func(job *Job) bool {
+		if !inAttempt(job, JobStatusProcessing, attempt) {
+			return false
+		}
+		job.Status = JobStatusQueued
+		job.StartedAt, job.CompletedAt = nil, nil
+		return true
+	})
+}
+
+func (bs *BoltStore) MarkJobAsProcessing(ctx context.Context, id uuid.UUID) error {
+	_, err := bs.updateOne("mark job as processing", id, func(job *Job) bool {
+		now := time.Now()
+		job.Status = JobStatusProcessing
+		job.StartedAt, job.LastHeartbeatAt = timePtr(now), timePtr(now)
+		job.Attempts++
+		return true
+	})
+	return err
+}
+
+func (bs *BoltStore) ClaimJob(ctx context.Context, id uuid.UUID, workerID string, staleAfter time.Duration) (bool, error) {
+	var claimed bool
+	err := bs.update("claim job", func(tx *bolt.Tx) error {
+		now := time.Now()
+		var attempt int
+		var err error
+		claimed, err = bs.updateJob(tx, id, func(job *Job) bool {
+			if !claimable(job, now, staleAfter) {
+				return false
+			}
+			startClaimedJob(job, now)
+			attempt = job.Attempts
+			return true
+		})
+		if err != nil || !claimed {
+			return err
+		}
+
+		err = updateRunningAttempts(tx, id, func(a *JobAttempt) {
+			a.Outcome = AttemptAbandoned
+			a.FinishedAt = timePtr(now)
+		})
+		if err != nil {
+			return err
+		}
+
+		b := tx.Bucket(attemptsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return putJSON(b, historyKey(id, int64(seq)), &JobAttempt{
+			ID:        int64(seq),
+			JobID:     id,
+			Attempt:   attempt,
+			WorkerID:  workerID,
+			Outcome:   AttemptRunning,
+			StartedAt: now,
+		})
+	})
+	return claimed, err
+}
+
+func (bs *BoltStore) Heartbeat(ctx context.Context, id uuid.UUID, attempt int) (held, cancelRequested bool, err error) {
+	held, err = bs.updateOne("record heartbeat", id, func(job *Job) bool {
+		if !inAttempt(job, JobStatusProcessing, attempt) {
+			return false
+		}
+		job.LastHeartbeatAt = timePtr(time.Now())
+		cancelRequested = job.CancelRequestedAt != nil
+		return true
+	})
+	return held, held && cancelRequested, err
+}
+
+func (bs *BoltStore) UpdateProgress(ctx context.Context, id uuid.UUID, attempt int, percent int, message string, checkpoint []byte) (held, cancelRequested bool, err error) {
+	held, err = bs.updateOne("update progress", id, func(job *Job) bool {
+		if !inAttempt(job, JobStatusProcessing, attempt) {
+			return false
+		}
+
+		now := time.Now()
+		job.ProgressPercent = &percent
+		job.ProgressMessage = stringPtr(message)
+		job.ProgressUpdatedAt = timePtr(now)
+		if checkpoint != nil {
+			job.Checkpoint = bytes.Clone(checkpoint)
+		}
+		job.LastHeartbeatAt = timePtr(now)
+
+		cancelRequested = job.CancelRequestedAt != nil
+		return true
+	})
+	return held, held && cancelRequested, err
+}
+
+func (bs *BoltStore) RecordWebhookResponse(ctx context.Context, id uuid.UUID, statusCode int, latency time.Duration, body []byte) error {
+	return bs.update("record webhook response", func(tx *bolt.Tx) error {
+		err := updateRunningAttempts(tx, id, func(a *JobAttempt) {
+			a.ResponseStatus = &statusCode
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = bs.updateJob(tx, id, func(job *Job) bool {
+			latencyMs := int(latency.Milliseconds())
+			job.ResponseStatus = &statusCode
+			job.ResponseLatencyMs = &latencyMs
+			job.ResponseBody = bytes.Clone(body)
+			return true
+		})
+		return err
+	})
+}
+
+func (bs *BoltStore) FinishAttempt(ctx context.Context, id uuid.UUID, attempt int, end AttemptEnd) error {
+	return bs.update("finish attempt", func(tx *bolt.Tx) error {
+		now := time.Now()
+		attempts, err := jobHistory[JobAttempt](tx, attemptsBucket, id)
+		if err != nil {
+			return err
+		}
+		for _, a := range attempts {
+			if a.Attempt != attempt || a.Outcome != AttemptRunning {
+				continue
+			}
+			latencyMs := now.Sub(a.StartedAt).Milliseconds()
+			a.Outcome = end.Outcome
+			a.FinishedAt = timePtr(now)
+			a.LatencyMs = &latencyMs
+			a.Error = optionalString(end.Error.Message)
+			a.ErrorCode = optionalString(end.Error.Code)
+			a.ErrorDetails = optionalString(end.Error.Details)
+			if err := putJSON(tx.Bucket(attemptsBucket), historyKey(id, a.ID), a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BoltStore) ListJobAttempts(ctx context.Context, id uuid.UUID) ([]*JobAttempt, error) {
+	var attempts []*JobAttempt
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		attempts, err = jobHistory[JobAttempt](tx, attemptsBucket, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+func (bs *BoltStore) MarkJobAsCompleted(ctx context.Context, id uuid.UUID, attempt int, result []byte) (bool, error) {
+	return bs.updateOne("mark job as completed", id, func(job *Job) bool {
+		if !inAttempt(job, JobStatusProcessing, attempt) {
+			return false
+		}
+		job.Status = JobStatusCompleted
+		job.CompletedAt = timePtr(time.Now())
+		job.Result = bytes.Clone(result)
+		return true
+	})
+}
+
+func (bs *BoltStore) MarkJobAsFailed(ctx context.Context, id uuid.UUID, jobErr JobError) error {
+	_, err := bs.updateOne("mark job as failed", id, func(job *Job) bool {
+		job.Status = JobStatusFailed
+		job.CompletedAt = timePtr(time.Now())
+		setError(job, jobErr)
+		return true
+	})
+	return err
+}
+
+// finishWithFailure moves a job in fromStatus at attempt to status with
+// jobErr as its last error and records the failure, reporting false if the
+// job had moved on.
+func (bs *BoltStore) finishWithFailure(failure string, id uuid.UUID, fromStatus string, attempt int, status string, jobErr JobError) (bool, error) {
+	var moved bool
+	err := bs.update(failure, func(tx *bolt.Tx) error {
+		now := time.Now()
+		var job *Job
+		var err error
+		moved, err = bs.updateJob(tx, id, func(j *Job) bool {
+			if !inAttempt(j, fromStatus, attempt) {
+				return false
+			}
+			j.Status = status
+			j.CompletedAt = timePtr(now)
+			if status == JobStatusDead {
+				j.DeadAt = timePtr(now)
+			}
+			setError(j, jobErr)
+			job = j
+			return true
+		})
+		if err != nil || !moved {
+			return err
+		}
+		return addFailure(tx, job, now)
+	})
+	return moved, err
+}
+
+func (bs *BoltStore) FailJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error) {
+	return bs.finishWithFailure("fail job", id, fromStatus, attempt, JobStatusFailed, jobErr)
+}
+
+func (bs *BoltStore) ScheduleRetry(ctx context.Context, id uuid.UUID, attempt int, jobErr JobError, delay time.Duration) (*OutboxEntry, error) {
+	var entry *OutboxEntry
+	err := bs.update("schedule retry", func(tx *bolt.Tx) error {
+		now := time.Now()
+		var job *Job
+		ok, err := bs.updateJob(tx, id, func(j *Job) bool {
+			if !inAttempt(j, JobStatusProcessing, attempt) {
+				return false
+			}
+			j.Status = JobStatusRetrying
+			j.NextRunAt = timePtr(now.Add(delay))
+			setError(j, jobErr)
+			job = j
+			return true
+		})
+		if err != nil || !ok {
+			return err
+		}
+		if err := addFailure(tx, job, now); err != nil {
+			return err
+		}
+		entry, err = addOutboxEntry(tx, job, job.NextRunAt, now)
+		return err
+	})
+	return entry, err
+}
+
+func (bs *BoltStore) CancelJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	var before *Job
+	ok, err := bs.updateOne("cancel job", id, func(job *Job) bool {
+		before = cancelJob(job, time.Now())
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return before, nil
+}
+
+func (bs *BoltStore) MarkJobAsCancelled(ctx context.Context, id uuid.UUID, attempt int) (bool, error) {
+	return bs.updateOne("mark job as cancelled", id, func(job *Job) bool {
+		if !inAttempt(job, JobStatusProcessing, attempt) {
+			return false
+		}
+		job.Status = JobStatusCancelled
+		job.CompletedAt = timePtr(time.Now())
+		return true
+	})
+}
+
+func (bs *BoltStore) DeadLetterJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error) {
+	return bs.finishWithFailure("dead-letter job", id, fromStatus, attempt, JobStatusDead, jobErr)
+}
+
+// deadJobs returns the dead jobs matching filter, longest dead first.
+func deadJobs(tx *bolt.Tx, filter DeadJobFilter) ([]*Job, error) {
+	jobs, err := scanJobs(tx, filter.matches)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(jobs, compareDeath)
+	return jobs, nil
+}
+
+func (bs *BoltStore) ListDeadJobs(ctx context.Context, filter DeadJobFilter, after *DeadJobCursor, limit int) ([]*Job, error) {
+	var jobs []*Job
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		jobs, err = deadJobs(tx, filter)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+	slices.Reverse(jobs)
+
+	var page []*Job
+	for _, job := range jobs {
+		if len(page) == limit {
+			break
+		}
+		if after != nil && compareDeath(job, &Job{ID: after.ID, DeadAt: &after.DeadAt}) >= 0 {
+			continue
+		}
+		page = append(page, job)
+	}
+	return page, nil
+}
+
+func (bs *BoltStore) ListJobFailures(ctx context.Context, jobID uuid.UUID) ([]*JobFailure, error) {
+	var failures []*JobFailure
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		failures, err = jobHistory[JobFailure](tx, failuresBucket, jobID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job failures: %w", err)
+	}
+	return failures, nil
+}
+
+func (bs *BoltStore) ReplayDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*OutboxEntry, error) {
+	var entries []*OutboxEntry
+	err := bs.update("replay dead jobs", func(tx *bolt.Tx) error {
+		jobs, err := deadJobs(tx, filter)
+		if err != nil {
+			return err
+		}
+		if len(jobs) > limit {
+			jobs = jobs[:limit]
+		}
+
+		now := time.Now()
+		for _, job := range jobs {
+			resetDeadJob(job)
+			if err := bs.putJob(tx, job); err != nil {
+				return err
+			}
+			entry, err := addOutboxEntry(tx, job, nil, now)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (bs *BoltStore) PurgeDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*Job, error) {
+	var purged []*Job
+	err := bs.update("purge dead jobs", func(tx *bolt.Tx) error {
+		jobs, err := deadJobs(tx, filter)
+		if err != nil {
+			return err
+		}
+		if len(jobs) > limit {
+			jobs = jobs[:limit]
+		}
+
+		// History and outbox entries go with the jobs, as the foreign keys of
+		// their tables cascade
+		deleted := make(map[uuid.UUID]bool, len(jobs))
+		for _, job := range jobs {
+			if err := tx.Bucket(jobsBucket).Delete(job.ID[:]); err != nil {
+				return err
+			}
+			if job.IdempotencyKey != nil {
+				if err := tx.Bucket(idempotencyKeysBucket).Delete([]byte(*job.IdempotencyKey)); err != nil {
+					return err
+				}
+			}
+			for _, bucket := range [][]byte{failuresBucket, attemptsBucket} {
+				if err := deleteByPrefix(tx.Bucket(bucket), job.ID[:]); err != nil {
+					return err
+				}
+			}
+			deleted[job.ID] = true
+			purged = append(purged, &Job{ID: job.ID, Type: job.Type})
+		}
+
+		orphans, err := outboxEntries(tx, func(entry *OutboxEntry) bool { return deleted[entry.JobID] })
+		if err != nil {
+			return err
+		}
+		for _, entry := range orphans {
+			if err := tx.Bucket(outboxBucket).Delete(idKey(uint64(entry.ID))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+func (bs *BoltStore) ListStaleJobs(ctx context.Context, olderThan time.Duration, limit int) ([]*Job, error) {
+	var jobs []*Job
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		pending := make(map[uuid.UUID]bool)
+		_, err := outboxEntries(tx, func(entry *OutboxEntry) bool {
+			if entry.SentAt == nil {
+				pending[entry.JobID] = true
+			}
+			return false
+		})
+		if err != nil {
+			return err
+		}
+
+		cutoff := time.Now().Add(-olderThan)
+		jobs, err = jobsByCreation(tx, func(job *Job) bool {
+			return !pending[job.ID] && isStale(job, cutoff)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale jobs: %w", err)
+	}
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	stale := make([]*Job, len(jobs))
+	for i, job := range jobs {
+		stale[i] = staleSummary(job)
+	}
+	return stale, nil
+}
+
+func (bs *BoltStore) ListAbandonedJobs(ctx context.Context, timeout time.Duration, limit int) ([]*Job, error) {
+	var jobs []*Job
+	err := bs.db.View(func(tx *bolt.Tx) (err error) {
+		cutoff := time.Now().Add(-timeout)
+		jobs, err = scanJobs(tx, func(job *Job) bool {
+			return job.Status == JobStatusProcessing && job.LastHeartbeatAt != nil && job.LastHeartbeatAt.Before(cutoff)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list abandoned jobs: %w", err)
+	}
+	slices.SortFunc(jobs, func(a, b *Job) int {
+		return a.LastHeartbeatAt.Compare(*b.LastHeartbeatAt)
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	for _, job := range jobs {
+		job.Payload, job.Checkpoint, job.Result = nil, nil, nil
+	}
+	return jobs, nil
+}
+
+func (bs *BoltStore) RequeueJob(ctx context.Context, id uuid.UUID, fromStatus, reason string) (bool, error) {
+	return bs.updateOne("requeue job", id, func(job *Job) bool {
+		if job.Status != fromStatus {
+			return false
+		}
+		job.Status = JobStatusQueued
+		job.StartedAt, job.CompletedAt = nil, nil
+		job.LastError = stringPtr(reason)
+		return true
+	})
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/turnertastic1/boltq/internal/events"
+)
+
+func openBoltStore(t *testing.T, path string) *BoltStore {
+	db, err := bolt.Open(path, 0o600, nil)
+	require.NoError(t, err)
+
+	bs, err := NewBoltStore(db)
+	require.NoError(t, err)
+	return bs
+}
+
+func TestBoltStore_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "boltq.db")
+	ctx := context.Background()
+	key := "restart-key"
+
+	bs := openBoltStore(t, path)
+	job := &Job{ID: uuid.New(), Type: "JOB_STANDARD", Payload: []byte("payload"), Status: JobStatusQueued, IdempotencyKey: &key}
+	entry, err := bs.CreateJobWithOutbox(ctx, job)
+	require.NoError(t, err)
+
+	claimed, err := bs.ClaimJob(ctx, job.ID, "worker-1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, bs.Close())
+
+	bs = openBoltStore(t, path)
+	defer bs.Close()
+
+	stored, err := bs.GetJobByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusProcessing, stored.Status)
+	assert.Equal(t, []byte("payload"), stored.Payload)
+	assert.Equal(t, 1, stored.Attempts)
+
+	attempts, err := bs.ListJobAttempts(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "worker-1", attempts[0].WorkerID)
+
+	pending, err := bs.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pending)
+
+	// The idempotency key is still held, and IDs keep counting up
+	existing, _, err := bs.CreateIdempotentJob(ctx, &Job{ID: uuid.New(), Type: "JOB_STANDARD", Status: JobStatusQueued, IdempotencyKey: &key}, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, job.ID, existing.ID)
+
+	next, err := bs.CreateJobWithOutbox(ctx, &Job{ID: uuid.New(), Type: "JOB_STANDARD", Status: JobStatusQueued})
+	require.NoError(t, err)
+	assert.Greater(t, next.ID, entry.ID)
+}
+
+func TestBoltStore_PublishesEvents(t *testing.T) {
+	bs := openBoltStore(t, filepath.Join(t.TempDir(), "boltq.db"))
+	defer bs.Close()
+	ctx := context.Background()
+
+	var published []events.Event
+	bs.PublishEvents(func(ev events.Event) { published = append(published, ev) })
+
+	job := &Job{ID: uuid.New(), Type: "JOB_STANDARD", Status: JobStatusQueued, Tags: map[string]string{"tenant": "acme"}}
+	_, err := bs.CreateJobWithOutbox(ctx, job)
+	require.NoError(t, err)
+
+	claimed, err := bs.ClaimJob(ctx, job.ID, "worker-1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// Heartbeats change neither status nor progress
+	held, _, err := bs.Heartbeat(ctx, job.ID, 1)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	held, _, err = bs.UpdateProgress(ctx, job.ID, 1, 40, "halfway there", nil)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	ok, err := bs.MarkJobAsCompleted(ctx, job.ID, 1, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// A transaction that fails publishes nothing
+	_, err = bs.CreateJobWithOutbox(ctx, &Job{ID: job.ID, Type: "JOB_STANDARD", Status: JobStatusQueued})
+	require.Error(t, err)
+
+	require.Len(t, published, 4)
+	for _, ev := range published {
+		assert.Equal(t, job.ID, ev.JobID)
+		assert.Equal(t, "JOB_STANDARD", ev.Type)
+		assert.Equal(t, map[string]string{"tenant": "acme"}, ev.Tags)
+	}
+	assert.Equal(t, JobStatusQueued, published[0].Status)
+	assert.Equal(t, JobStatusProcessing, published[1].Status)
+	assert.Nil(t, published[1].Progress)
+
+	assert.Equal(t, JobStatusProcessing, published[2].Status)
+	require.NotNil(t, published[2].Progress)
+	assert.Equal(t, 40, published[2].Progress.Percent)
+	assert.Equal(t, "halfway there", published[2].Progress.Message)
+
+	assert.Equal(t, JobStatusCompleted, published[3].Status)
+	assert.Nil(t, published[3].Progress)
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// CancelJob cancels a job that has not started running: a queued, scheduled
+// or retrying job moves to cancelled. For a processing job it records that
+// cancellation was requested and leaves the status to the worker running it.
+// Jobs in any other status are left alone. It returns the job as it was before
+// the call, with only its ID, type, status and priority loaded.
+func (ps *PostgresStore) CancelJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	job := &Job{ID: id}
+	err = tx.QueryRowContext(ctx, `
+		SELECT type, status, priority
+		FROM jobs
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&job.Type, &job.Status, &job.Priority)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job for cancellation: %w", err)
+	}
+
+	switch job.Status {
+	case JobStatusQueued, JobStatusScheduled, JobStatusRetrying:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE jobs
+			SET status = $1, completed_at = NOW(), next_run_at = NULL
+			WHERE id = $2
+		`, JobStatusCancelled, id)
+	case JobStatusProcessing:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE jobs
+			SET cancel_requested_at = COALESCE(cancel_requested_at, NOW())
+			WHERE id = $1
+		`, id)
+	default:
+		return job, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cancellation: %w", err)
+	}
+
+	return job, nil
+}
+
+// MarkJobAsCancelled moves a processing job whose handler was stopped by a
+// cancellation request to cancelled. It reports false if the job was no
+// longer processing attempt.
+func (ps *PostgresStore) MarkJobAsCancelled(ctx context.Context, id uuid.UUID, attempt int) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET status = $1, completed_at = NOW()
+		WHERE id = $2 AND status = $3 AND attempts = $4
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, JobStatusCancelled, id, JobStatusProcessing, attempt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark job as cancelled: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark job as cancelled: %w", err)
+	}
+
+	return affected > 0, nil
+}
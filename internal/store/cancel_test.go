@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_CancelJob_Queued(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT type, status, priority FROM jobs (.+) FOR UPDATE").
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "status", "priority"}).AddRow("job.standard", JobStatusQueued, 1))
+	mock.ExpectExec("UPDATE jobs SET status = \\$1, completed_at = NOW\\(\\)").
+		WithArgs(JobStatusCancelled, jobID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	job, err := store.CancelJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusQueued, job.Status)
+	assert.Equal(t, "job.standard", job.Type)
+	assert.Equal(t, 1, job.Priority)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CancelJob_Processing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT type, status, priority FROM jobs").
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "status", "priority"}).AddRow("job.standard", JobStatusProcessing, 0))
+	mock.ExpectExec("UPDATE jobs SET cancel_requested_at").
+		WithArgs(jobID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	job, err := store.CancelJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusProcessing, job.Status)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CancelJob_Finished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT type, status, priority FROM jobs").
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "status", "priority"}).AddRow("job.standard", JobStatusCompleted, 0))
+	mock.ExpectRollback()
+
+	job, err := store.CancelJob(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusCompleted, job.Status)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CancelJob_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT type, status, priority FROM jobs").
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "status", "priority"}))
+	mock.ExpectRollback()
+
+	_, err = store.CancelJob(context.Background(), jobID)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_MarkJobAsCancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	jobID := uuid.New()
+
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(JobStatusCancelled, jobID, JobStatusProcessing, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	cancelled, err := store.MarkJobAsCancelled(context.Background(), jobID, 2)
+	assert.NoError(t, err)
+	assert.True(t, cancelled)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
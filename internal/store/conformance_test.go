@@ -0,0 +1,42 @@
+package store_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/store/storetest"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.JobStore {
+		return store.NewMemoryStore()
+	})
+}
+
+func TestBoltStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.JobStore {
+		db, err := bolt.Open(filepath.Join(t.TempDir(), "boltq.db"), 0o600, nil)
+		require.NoError(t, err)
+
+		bs, err := store.NewBoltStore(db)
+		require.NoError(t, err)
+		t.Cleanup(func() { bs.Close() })
+		return bs
+	})
+}
+
+func TestPostgresStore_Conformance(t *testing.T) {
+	db := testutil.StartPostgres(t)
+
+	storetest.Run(t, func(t *testing.T) store.JobStore {
+		// Job history and outbox rows go with the jobs
+		_, err := db.Exec(`TRUNCATE jobs CASCADE`)
+		require.NoError(t, err)
+		return store.NewPostgresStore(db)
+	})
+}
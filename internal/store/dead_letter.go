@@ -0,0 +1,253 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeadJobFilter selects dead-lettered jobs. Zero fields match every dead job.
+type DeadJobFilter struct {
+	Type          string
+	JobIDs        []uuid.UUID
+	DeadAfter     *time.Time
+	DeadBefore    *time.Time
+	ErrorContains string
+}
+
+// IsEmpty reports whether the filter matches every dead job.
+func (f DeadJobFilter) IsEmpty() bool {
+	return f.Type == "" && len(f.JobIDs) == 0 && f.DeadAfter == nil && f.DeadBefore == nil && f.ErrorContains == ""
+}
+
+// where returns the SQL conditions for the filter, numbering placeholders
+// after the arguments already in args, and the extended argument list.
+func (f DeadJobFilter) where(args []any) (string, []any) {
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conds := []string{"status = " + arg(JobStatusDead)}
+	if f.Type != "" {
+		conds = append(conds, "type = "+arg(f.Type))
+	}
+	if len(f.JobIDs) > 0 {
+		placeholders := make([]string, len(f.JobIDs))
+		for i, id := range f.JobIDs {
+			placeholders[i] = arg(id)
+		}
+		conds = append(conds, "id IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.DeadAfter != nil {
+		conds = append(conds, "dead_at >= "+arg(*f.DeadAfter))
+	}
+	if f.DeadBefore != nil {
+		conds = append(conds, "dead_at < "+arg(*f.DeadBefore))
+	}
+	if f.ErrorContains != "" {
+		conds = append(conds, "position("+arg(f.ErrorContains)+" IN last_error) > 0")
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// DeadJobCursor marks the last job of a page of ListDeadJobs results.
+type DeadJobCursor struct {
+	DeadAt time.Time
+	ID     uuid.UUID
+}
+
+// DeadLetterJob moves a job from fromStatus to dead once it has exhausted its
+// retries, recording jobErr as its last error and its message in its failure
+// history. It reports false if the job was no longer in fromStatus at attempt.
+func (ps *PostgresStore) DeadLetterJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error) {
+	query := `
+		WITH dead AS (
+		  UPDATE jobs
+		  SET status = $1, completed_at = NOW(), dead_at = NOW(), last_error = $2, error_code = $3, error_details = $4
+		  WHERE id = $5 AND status = $6 AND attempts = $7
+		  RETURNING id, attempts, last_error
+		)
+		INSERT INTO job_failures (job_id, attempt, error)
+		SELECT id, attempts, last_error FROM dead
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, JobStatusDead, jobErr.Message, nullString(jobErr.Code), nullString(jobErr.Details), id, fromStatus, attempt)
+	if err != nil {
+		return false, fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// ListDeadJobs returns up to limit dead jobs matching filter, most recently
+// dead-lettered first. Pass the cursor of the last job of a page to fetch the
+// next one.
+func (ps *PostgresStore) ListDeadJobs(ctx context.Context, filter DeadJobFilter, after *DeadJobCursor, limit int) ([]*Job, error) {
+	where, args := filter.where(nil)
+	if after != nil {
+		args = append(args, after.DeadAt, after.ID)
+		where += fmt.Sprintf(" AND (dead_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+	args = append(args, limit)
+
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE ` + where +
+		fmt.Sprintf(" ORDER BY dead_at DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ListJobFailures returns a job's failed attempts, oldest first.
+func (ps *PostgresStore) ListJobFailures(ctx context.Context, jobID uuid.UUID) ([]*JobFailure, error) {
+	query := `
+		SELECT id, job_id, attempt, error, failed_at
+		FROM job_failures
+		WHERE job_id = $1
+		ORDER BY failed_at, id
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []*JobFailure
+	for rows.Next() {
+		f := &JobFailure{}
+		if err := rows.Scan(&f.ID, &f.JobID, &f.Attempt, &f.Error, &f.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job failure: %w", err)
+		}
+		failures = append(failures, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list job failures: %w", err)
+	}
+
+	return failures, nil
+}
+
+// ReplayDeadJobs moves up to limit dead jobs matching filter back to queued
+// with a fresh attempt budget and hands each off to the queue, in one
+// transaction. Attempts keep counting, so attempt numbers never repeat; the
+// budget starts from the attempts made so far. The caller publishes the returned entries; the outbox relay
+// picks up any it does not. Failure history and last_error are kept.
+func (ps *PostgresStore) ReplayDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*OutboxEntry, error) {
+	args := []any{JobStatusQueued}
+	where, args := filter.where(args)
+	args = append(args, limit)
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		UPDATE jobs
+		SET status = $1, attempts_before_replay = attempts, started_at = NULL, completed_at = NULL, next_run_at = NULL, dead_at = NULL
+		WHERE id IN (
+		  SELECT id FROM jobs
+		  WHERE %s
+		  ORDER BY dead_at
+		  LIMIT $%d
+		  FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, type, priority
+	`, where, len(args))
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay dead jobs: %w", err)
+	}
+
+	var entries []*OutboxEntry
+	for rows.Next() {
+		entry := &OutboxEntry{}
+		if err := rows.Scan(&entry.JobID, &entry.JobType, &entry.Priority); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan replayed job: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to replay dead jobs: %w", err)
+	}
+
+	if err := ps.handOff(ctx, tx, entries); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit replay: %w", err)
+	}
+
+	return entries, nil
+}
+
+// PurgeDeadJobs deletes up to limit dead jobs matching filter, along with their
+// failure history, and returns the ID and type of each deleted job.
+func (ps *PostgresStore) PurgeDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*Job, error) {
+	where, args := filter.where(nil)
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		DELETE FROM jobs
+		WHERE id IN (
+		  SELECT id FROM jobs
+		  WHERE %s
+		  ORDER BY dead_at
+		  LIMIT $%d
+		  FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, type
+	`, where, len(args))
+
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge dead jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job := &Job{}
+		if err := rows.Scan(&job.ID, &job.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan purged job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to purge dead jobs: %w", err)
+	}
+
+	return jobs, nil
+}
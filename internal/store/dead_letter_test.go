@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadJobFilter_Where(t *testing.T) {
+	id := uuid.New()
+	after := time.Now().Add(-time.Hour)
+
+	where, args := DeadJobFilter{
+		Type:          "JOB_WEBHOOK",
+		JobIDs:        []uuid.UUID{id},
+		DeadAfter:     &after,
+		ErrorContains: "503",
+	}.where([]any{"first"})
+
+	assert.Equal(t, "status = $2 AND type = $3 AND id IN ($4) AND dead_at >= $5 AND position($6 IN last_error) > 0", where)
+	assert.Equal(t, []any{"first", JobStatusDead, "JOB_WEBHOOK", id, after, "503"}, args)
+
+	assert.True(t, DeadJobFilter{}.IsEmpty())
+	assert.False(t, DeadJobFilter{Type: "JOB_WEBHOOK"}.IsEmpty())
+}
+
+func TestPostgresStore_DeadLetterJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectExec("UPDATE jobs SET status (.+) INSERT INTO job_failures").
+		WithArgs(JobStatusDead, "boom", nil, nil, jobID, JobStatusProcessing, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	dead, err := store.DeadLetterJob(ctx, jobID, JobStatusProcessing, 3, JobError{Message: "boom"})
+	assert.NoError(t, err)
+	assert.True(t, dead)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ListDeadJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+	now := time.Now()
+	cursor := &DeadJobCursor{DeadAt: now, ID: uuid.New()}
+
+	rows := sqlmock.NewRows([]string{
+		"id", "type", "payload", "status", "created_at", "started_at", "completed_at", "attempts", "last_error",
+		"response_status", "response_latency_ms", "response_body",
+		"max_attempts", "retry_base_delay_ms", "retry_max_delay_ms", "retry_jitter", "next_run_at",
+		"dead_at", "priority", "idempotency_key", "cancel_requested_at", "tags", "last_heartbeat_at",
+		"progress_percent", "progress_message", "progress_updated_at", "checkpoint",
+		"result", "error_code", "error_details", "attempts_before_replay",
+	}).AddRow(jobID, "JOB_STANDARD", []byte("payload"), JobStatusDead, now, now, now, 3, "boom",
+		nil, nil, nil, 3, 1000, 60000, "full", nil, now, 0, nil, nil, []byte("{}"), nil, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	mock.ExpectQuery(`FROM jobs WHERE status = \$1 AND type = \$2 AND \(dead_at, id\) < \(\$3, \$4\) ORDER BY dead_at DESC, id DESC LIMIT \$5`).
+		WithArgs(JobStatusDead, "JOB_STANDARD", cursor.DeadAt, cursor.ID, 10).
+		WillReturnRows(rows)
+
+	jobs, err := store.ListDeadJobs(ctx, DeadJobFilter{Type: "JOB_STANDARD"}, cursor, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, jobID, jobs[0].ID)
+	assert.NotNil(t, jobs[0].DeadAt)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ListJobFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM job_failures").
+		WithArgs(jobID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "attempt", "error", "failed_at"}).
+			AddRow(1, jobID, 1, "timeout", now).
+			AddRow(2, jobID, 2, "boom", now))
+
+	failures, err := store.ListJobFailures(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	assert.Equal(t, 1, failures[0].Attempt)
+	assert.Equal(t, "boom", failures[1].Error)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ReplayDeadJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE jobs SET status = \\$1, attempts_before_replay = attempts, (.+) RETURNING id, type, priority").
+		WithArgs(JobStatusQueued, JobStatusDead, jobID, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "priority"}).AddRow(jobID, "JOB_STANDARD", -1))
+	mock.ExpectQuery("INSERT INTO outbox").
+		WithArgs(jobID, "JOB_STANDARD", nil, -1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "created_at", "available_at"}).AddRow(7, jobID, now, now))
+	mock.ExpectCommit()
+
+	entries, err := store.ReplayDeadJobs(ctx, DeadJobFilter{JobIDs: []uuid.UUID{jobID}}, 100)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(7), entries[0].ID)
+	assert.Equal(t, jobID, entries[0].JobID)
+	assert.Equal(t, -1, entries[0].Priority)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_PurgeDeadJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectQuery("DELETE FROM jobs").
+		WithArgs(JobStatusDead, "JOB_WEBHOOK", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(jobID, "JOB_WEBHOOK"))
+
+	jobs, err := store.PurgeDeadJobs(ctx, DeadJobFilter{Type: "JOB_WEBHOOK"}, 5)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, jobID, jobs[0].ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
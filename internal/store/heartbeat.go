@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Heartbeat records that the worker running attempt of a job is still alive.
+// It reports whether the job is still processing that attempt, and so still
+// held by the worker, and whether its cancellation was requested meanwhile.
+func (ps *PostgresStore) Heartbeat(ctx context.Context, id uuid.UUID, attempt int) (held, cancelRequested bool, err error) {
+	query := `
+		UPDATE jobs
+		SET last_heartbeat_at = NOW()
+		WHERE id = $1 AND status = $2 AND attempts = $3
+		RETURNING cancel_requested_at IS NOT NULL
+	`
+
+	err = ps.db.QueryRowContext(ctx, query, id, JobStatusProcessing, attempt).Scan(&cancelRequested)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	return true, cancelRequested, nil
+}
+
+// ListAbandonedJobs returns up to limit processing jobs whose worker has sent
+// no heartbeat for longer than timeout, longest silent first. Payloads and
+// checkpoints are not loaded.
+func (ps *PostgresStore) ListAbandonedJobs(ctx context.Context, timeout time.Duration, limit int) ([]*Job, error) {
+	query := `
+		SELECT ` + jobSummaryColumns + `
+		FROM jobs
+		WHERE status = $1 AND last_heartbeat_at < NOW() - make_interval(secs => $2)
+		ORDER BY last_heartbeat_at
+		LIMIT $3
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, JobStatusProcessing, timeout.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list abandoned jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan abandoned job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list abandoned jobs: %w", err)
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_Heartbeat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectQuery("UPDATE jobs SET last_heartbeat_at = NOW\\(\\) WHERE id = \\$1 AND status = \\$2 AND attempts = \\$3").
+		WithArgs(jobID, JobStatusProcessing, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"cancel_requested"}).AddRow(true))
+
+	held, cancelRequested, err := store.Heartbeat(ctx, jobID, 1)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.True(t, cancelRequested)
+
+	// A job that has left processing, or been claimed again, is no longer held
+	mock.ExpectQuery("UPDATE jobs SET last_heartbeat_at").
+		WithArgs(jobID, JobStatusProcessing, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"cancel_requested"}))
+
+	held, cancelRequested, err = store.Heartbeat(ctx, jobID, 1)
+	require.NoError(t, err)
+	assert.False(t, held)
+	assert.False(t, cancelRequested)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ListAbandonedJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+	now := time.Now()
+	beat := now.Add(-5 * time.Minute)
+
+	rows := sqlmock.NewRows([]string{
+		"id", "type", "payload", "status", "created_at", "started_at", "completed_at", "attempts", "last_error",
+		"response_status", "response_latency_ms", "response_body",
+		"max_attempts", "retry_base_delay_ms", "retry_max_delay_ms", "retry_jitter", "next_run_at",
+		"dead_at", "priority", "idempotency_key", "cancel_requested_at", "tags", "last_heartbeat_at",
+		"progress_percent", "progress_message", "progress_updated_at", "checkpoint",
+		"result", "error_code", "error_details", "attempts_before_replay",
+	}).AddRow(jobID, "JOB_STANDARD", nil, JobStatusProcessing, now, now, nil, 1, nil,
+		nil, nil, nil, 3, 1000, 60000, "full", nil, nil, 0, nil, nil, []byte("{}"), beat, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	mock.ExpectQuery(`SELECT (.+) NULL, (.+) FROM jobs WHERE status = \$1 AND last_heartbeat_at < NOW\(\) - make_interval\(secs => \$2\) ORDER BY last_heartbeat_at LIMIT \$3`).
+		WithArgs(JobStatusProcessing, float64(120), 50).
+		WillReturnRows(rows)
+
+	jobs, err := store.ListAbandonedJobs(ctx, 2*time.Minute, 50)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, jobID, jobs[0].ID)
+	assert.Nil(t, jobs[0].Payload)
+	require.NotNil(t, jobs[0].LastHeartbeatAt)
+	assert.Equal(t, beat, *jobs[0].LastHeartbeatAt)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
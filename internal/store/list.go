@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobFilter selects jobs for ListJobs. Zero fields match every job.
+type JobFilter struct {
+	Type          string
+	Statuses      []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Tags matches jobs carrying every one of these tags
+	Tags map[string]string
+}
+
+// where returns the SQL conditions for the filter, numbering placeholders
+// after the arguments already in args, and the extended argument list.
+func (f JobFilter) where(args []any) (string, []any) {
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conds []string
+	if f.Type != "" {
+		conds = append(conds, "type = "+arg(f.Type))
+	}
+	if len(f.Statuses) > 0 {
+		placeholders := make([]string, len(f.Statuses))
+		for i, s := range f.Statuses {
+			placeholders[i] = arg(s)
+		}
+		conds = append(conds, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.CreatedAfter != nil {
+		conds = append(conds, "created_at >= "+arg(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		conds = append(conds, "created_at < "+arg(*f.CreatedBefore))
+	}
+	if len(f.Tags) > 0 {
+		conds = append(conds, "tags @> "+arg(marshalTags(f.Tags))+"::jsonb")
+	}
+
+	if len(conds) == 0 {
+		return "TRUE", args
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// JobCursor marks the last job of a page of ListJobs results.
+type JobCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// ListJobsOptions controls the page of jobs ListJobs returns.
+type ListJobsOptions struct {
+	// After is the cursor of the last job of the previous page
+	After *JobCursor
+	// OldestFirst orders jobs by ascending created_at instead of newest first
+	OldestFirst bool
+	Limit       int
+	// IncludePayload loads each job's payload, which is left nil otherwise
+	IncludePayload bool
+}
+
+// ListJobs returns a page of jobs matching filter ordered by created_at, with
+// ties broken by ID. Pages are keyset paginated, so jobs inserted while
+// paging through newest first never shift later pages.
+func (ps *PostgresStore) ListJobs(ctx context.Context, filter JobFilter, opts ListJobsOptions) ([]*Job, error) {
+	where, args := filter.where(nil)
+
+	cmp, dir := "<", "DESC"
+	if opts.OldestFirst {
+		cmp, dir = ">", "ASC"
+	}
+	if opts.After != nil {
+		args = append(args, opts.After.CreatedAt, opts.After.ID)
+		where += fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", cmp, len(args)-1, len(args))
+	}
+	args = append(args, opts.Limit)
+
+	columns := jobColumns
+	if !opts.IncludePayload {
+		// Payloads can be up to a megabyte each, so skip reading them
+		columns = jobSummaryColumns
+	}
+
+	query := `SELECT ` + columns + ` FROM jobs WHERE ` + where +
+		fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", dir, dir, len(args))
+
+	rows, err := ps.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	return jobs, nil
+}
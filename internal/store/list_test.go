@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobFilter_Where(t *testing.T) {
+	after := time.Now().Add(-time.Hour)
+
+	where, args := JobFilter{
+		Type:         "JOB_WEBHOOK",
+		Statuses:     []string{JobStatusFailed, JobStatusDead},
+		CreatedAfter: &after,
+		Tags:         map[string]string{"tenant": "acme"},
+	}.where(nil)
+
+	assert.Equal(t, "type = $1 AND status IN ($2, $3) AND created_at >= $4 AND tags @> $5::jsonb", where)
+	assert.Equal(t, []any{"JOB_WEBHOOK", JobStatusFailed, JobStatusDead, after, `{"tenant":"acme"}`}, args)
+
+	where, args = JobFilter{}.where(nil)
+	assert.Equal(t, "TRUE", where)
+	assert.Empty(t, args)
+}
+
+func TestPostgresStore_ListJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+	now := time.Now()
+	cursor := &JobCursor{CreatedAt: now, ID: uuid.New()}
+
+	rows := sqlmock.NewRows([]string{
+		"id", "type", "payload", "status", "created_at", "started_at", "completed_at", "attempts", "last_error",
+		"response_status", "response_latency_ms", "response_body",
+		"max_attempts", "retry_base_delay_ms", "retry_max_delay_ms", "retry_jitter", "next_run_at",
+		"dead_at", "priority", "idempotency_key", "cancel_requested_at", "tags", "last_heartbeat_at",
+		"progress_percent", "progress_message", "progress_updated_at", "checkpoint",
+		"result", "error_code", "error_details", "attempts_before_replay",
+	}).AddRow(jobID, "JOB_STANDARD", nil, JobStatusQueued, now, nil, nil, 0, nil,
+		nil, nil, nil, 3, 1000, 60000, "full", nil, nil, 0, nil, nil, []byte(`{"tenant":"acme"}`), nil, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	mock.ExpectQuery(`SELECT\s+id, type, NULL, status, .+ FROM jobs WHERE status IN \(\$1\) AND \(created_at, id\) > \(\$2, \$3\) ORDER BY created_at ASC, id ASC LIMIT \$4`).
+		WithArgs(JobStatusQueued, cursor.CreatedAt, cursor.ID, 10).
+		WillReturnRows(rows)
+
+	jobs, err := store.ListJobs(ctx, JobFilter{Statuses: []string{JobStatusQueued}}, ListJobsOptions{
+		After:       cursor,
+		OldestFirst: true,
+		Limit:       10,
+	})
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, jobID, jobs[0].ID)
+	assert.Nil(t, jobs[0].Payload)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, jobs[0].Tags)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ListJobs_NewestFirstWithPayload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT\s+id, type, payload, .+ FROM jobs WHERE TRUE ORDER BY created_at DESC, id DESC LIMIT \$1`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	jobs, err := store.ListJobs(ctx, JobFilter{}, ListJobsOptions{Limit: 5, IncludePayload: true})
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
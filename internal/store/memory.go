@@ -0,0 +1,1028 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errDuplicateJob mirrors the unique violations Postgres reports for a job ID
+// or idempotency key that is already taken.
+var errDuplicateJob = errors.New("duplicate job")
+
+// MemoryStore is a JobStore that keeps jobs and their history in process
+// memory. It behaves like PostgresStore, with every method atomic, and is
+// meant for unit tests and single-process deployments that need no
+// durability. Jobs are returned as copies, so callers cannot change the
+// stored ones.
+type MemoryStore struct {
+	mu       sync.Mutex
+	jobs     map[uuid.UUID]*Job
+	failures []*JobFailure
+	attempts []*JobAttempt
+	outbox   []*OutboxEntry
+	relaying map[int64]bool // outbox entries being published by RelayOutbox
+	lastID   int64          // shared by failures, attempts and outbox entries
+}
+
+var _ JobStore = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:     make(map[uuid.UUID]*Job),
+		relaying: make(map[int64]bool),
+	}
+}
+
+func (ms *MemoryStore) Close() error {
+	return nil
+}
+
+// nextID returns the next ID of a failure, attempt or outbox entry. ms.mu must be held.
+func (ms *MemoryStore) nextID() int64 {
+	ms.lastID++
+	return ms.lastID
+}
+
+// cloneJob copies a job along with its tags and byte slices. Pointer fields are
+// shared: the store replaces them rather than writing through them.
+func cloneJob(job *Job) *Job {
+	c := *job
+	c.Tags = maps.Clone(job.Tags)
+	c.Payload = bytes.Clone(job.Payload)
+	c.ResponseBody = bytes.Clone(job.ResponseBody)
+	c.Checkpoint = bytes.Clone(job.Checkpoint)
+	c.Result = bytes.Clone(job.Result)
+	return &c
+}
+
+// summarizeJob is cloneJob without the payload, checkpoint and result, like
+// rows read with jobSummaryColumns.
+func summarizeJob(job *Job) *Job {
+	c := *job
+	c.Tags = maps.Clone(job.Tags)
+	c.ResponseBody = bytes.Clone(job.ResponseBody)
+	c.Payload, c.Checkpoint, c.Result = nil, nil, nil
+	return &c
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// optionalString returns nil for an empty string, like nullString.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// newStoredJob returns the job as inserted: only the columns insertJobArgs
+// writes are taken from job, and the rest start at their defaults.
+func newStoredJob(job *Job, now time.Time) *Job {
+	tags := maps.Clone(job.Tags)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return &Job{
+		ID:             job.ID,
+		Type:           job.Type,
+		Payload:        bytes.Clone(job.Payload),
+		Status:         job.Status,
+		CreatedAt:      now,
+		MaxAttempts:    job.MaxAttempts,
+		RetryBaseDelay: job.RetryBaseDelay.Truncate(time.Millisecond),
+		RetryMaxDelay:  job.RetryMaxDelay.Truncate(time.Millisecond),
+		RetryJitter:    job.RetryJitter,
+		NextRunAt:      job.NextRunAt,
+		Priority:       job.Priority,
+		IdempotencyKey: job.IdempotencyKey,
+		Tags:           tags,
+	}
+}
+
+// insert stores a new job. ms.mu must be held.
+func (ms *MemoryStore) insert(job *Job, now time.Time) error {
+	if _, ok := ms.jobs[job.ID]; ok {
+		return fmt.Errorf("%w: ID %s is taken", errDuplicateJob, job.ID)
+	}
+	if job.IdempotencyKey != nil && ms.keyHolder(*job.IdempotencyKey) != nil {
+		return fmt.Errorf("%w: idempotency key %q is taken", errDuplicateJob, *job.IdempotencyKey)
+	}
+
+	ms.jobs[job.ID] = newStoredJob(job, now)
+	return nil
+}
+
+// keyHolder returns the job holding an idempotency key, or nil. ms.mu must be held.
+func (ms *MemoryStore) keyHolder(key string) *Job {
+	for _, job := range ms.jobs {
+		if job.IdempotencyKey != nil && *job.IdempotencyKey == key {
+			return job
+		}
+	}
+	return nil
+}
+
+// releaseExpiredKey frees an idempotency key held by a job created before
+// cutoff. ms.mu must be held.
+func (ms *MemoryStore) releaseExpiredKey(key string, cutoff time.Time) {
+	if holder := ms.keyHolder(key); holder != nil && holder.CreatedAt.Before(cutoff) {
+		holder.IdempotencyKey = nil
+	}
+}
+
+// addOutboxEntry writes the outbox entry that hands a job off to the queue.
+// ms.mu must be held.
+func (ms *MemoryStore) addOutboxEntry(job *Job, runAt *time.Time, now time.Time) *OutboxEntry {
+	entry := &OutboxEntry{
+		ID:          ms.nextID(),
+		JobID:       job.ID,
+		JobType:     job.Type,
+		CreatedAt:   now,
+		AvailableAt: now,
+		RunAt:       runAt,
+		Priority:    job.Priority,
+	}
+	ms.outbox = append(ms.outbox, entry)
+
+	c := *entry
+	return &c
+}
+
+// addFailure records a failed attempt in a job's failure history. ms.mu must be held.
+func (ms *MemoryStore) addFailure(job *Job, now time.Time) {
+	ms.failures = append(ms.failures, &JobFailure{
+		ID:       ms.nextID(),
+		JobID:    job.ID,
+		Attempt:  job.Attempts,
+		Error:    *job.LastError,
+		FailedAt: now,
+	})
+}
+
+// setError records jobErr as a job's last error.
+func setError(job *Job, jobErr JobError) {
+	job.LastError = stringPtr(jobErr.Message)
+	job.ErrorCode = optionalString(jobErr.Code)
+	job.ErrorDetails = optionalString(jobErr.Details)
+}
+
+// claimable reports whether ClaimJob may take a job at now: it is waiting to
+// run, or processing with no sign of life for staleAfter.
+func claimable(job *Job, now time.Time, staleAfter time.Duration) bool {
+	switch job.Status {
+	case JobStatusQueued, JobStatusRetrying, JobStatusScheduled:
+		return true
+	case JobStatusProcessing:
+		lastSeen := job.LastHeartbeatAt
+		if lastSeen == nil {
+			lastSeen = job.StartedAt
+		}
+		return lastSeen != nil && lastSeen.Before(now.Add(-staleAfter))
+	}
+	return false
+}
+
+// inAttempt reports whether a job is in status at the given attempt, which is
+// how outcomes of a run are kept from landing on the run that replaced it.
+func inAttempt(job *Job, status string, attempt int) bool {
+	return job.Status == status && job.Attempts == attempt
+}
+
+// startClaimedJob starts the next attempt of a job ClaimJob took.
+func startClaimedJob(job *Job, now time.Time) {
+	job.Status = JobStatusProcessing
+	job.StartedAt, job.LastHeartbeatAt = timePtr(now), timePtr(now)
+	job.NextRunAt = nil
+	job.Attempts++
+}
+
+// cancelJob cancels a job that has not started, or requests cancellation of a
+// processing one, and returns the job as CancelJob reports it: as it was before.
+func cancelJob(job *Job, now time.Time) *Job {
+	before := &Job{ID: job.ID, Type: job.Type, Status: job.Status, Priority: job.Priority}
+
+	switch job.Status {
+	case JobStatusQueued, JobStatusScheduled, JobStatusRetrying:
+		job.Status = JobStatusCancelled
+		job.CompletedAt = timePtr(now)
+		job.NextRunAt = nil
+	case JobStatusProcessing:
+		if job.CancelRequestedAt == nil {
+			job.CancelRequestedAt = timePtr(now)
+		}
+	}
+
+	return before
+}
+
+// resetDeadJob returns a dead job to the queued state with a fresh attempt
+// budget, for ReplayDeadJobs.
+func resetDeadJob(job *Job) {
+	job.Status = JobStatusQueued
+	job.AttemptsBeforeReplay = job.Attempts
+	job.StartedAt, job.CompletedAt, job.NextRunAt, job.DeadAt = nil, nil, nil, nil
+}
+
+// isStale reports whether a job without a pending outbox entry has been
+// waiting or processing since before cutoff, for ListStaleJobs.
+func isStale(job *Job, cutoff time.Time) bool {
+	before := func(t *time.Time) bool { return t != nil && t.Before(cutoff) }
+	switch job.Status {
+	case JobStatusQueued:
+		return job.CreatedAt.Before(cutoff)
+	case JobStatusProcessing:
+		if job.LastHeartbeatAt != nil {
+			return before(job.LastHeartbeatAt)
+		}
+		return before(job.StartedAt)
+	case JobStatusRetrying, JobStatusScheduled:
+		return before(job.NextRunAt)
+	}
+	return false
+}
+
+// staleSummary copies the columns ListStaleJobs reads.
+func staleSummary(job *Job) *Job {
+	return &Job{
+		ID:          job.ID,
+		Type:        job.Type,
+		Status:      job.Status,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+		Attempts:    job.Attempts,
+		LastError:   job.LastError,
+		MaxAttempts: job.MaxAttempts,
+		Priority:    job.Priority,
+
+		AttemptsBeforeReplay: job.AttemptsBeforeReplay,
+	}
+}
+
+// jobsByCreation returns the stored jobs matching keep, oldest first with ties
+// broken by ID. ms.mu must be held.
+func (ms *MemoryStore) jobsByCreation(keep func(*Job) bool) []*Job {
+	var jobs []*Job
+	for _, job := range ms.jobs {
+		if keep(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b *Job) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	return jobs
+}
+
+func (ms *MemoryStore) CreateJob(ctx context.Context, job *Job) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.insert(job, time.Now()); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+func (ms *MemoryStore) GetJobByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return cloneJob(job), nil
+}
+
+// matches reports whether a job passes the filter.
+func (f JobFilter) matches(job *Job) bool {
+	if f.Type != "" && job.Type != f.Type {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, job.Status) {
+		return false
+	}
+	if f.CreatedAfter != nil && job.CreatedAt.Before(*f.CreatedAfter) {
+		return false
+	}
+	if f.CreatedBefore != nil && !job.CreatedAt.Before(*f.CreatedBefore) {
+		return false
+	}
+	for k, v := range f.Tags {
+		if tag, ok := job.Tags[k]; !ok || tag != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (ms *MemoryStore) ListJobs(ctx context.Context, filter JobFilter, opts ListJobsOptions) ([]*Job, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	jobs := ms.jobsByCreation(filter.matches)
+	if !opts.OldestFirst {
+		slices.Reverse(jobs)
+	}
+
+	var page []*Job
+	for _, job := range jobs {
+		if len(page) == opts.Limit {
+			break
+		}
+		if opts.After != nil {
+			c := job.CreatedAt.Compare(opts.After.CreatedAt)
+			if c == 0 {
+				c = bytes.Compare(job.ID[:], opts.After.ID[:])
+			}
+			if (opts.OldestFirst && c <= 0) || (!opts.OldestFirst && c >= 0) {
+				continue
+			}
+		}
+		if opts.IncludePayload {
+			page = append(page, cloneJob(job))
+		} else {
+			page = append(page, summarizeJob(job))
+		}
+	}
+
+	return page, nil
+}
+
+func (ms *MemoryStore) CreateJobWithOutbox(ctx context.Context, job *Job) (*OutboxEntry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	if err := ms.insert(job, now); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return ms.addOutboxEntry(job, job.NextRunAt, now), nil
+}
+
+func (ms *MemoryStore) CreateIdempotentJob(ctx context.Context, job *Job, retention time.Duration) (*Job, *OutboxEntry, error) {
+	if job.IdempotencyKey == nil {
+		return nil, nil, errors.New("job has no idempotency key")
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	ms.releaseExpiredKey(*job.IdempotencyKey, now.Add(-retention))
+	if holder := ms.keyHolder(*job.IdempotencyKey); holder != nil {
+		return cloneJob(holder), nil, nil
+	}
+
+	if err := ms.insert(job, now); err != nil {
+		return nil, nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil, ms.addOutboxEntry(job, job.NextRunAt, now), nil
+}
+
+func (ms *MemoryStore) CreateJobsWithOutbox(ctx context.Context, jobs []*Job, retention time.Duration, check func(existing map[string]*Job) error) ([]*OutboxEntry, map[string]*Job, error) {
+	if len(jobs) == 0 {
+		return nil, nil, nil
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-retention)
+
+	// Work out the whole batch before changing anything, so check can still
+	// reject it
+	holders := make(map[string]*Job)
+	for _, job := range jobs {
+		if job.IdempotencyKey == nil {
+			continue
+		}
+		key := *job.IdempotencyKey
+		if holder := ms.keyHolder(key); holder != nil && !holder.CreatedAt.Before(cutoff) {
+			holders[key] = holder
+		}
+	}
+
+	var inserted []*Job
+	existing := make(map[string]*Job)
+	batchIDs := make(map[uuid.UUID]bool, len(jobs))
+	for _, job := range jobs {
+		if _, ok := ms.jobs[job.ID]; ok || batchIDs[job.ID] {
+			return nil, nil, fmt.Errorf("failed to create jobs: %w: ID %s is taken", errDuplicateJob, job.ID)
+		}
+		if job.IdempotencyKey != nil {
+			key := *job.IdempotencyKey
+			if holder, ok := holders[key]; ok {
+				existing[key] = cloneJob(holder)
+				continue
+			}
+			holders[key] = newStoredJob(job, now)
+		}
+		batchIDs[job.ID] = true
+		inserted = append(inserted, job)
+	}
+
+	if check != nil {
+		if err := check(existing); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, job := range jobs {
+		if job.IdempotencyKey != nil {
+			ms.releaseExpiredKey(*job.IdempotencyKey, cutoff)
+		}
+	}
+	entries := make([]*OutboxEntry, 0, len(inserted))
+	for _, job := range inserted {
+		if err := ms.insert(job, now); err != nil {
+			// Ruled out above
+			return nil, nil, fmt.Errorf("failed to create jobs: %w", err)
+		}
+		entries = append(entries, ms.addOutboxEntry(job, job.NextRunAt, now))
+	}
+
+	return entries, existing, nil
+}
+
+// markSent marks an outbox entry sent. ms.mu must be held.
+func (ms *MemoryStore) markSent(id int64, now time.Time) {
+	for _, entry := range ms.outbox {
+		if entry.ID == id && entry.SentAt == nil {
+			entry.SentAt = timePtr(now)
+		}
+	}
+}
+
+func (ms *MemoryStore) MarkOutboxSent(ctx context.Context, id int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.markSent(id, time.Now())
+	return nil
+}
+
+func (ms *MemoryStore) MarkOutboxSentBatch(ctx context.Context, ids []int64) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		ms.markSent(id, now)
+	}
+	return nil
+}
+
+// RelayOutbox is PostgresStore.RelayOutbox. Entries being published are
+// skipped by concurrent calls, as locked rows are; the store is not locked
+// while publish runs.
+func (ms *MemoryStore) RelayOutbox(ctx context.Context, limit int, minAge time.Duration, publish func(context.Context, *OutboxEntry) error) (int, error) {
+	ms.mu.Lock()
+	cutoff := time.Now().Add(-minAge)
+	var pending []*OutboxEntry
+	for _, entry := range ms.outbox {
+		if entry.SentAt == nil && !entry.AvailableAt.After(cutoff) && !ms.relaying[entry.ID] {
+			pending = append(pending, entry)
+		}
+	}
+	slices.SortStableFunc(pending, func(a, b *OutboxEntry) int {
+		return a.AvailableAt.Compare(b.AvailableAt)
+	})
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	batch := make([]*OutboxEntry, len(pending))
+	for i, entry := range pending {
+		ms.relaying[entry.ID] = true
+		c := *entry
+		batch[i] = &c
+	}
+	ms.mu.Unlock()
+
+	errs := make([]error, len(batch))
+	for i, entry := range batch {
+		errs[i] = publish(ctx, entry)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	sent := 0
+	for i, entry := range pending {
+		delete(ms.relaying, entry.ID)
+		entry.Attempts++
+		if errs[i] != nil {
+			entry.LastError = stringPtr(errs[i].Error())
+			continue
+		}
+		sent++
+		entry.SentAt = timePtr(now)
+		entry.LastError = nil
+	}
+
+	return sent, nil
+}
+
+func (ms *MemoryStore) DeleteSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	cutoff := time.Now().Add(-olderThan)
+	before := len(ms.outbox)
+	ms.outbox = slices.DeleteFunc(ms.outbox, func(entry *OutboxEntry) bool {
+		return entry.SentAt != nil && entry.SentAt.Before(cutoff)
+	})
+	return int64(before - len(ms.outbox)), nil
+}
+
+func (ms *MemoryStore) CountPendingOutbox(ctx context.Context) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var count int64
+	for _, entry := range ms.outbox {
+		if entry.SentAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (ms *MemoryStore) PromoteScheduledJobs(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var promoted int64
+	for _, id := range ids {
+		if job, ok := ms.jobs[id]; ok && job.Status == JobStatusScheduled {
+			job.Status = JobStatusQueued
+			job.NextRunAt = nil
+			promoted++
+		}
+	}
+	return promoted, nil
+}
+
+func (ms *MemoryStore) MarkJobAsQueued(ctx context.Context, id uuid.UUID, attempt int) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, JobStatusProcessing, attempt) {
+		return false, nil
+	}
+	job.Status = JobStatusQueued
+	job.StartedAt, job.CompletedAt = nil, nil
+	return true, nil
+}
+
+func (ms *MemoryStore) MarkJobAsProcessing(ctx context.Context, id uuid.UUID) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if job, ok := ms.jobs[id]; ok {
+		now := time.Now()
+		job.Status = JobStatusProcessing
+		job.StartedAt, job.LastHeartbeatAt = timePtr(now), timePtr(now)
+		job.Attempts++
+	}
+	return nil
+}
+
+func (ms *MemoryStore) ClaimJob(ctx context.Context, id uuid.UUID, workerID string, staleAfter time.Duration) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok {
+		return false, nil
+	}
+
+	now := time.Now()
+	if !claimable(job, now, staleAfter) {
+		return false, nil
+	}
+	startClaimedJob(job, now)
+
+	for _, a := range ms.attempts {
+		if a.JobID == id && a.Outcome == AttemptRunning {
+			a.Outcome = AttemptAbandoned
+			a.FinishedAt = timePtr(now)
+		}
+	}
+	ms.attempts = append(ms.attempts, &JobAttempt{
+		ID:        ms.nextID(),
+		JobID:     id,
+		Attempt:   job.Attempts,
+		WorkerID:  workerID,
+		Outcome:   AttemptRunning,
+		StartedAt: now,
+	})
+
+	return true, nil
+}
+
+func (ms *MemoryStore) Heartbeat(ctx context.Context, id uuid.UUID, attempt int) (held, cancelRequested bool, err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, JobStatusProcessing, attempt) {
+		return false, false, nil
+	}
+	job.LastHeartbeatAt = timePtr(time.Now())
+	return true, job.CancelRequestedAt != nil, nil
+}
+
+func (ms *MemoryStore) UpdateProgress(ctx context.Context, id uuid.UUID, attempt int, percent int, message string, checkpoint []byte) (held, cancelRequested bool, err error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, JobStatusProcessing, attempt) {
+		return false, false, nil
+	}
+
+	now := time.Now()
+	job.ProgressPercent = &percent
+	job.ProgressMessage = stringPtr(message)
+	job.ProgressUpdatedAt = timePtr(now)
+	if checkpoint != nil {
+		job.Checkpoint = bytes.Clone(checkpoint)
+	}
+	job.LastHeartbeatAt = timePtr(now)
+
+	return true, job.CancelRequestedAt != nil, nil
+}
+
+func (ms *MemoryStore) RecordWebhookResponse(ctx context.Context, id uuid.UUID, statusCode int, latency time.Duration, body []byte) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, a := range ms.attempts {
+		if a.JobID == id && a.Outcome == AttemptRunning {
+			a.ResponseStatus = &statusCode
+		}
+	}
+	if job, ok := ms.jobs[id]; ok {
+		latencyMs := int(latency.Milliseconds())
+		job.ResponseStatus = &statusCode
+		job.ResponseLatencyMs = &latencyMs
+		job.ResponseBody = bytes.Clone(body)
+	}
+	return nil
+}
+
+func (ms *MemoryStore) FinishAttempt(ctx context.Context, id uuid.UUID, attempt int, end AttemptEnd) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	for _, a := range ms.attempts {
+		if a.JobID != id || a.Attempt != attempt || a.Outcome != AttemptRunning {
+			continue
+		}
+		latencyMs := now.Sub(a.StartedAt).Milliseconds()
+		a.Outcome = end.Outcome
+		a.FinishedAt = timePtr(now)
+		a.LatencyMs = &latencyMs
+		a.Error = optionalString(end.Error.Message)
+		a.ErrorCode = optionalString(end.Error.Code)
+		a.ErrorDetails = optionalString(end.Error.Details)
+	}
+	return nil
+}
+
+func (ms *MemoryStore) ListJobAttempts(ctx context.Context, id uuid.UUID) ([]*JobAttempt, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var attempts []*JobAttempt
+	for _, a := range ms.attempts {
+		if a.JobID == id {
+			c := *a
+			attempts = append(attempts, &c)
+		}
+	}
+	return attempts, nil
+}
+
+func (ms *MemoryStore) MarkJobAsCompleted(ctx context.Context, id uuid.UUID, attempt int, result []byte) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, JobStatusProcessing, attempt) {
+		return false, nil
+	}
+	job.Status = JobStatusCompleted
+	job.CompletedAt = timePtr(time.Now())
+	job.Result = bytes.Clone(result)
+	return true, nil
+}
+
+func (ms *MemoryStore) MarkJobAsFailed(ctx context.Context, id uuid.UUID, jobErr JobError) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if job, ok := ms.jobs[id]; ok {
+		job.Status = JobStatusFailed
+		job.CompletedAt = timePtr(time.Now())
+		setError(job, jobErr)
+	}
+	return nil
+}
+
+func (ms *MemoryStore) FailJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, fromStatus, attempt) {
+		return false, nil
+	}
+
+	now := time.Now()
+	job.Status = JobStatusFailed
+	job.CompletedAt = timePtr(now)
+	setError(job, jobErr)
+	ms.addFailure(job, now)
+
+	return true, nil
+}
+
+func (ms *MemoryStore) ScheduleRetry(ctx context.Context, id uuid.UUID, attempt int, jobErr JobError, delay time.Duration) (*OutboxEntry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, JobStatusProcessing, attempt) {
+		return nil, nil
+	}
+
+	now := time.Now()
+	job.Status = JobStatusRetrying
+	job.NextRunAt = timePtr(now.Add(delay))
+	setError(job, jobErr)
+	ms.addFailure(job, now)
+
+	return ms.addOutboxEntry(job, job.NextRunAt, now), nil
+}
+
+func (ms *MemoryStore) CancelJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return cancelJob(job, time.Now()), nil
+}
+
+func (ms *MemoryStore) MarkJobAsCancelled(ctx context.Context, id uuid.UUID, attempt int) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, JobStatusProcessing, attempt) {
+		return false, nil
+	}
+	job.Status = JobStatusCancelled
+	job.CompletedAt = timePtr(time.Now())
+	return true, nil
+}
+
+func (ms *MemoryStore) DeadLetterJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || !inAttempt(job, fromStatus, attempt) {
+		return false, nil
+	}
+
+	now := time.Now()
+	job.Status = JobStatusDead
+	job.CompletedAt, job.DeadAt = timePtr(now), timePtr(now)
+	setError(job, jobErr)
+	ms.addFailure(job, now)
+
+	return true, nil
+}
+
+// matches reports whether a job passes the filter.
+func (f DeadJobFilter) matches(job *Job) bool {
+	if job.Status != JobStatusDead {
+		return false
+	}
+	if f.Type != "" && job.Type != f.Type {
+		return false
+	}
+	if len(f.JobIDs) > 0 && !slices.Contains(f.JobIDs, job.ID) {
+		return false
+	}
+	if f.DeadAfter != nil && (job.DeadAt == nil || job.DeadAt.Before(*f.DeadAfter)) {
+		return false
+	}
+	if f.DeadBefore != nil && (job.DeadAt == nil || !job.DeadAt.Before(*f.DeadBefore)) {
+		return false
+	}
+	if f.ErrorContains != "" && (job.LastError == nil || !strings.Contains(*job.LastError, f.ErrorContains)) {
+		return false
+	}
+	return true
+}
+
+// compareDeath orders dead jobs by when they were dead-lettered, then by ID.
+func compareDeath(a, b *Job) int {
+	var at, bt time.Time
+	if a.DeadAt != nil {
+		at = *a.DeadAt
+	}
+	if b.DeadAt != nil {
+		bt = *b.DeadAt
+	}
+	if c := at.Compare(bt); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// deadJobs returns the dead jobs matching filter, longest dead first. ms.mu must be held.
+func (ms *MemoryStore) deadJobs(filter DeadJobFilter) []*Job {
+	var jobs []*Job
+	for _, job := range ms.jobs {
+		if filter.matches(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	slices.SortFunc(jobs, compareDeath)
+	return jobs
+}
+
+func (ms *MemoryStore) ListDeadJobs(ctx context.Context, filter DeadJobFilter, after *DeadJobCursor, limit int) ([]*Job, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	jobs := ms.deadJobs(filter)
+	slices.Reverse(jobs)
+
+	var page []*Job
+	for _, job := range jobs {
+		if len(page) == limit {
+			break
+		}
+		if after != nil && compareDeath(job, &Job{ID: after.ID, DeadAt: &after.DeadAt}) >= 0 {
+			continue
+		}
+		page = append(page, cloneJob(job))
+	}
+	return page, nil
+}
+
+func (ms *MemoryStore) ListJobFailures(ctx context.Context, jobID uuid.UUID) ([]*JobFailure, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var failures []*JobFailure
+	for _, f := range ms.failures {
+		if f.JobID == jobID {
+			c := *f
+			failures = append(failures, &c)
+		}
+	}
+	return failures, nil
+}
+
+func (ms *MemoryStore) ReplayDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*OutboxEntry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	jobs := ms.deadJobs(filter)
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	now := time.Now()
+	var entries []*OutboxEntry
+	for _, job := range jobs {
+		resetDeadJob(job)
+		entries = append(entries, ms.addOutboxEntry(job, nil, now))
+	}
+	return entries, nil
+}
+
+func (ms *MemoryStore) PurgeDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*Job, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	jobs := ms.deadJobs(filter)
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	purged := make([]*Job, len(jobs))
+	for i, job := range jobs {
+		delete(ms.jobs, job.ID)
+		purged[i] = &Job{ID: job.ID, Type: job.Type}
+	}
+	ms.deleteOrphans()
+
+	return purged, nil
+}
+
+// deleteOrphans removes the history and outbox entries of deleted jobs, as
+// the foreign keys of their tables cascade. ms.mu must be held.
+func (ms *MemoryStore) deleteOrphans() {
+	ms.failures = slices.DeleteFunc(ms.failures, func(f *JobFailure) bool { return ms.jobs[f.JobID] == nil })
+	ms.attempts = slices.DeleteFunc(ms.attempts, func(a *JobAttempt) bool { return ms.jobs[a.JobID] == nil })
+	ms.outbox = slices.DeleteFunc(ms.outbox, func(e *OutboxEntry) bool { return ms.jobs[e.JobID] == nil })
+}
+
+func (ms *MemoryStore) ListStaleJobs(ctx context.Context, olderThan time.Duration, limit int) ([]*Job, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	pending := make(map[uuid.UUID]bool)
+	for _, entry := range ms.outbox {
+		if entry.SentAt == nil {
+			pending[entry.JobID] = true
+		}
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	jobs := ms.jobsByCreation(func(job *Job) bool {
+		return !pending[job.ID] && isStale(job, cutoff)
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	stale := make([]*Job, len(jobs))
+	for i, job := range jobs {
+		stale[i] = staleSummary(job)
+	}
+	return stale, nil
+}
+
+func (ms *MemoryStore) ListAbandonedJobs(ctx context.Context, timeout time.Duration, limit int) ([]*Job, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	cutoff := time.Now().Add(-timeout)
+	var jobs []*Job
+	for _, job := range ms.jobs {
+		if job.Status == JobStatusProcessing && job.LastHeartbeatAt != nil && job.LastHeartbeatAt.Before(cutoff) {
+			jobs = append(jobs, job)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b *Job) int {
+		return a.LastHeartbeatAt.Compare(*b.LastHeartbeatAt)
+	})
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	abandoned := make([]*Job, len(jobs))
+	for i, job := range jobs {
+		abandoned[i] = summarizeJob(job)
+	}
+	return abandoned, nil
+}
+
+func (ms *MemoryStore) RequeueJob(ctx context.Context, id uuid.UUID, fromStatus, reason string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	job, ok := ms.jobs[id]
+	if !ok || job.Status != fromStatus {
+		return false, nil
+	}
+	job.Status = JobStatusQueued
+	job.StartedAt, job.CompletedAt = nil, nil
+	job.LastError = stringPtr(reason)
+	return true, nil
+}
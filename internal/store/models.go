@@ -15,6 +15,74 @@ type Job struct {
 	CreatedAt   time.Time  `db:"created_at"`
 	StartedAt   *time.Time `db:"started_at"`
 	CompletedAt *time.Time `db:"completed_at"`
+	Attempts    int        `db:"attempts"`
+	LastError   *string    `db:"last_error"`
+
+	// Attempts made before the job was last replayed from the dead-letter
+	// queue, which its retry budget no longer counts
+	AttemptsBeforeReplay int `db:"attempts_before_replay"`
+
+	// Higher runs sooner; zero is normal priority
+	Priority int `db:"priority"`
+
+	// Client-supplied key that deduplicates enqueues of the same job
+	IdempotencyKey *string `db:"idempotency_key"`
+
+	// Free-form labels for finding the job with ListJobs
+	Tags map[string]string `db:"tags"`
+
+	// Outcome of the last webhook delivery attempt, set for webhook jobs only
+	ResponseStatus    *int   `db:"response_status"`
+	ResponseLatencyMs *int   `db:"response_latency_ms"`
+	ResponseBody      []byte `db:"response_body"`
+
+	// Retry policy; zero values fall back to the job type's defaults
+	MaxAttempts    int           `db:"max_attempts"`
+	RetryBaseDelay time.Duration `db:"retry_base_delay_ms"`
+	RetryMaxDelay  time.Duration `db:"retry_max_delay_ms"`
+	RetryJitter    string        `db:"retry_jitter"`
+
+	// When a scheduled or retrying job is due to run
+	NextRunAt *time.Time `db:"next_run_at"`
+
+	// Set when the job exhausted its retries and was dead-lettered
+	DeadAt *time.Time `db:"dead_at"`
+
+	// Set when cancellation was requested while the job was processing
+	CancelRequestedAt *time.Time `db:"cancel_requested_at"`
+
+	// Last sign of life from the worker running the job
+	LastHeartbeatAt *time.Time `db:"last_heartbeat_at"`
+
+	// Progress last reported by the worker running the job
+	ProgressPercent   *int       `db:"progress_percent"`
+	ProgressMessage   *string    `db:"progress_message"`
+	ProgressUpdatedAt *time.Time `db:"progress_updated_at"`
+
+	// Handler state saved while running, handed back on retry so the job can resume
+	Checkpoint []byte `db:"checkpoint"`
+
+	// Output of the job once completed
+	Result []byte `db:"result"`
+
+	// Detail about the last failure, whose message is in LastError
+	ErrorCode    *string `db:"error_code"`
+	ErrorDetails *string `db:"error_details"`
+}
+
+// AttemptsSinceReplay returns the attempts counted against the job's retry
+// budget: those made since it was last replayed from the dead-letter queue.
+func (j *Job) AttemptsSinceReplay() int {
+	return j.Attempts - j.AttemptsBeforeReplay
+}
+
+// JobError describes why an attempt of a job failed.
+type JobError struct {
+	Message string
+	// Short machine-readable reason, e.g. "http_503"; optional
+	Code string
+	// Longer diagnostics such as a stack trace; optional
+	Details string
 }
 
 // Job status constants
@@ -23,4 +91,72 @@ const (
 	JobStatusProcessing = "processing"
 	JobStatusCompleted  = "completed"
 	JobStatusFailed     = "failed"
+	JobStatusRetrying   = "retrying"
+	JobStatusDead       = "dead"
+	JobStatusScheduled  = "scheduled"
+	JobStatusCancelled  = "cancelled"
+)
+
+// Attempt outcome constants
+const (
+	AttemptRunning   = "running"
+	AttemptSucceeded = "succeeded"
+	AttemptFailed    = "failed"
+	AttemptCancelled = "cancelled"
+	// The worker shut down while running it and handed the job back
+	AttemptInterrupted = "interrupted"
+	// The worker was presumed dead: it stopped sending heartbeats, or the job
+	// was claimed again without it reporting an outcome
+	AttemptAbandoned = "abandoned"
 )
+
+// JobAttempt represents a row in the job_attempts table: one execution of a job.
+type JobAttempt struct {
+	ID             int64      `db:"id"`
+	JobID          uuid.UUID  `db:"job_id"`
+	Attempt        int        `db:"attempt"`
+	WorkerID       string     `db:"worker_id"`
+	Outcome        string     `db:"outcome"`
+	StartedAt      time.Time  `db:"started_at"`
+	FinishedAt     *time.Time `db:"finished_at"`
+	LatencyMs      *int64     `db:"latency_ms"`
+	Error          *string    `db:"error"`
+	ErrorCode      *string    `db:"error_code"`
+	ErrorDetails   *string    `db:"error_details"`
+	ResponseStatus *int       `db:"response_status"` // set for webhook jobs that got a response
+}
+
+// AttemptEnd describes how an attempt ended, for FinishAttempt.
+type AttemptEnd struct {
+	Outcome string
+	// Zero unless the attempt failed or was abandoned
+	Error JobError
+}
+
+// JobFailure represents a row in the job_failures table: one failed attempt.
+type JobFailure struct {
+	ID       int64     `db:"id"`
+	JobID    uuid.UUID `db:"job_id"`
+	Attempt  int       `db:"attempt"`
+	Error    string    `db:"error"`
+	FailedAt time.Time `db:"failed_at"`
+}
+
+// OutboxEntry represents a row in the outbox table: a job reference waiting
+// to be published to the queue.
+type OutboxEntry struct {
+	ID          int64      `db:"id"`
+	JobID       uuid.UUID  `db:"job_id"`
+	JobType     string     `db:"job_type"`
+	CreatedAt   time.Time  `db:"created_at"`
+	AvailableAt time.Time  `db:"available_at"`
+	RunAt       *time.Time `db:"run_at"` // set for jobs that run at a later time
+	Priority    int        `db:"priority"`
+	SentAt      *time.Time `db:"sent_at"`
+	Attempts    int        `db:"attempts"`
+	LastError   *string    `db:"last_error"`
+
+	// Attempts made before the job was last replayed from the dead-letter
+	// queue, which its retry budget no longer counts
+	AttemptsBeforeReplay int `db:"attempts_before_replay"`
+}
@@ -0,0 +1,275 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateJobWithOutbox inserts the job and an outbox row referencing it in a
+// single transaction, so a job is never persisted without a pending hand-off
+// to the queue. A job with NextRunAt set is handed off to run at that time.
+// The returned entry can be marked sent once published.
+func (ps *PostgresStore) CreateJobWithOutbox(ctx context.Context, job *Job) (*OutboxEntry, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, insertJobQuery, insertJobArgs(job)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	entry, err := ps.handOffJob(ctx, tx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job: %w", err)
+	}
+
+	return entry, nil
+}
+
+// CreateIdempotentJob is CreateJobWithOutbox for a job with an IdempotencyKey.
+// If a job created less than retention ago holds the same key, nothing is
+// written and that job is returned instead of an outbox entry; the caller
+// decides whether the two requests match. A key held by an older job is
+// released and given to the new one.
+func (ps *PostgresStore) CreateIdempotentJob(ctx context.Context, job *Job, retention time.Duration) (*Job, *OutboxEntry, error) {
+	if job.IdempotencyKey == nil {
+		return nil, nil, errors.New("job has no idempotency key")
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE jobs
+		SET idempotency_key = NULL
+		WHERE idempotency_key = $1 AND created_at < NOW() - make_interval(secs => $2)
+	`, *job.IdempotencyKey, retention.Seconds())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to release expired idempotency key: %w", err)
+	}
+
+	// A concurrent insert with the same key makes this one wait for it to
+	// commit, then do nothing
+	result, err := tx.ExecContext(ctx, insertJobQuery+`
+		ON CONFLICT (idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+	`, insertJobArgs(job)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	if inserted == 0 {
+		existing, err := scanJob(tx.QueryRowContext(ctx,
+			`SELECT `+jobColumns+` FROM jobs WHERE idempotency_key = $1`, *job.IdempotencyKey))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get job by idempotency key: %w", err)
+		}
+		return existing, nil, nil
+	}
+
+	entry, err := ps.handOffJob(ctx, tx, job)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit job: %w", err)
+	}
+
+	return nil, entry, nil
+}
+
+// handOffJob writes the hand-off of a new job to the queue.
+func (ps *PostgresStore) handOffJob(ctx context.Context, tx *sql.Tx, job *Job) (*OutboxEntry, error) {
+	entry := &OutboxEntry{JobID: job.ID, JobType: job.Type, RunAt: job.NextRunAt, Priority: job.Priority}
+	if err := ps.handOff(ctx, tx, []*OutboxEntry{entry}); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// handOff writes the hand-offs of entries, which have their job, type, due
+// time and priority set, to the queue in one statement. Each is normally an
+// outbox row, whose ID and times are filled in. When the queue is kept in
+// the jobs database the messages are written instead, and the entries come
+// back already sent.
+func (ps *PostgresStore) handOff(ctx context.Context, tx *sql.Tx, entries []*OutboxEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if ps.queue != nil {
+		if err := ps.queue.EnqueueTx(ctx, tx, entries); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, entry := range entries {
+			entry.CreatedAt, entry.AvailableAt, entry.SentAt = now, now, &now
+		}
+		return nil
+	}
+
+	var args []any
+	rows := make([]string, len(entries))
+	byJob := make(map[uuid.UUID]*OutboxEntry, len(entries))
+	for i, entry := range entries {
+		var row string
+		args, row = appendPlaceholders(args, entry.JobID, entry.JobType, entry.RunAt, entry.Priority)
+		rows[i] = "(" + row + ")"
+		byJob[entry.JobID] = entry
+	}
+
+	result, err := tx.QueryContext(ctx, `
+		INSERT INTO outbox (job_id, job_type, run_at, priority)
+		VALUES `+strings.Join(rows, ", ")+`
+		RETURNING id, job_id, created_at, available_at
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox entries: %w", err)
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var id int64
+		var jobID uuid.UUID
+		var createdAt, availableAt time.Time
+		if err := result.Scan(&id, &jobID, &createdAt, &availableAt); err != nil {
+			return fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		entry, ok := byJob[jobID]
+		if !ok {
+			return errors.New("outbox entry returned for an unknown job")
+		}
+		entry.ID, entry.CreatedAt, entry.AvailableAt = id, createdAt, availableAt
+	}
+	if err := result.Err(); err != nil {
+		return fmt.Errorf("failed to create outbox entries: %w", err)
+	}
+	return nil
+}
+
+func (ps *PostgresStore) MarkOutboxSent(ctx context.Context, id int64) error {
+	query := `
+		UPDATE outbox
+		SET sent_at = NOW()
+		WHERE id = $1 AND sent_at IS NULL
+	`
+
+	_, err := ps.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entry as sent: %w", err)
+	}
+
+	return nil
+}
+
+// RelayOutbox locks up to limit pending outbox rows that became available at
+// least minAge ago and calls publish for each. Published rows are marked sent;
+// rows whose publish fails record the error and stay pending for the next call.
+// Rows are locked with SKIP LOCKED, so concurrent relays never publish the same
+// row at the same time. It returns the number of rows published.
+func (ps *PostgresStore) RelayOutbox(ctx context.Context, limit int, minAge time.Duration, publish func(context.Context, *OutboxEntry) error) (int, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, job_id, job_type, created_at, available_at, run_at, priority, attempts
+		FROM outbox
+		WHERE sent_at IS NULL AND available_at <= NOW() - make_interval(secs => $1)
+		ORDER BY available_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, minAge.Seconds(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch pending outbox entries: %w", err)
+	}
+
+	var entries []*OutboxEntry
+	for rows.Next() {
+		entry := &OutboxEntry{}
+		if err := rows.Scan(&entry.ID, &entry.JobID, &entry.JobType, &entry.CreatedAt, &entry.AvailableAt, &entry.RunAt, &entry.Priority, &entry.Attempts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to fetch pending outbox entries: %w", err)
+	}
+
+	sent := 0
+	for _, entry := range entries {
+		if pubErr := publish(ctx, entry); pubErr != nil {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE outbox
+				SET attempts = attempts + 1, last_error = $1
+				WHERE id = $2
+			`, pubErr.Error(), entry.ID)
+		} else {
+			sent++
+			_, err = tx.ExecContext(ctx, `
+				UPDATE outbox
+				SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL
+				WHERE id = $1
+			`, entry.ID)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update outbox entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox relay: %w", err)
+	}
+
+	return sent, nil
+}
+
+// DeleteSentOutbox removes outbox rows that were sent more than olderThan ago
+// and returns how many were deleted.
+func (ps *PostgresStore) DeleteSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `
+		DELETE FROM outbox
+		WHERE sent_at IS NOT NULL AND sent_at < NOW() - make_interval(secs => $1)
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, olderThan.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox entries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// CountPendingOutbox returns the number of outbox rows not yet published.
+func (ps *PostgresStore) CountPendingOutbox(ctx context.Context) (int64, error) {
+	var count int64
+	err := ps.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE sent_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending outbox entries: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,277 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_CreateJobWithOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO jobs").
+		WithArgs(jobID, "job.standard", []byte("test payload"), JobStatusQueued, 3, int64(1000), int64(60000), "full", nil, 0, nil, "{}").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO outbox").
+		WithArgs(jobID, "job.standard", nil, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "created_at", "available_at"}).AddRow(42, jobID, now, now))
+	mock.ExpectCommit()
+
+	entry, err := store.CreateJobWithOutbox(ctx, &Job{
+		ID:      jobID,
+		Type:    "job.standard",
+		Payload: []byte("test payload"),
+		Status:  JobStatusQueued,
+
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  time.Minute,
+		RetryJitter:    "full",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), entry.ID)
+	assert.Equal(t, jobID, entry.JobID)
+	assert.Equal(t, "job.standard", entry.JobType)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CreateJobWithOutbox_QueueInDatabase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	q := &fakeTxEnqueuer{}
+	store := NewPostgresStoreWithQueue(db, q)
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	runAt := time.Now().Add(time.Hour)
+
+	// The queue row is written in place of the outbox row, before committing
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO jobs").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO job_queue").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	entry, err := store.CreateJobWithOutbox(ctx, &Job{
+		ID:        jobID,
+		Type:      "job.standard",
+		Status:    JobStatusQueued,
+		Priority:  -1,
+		NextRunAt: &runAt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, jobID, entry.JobID)
+	assert.NotNil(t, entry.SentAt)
+
+	require.Len(t, q.entries, 1)
+	assert.Equal(t, -1, q.entries[0].Priority)
+	assert.Equal(t, &runAt, q.entries[0].RunAt)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// fakeTxEnqueuer records the entries it is given and writes a placeholder
+// job_queue row in the transaction, so tests see when it ran.
+type fakeTxEnqueuer struct {
+	entries []*OutboxEntry
+}
+
+func (f *fakeTxEnqueuer) EnqueueTx(ctx context.Context, tx *sql.Tx, entries []*OutboxEntry) error {
+	f.entries = append(f.entries, entries...)
+	_, err := tx.ExecContext(ctx, "INSERT INTO job_queue DEFAULT VALUES")
+	return err
+}
+
+func TestPostgresStore_CreateJobWithOutbox_RollsBackOnFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO jobs").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO outbox").
+		WillReturnError(errors.New("outbox unavailable"))
+	mock.ExpectRollback()
+
+	entry, err := store.CreateJobWithOutbox(ctx, &Job{
+		ID:      jobID,
+		Type:    "job.standard",
+		Payload: []byte("test payload"),
+		Status:  JobStatusQueued,
+	})
+	assert.Error(t, err)
+	assert.Nil(t, entry)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CreateIdempotentJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	key := "order-42"
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE jobs SET idempotency_key = NULL").
+		WithArgs(key, float64(86400)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO jobs (.+) ON CONFLICT").
+		WithArgs(jobID, "job.standard", []byte("test payload"), JobStatusQueued, 0, int64(0), int64(0), "", nil, 0, &key, "{}").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO outbox").
+		WithArgs(jobID, "job.standard", nil, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "created_at", "available_at"}).AddRow(42, jobID, now, now))
+	mock.ExpectCommit()
+
+	existing, entry, err := store.CreateIdempotentJob(ctx, &Job{
+		ID:             jobID,
+		Type:           "job.standard",
+		Payload:        []byte("test payload"),
+		Status:         JobStatusQueued,
+		IdempotencyKey: &key,
+	}, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	require.NotNil(t, entry)
+	assert.Equal(t, int64(42), entry.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CreateIdempotentJob_Duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	originalID := uuid.New()
+	key := "order-42"
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE jobs SET idempotency_key = NULL").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO jobs (.+) ON CONFLICT").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT (.+) FROM jobs WHERE idempotency_key").
+		WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "type", "payload", "status", "created_at", "started_at", "completed_at", "attempts", "last_error",
+			"response_status", "response_latency_ms", "response_body",
+			"max_attempts", "retry_base_delay_ms", "retry_max_delay_ms", "retry_jitter", "next_run_at",
+			"dead_at", "priority", "idempotency_key", "cancel_requested_at", "tags", "last_heartbeat_at",
+			"progress_percent", "progress_message", "progress_updated_at", "checkpoint",
+			"result", "error_code", "error_details", "attempts_before_replay",
+		}).AddRow(originalID, "job.standard", []byte("test payload"), JobStatusQueued, now, nil, nil, 0, nil,
+			nil, nil, nil, 3, 1000, 60000, "full", nil, nil, 0, key, nil, []byte("{}"), nil, nil, nil, nil, nil, nil, nil, nil, 0))
+	mock.ExpectRollback()
+
+	existing, entry, err := store.CreateIdempotentJob(ctx, &Job{
+		ID:             uuid.New(),
+		Type:           "job.standard",
+		Payload:        []byte("test payload"),
+		Status:         JobStatusQueued,
+		IdempotencyKey: &key,
+	}, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+	require.NotNil(t, existing)
+	assert.Equal(t, originalID, existing.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_MarkOutboxSent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+
+	mock.ExpectExec("UPDATE outbox SET sent_at").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.MarkOutboxSent(context.Background(), 7)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_RelayOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	okJob, failJob := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM outbox (.+) FOR UPDATE SKIP LOCKED").
+		WithArgs(float64(5), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "job_type", "created_at", "available_at", "run_at", "priority", "attempts"}).
+			AddRow(1, okJob, "job.standard", now, now, nil, 5, 0).
+			AddRow(2, failJob, "job.standard", now, now, now.Add(time.Minute), 0, 2))
+	mock.ExpectExec("UPDATE outbox SET sent_at").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox SET attempts").
+		WithArgs("redis down", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var published []uuid.UUID
+	sent, err := store.RelayOutbox(ctx, 10, 5*time.Second, func(ctx context.Context, entry *OutboxEntry) error {
+		published = append(published, entry.JobID)
+		if entry.JobID == okJob {
+			assert.Equal(t, 5, entry.Priority)
+		}
+		if entry.JobID == failJob {
+			assert.NotNil(t, entry.RunAt)
+			return errors.New("redis down")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []uuid.UUID{okJob, failJob}, published)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
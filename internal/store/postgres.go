@@ -3,47 +3,136 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrJobNotFound is returned when no job exists for the requested ID.
+var ErrJobNotFound = errors.New("job not found")
+
 type PostgresStore struct {
 	db *sql.DB
+	// queue is set when the queue is kept in db
+	queue TxEnqueuer
+}
+
+// TxEnqueuer queues jobs as part of a transaction on the jobs database, for a
+// queue kept in that database.
+type TxEnqueuer interface {
+	// EnqueueTx queues the job of each entry, or schedules it if RunAt is
+	// set, once tx commits.
+	EnqueueTx(ctx context.Context, tx *sql.Tx, entries []*OutboxEntry) error
 }
 
 func NewPostgresStore(db *sql.DB) *PostgresStore {
 	return &PostgresStore{db: db}
 }
 
+// NewPostgresStoreWithQueue creates a PostgresStore for a deployment whose
+// queue is kept in the same database, such as the job_queue table of
+// queue.PostgresQueue. Jobs are handed off by writing to q in the transaction
+// that writes the jobs rather than through the outbox, so jobs and queue
+// state cannot disagree, and the returned outbox entries are already sent.
+func NewPostgresStoreWithQueue(db *sql.DB, q TxEnqueuer) *PostgresStore {
+	return &PostgresStore{db: db, queue: q}
+}
+
 func (ps *PostgresStore) Close() error {
 	return ps.db.Close()
 }
 
-func (ps *PostgresStore) CreateJob(ctx context.Context, job *Job) error {
-	query := `
-		INSERT INTO jobs (id, type, payload, status)
-		VALUES ($1, $2, $3, $4)
-	`
+// jobColumns lists the columns read into a Job, in the order scanJob expects.
+const jobColumns = `
+	id, type, payload, status, created_at, started_at, completed_at, attempts, last_error,
+	response_status, response_latency_ms, response_body,
+	max_attempts, retry_base_delay_ms, retry_max_delay_ms, retry_jitter, next_run_at,
+	dead_at, priority, idempotency_key, cancel_requested_at, tags, last_heartbeat_at,
+	progress_percent, progress_message, progress_updated_at, checkpoint,
+	result, error_code, error_details, attempts_before_replay
+`
 
-	_, err := ps.db.ExecContext(ctx, query, job.ID, job.Type, job.Payload, job.Status)
+// jobSummaryColumns is jobColumns with the payload, checkpoint and result,
+// which can be up to a megabyte each, read as NULL.
+const jobSummaryColumns = `
+	id, type, NULL, status, created_at, started_at, completed_at, attempts, last_error,
+	response_status, response_latency_ms, response_body,
+	max_attempts, retry_base_delay_ms, retry_max_delay_ms, retry_jitter, next_run_at,
+	dead_at, priority, idempotency_key, cancel_requested_at, tags, last_heartbeat_at,
+	progress_percent, progress_message, progress_updated_at, NULL,
+	NULL, error_code, error_details, attempts_before_replay
+`
 
-	if err != nil {
-		return fmt.Errorf("failed to create job: %w", err)
+// insertJobPrefix is followed by one row of insertJobArgs per job inserted.
+const insertJobPrefix = `
+	INSERT INTO jobs (id, type, payload, status, max_attempts, retry_base_delay_ms, retry_max_delay_ms, retry_jitter, next_run_at, priority, idempotency_key, tags)
+	VALUES `
+
+// insertJobQuery inserts a job from the arguments returned by insertJobArgs.
+const insertJobQuery = insertJobPrefix + `($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+`
+
+func insertJobArgs(job *Job) []any {
+	return []any{
+		job.ID,
+		job.Type,
+		job.Payload,
+		job.Status,
+		job.MaxAttempts,
+		job.RetryBaseDelay.Milliseconds(),
+		job.RetryMaxDelay.Milliseconds(),
+		job.RetryJitter,
+		job.NextRunAt,
+		job.Priority,
+		job.IdempotencyKey,
+		marshalTags(job.Tags),
 	}
+}
 
-	return nil
+// marshalTags encodes tags for the JSONB tags column. It returns a string
+// because lib/pq sends []byte arguments as bytea.
+func marshalTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return "{}"
+	}
+	// A map of strings always marshals
+	data, _ := json.Marshal(tags)
+	return string(data)
 }
 
-func (ps *PostgresStore) GetJobByID(ctx context.Context, id uuid.UUID) (*Job, error) {
-	query := `
-		SELECT id, type, payload, status, created_at, started_at, completed_at
-		FROM jobs
-		WHERE id = $1
-	`
+// nullBytes returns nil for a nil slice, which lib/pq would otherwise send
+// as an empty bytea rather than NULL.
+func nullBytes(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// nullString returns nil for an empty string, to store it as NULL.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
 
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJob reads a row selected with jobColumns.
+func scanJob(row scanner) (*Job, error) {
 	job := &Job{}
-	err := ps.db.QueryRowContext(ctx, query, id).Scan(
+	var baseDelayMs, maxDelayMs int64
+	var tags []byte
+
+	err := row.Scan(
 		&job.ID,
 		&job.Type,
 		&job.Payload,
@@ -51,10 +140,63 @@ func (ps *PostgresStore) GetJobByID(ctx context.Context, id uuid.UUID) (*Job, er
 		&job.CreatedAt,
 		&job.StartedAt,
 		&job.CompletedAt,
+		&job.Attempts,
+		&job.LastError,
+		&job.ResponseStatus,
+		&job.ResponseLatencyMs,
+		&job.ResponseBody,
+		&job.MaxAttempts,
+		&baseDelayMs,
+		&maxDelayMs,
+		&job.RetryJitter,
+		&job.NextRunAt,
+		&job.DeadAt,
+		&job.Priority,
+		&job.IdempotencyKey,
+		&job.CancelRequestedAt,
+		&tags,
+		&job.LastHeartbeatAt,
+		&job.ProgressPercent,
+		&job.ProgressMessage,
+		&job.ProgressUpdatedAt,
+		&job.Checkpoint,
+		&job.Result,
+		&job.ErrorCode,
+		&job.ErrorDetails,
+		&job.AttemptsBeforeReplay,
 	)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		if err := json.Unmarshal(tags, &job.Tags); err != nil {
+			return nil, fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+
+	job.RetryBaseDelay = time.Duration(baseDelayMs) * time.Millisecond
+	job.RetryMaxDelay = time.Duration(maxDelayMs) * time.Millisecond
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("job not found for ID %s", id)
+	return job, nil
+}
+
+func (ps *PostgresStore) CreateJob(ctx context.Context, job *Job) error {
+	_, err := ps.db.ExecContext(ctx, insertJobQuery, insertJobArgs(job)...)
+
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+func (ps *PostgresStore) GetJobByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	job, err := scanJob(ps.db.QueryRowContext(ctx, query, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job by ID: %w", err)
@@ -63,25 +205,33 @@ func (ps *PostgresStore) GetJobByID(ctx context.Context, id uuid.UUID) (*Job, er
 	return job, nil
 }
 
-func (ps *PostgresStore) MarkJobAsQueued(ctx context.Context, id uuid.UUID) error {
+// MarkJobAsQueued returns a job whose attempt was interrupted to the queued
+// status. It reports false if the job is no longer processing that attempt,
+// as when its lease expired and another worker claimed it.
+func (ps *PostgresStore) MarkJobAsQueued(ctx context.Context, id uuid.UUID, attempt int) (bool, error) {
 	query := `
 		UPDATE jobs
 		SET status = $1, started_at = NULL, completed_at = NULL
-		WHERE id = $2
+		WHERE id = $2 AND status = $3 AND attempts = $4
 	`
 
-	_, err := ps.db.ExecContext(ctx, query, JobStatusQueued, id)
+	res, err := ps.db.ExecContext(ctx, query, JobStatusQueued, id, JobStatusProcessing, attempt)
 	if err != nil {
-		return fmt.Errorf("failed to mark job as queued: %w", err)
+		return false, fmt.Errorf("failed to mark job as queued: %w", err)
 	}
 
-	return nil
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark job as queued: %w", err)
+	}
+
+	return affected > 0, nil
 }
 
 func (ps *PostgresStore) MarkJobAsProcessing(ctx context.Context, id uuid.UUID) error {
 	query := `
 		UPDATE jobs
-		SET status = $1, started_at = NOW()
+		SET status = $1, started_at = NOW(), last_heartbeat_at = NOW(), attempts = attempts + 1
 		WHERE id = $2
 	`
 
@@ -93,32 +243,286 @@ func (ps *PostgresStore) MarkJobAsProcessing(ctx context.Context, id uuid.UUID)
 	return nil
 }
 
-func (ps *PostgresStore) MarkJobAsCompleted(ctx context.Context, id uuid.UUID) error {
+// ClaimJob marks a job as processing on behalf of a worker and reports whether
+// the claim succeeded. A queued, retrying or scheduled job can always be claimed; a
+// processing job only once its worker has sent no heartbeat for longer than
+// staleAfter, which means the worker that held it is gone. This keeps duplicate queue
+// messages from running a job twice concurrently.
+//
+// A successful claim opens a running attempt for workerID in the job's attempt
+// history, and closes any attempt left open by a previous worker as abandoned.
+func (ps *PostgresStore) ClaimJob(ctx context.Context, id uuid.UUID, workerID string, staleAfter time.Duration) (bool, error) {
+	query := `
+		WITH claimed AS (
+		  UPDATE jobs
+		  SET status = $1, started_at = NOW(), last_heartbeat_at = NOW(), next_run_at = NULL, attempts = attempts + 1
+		  WHERE id = $2
+		    AND (status IN ($3, $4, $5) OR (status = $1 AND COALESCE(last_heartbeat_at, started_at) < NOW() - make_interval(secs => $6)))
+		  RETURNING id, attempts, started_at
+		), abandoned AS (
+		  UPDATE job_attempts
+		  SET outcome = $7, finished_at = NOW()
+		  WHERE job_id IN (SELECT id FROM claimed) AND outcome = $8
+		)
+		INSERT INTO job_attempts (job_id, attempt, worker_id, outcome, started_at)
+		SELECT id, attempts, $9, $8, started_at FROM claimed
+	`
+
+	result, err := ps.db.ExecContext(ctx, query,
+		JobStatusProcessing, id, JobStatusQueued, JobStatusRetrying, JobStatusScheduled, staleAfter.Seconds(),
+		AttemptAbandoned, AttemptRunning, workerID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// PromoteScheduledJobs marks scheduled jobs as queued once the promoter has
+// moved them to the ready queue, and returns how many were updated. Jobs that
+// are no longer scheduled are left alone.
+func (ps *PostgresStore) PromoteScheduledJobs(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := []any{JobStatusQueued, JobStatusScheduled}
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		args = append(args, id)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
 	query := `
 		UPDATE jobs
-		SET status = $1, completed_at = NOW()
-		WHERE id = $2
+		SET status = $1, next_run_at = NULL
+		WHERE status = $2 AND id IN (` + strings.Join(placeholders, ", ") + `)
 	`
 
-	_, err := ps.db.ExecContext(ctx, query, JobStatusCompleted, id)
+	result, err := ps.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to mark job as completed: %w", err)
+		return 0, fmt.Errorf("failed to promote scheduled jobs: %w", err)
 	}
 
-	return nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote scheduled jobs: %w", err)
+	}
+
+	return affected, nil
 }
 
-func (ps *PostgresStore) MarkJobAsFailed(ctx context.Context, id uuid.UUID) error {
+// MarkJobAsCompleted marks a processing job completed with its output, which
+// may be nil. It reports false if the job was no longer processing attempt.
+func (ps *PostgresStore) MarkJobAsCompleted(ctx context.Context, id uuid.UUID, attempt int, result []byte) (bool, error) {
 	query := `
 		UPDATE jobs
-		SET status = $1, completed_at = NOW()
-		WHERE id = $2
+		SET status = $1, completed_at = NOW(), result = $2
+		WHERE id = $3 AND status = $4 AND attempts = $5
+	`
+
+	res, err := ps.db.ExecContext(ctx, query, JobStatusCompleted, nullBytes(result), id, JobStatusProcessing, attempt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark job as completed: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark job as completed: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// MarkJobAsFailed marks a job failed with the error that failed it.
+func (ps *PostgresStore) MarkJobAsFailed(ctx context.Context, id uuid.UUID, jobErr JobError) error {
+	query := `
+		UPDATE jobs
+		SET status = $1, completed_at = NOW(), last_error = $2, error_code = $3, error_details = $4
+		WHERE id = $5
 	`
 
-	_, err := ps.db.ExecContext(ctx, query, JobStatusFailed, id)
+	_, err := ps.db.ExecContext(ctx, query, JobStatusFailed, jobErr.Message, nullString(jobErr.Code), nullString(jobErr.Details), id)
 	if err != nil {
 		return fmt.Errorf("failed to mark job as failed: %w", err)
 	}
 
 	return nil
 }
+
+// RecordWebhookResponse stores the status, latency and (already truncated) body
+// of the response to a webhook delivery attempt. The status is also recorded
+// against the job's running attempt.
+func (ps *PostgresStore) RecordWebhookResponse(ctx context.Context, id uuid.UUID, statusCode int, latency time.Duration, body []byte) error {
+	query := `
+		WITH attempt AS (
+		  UPDATE job_attempts
+		  SET response_status = $1
+		  WHERE job_id = $4 AND outcome = $5
+		)
+		UPDATE jobs
+		SET response_status = $1, response_latency_ms = $2, response_body = $3
+		WHERE id = $4
+	`
+
+	_, err := ps.db.ExecContext(ctx, query, statusCode, latency.Milliseconds(), body, id, AttemptRunning)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook response: %w", err)
+	}
+
+	return nil
+}
+
+// ListStaleJobs returns up to limit jobs that have sat in queued (since creation),
+// processing (since being started) or retrying or scheduled (since their next
+// run was due) for longer than olderThan. Jobs whose outbox entry is still pending are
+// excluded, as the relay will publish them. Payloads are not loaded.
+func (ps *PostgresStore) ListStaleJobs(ctx context.Context, olderThan time.Duration, limit int) ([]*Job, error) {
+	query := `
+		SELECT id, type, status, created_at, started_at, completed_at, attempts, last_error, max_attempts, priority,
+		       attempts_before_replay
+		FROM jobs j
+		WHERE ((j.status = $1 AND j.created_at < NOW() - make_interval(secs => $4))
+		    OR (j.status = $2 AND COALESCE(j.last_heartbeat_at, j.started_at) < NOW() - make_interval(secs => $4))
+		    OR (j.status IN ($3, $5) AND j.next_run_at < NOW() - make_interval(secs => $4)))
+		  AND NOT EXISTS (
+		    SELECT 1 FROM outbox o WHERE o.job_id = j.id AND o.sent_at IS NULL
+		  )
+		ORDER BY j.created_at
+		LIMIT $6
+	`
+
+	rows, err := ps.db.QueryContext(ctx, query, JobStatusQueued, JobStatusProcessing, JobStatusRetrying, olderThan.Seconds(), JobStatusScheduled, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job := &Job{}
+		if err := rows.Scan(
+			&job.ID,
+			&job.Type,
+			&job.Status,
+			&job.CreatedAt,
+			&job.StartedAt,
+			&job.CompletedAt,
+			&job.Attempts,
+			&job.LastError,
+			&job.MaxAttempts,
+			&job.Priority,
+			&job.AttemptsBeforeReplay,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stale job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list stale jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// RequeueJob moves a job from fromStatus back to queued, recording reason as its
+// last error. It reports false if the job was no longer in fromStatus.
+func (ps *PostgresStore) RequeueJob(ctx context.Context, id uuid.UUID, fromStatus, reason string) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET status = $1, started_at = NULL, completed_at = NULL, last_error = $2
+		WHERE id = $3 AND status = $4
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, JobStatusQueued, reason, id, fromStatus)
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// FailJob moves a job from fromStatus to failed, recording jobErr as its last
+// error and its message in its failure history. It reports false if the job
+// was no longer in fromStatus at attempt.
+func (ps *PostgresStore) FailJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error) {
+	query := `
+		WITH failed AS (
+		  UPDATE jobs
+		  SET status = $1, completed_at = NOW(), last_error = $2, error_code = $3, error_details = $4
+		  WHERE id = $5 AND status = $6 AND attempts = $7
+		  RETURNING id, attempts, last_error
+		)
+		INSERT INTO job_failures (job_id, attempt, error)
+		SELECT id, attempts, last_error FROM failed
+	`
+
+	result, err := ps.db.ExecContext(ctx, query, JobStatusFailed, jobErr.Message, nullString(jobErr.Code), nullString(jobErr.Details), id, fromStatus, attempt)
+	if err != nil {
+		return false, fmt.Errorf("failed to fail job: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to fail job: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// ScheduleRetry moves a processing job to retrying after a failed attempt,
+// recording jobErr as its last error and its message in its failure history, and writes
+// an outbox entry to run the job again after delay. Both happen in one
+// transaction. The caller publishes the returned entry; the outbox relay picks
+// it up otherwise. It returns nil if the job was no longer processing attempt.
+func (ps *PostgresStore) ScheduleRetry(ctx context.Context, id uuid.UUID, attempt int, jobErr JobError, delay time.Duration) (*OutboxEntry, error) {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	entry := &OutboxEntry{JobID: id}
+	var nextRunAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		WITH retried AS (
+		  UPDATE jobs
+		  SET status = $1, last_error = $2, error_code = $3, error_details = $4,
+		    next_run_at = NOW() + make_interval(secs => $5)
+		  WHERE id = $6 AND status = $7 AND attempts = $8
+		  RETURNING id, type, attempts, last_error, next_run_at, priority
+		), failure AS (
+		  INSERT INTO job_failures (job_id, attempt, error)
+		  SELECT id, attempts, last_error FROM retried
+		)
+		SELECT type, next_run_at, priority FROM retried
+	`, JobStatusRetrying, jobErr.Message, nullString(jobErr.Code), nullString(jobErr.Details), delay.Seconds(), id, JobStatusProcessing, attempt).Scan(&entry.JobType, &nextRunAt, &entry.Priority)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule retry: %w", err)
+	}
+	entry.RunAt = &nextRunAt
+
+	if err := ps.handOff(ctx, tx, []*OutboxEntry{entry}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit retry: %w", err)
+	}
+
+	return entry, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPostgresStore_CreateJob(t *testing.T) {
@@ -23,7 +24,7 @@ func TestPostgresStore_CreateJob(t *testing.T) {
 
 	// Only mock the INSERT
 	mock.ExpectExec("INSERT INTO jobs").
-		WithArgs(jobID, "job.standard", []byte("test payload"), JobStatusQueued).
+		WithArgs(jobID, "job.standard", []byte("test payload"), JobStatusQueued, 5, int64(2000), int64(30000), "equal", nil, 3, nil, `{"tenant":"acme"}`).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	job := &Job{
@@ -31,6 +32,13 @@ func TestPostgresStore_CreateJob(t *testing.T) {
 		Type:    "job.standard",
 		Payload: []byte("test payload"),
 		Status:  JobStatusQueued,
+
+		MaxAttempts:    5,
+		RetryBaseDelay: 2 * time.Second,
+		RetryMaxDelay:  30 * time.Second,
+		RetryJitter:    "equal",
+		Priority:       3,
+		Tags:           map[string]string{"tenant": "acme"},
 	}
 
 	err = store.CreateJob(ctx, job)
@@ -52,7 +60,12 @@ func TestPostgresStore_GetJobByID(t *testing.T) {
 
 	// Only mock the SELECT - no INSERT needed!
 	rows := sqlmock.NewRows([]string{
-		"id", "type", "payload", "status", "created_at", "started_at", "completed_at",
+		"id", "type", "payload", "status", "created_at", "started_at", "completed_at", "attempts", "last_error",
+		"response_status", "response_latency_ms", "response_body",
+		"max_attempts", "retry_base_delay_ms", "retry_max_delay_ms", "retry_jitter", "next_run_at",
+		"dead_at", "priority", "idempotency_key", "cancel_requested_at", "tags", "last_heartbeat_at",
+		"progress_percent", "progress_message", "progress_updated_at", "checkpoint",
+		"result", "error_code", "error_details", "attempts_before_replay",
 	}).AddRow(
 		jobID,
 		"job.standard",
@@ -61,6 +74,30 @@ func TestPostgresStore_GetJobByID(t *testing.T) {
 		now,
 		nil,
 		nil,
+		0,
+		nil,
+		nil,
+		nil,
+		nil,
+		3,
+		1000,
+		60000,
+		"full",
+		nil,
+		nil,
+		-1,
+		nil,
+		nil,
+		[]byte(`{"tenant":"acme"}`),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		0,
 	)
 
 	mock.ExpectQuery("SELECT (.+) FROM jobs WHERE id").
@@ -76,6 +113,15 @@ func TestPostgresStore_GetJobByID(t *testing.T) {
 	assert.Equal(t, []byte("test payload"), retrieved.Payload)
 	assert.Equal(t, JobStatusQueued, retrieved.Status)
 	assert.NotZero(t, retrieved.CreatedAt)
+	assert.Equal(t, 0, retrieved.Attempts)
+	assert.Nil(t, retrieved.LastError)
+	assert.Equal(t, 3, retrieved.MaxAttempts)
+	assert.Equal(t, time.Second, retrieved.RetryBaseDelay)
+	assert.Equal(t, time.Minute, retrieved.RetryMaxDelay)
+	assert.Equal(t, "full", retrieved.RetryJitter)
+	assert.Nil(t, retrieved.NextRunAt)
+	assert.Nil(t, retrieved.DeadAt)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, retrieved.Tags)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -96,7 +142,7 @@ func TestPostgresStore_GetJobByID_NotFound(t *testing.T) {
 		WillReturnError(sql.ErrNoRows)
 
 	retrieved, err := store.GetJobByID(ctx, jobID)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrJobNotFound)
 	assert.Nil(t, retrieved)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -112,11 +158,20 @@ func TestPostgresStore_MarkJobAsQueued(t *testing.T) {
 	jobID := uuid.New()
 
 	mock.ExpectExec("UPDATE jobs SET status").
-		WithArgs(JobStatusQueued, jobID).
+		WithArgs(JobStatusQueued, jobID, JobStatusProcessing, 2).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(JobStatusQueued, jobID, JobStatusProcessing, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
 
-	err = store.MarkJobAsQueued(ctx, jobID)
+	requeued, err := store.MarkJobAsQueued(ctx, jobID, 2)
 	assert.NoError(t, err)
+	assert.True(t, requeued)
+
+	// The attempt ended or was taken over
+	requeued, err = store.MarkJobAsQueued(ctx, jobID, 2)
+	assert.NoError(t, err)
+	assert.False(t, requeued)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -140,6 +195,36 @@ func TestPostgresStore_MarkJobAsProcessing(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestPostgresStore_ClaimJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectExec(`WITH claimed AS \( UPDATE jobs SET status (.+) INSERT INTO job_attempts`).
+		WithArgs(JobStatusProcessing, jobID, JobStatusQueued, JobStatusRetrying, JobStatusScheduled, float64(60),
+			AttemptAbandoned, AttemptRunning, "worker-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`WITH claimed AS \( UPDATE jobs SET status (.+) INSERT INTO job_attempts`).
+		WithArgs(JobStatusProcessing, jobID, JobStatusQueued, JobStatusRetrying, JobStatusScheduled, float64(60),
+			AttemptAbandoned, AttemptRunning, "worker-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	claimed, err := store.ClaimJob(ctx, jobID, "worker-1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Already held by another worker
+	claimed, err = store.ClaimJob(ctx, jobID, "worker-1", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostgresStore_MarkJobAsCompleted(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -149,12 +234,22 @@ func TestPostgresStore_MarkJobAsCompleted(t *testing.T) {
 	ctx := context.Background()
 	jobID := uuid.New()
 
-	mock.ExpectExec("UPDATE jobs SET status").
-		WithArgs(JobStatusCompleted, jobID).
+	mock.ExpectExec("UPDATE jobs SET status (.+) WHERE id = \\$3 AND status = \\$4 AND attempts = \\$5").
+		WithArgs(JobStatusCompleted, []byte(`{"rows":42}`), jobID, JobStatusProcessing, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err = store.MarkJobAsCompleted(ctx, jobID)
+	completed, err := store.MarkJobAsCompleted(ctx, jobID, 1, []byte(`{"rows":42}`))
+	assert.NoError(t, err)
+	assert.True(t, completed)
+
+	// No result is stored as NULL; a job claimed again meanwhile is left alone
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(JobStatusCompleted, nil, jobID, JobStatusProcessing, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	completed, err = store.MarkJobAsCompleted(ctx, jobID, 1, nil)
 	assert.NoError(t, err)
+	assert.False(t, completed)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -169,11 +264,181 @@ func TestPostgresStore_MarkJobAsFailed(t *testing.T) {
 	jobID := uuid.New()
 
 	mock.ExpectExec("UPDATE jobs SET status").
-		WithArgs(JobStatusFailed, jobID).
+		WithArgs(JobStatusFailed, "invalid payload", "bad_request", "missing field url", jobID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.MarkJobAsFailed(ctx, jobID, JobError{Message: "invalid payload", Code: "bad_request", Details: "missing field url"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_RecordWebhookResponse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectExec(`WITH attempt AS \( UPDATE job_attempts SET response_status = \$1 (.+) UPDATE jobs SET response_status`).
+		WithArgs(502, int64(1500), []byte("bad gateway"), jobID, AttemptRunning).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err = store.MarkJobAsFailed(ctx, jobID)
+	err = store.RecordWebhookResponse(ctx, jobID, 502, 1500*time.Millisecond, []byte("bad gateway"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ListStaleJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+
+	queuedID, processingID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "type", "status", "created_at", "started_at", "completed_at", "attempts", "last_error", "max_attempts", "priority",
+		"attempts_before_replay",
+	}).
+		AddRow(queuedID, "job.standard", JobStatusQueued, now, nil, nil, 0, nil, 3, 0, 0).
+		AddRow(processingID, "job.standard", JobStatusProcessing, now, now, nil, 4, nil, 5, 2, 3)
+
+	mock.ExpectQuery("SELECT (.+) FROM jobs (.+) NOT EXISTS").
+		WithArgs(JobStatusQueued, JobStatusProcessing, JobStatusRetrying, float64(600), JobStatusScheduled, 100).
+		WillReturnRows(rows)
+
+	jobs, err := store.ListStaleJobs(ctx, 10*time.Minute, 100)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, queuedID, jobs[0].ID)
+	assert.Equal(t, JobStatusProcessing, jobs[1].Status)
+	assert.Equal(t, 4, jobs[1].Attempts)
+	assert.Equal(t, 1, jobs[1].AttemptsSinceReplay())
+	assert.Equal(t, 5, jobs[1].MaxAttempts)
+	assert.Equal(t, 2, jobs[1].Priority)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_RequeueJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectExec("UPDATE jobs SET status").
+		WithArgs(JobStatusQueued, "worker lost", jobID, JobStatusProcessing).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	requeued, err := store.RequeueJob(ctx, jobID, JobStatusProcessing, "worker lost")
+	assert.NoError(t, err)
+	assert.True(t, requeued)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_FailJob_StatusChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectExec("UPDATE jobs SET status (.+) INSERT INTO job_failures").
+		WithArgs(JobStatusFailed, "worker lost", nil, nil, jobID, JobStatusProcessing, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	failed, err := store.FailJob(ctx, jobID, JobStatusProcessing, 2, JobError{Message: "worker lost"})
+	assert.NoError(t, err)
+	assert.False(t, failed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ScheduleRetry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+	nextRunAt := time.Now().Add(30 * time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE jobs SET status (.+) INSERT INTO job_failures").
+		WithArgs(JobStatusRetrying, "boom", "http_503", nil, float64(30), jobID, JobStatusProcessing, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "next_run_at", "priority"}).AddRow("job.standard", nextRunAt, 1))
+	mock.ExpectQuery("INSERT INTO outbox").
+		WithArgs(jobID, "job.standard", nextRunAt, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "created_at", "available_at"}).AddRow(9, jobID, time.Now(), time.Now()))
+	mock.ExpectCommit()
+
+	entry, err := store.ScheduleRetry(ctx, jobID, 1, JobError{Message: "boom", Code: "http_503"}, 30*time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, int64(9), entry.ID)
+	assert.Equal(t, "job.standard", entry.JobType)
+	assert.Equal(t, &nextRunAt, entry.RunAt)
+	assert.Equal(t, 1, entry.Priority)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_ScheduleRetry_StatusChanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE jobs SET status").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	entry, err := store.ScheduleRetry(ctx, jobID, 1, JobError{Message: "boom"}, time.Second)
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_PromoteScheduledJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectExec(`UPDATE jobs SET status = \$1, next_run_at = NULL WHERE status = \$2 AND id IN \(\$3, \$4\)`).
+		WithArgs(JobStatusQueued, JobStatusScheduled, first, second).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	promoted, err := store.PromoteScheduledJobs(ctx, []uuid.UUID{first, second})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), promoted)
+
+	// Nothing to promote means no query
+	promoted, err = store.PromoteScheduledJobs(ctx, nil)
 	assert.NoError(t, err)
+	assert.Zero(t, promoted)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// UpdateProgress records the progress reported by the worker running a job,
+// which also counts as its heartbeat. A nil checkpoint leaves the job's
+// checkpoint as it was. It reports whether the job is still processing
+// attempt, and so still held by the worker, and whether its cancellation was
+// requested.
+func (ps *PostgresStore) UpdateProgress(ctx context.Context, id uuid.UUID, attempt int, percent int, message string, checkpoint []byte) (held, cancelRequested bool, err error) {
+	query := `
+		UPDATE jobs
+		SET progress_percent = $1, progress_message = $2, progress_updated_at = NOW(),
+			checkpoint = COALESCE($3, checkpoint), last_heartbeat_at = NOW()
+		WHERE id = $4 AND status = $5 AND attempts = $6
+		RETURNING cancel_requested_at IS NOT NULL
+	`
+
+	err = ps.db.QueryRowContext(ctx, query, percent, message, nullBytes(checkpoint), id, JobStatusProcessing, attempt).Scan(&cancelRequested)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to update job progress: %w", err)
+	}
+
+	return true, cancelRequested, nil
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_UpdateProgress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	mock.ExpectQuery(`UPDATE jobs SET progress_percent = \$1, progress_message = \$2, progress_updated_at = NOW\(\), checkpoint = COALESCE\(\$3, checkpoint\), last_heartbeat_at = NOW\(\) WHERE id = \$4 AND status = \$5 AND attempts = \$6`).
+		WithArgs(40, "copied 4 of 10 files", []byte(`{"next":4}`), jobID, JobStatusProcessing, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"cancel_requested"}).AddRow(false))
+
+	held, cancelRequested, err := store.UpdateProgress(ctx, jobID, 1, 40, "copied 4 of 10 files", []byte(`{"next":4}`))
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.False(t, cancelRequested)
+
+	// Without a checkpoint the saved one is kept
+	mock.ExpectQuery("UPDATE jobs SET progress_percent").
+		WithArgs(50, "", nil, jobID, JobStatusProcessing, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"cancel_requested"}))
+
+	held, _, err = store.UpdateProgress(ctx, jobID, 1, 50, "", nil)
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobStore persists jobs, their outbox hand-offs and their history.
+// PostgresStore is the production implementation; BoltStore keeps everything
+// in a local bbolt file for embedded mode, and MemoryStore keeps it in process.
+// All pass the conformance suite in package storetest, which documents the
+// behavior every implementation must share.
+//
+// Methods that record the outcome of a job's run take the attempt the caller
+// holds, which is the job's attempt count once ClaimJob took it. Each claim
+// starts a new attempt, so a worker whose job was taken over cannot record an
+// outcome for the run that replaced its own.
+type JobStore interface {
+	// Jobs
+	CreateJob(ctx context.Context, job *Job) error
+	GetJobByID(ctx context.Context, id uuid.UUID) (*Job, error)
+	ListJobs(ctx context.Context, filter JobFilter, opts ListJobsOptions) ([]*Job, error)
+
+	// Enqueueing and the outbox
+	CreateJobWithOutbox(ctx context.Context, job *Job) (*OutboxEntry, error)
+	CreateIdempotentJob(ctx context.Context, job *Job, retention time.Duration) (*Job, *OutboxEntry, error)
+	CreateJobsWithOutbox(ctx context.Context, jobs []*Job, retention time.Duration, check func(existing map[string]*Job) error) ([]*OutboxEntry, map[string]*Job, error)
+	MarkOutboxSent(ctx context.Context, id int64) error
+	MarkOutboxSentBatch(ctx context.Context, ids []int64) error
+	RelayOutbox(ctx context.Context, limit int, minAge time.Duration, publish func(context.Context, *OutboxEntry) error) (int, error)
+	DeleteSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error)
+	CountPendingOutbox(ctx context.Context) (int64, error)
+	PromoteScheduledJobs(ctx context.Context, ids []uuid.UUID) (int64, error)
+
+	// Running jobs
+	MarkJobAsQueued(ctx context.Context, id uuid.UUID, attempt int) (bool, error)
+	MarkJobAsProcessing(ctx context.Context, id uuid.UUID) error
+	ClaimJob(ctx context.Context, id uuid.UUID, workerID string, staleAfter time.Duration) (bool, error)
+	Heartbeat(ctx context.Context, id uuid.UUID, attempt int) (held, cancelRequested bool, err error)
+	UpdateProgress(ctx context.Context, id uuid.UUID, attempt int, percent int, message string, checkpoint []byte) (held, cancelRequested bool, err error)
+	RecordWebhookResponse(ctx context.Context, id uuid.UUID, statusCode int, latency time.Duration, body []byte) error
+	FinishAttempt(ctx context.Context, id uuid.UUID, attempt int, end AttemptEnd) error
+	ListJobAttempts(ctx context.Context, id uuid.UUID) ([]*JobAttempt, error)
+
+	// Finishing jobs
+	MarkJobAsCompleted(ctx context.Context, id uuid.UUID, attempt int, result []byte) (bool, error)
+	MarkJobAsFailed(ctx context.Context, id uuid.UUID, jobErr JobError) error
+	FailJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error)
+	ScheduleRetry(ctx context.Context, id uuid.UUID, attempt int, jobErr JobError, delay time.Duration) (*OutboxEntry, error)
+	CancelJob(ctx context.Context, id uuid.UUID) (*Job, error)
+	MarkJobAsCancelled(ctx context.Context, id uuid.UUID, attempt int) (bool, error)
+
+	// Dead letters
+	DeadLetterJob(ctx context.Context, id uuid.UUID, fromStatus string, attempt int, jobErr JobError) (bool, error)
+	ListDeadJobs(ctx context.Context, filter DeadJobFilter, after *DeadJobCursor, limit int) ([]*Job, error)
+	ListJobFailures(ctx context.Context, jobID uuid.UUID) ([]*JobFailure, error)
+	ReplayDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*OutboxEntry, error)
+	PurgeDeadJobs(ctx context.Context, filter DeadJobFilter, limit int) ([]*Job, error)
+
+	// Reconciliation
+	ListStaleJobs(ctx context.Context, olderThan time.Duration, limit int) ([]*Job, error)
+	ListAbandonedJobs(ctx context.Context, timeout time.Duration, limit int) ([]*Job, error)
+	RequeueJob(ctx context.Context, id uuid.UUID, fromStatus, reason string) (bool, error)
+
+	Close() error
+}
+
+var _ JobStore = (*PostgresStore)(nil)
@@ -0,0 +1,811 @@
+// Package storetest is the conformance suite for store.JobStore
+// implementations. Every implementation runs it from its own tests, so they
+// all behave like PostgresStore, the reference implementation.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+const testJobType = "JOB_STANDARD"
+
+// tick is slept between steps that compare stored timestamps with the
+// store's clock, so they cannot fall on the same instant.
+const tick = 20 * time.Millisecond
+
+// Run runs the suite. newStore must return a store holding no jobs.
+func Run(t *testing.T, newStore func(t *testing.T) store.JobStore) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.JobStore)
+	}{
+		{"CreateAndGetJob", testCreateAndGetJob},
+		{"ListJobs", testListJobs},
+		{"Outbox", testOutbox},
+		{"IdempotentJob", testIdempotentJob},
+		{"JobsWithOutbox", testJobsWithOutbox},
+		{"ClaimAndComplete", testClaimAndComplete},
+		{"ClaimStaleJob", testClaimStaleJob},
+		{"RetryAndFail", testRetryAndFail},
+		{"DeadLetters", testDeadLetters},
+		{"ReplayKeepsAttempts", testReplayKeepsAttempts},
+		{"Cancel", testCancel},
+		{"PromoteScheduledJobs", testPromoteScheduledJobs},
+		{"StaleAndAbandonedJobs", testStaleAndAbandonedJobs},
+		{"WebhookResponse", testWebhookResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func newJob(jobType string) *store.Job {
+	return &store.Job{
+		ID:             uuid.New(),
+		Type:           jobType,
+		Payload:        []byte("payload"),
+		Status:         store.JobStatusQueued,
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  time.Minute,
+		RetryJitter:    "full",
+	}
+}
+
+func createJob(t *testing.T, s store.JobStore, job *store.Job) *store.Job {
+	t.Helper()
+	require.NoError(t, s.CreateJob(context.Background(), job))
+	return job
+}
+
+// claimJob creates a job and claims it for worker-1.
+func claimJob(t *testing.T, s store.JobStore) *store.Job {
+	t.Helper()
+	job := createJob(t, s, newJob(testJobType))
+	claimed, err := s.ClaimJob(context.Background(), job.ID, "worker-1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	return job
+}
+
+func getJob(t *testing.T, s store.JobStore, id uuid.UUID) *store.Job {
+	t.Helper()
+	job, err := s.GetJobByID(context.Background(), id)
+	require.NoError(t, err)
+	return job
+}
+
+func testCreateAndGetJob(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	job := newJob(testJobType)
+	job.Priority = 5
+	job.Tags = map[string]string{"tenant": "acme"}
+	createJob(t, s, job)
+
+	got := getJob(t, s, job.ID)
+	assert.Equal(t, job.Type, got.Type)
+	assert.Equal(t, job.Payload, got.Payload)
+	assert.Equal(t, store.JobStatusQueued, got.Status)
+	assert.Equal(t, 0, got.Attempts)
+	assert.Equal(t, 5, got.Priority)
+	assert.Equal(t, 3, got.MaxAttempts)
+	assert.Equal(t, time.Second, got.RetryBaseDelay)
+	assert.Equal(t, time.Minute, got.RetryMaxDelay)
+	assert.Equal(t, "full", got.RetryJitter)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, got.Tags)
+	assert.False(t, got.CreatedAt.IsZero())
+	assert.Nil(t, got.StartedAt)
+
+	// A job ID can only be used once
+	assert.Error(t, s.CreateJob(ctx, job))
+
+	_, err := s.GetJobByID(ctx, uuid.New())
+	assert.True(t, errors.Is(err, store.ErrJobNotFound))
+}
+
+func testListJobs(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	var ids []uuid.UUID
+	for i := range 5 {
+		job := newJob(testJobType)
+		if i%2 == 0 {
+			job.Tags = map[string]string{"tenant": "acme"}
+		}
+		createJob(t, s, job)
+		ids = append(ids, job.ID)
+		time.Sleep(time.Millisecond)
+	}
+	createJob(t, s, newJob("JOB_WEBHOOK"))
+
+	// Newest first by default, in keyset pages
+	filter := store.JobFilter{Type: testJobType}
+	page, err := s.ListJobs(ctx, filter, store.ListJobsOptions{Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, ids[4], page[0].ID)
+	assert.Equal(t, ids[2], page[2].ID)
+	assert.Nil(t, page[0].Payload)
+
+	last := page[2]
+	page, err = s.ListJobs(ctx, filter, store.ListJobsOptions{
+		Limit: 3,
+		After: &store.JobCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+	})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, ids[1], page[0].ID)
+	assert.Equal(t, ids[0], page[1].ID)
+
+	page, err = s.ListJobs(ctx, filter, store.ListJobsOptions{Limit: 1, OldestFirst: true, IncludePayload: true})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, ids[0], page[0].ID)
+	assert.Equal(t, []byte("payload"), page[0].Payload)
+
+	page, err = s.ListJobs(ctx, store.JobFilter{Tags: map[string]string{"tenant": "acme"}}, store.ListJobsOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page, 3)
+
+	page, err = s.ListJobs(ctx, store.JobFilter{Statuses: []string{store.JobStatusCompleted}}, store.ListJobsOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page)
+
+	// Results are left out of listings like payloads
+	_, err = s.ClaimJob(ctx, ids[0], "worker-1", time.Minute)
+	require.NoError(t, err)
+	_, err = s.MarkJobAsCompleted(ctx, ids[0], 1, []byte(`{"rows":5}`))
+	require.NoError(t, err)
+	page, err = s.ListJobs(ctx, store.JobFilter{Statuses: []string{store.JobStatusCompleted}}, store.ListJobsOptions{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, ids[0], page[0].ID)
+	assert.Nil(t, page[0].Result)
+}
+
+func testOutbox(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	job := newJob(testJobType)
+	job.Priority = 2
+	entry, err := s.CreateJobWithOutbox(ctx, job)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, entry.JobID)
+	assert.Equal(t, testJobType, entry.JobType)
+	assert.Equal(t, 2, entry.Priority)
+	assert.Nil(t, entry.RunAt)
+
+	runAt := time.Now().Add(time.Hour)
+	scheduled := newJob(testJobType)
+	scheduled.Status = store.JobStatusScheduled
+	scheduled.NextRunAt = &runAt
+	scheduledEntry, err := s.CreateJobWithOutbox(ctx, scheduled)
+	require.NoError(t, err)
+	require.NotNil(t, scheduledEntry.RunAt)
+
+	pending, err := s.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), pending)
+
+	require.NoError(t, s.MarkOutboxSent(ctx, entry.ID))
+	time.Sleep(tick)
+
+	// A failed publish leaves the entry pending with the error recorded
+	var published []uuid.UUID
+	sent, err := s.RelayOutbox(ctx, 10, 0, func(ctx context.Context, e *store.OutboxEntry) error {
+		published = append(published, e.JobID)
+		return errors.New("queue unavailable")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Equal(t, []uuid.UUID{scheduled.ID}, published)
+
+	// Entries younger than minAge are left for the enqueue path
+	sent, err = s.RelayOutbox(ctx, 10, time.Hour, func(ctx context.Context, e *store.OutboxEntry) error {
+		t.Error("published an entry younger than minAge")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	sent, err = s.RelayOutbox(ctx, 10, 0, func(ctx context.Context, e *store.OutboxEntry) error {
+		assert.Equal(t, 1, e.Attempts)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	pending, err = s.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pending)
+
+	time.Sleep(tick)
+	deleted, err := s.DeleteSentOutbox(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+	deleted, err = s.DeleteSentOutbox(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+func testIdempotentJob(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+	key := "order-42"
+
+	first := newJob(testJobType)
+	first.IdempotencyKey = &key
+	existing, entry, err := s.CreateIdempotentJob(ctx, first, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	require.NotNil(t, entry)
+
+	// The key's holder is returned instead of creating a second job
+	second := newJob(testJobType)
+	second.IdempotencyKey = &key
+	existing, entry, err = s.CreateIdempotentJob(ctx, second, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+	require.NotNil(t, existing)
+	assert.Equal(t, first.ID, existing.ID)
+
+	_, err = s.GetJobByID(ctx, second.ID)
+	assert.True(t, errors.Is(err, store.ErrJobNotFound))
+
+	// Once the retention has passed the key is given to the new job
+	time.Sleep(tick)
+	existing, entry, err = s.CreateIdempotentJob(ctx, second, time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	require.NotNil(t, entry)
+
+	released := getJob(t, s, first.ID)
+	assert.Nil(t, released.IdempotencyKey)
+	holder := getJob(t, s, second.ID)
+	require.NotNil(t, holder.IdempotencyKey)
+	assert.Equal(t, key, *holder.IdempotencyKey)
+
+	_, _, err = s.CreateIdempotentJob(ctx, newJob(testJobType), time.Hour)
+	assert.Error(t, err)
+}
+
+func testJobsWithOutbox(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+	taken, repeated := "taken", "repeated"
+
+	holder := newJob(testJobType)
+	holder.IdempotencyKey = &taken
+	createJob(t, s, holder)
+
+	plain := newJob(testJobType)
+	dup := newJob(testJobType)
+	dup.IdempotencyKey = &taken
+	firstRepeat := newJob(testJobType)
+	firstRepeat.IdempotencyKey = &repeated
+	secondRepeat := newJob(testJobType)
+	secondRepeat.IdempotencyKey = &repeated
+	batch := []*store.Job{plain, dup, firstRepeat, secondRepeat}
+
+	// An error from check rolls the batch back
+	rejected := errors.New("payload mismatch")
+	_, _, err := s.CreateJobsWithOutbox(ctx, batch, time.Hour, func(existing map[string]*store.Job) error {
+		return rejected
+	})
+	assert.Equal(t, rejected, err)
+	_, err = s.GetJobByID(ctx, plain.ID)
+	assert.True(t, errors.Is(err, store.ErrJobNotFound))
+
+	var checked map[string]*store.Job
+	entries, existing, err := s.CreateJobsWithOutbox(ctx, batch, time.Hour, func(existing map[string]*store.Job) error {
+		checked = existing
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, plain.ID, entries[0].JobID)
+	assert.Equal(t, firstRepeat.ID, entries[1].JobID)
+
+	require.Len(t, existing, 2)
+	assert.Equal(t, holder.ID, existing[taken].ID)
+	assert.Equal(t, firstRepeat.ID, existing[repeated].ID)
+	assert.Equal(t, existing, checked)
+
+	_, err = s.GetJobByID(ctx, secondRepeat.ID)
+	assert.True(t, errors.Is(err, store.ErrJobNotFound))
+
+	require.NoError(t, s.MarkOutboxSentBatch(ctx, []int64{entries[0].ID, entries[1].ID}))
+	pending, err := s.CountPendingOutbox(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pending)
+}
+
+func testClaimAndComplete(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+	job := claimJob(t, s)
+
+	running := getJob(t, s, job.ID)
+	assert.Equal(t, store.JobStatusProcessing, running.Status)
+	assert.Equal(t, 1, running.Attempts)
+	assert.NotNil(t, running.StartedAt)
+	assert.NotNil(t, running.LastHeartbeatAt)
+
+	// A job held by a live worker cannot be claimed again
+	claimed, err := s.ClaimJob(ctx, job.ID, "worker-2", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	held, cancelRequested, err := s.Heartbeat(ctx, job.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.False(t, cancelRequested)
+
+	held, _, err = s.UpdateProgress(ctx, job.ID, 1, 50, "halfway", []byte("cursor=5"))
+	require.NoError(t, err)
+	assert.True(t, held)
+	_, _, err = s.UpdateProgress(ctx, job.ID, 1, 60, "", nil)
+	require.NoError(t, err)
+
+	running = getJob(t, s, job.ID)
+	require.NotNil(t, running.ProgressPercent)
+	assert.Equal(t, 60, *running.ProgressPercent)
+	assert.Equal(t, []byte("cursor=5"), running.Checkpoint)
+
+	completed, err := s.MarkJobAsCompleted(ctx, job.ID, 1, []byte("done"))
+	require.NoError(t, err)
+	assert.True(t, completed)
+	require.NoError(t, s.FinishAttempt(ctx, job.ID, 1, store.AttemptEnd{Outcome: store.AttemptSucceeded}))
+
+	done := getJob(t, s, job.ID)
+	assert.Equal(t, store.JobStatusCompleted, done.Status)
+	assert.Equal(t, []byte("done"), done.Result)
+	assert.NotNil(t, done.CompletedAt)
+
+	// A finished job is no longer held
+	held, _, err = s.Heartbeat(ctx, job.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, held)
+	held, _, err = s.UpdateProgress(ctx, job.ID, 1, 100, "", nil)
+	require.NoError(t, err)
+	assert.False(t, held)
+	completed, err = s.MarkJobAsCompleted(ctx, job.ID, 1, nil)
+	require.NoError(t, err)
+	assert.False(t, completed)
+
+	attempts, err := s.ListJobAttempts(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.Equal(t, "worker-1", attempts[0].WorkerID)
+	assert.Equal(t, store.AttemptSucceeded, attempts[0].Outcome)
+	assert.NotNil(t, attempts[0].FinishedAt)
+	assert.NotNil(t, attempts[0].LatencyMs)
+	assert.Nil(t, attempts[0].Error)
+
+	// A finished attempt is not finished again
+	require.NoError(t, s.FinishAttempt(ctx, job.ID, 1, store.AttemptEnd{Outcome: store.AttemptFailed}))
+	attempts, err = s.ListJobAttempts(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.AttemptSucceeded, attempts[0].Outcome)
+}
+
+func testClaimStaleJob(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+	job := claimJob(t, s)
+
+	// Once the worker holding it falls silent, another may take the job over
+	time.Sleep(tick)
+	claimed, err := s.ClaimJob(ctx, job.ID, "worker-2", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	attempts, err := s.ListJobAttempts(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, store.AttemptAbandoned, attempts[0].Outcome)
+	assert.NotNil(t, attempts[0].FinishedAt)
+	assert.Equal(t, 2, attempts[1].Attempt)
+	assert.Equal(t, "worker-2", attempts[1].WorkerID)
+	assert.Equal(t, store.AttemptRunning, attempts[1].Outcome)
+
+	// The first worker finishing late leaves the second's attempt alone
+	require.NoError(t, s.FinishAttempt(ctx, job.ID, 1, store.AttemptEnd{Outcome: store.AttemptSucceeded}))
+	attempts, err = s.ListJobAttempts(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.AttemptAbandoned, attempts[0].Outcome)
+	assert.Equal(t, store.AttemptRunning, attempts[1].Outcome)
+
+	// and can record nothing for the job, which runs its second attempt now
+	held, _, err := s.Heartbeat(ctx, job.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, held)
+	held, _, err = s.UpdateProgress(ctx, job.ID, 1, 90, "", nil)
+	require.NoError(t, err)
+	assert.False(t, held)
+	completed, err := s.MarkJobAsCompleted(ctx, job.ID, 1, []byte("late"))
+	require.NoError(t, err)
+	assert.False(t, completed)
+	cancelled, err := s.MarkJobAsCancelled(ctx, job.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, cancelled)
+	entry, err := s.ScheduleRetry(ctx, job.ID, 1, store.JobError{Message: "late"}, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+	failed, err := s.FailJob(ctx, job.ID, store.JobStatusProcessing, 1, store.JobError{Message: "late"})
+	require.NoError(t, err)
+	assert.False(t, failed)
+	dead, err := s.DeadLetterJob(ctx, job.ID, store.JobStatusProcessing, 1, store.JobError{Message: "late"})
+	require.NoError(t, err)
+	assert.False(t, dead)
+
+	running := getJob(t, s, job.ID)
+	assert.Equal(t, store.JobStatusProcessing, running.Status)
+	assert.Equal(t, 2, running.Attempts)
+	assert.Nil(t, running.Result)
+	assert.Nil(t, running.LastError)
+
+	held, _, err = s.Heartbeat(ctx, job.ID, 2)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	// Only the running attempt can be requeued
+	requeued, err := s.MarkJobAsQueued(ctx, job.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, requeued)
+	assert.Equal(t, store.JobStatusProcessing, getJob(t, s, job.ID).Status)
+
+	// Requeued and claimed the ordinary way
+	requeued, err = s.MarkJobAsQueued(ctx, job.ID, 2)
+	require.NoError(t, err)
+	assert.True(t, requeued)
+	queued := getJob(t, s, job.ID)
+	assert.Equal(t, store.JobStatusQueued, queued.Status)
+	assert.Nil(t, queued.StartedAt)
+
+	claimed, err = s.ClaimJob(ctx, job.ID, "worker-3", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, 3, getJob(t, s, job.ID).Attempts)
+
+	claimed, err = s.ClaimJob(ctx, uuid.New(), "worker-3", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+func testRetryAndFail(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+	job := claimJob(t, s)
+
+	jobErr := store.JobError{Message: "webhook returned status 503", Code: "http_503"}
+	entry, err := s.ScheduleRetry(ctx, job.ID, 1, jobErr, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, job.ID, entry.JobID)
+	assert.Equal(t, testJobType, entry.JobType)
+	require.NotNil(t, entry.RunAt)
+
+	retrying := getJob(t, s, job.ID)
+	assert.Equal(t, store.JobStatusRetrying, retrying.Status)
+	require.NotNil(t, retrying.NextRunAt)
+	require.NotNil(t, retrying.LastError)
+	assert.Equal(t, jobErr.Message, *retrying.LastError)
+	require.NotNil(t, retrying.ErrorCode)
+	assert.Equal(t, "http_503", *retrying.ErrorCode)
+	assert.Nil(t, retrying.ErrorDetails)
+
+	// Only a processing job can be retried
+	entry, err = s.ScheduleRetry(ctx, job.ID, 1, jobErr, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	failed, err := s.FailJob(ctx, job.ID, store.JobStatusProcessing, 1, store.JobError{Message: "boom"})
+	require.NoError(t, err)
+	assert.False(t, failed)
+
+	claimed, err := s.ClaimJob(ctx, job.ID, "worker-1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	assert.Nil(t, getJob(t, s, job.ID).NextRunAt)
+
+	failed, err = s.FailJob(ctx, job.ID, store.JobStatusProcessing, 2, store.JobError{Message: "boom", Details: "stack"})
+	require.NoError(t, err)
+	assert.True(t, failed)
+
+	failedJob := getJob(t, s, job.ID)
+	assert.Equal(t, store.JobStatusFailed, failedJob.Status)
+	assert.NotNil(t, failedJob.CompletedAt)
+	assert.Nil(t, failedJob.ErrorCode)
+	require.NotNil(t, failedJob.ErrorDetails)
+	assert.Equal(t, "stack", *failedJob.ErrorDetails)
+
+	failures, err := s.ListJobFailures(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	assert.Equal(t, 1, failures[0].Attempt)
+	assert.Equal(t, jobErr.Message, failures[0].Error)
+	assert.Equal(t, 2, failures[1].Attempt)
+	assert.Equal(t, "boom", failures[1].Error)
+
+	// MarkJobAsFailed fails a job whatever its status, without failure history
+	other := createJob(t, s, newJob(testJobType))
+	require.NoError(t, s.MarkJobAsProcessing(ctx, other.ID))
+	assert.Equal(t, 1, getJob(t, s, other.ID).Attempts)
+	require.NoError(t, s.MarkJobAsFailed(ctx, other.ID, store.JobError{Message: "bad payload", Code: "invalid"}))
+	otherFailed := getJob(t, s, other.ID)
+	assert.Equal(t, store.JobStatusFailed, otherFailed.Status)
+	require.NotNil(t, otherFailed.LastError)
+	assert.Equal(t, "bad payload", *otherFailed.LastError)
+	failures, err = s.ListJobFailures(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+}
+
+// deadJob creates a job of a type and dead-letters it with message.
+func deadJob(t *testing.T, s store.JobStore, jobType, message string) *store.Job {
+	t.Helper()
+	job := createJob(t, s, newJob(jobType))
+	dead, err := s.DeadLetterJob(context.Background(), job.ID, store.JobStatusQueued, 0, store.JobError{Message: message})
+	require.NoError(t, err)
+	require.True(t, dead)
+	time.Sleep(time.Millisecond)
+	return job
+}
+
+func testDeadLetters(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	first := deadJob(t, s, testJobType, "connection refused")
+	second := deadJob(t, s, testJobType, "status 500")
+	third := deadJob(t, s, "JOB_WEBHOOK", "connection refused")
+	createJob(t, s, newJob(testJobType))
+
+	dead := getJob(t, s, first.ID)
+	assert.Equal(t, store.JobStatusDead, dead.Status)
+	assert.NotNil(t, dead.DeadAt)
+
+	// Already dead, so not in the expected status
+	again, err := s.DeadLetterJob(ctx, first.ID, store.JobStatusQueued, 0, store.JobError{Message: "again"})
+	require.NoError(t, err)
+	assert.False(t, again)
+
+	// Most recently dead first, in keyset pages
+	page, err := s.ListDeadJobs(ctx, store.DeadJobFilter{}, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, third.ID, page[0].ID)
+	assert.Equal(t, second.ID, page[1].ID)
+
+	page, err = s.ListDeadJobs(ctx, store.DeadJobFilter{}, &store.DeadJobCursor{DeadAt: *page[1].DeadAt, ID: page[1].ID}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, first.ID, page[0].ID)
+
+	page, err = s.ListDeadJobs(ctx, store.DeadJobFilter{Type: testJobType, ErrorContains: "refused"}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, first.ID, page[0].ID)
+
+	page, err = s.ListDeadJobs(ctx, store.DeadJobFilter{JobIDs: []uuid.UUID{second.ID, third.ID}}, nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, page, 2)
+
+	// Replayed jobs are queued with a fresh attempt budget
+	entries, err := s.ReplayDeadJobs(ctx, store.DeadJobFilter{Type: testJobType}, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, first.ID, entries[0].JobID)
+	assert.Nil(t, entries[0].RunAt)
+
+	replayed := getJob(t, s, first.ID)
+	assert.Equal(t, store.JobStatusQueued, replayed.Status)
+	assert.Equal(t, 0, replayed.Attempts)
+	assert.Nil(t, replayed.DeadAt)
+	failures, err := s.ListJobFailures(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Len(t, failures, 1)
+
+	// Purged jobs are gone along with their history
+	purged, err := s.PurgeDeadJobs(ctx, store.DeadJobFilter{}, 10)
+	require.NoError(t, err)
+	require.Len(t, purged, 2)
+	assert.ElementsMatch(t, []uuid.UUID{second.ID, third.ID}, []uuid.UUID{purged[0].ID, purged[1].ID})
+
+	_, err = s.GetJobByID(ctx, second.ID)
+	assert.True(t, errors.Is(err, store.ErrJobNotFound))
+	failures, err = s.ListJobFailures(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+
+	page, err = s.ListDeadJobs(ctx, store.DeadJobFilter{}, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func testReplayKeepsAttempts(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	job := claimJob(t, s)
+	dead, err := s.DeadLetterJob(ctx, job.ID, store.JobStatusProcessing, 1, store.JobError{Message: "boom"})
+	require.NoError(t, err)
+	require.True(t, dead)
+
+	// The attempt count is kept, and the budget starts from it
+	entries, err := s.ReplayDeadJobs(ctx, store.DeadJobFilter{JobIDs: []uuid.UUID{job.ID}}, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	replayed := getJob(t, s, job.ID)
+	assert.Equal(t, 1, replayed.Attempts)
+	assert.Equal(t, 1, replayed.AttemptsBeforeReplay)
+	assert.Equal(t, 0, replayed.AttemptsSinceReplay())
+
+	// So the next attempt is numbered after the earlier ones
+	claimed, err := s.ClaimJob(ctx, job.ID, "worker-2", time.Minute)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	again := getJob(t, s, job.ID)
+	assert.Equal(t, 2, again.Attempts)
+	assert.Equal(t, 1, again.AttemptsSinceReplay())
+
+	dead, err = s.DeadLetterJob(ctx, job.ID, store.JobStatusProcessing, 2, store.JobError{Message: "boom again"})
+	require.NoError(t, err)
+	require.True(t, dead)
+
+	failures, err := s.ListJobFailures(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	assert.ElementsMatch(t, []int{1, 2}, []int{failures[0].Attempt, failures[1].Attempt})
+}
+
+func testCancel(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	queued := createJob(t, s, newJob(testJobType))
+	before, err := s.CancelJob(ctx, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusQueued, before.Status)
+	assert.Equal(t, testJobType, before.Type)
+	assert.Equal(t, store.JobStatusCancelled, getJob(t, s, queued.ID).Status)
+
+	// A running job is only asked to stop
+	running := claimJob(t, s)
+	before, err = s.CancelJob(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusProcessing, before.Status)
+	assert.NotNil(t, getJob(t, s, running.ID).CancelRequestedAt)
+
+	held, cancelRequested, err := s.Heartbeat(ctx, running.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.True(t, cancelRequested)
+
+	cancelled, err := s.MarkJobAsCancelled(ctx, running.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, cancelled)
+	assert.Equal(t, store.JobStatusCancelled, getJob(t, s, running.ID).Status)
+
+	cancelled, err = s.MarkJobAsCancelled(ctx, running.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, cancelled)
+
+	// A finished job is left alone
+	before, err = s.CancelJob(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusCancelled, before.Status)
+
+	_, err = s.CancelJob(ctx, uuid.New())
+	assert.True(t, errors.Is(err, store.ErrJobNotFound))
+}
+
+func testPromoteScheduledJobs(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	runAt := time.Now().Add(time.Hour)
+	scheduled := newJob(testJobType)
+	scheduled.Status = store.JobStatusScheduled
+	scheduled.NextRunAt = &runAt
+	createJob(t, s, scheduled)
+	queued := createJob(t, s, newJob(testJobType))
+
+	promoted, err := s.PromoteScheduledJobs(ctx, []uuid.UUID{scheduled.ID, queued.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), promoted)
+
+	job := getJob(t, s, scheduled.ID)
+	assert.Equal(t, store.JobStatusQueued, job.Status)
+	assert.Nil(t, job.NextRunAt)
+
+	promoted, err = s.PromoteScheduledJobs(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), promoted)
+}
+
+func testStaleAndAbandonedJobs(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+
+	queued := createJob(t, s, newJob(testJobType))
+	running := claimJob(t, s)
+	// Its outbox entry is pending, so the relay will publish it
+	_, err := s.CreateJobWithOutbox(ctx, newJob(testJobType))
+	require.NoError(t, err)
+	done := claimJob(t, s)
+	_, err = s.MarkJobAsCompleted(ctx, done.ID, 1, nil)
+	require.NoError(t, err)
+
+	time.Sleep(tick)
+
+	stale, err := s.ListStaleJobs(ctx, time.Hour, 10)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	stale, err = s.ListStaleJobs(ctx, time.Millisecond, 10)
+	require.NoError(t, err)
+	require.Len(t, stale, 2)
+	assert.Equal(t, queued.ID, stale[0].ID)
+	assert.Equal(t, store.JobStatusQueued, stale[0].Status)
+	assert.Equal(t, running.ID, stale[1].ID)
+	assert.Equal(t, 1, stale[1].Attempts)
+
+	abandoned, err := s.ListAbandonedJobs(ctx, time.Millisecond, 10)
+	require.NoError(t, err)
+	require.Len(t, abandoned, 1)
+	assert.Equal(t, running.ID, abandoned[0].ID)
+	assert.Nil(t, abandoned[0].Payload)
+
+	// A heartbeat shows the worker is alive
+	_, _, err = s.Heartbeat(ctx, running.ID, 1)
+	require.NoError(t, err)
+	abandoned, err = s.ListAbandonedJobs(ctx, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, abandoned)
+
+	requeued, err := s.RequeueJob(ctx, running.ID, store.JobStatusProcessing, "worker lost")
+	require.NoError(t, err)
+	assert.True(t, requeued)
+	job := getJob(t, s, running.ID)
+	assert.Equal(t, store.JobStatusQueued, job.Status)
+	require.NotNil(t, job.LastError)
+	assert.Equal(t, "worker lost", *job.LastError)
+
+	requeued, err = s.RequeueJob(ctx, running.ID, store.JobStatusProcessing, "worker lost")
+	require.NoError(t, err)
+	assert.False(t, requeued)
+}
+
+func testWebhookResponse(t *testing.T, s store.JobStore) {
+	ctx := context.Background()
+	job := claimJob(t, s)
+
+	require.NoError(t, s.RecordWebhookResponse(ctx, job.ID, 503, 120*time.Millisecond, []byte("unavailable")))
+	require.NoError(t, s.FinishAttempt(ctx, job.ID, 1, store.AttemptEnd{
+		Outcome: store.AttemptFailed,
+		Error:   store.JobError{Message: "webhook returned status 503", Code: "http_503"},
+	}))
+
+	got := getJob(t, s, job.ID)
+	require.NotNil(t, got.ResponseStatus)
+	assert.Equal(t, 503, *got.ResponseStatus)
+	require.NotNil(t, got.ResponseLatencyMs)
+	assert.Equal(t, 120, *got.ResponseLatencyMs)
+	assert.Equal(t, []byte("unavailable"), got.ResponseBody)
+
+	attempts, err := s.ListJobAttempts(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	require.NotNil(t, attempts[0].ResponseStatus)
+	assert.Equal(t, 503, *attempts[0].ResponseStatus)
+	require.NotNil(t, attempts[0].Error)
+	assert.Equal(t, "webhook returned status 503", *attempts[0].Error)
+	require.NotNil(t, attempts[0].ErrorCode)
+	assert.Equal(t, "http_503", *attempts[0].ErrorCode)
+}
@@ -0,0 +1,116 @@
+// Package testutil starts the Postgres and Redis containers used by integration tests.
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/modules/redis"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// StartPostgres runs a Postgres container with all migrations applied and returns a connection to it.
+// The container is terminated when the test finishes.
+func StartPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	db, _ := StartPostgresWithConnString(t)
+	return db
+}
+
+// StartPostgresWithConnString is StartPostgres that also returns the connection
+// string, for clients that open their own connections.
+func StartPostgresWithConnString(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	pgContainer, err := postgres.Run(ctx,
+		"postgres:17",
+		postgres.WithDatabase("boltq"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := pgContainer.Terminate(ctx); err != nil {
+			t.Logf("failed to terminate postgres container: %s", err)
+		}
+	})
+
+	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", connStr)
+	require.NoError(t, err)
+	require.NoError(t, db.Ping())
+	t.Cleanup(func() { db.Close() })
+
+	RunMigrations(t, db)
+
+	return db, connStr
+}
+
+// StartRedis runs a Redis container and returns its address in host:port form.
+// The container is terminated when the test finishes.
+func StartRedis(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	redisContainer, err := redis.Run(ctx,
+		"redis:8.4",
+		redis.WithSnapshotting(10, 1),
+		redis.WithLogLevel(redis.LogLevelVerbose),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := redisContainer.Terminate(ctx); err != nil {
+			t.Logf("failed to terminate redis container: %s", err)
+		}
+	})
+
+	redisAddr, err := redisContainer.ConnectionString(ctx)
+	require.NoError(t, err)
+
+	// Remove "redis://" prefix if present
+	return strings.TrimPrefix(redisAddr, "redis://")
+}
+
+// RunMigrations applies every migration in the repository's migrations directory in order.
+func RunMigrations(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(migrationsDir(), "*.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = db.Exec(string(migration))
+		require.NoError(t, err, "failed to apply migration %s", filepath.Base(file))
+	}
+}
+
+// migrationsDir resolves the migrations directory relative to this source file,
+// so tests in any package can find it.
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "migrations")
+}
@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/worker"
+)
+
+const (
+	// DefaultMaxResponseBody is how much of a response body is stored with the job.
+	DefaultMaxResponseBody = 4 * 1024
+	// maxDrainBytes bounds how much of an oversized body is read and discarded
+	// so the connection can be reused.
+	maxDrainBytes      = 64 * 1024
+	bookkeepingTimeout = 5 * time.Second
+)
+
+// Handler delivers JOB_WEBHOOK jobs over HTTP.
+type Handler struct {
+	logger          *slog.Logger
+	store           store.JobStore
+	client          *http.Client
+	policy          Policy
+	maxResponseBody int
+}
+
+// NewHandler creates a webhook Handler. A nil client uses http.DefaultClient
+// and a non-positive maxResponseBody uses DefaultMaxResponseBody.
+func NewHandler(l *slog.Logger, s store.JobStore, client *http.Client, policy Policy, maxResponseBody int) *Handler {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if maxResponseBody <= 0 {
+		maxResponseBody = DefaultMaxResponseBody
+	}
+
+	return &Handler{
+		logger:          l,
+		store:           s,
+		client:          client,
+		policy:          policy,
+		maxResponseBody: maxResponseBody,
+	}
+}
+
+// Handle performs the webhook request described by the job's payload and
+// records the response against the job. A 2xx response succeeds; other
+// outcomes fail, permanently unless the policy deems them retryable, with
+// the code "http_<status>" for non-2xx responses.
+// It satisfies worker.HandlerFunc.
+func (h *Handler) Handle(ctx context.Context, job *store.Job) error {
+	payload, err := ParsePayload(job.Payload)
+	if err != nil {
+		return worker.Permanent(err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, payload.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, payload.Method, payload.URL, strings.NewReader(payload.Body))
+	if err != nil {
+		return worker.Permanent(fmt.Errorf("failed to build webhook request: %w", err))
+	}
+	for name, value := range payload.Headers {
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := h.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			// The worker is shutting down; let it return the job to the queue.
+			return ctx.Err()
+		}
+		err = fmt.Errorf("webhook request failed: %w", err)
+		if !h.policy.RetryNetworkErrors {
+			return worker.Permanent(err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, int64(h.maxResponseBody)))
+	latency := time.Since(start)
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+
+	h.logger.Info("Webhook delivered",
+		"job_id", job.ID.String(),
+		"url", payload.URL,
+		"status", resp.StatusCode,
+		"latency", latency,
+	)
+
+	if readErr != nil {
+		h.logger.Warn("Failed to read webhook response body", "job_id", job.ID.String(), "error", readErr)
+	}
+
+	h.record(job, resp.StatusCode, latency, body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = worker.WithCode(fmt.Errorf("webhook returned status %d", resp.StatusCode), fmt.Sprintf("http_%d", resp.StatusCode), "")
+	if !h.policy.IsRetryableStatus(resp.StatusCode) {
+		return worker.Permanent(err)
+	}
+	return err
+}
+
+// record stores the response using a fresh context so it is kept even if the
+// job context is cancelled.
+func (h *Handler) record(job *store.Job, statusCode int, latency time.Duration, body []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	if err := h.store.RecordWebhookResponse(ctx, job.ID, statusCode, latency, body); err != nil {
+		h.logger.Error("Failed to record webhook response", "job_id", job.ID.String(), "error", err)
+	}
+}
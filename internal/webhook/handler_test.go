@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/worker"
+)
+
+func setupTestHandler(t *testing.T, maxResponseBody int) (*Handler, sqlmock.Sqlmock) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	h := NewHandler(logger, store.NewPostgresStore(db), nil, DefaultPolicy(), maxResponseBody)
+	return h, mock
+}
+
+func webhookJob(payload string) *store.Job {
+	return &store.Job{ID: uuid.New(), Type: "JOB_WEBHOOK", Payload: []byte(payload)}
+}
+
+func TestHandler_Success(t *testing.T) {
+	var gotMethod, gotHeader, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotHeader = r.Header.Get("X-Signature")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("accepted"))
+	}))
+	defer server.Close()
+
+	h, mock := setupTestHandler(t, 0)
+	job := webhookJob(`{"url": "` + server.URL + `", "headers": {"X-Signature": "sig"}, "body": "hello"}`)
+
+	mock.ExpectExec("UPDATE jobs SET response_status").
+		WithArgs(http.StatusAccepted, sqlmock.AnyArg(), []byte("accepted"), job.ID, store.AttemptRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := h.Handle(context.Background(), job)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "sig", gotHeader)
+	assert.Equal(t, "hello", gotBody)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_TruncatesResponseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	h, mock := setupTestHandler(t, 10)
+	job := webhookJob(`{"url": "` + server.URL + `"}`)
+
+	mock.ExpectExec("UPDATE jobs SET response_status").
+		WithArgs(http.StatusOK, sqlmock.AnyArg(), []byte(strings.Repeat("x", 10)), job.ID, store.AttemptRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, h.Handle(context.Background(), job))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_RetryableStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	h, mock := setupTestHandler(t, 0)
+	job := webhookJob(`{"url": "` + server.URL + `"}`)
+
+	mock.ExpectExec("UPDATE jobs SET response_status").
+		WithArgs(http.StatusServiceUnavailable, sqlmock.AnyArg(), sqlmock.AnyArg(), job.ID, store.AttemptRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := h.Handle(context.Background(), job)
+	require.Error(t, err)
+	assert.False(t, worker.IsPermanent(err))
+	assert.Contains(t, err.Error(), "503")
+	assert.Equal(t, "http_503", worker.ErrorCode(err))
+}
+
+func TestHandler_TerminalStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	h, mock := setupTestHandler(t, 0)
+	job := webhookJob(`{"url": "` + server.URL + `"}`)
+
+	mock.ExpectExec("UPDATE jobs SET response_status").
+		WithArgs(http.StatusNotFound, sqlmock.AnyArg(), sqlmock.AnyArg(), job.ID, store.AttemptRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := h.Handle(context.Background(), job)
+	require.Error(t, err)
+	assert.True(t, worker.IsPermanent(err))
+}
+
+func TestHandler_TimeoutIsRetryable(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	h, mock := setupTestHandler(t, 0)
+	job := webhookJob(`{"url": "` + server.URL + `", "timeout_ms": 50}`)
+
+	err := h.Handle(context.Background(), job)
+	require.Error(t, err)
+	assert.False(t, worker.IsPermanent(err))
+
+	// Nothing is recorded when no response arrived
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_InvalidPayloadIsPermanent(t *testing.T) {
+	h, _ := setupTestHandler(t, 0)
+
+	err := h.Handle(context.Background(), webhookJob(`{"method": "POST"}`))
+	require.Error(t, err)
+	assert.True(t, worker.IsPermanent(err))
+}
@@ -0,0 +1,99 @@
+// Package webhook implements the JOB_WEBHOOK job type: the payload format,
+// its validation, and the worker handler that delivers the request.
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTimeout = 10 * time.Second
+	MaxTimeout     = 60 * time.Second
+)
+
+// allowedMethods are the HTTP methods a webhook may use.
+var allowedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// Payload is the JSON payload of a JOB_WEBHOOK job.
+type Payload struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	TimeoutMs int               `json:"timeout_ms,omitempty"`
+}
+
+// ParsePayload decodes and validates a webhook payload, filling in defaults
+// for the method (POST) and timeout.
+func ParsePayload(data []byte) (*Payload, error) {
+	var p Payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (p *Payload) validate() error {
+	if p.URL == "" {
+		return errors.New("webhook url is required")
+	}
+
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook url scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("webhook url must include a host")
+	}
+
+	p.Method = strings.ToUpper(p.Method)
+	if p.Method == "" {
+		p.Method = http.MethodPost
+	}
+	if !allowedMethods[p.Method] {
+		return fmt.Errorf("unsupported webhook method %q", p.Method)
+	}
+
+	for name := range p.Headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid webhook header name %q", name)
+		}
+	}
+
+	if p.TimeoutMs < 0 {
+		return errors.New("webhook timeout_ms cannot be negative")
+	}
+	if time.Duration(p.TimeoutMs)*time.Millisecond > MaxTimeout {
+		return fmt.Errorf("webhook timeout_ms exceeds maximum of %d", MaxTimeout.Milliseconds())
+	}
+
+	return nil
+}
+
+// Timeout returns the request timeout, falling back to DefaultTimeout.
+func (p *Payload) Timeout() time.Duration {
+	if p.TimeoutMs == 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(p.TimeoutMs) * time.Millisecond
+}
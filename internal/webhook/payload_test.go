@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayload_Defaults(t *testing.T) {
+	p, err := ParsePayload([]byte(`{"url": "https://example.com/hook", "body": "{}"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/hook", p.URL)
+	assert.Equal(t, http.MethodPost, p.Method)
+	assert.Equal(t, DefaultTimeout, p.Timeout())
+}
+
+func TestParsePayload_Full(t *testing.T) {
+	p, err := ParsePayload([]byte(`{
+		"url": "http://example.com/hook",
+		"method": "put",
+		"headers": {"X-Signature": "abc"},
+		"body": "hello",
+		"timeout_ms": 2500
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPut, p.Method)
+	assert.Equal(t, "abc", p.Headers["X-Signature"])
+	assert.Equal(t, "hello", p.Body)
+	assert.Equal(t, 2500*time.Millisecond, p.Timeout())
+}
+
+func TestParsePayload_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		errMsg  string
+	}{
+		{"not json", `not json`, "invalid webhook payload"},
+		{"missing url", `{"method": "POST"}`, "url is required"},
+		{"bad scheme", `{"url": "ftp://example.com"}`, "scheme must be http or https"},
+		{"missing host", `{"url": "https:///hook"}`, "must include a host"},
+		{"bad method", `{"url": "https://example.com", "method": "TRACE"}`, "unsupported webhook method"},
+		{"bad header", `{"url": "https://example.com", "headers": {"Bad Header": "x"}}`, "invalid webhook header name"},
+		{"negative timeout", `{"url": "https://example.com", "timeout_ms": -1}`, "cannot be negative"},
+		{"timeout too long", `{"url": "https://example.com", "timeout_ms": 600000}`, "exceeds maximum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePayload([]byte(tt.payload))
+			assert.Nil(t, p)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("408, 429,500-599", false)
+	require.NoError(t, err)
+
+	assert.True(t, policy.IsRetryableStatus(408))
+	assert.True(t, policy.IsRetryableStatus(429))
+	assert.True(t, policy.IsRetryableStatus(503))
+	assert.False(t, policy.IsRetryableStatus(400))
+	assert.False(t, policy.IsRetryableStatus(404))
+	assert.False(t, policy.RetryNetworkErrors)
+
+	_, err = ParsePolicy("500-400", true)
+	assert.Error(t, err)
+
+	_, err = ParsePolicy("abc", true)
+	assert.Error(t, err)
+}
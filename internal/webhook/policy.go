@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statusRange is an inclusive range of HTTP status codes.
+type statusRange struct {
+	from, to int
+}
+
+// Policy decides whether a failed delivery is worth retrying.
+type Policy struct {
+	// RetryableStatuses are the non-2xx status codes treated as transient.
+	// Any other non-2xx status fails the job permanently.
+	RetryableStatuses []statusRange
+	// RetryNetworkErrors treats connection failures and timeouts as transient.
+	RetryNetworkErrors bool
+}
+
+// DefaultPolicy retries timeouts, rate limiting and server errors.
+func DefaultPolicy() Policy {
+	policy, _ := ParsePolicy("408,425,429,500-599", true)
+	return policy
+}
+
+// ParsePolicy builds a Policy from a comma-separated list of status codes and
+// inclusive ranges, e.g. "408,429,500-599".
+func ParsePolicy(retryableStatuses string, retryNetworkErrors bool) (Policy, error) {
+	policy := Policy{RetryNetworkErrors: retryNetworkErrors}
+
+	for _, part := range strings.Split(retryableStatuses, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fromStr, toStr, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(fromStr))
+		if err != nil {
+			return Policy{}, fmt.Errorf("invalid status code %q", part)
+		}
+		to := from
+		if isRange {
+			to, err = strconv.Atoi(strings.TrimSpace(toStr))
+			if err != nil || to < from {
+				return Policy{}, fmt.Errorf("invalid status range %q", part)
+			}
+		}
+
+		policy.RetryableStatuses = append(policy.RetryableStatuses, statusRange{from: from, to: to})
+	}
+
+	return policy, nil
+}
+
+// IsRetryableStatus reports whether a non-2xx status code should be retried.
+func (p Policy) IsRetryableStatus(code int) bool {
+	for _, r := range p.RetryableStatuses {
+		if code >= r.from && code <= r.to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+// permanentError marks a handler failure that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err to signal that the job failed terminally, e.g. because
+// its payload is invalid or the remote end rejected it outright. Errors that
+// are not wrapped are treated as transient.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or any error it wraps, was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// maxErrorDetailsLen bounds the details recorded with a failure.
+const maxErrorDetailsLen = 16 * 1024
+
+// codedError attaches a code and details to a handler failure.
+type codedError struct {
+	err     error
+	code    string
+	details string
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// WithCode wraps err with a short machine-readable code, e.g. "http_503", and
+// optional details such as an excerpt of a response. Both are recorded with
+// the failure alongside its message. It composes with Permanent.
+func WithCode(err error, code, details string) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{err: err, code: code, details: details}
+}
+
+// ErrorCode returns the code err, or any error it wraps, was given with
+// WithCode, or "" if it has none.
+func ErrorCode(err error) string {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return ""
+}
+
+// panicError is a handler panic recovered by the worker.
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string { return fmt.Sprintf("handler panicked: %v", e.value) }
+
+// DescribeError returns what is recorded about a failed attempt: its message,
+// and the code and details of WithCode, or the stack of a panic. Details are
+// truncated to 16 KB.
+func DescribeError(err error) store.JobError {
+	jobErr := store.JobError{Message: err.Error()}
+
+	var coded *codedError
+	var panicked *panicError
+	switch {
+	case errors.As(err, &coded):
+		jobErr.Code, jobErr.Details = coded.code, coded.details
+	case errors.As(err, &panicked):
+		jobErr.Code, jobErr.Details = "panic", string(panicked.stack)
+	}
+	if len(jobErr.Details) > maxErrorDetailsLen {
+		jobErr.Details = jobErr.Details[:maxErrorDetailsLen]
+	}
+
+	return jobErr
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// MaxProgressMessageLen is the longest progress message accepted, in bytes.
+	MaxProgressMessageLen = 256
+	// MaxCheckpointSize is the largest checkpoint accepted, in bytes.
+	MaxCheckpointSize = 1024 * 1024 // 1 MB
+)
+
+// Progress is reported by a handler while its job runs. It is saved on the
+// job and published to its watchers.
+type Progress struct {
+	// Percent is how far along the job is, from 0 to 100.
+	Percent int
+	// Message briefly says what the job is doing.
+	Message string
+	// Checkpoint, if set, replaces the job's checkpoint: whatever state the
+	// handler needs to resume rather than start over. Should the attempt
+	// fail, the retry receives it in Job.Checkpoint.
+	Checkpoint []byte
+}
+
+// Validate reports whether p is within the accepted limits.
+func (p Progress) Validate() error {
+	if p.Percent < 0 || p.Percent > 100 {
+		return fmt.Errorf("progress percent must be between 0 and 100, got %d", p.Percent)
+	}
+	if len(p.Message) > MaxProgressMessageLen {
+		return fmt.Errorf("progress message exceeds maximum length: %d", MaxProgressMessageLen)
+	}
+	if len(p.Checkpoint) > MaxCheckpointSize {
+		return fmt.Errorf("checkpoint size exceeds maximum limit: %d", MaxCheckpointSize)
+	}
+	return nil
+}
+
+// ReportProgress records the progress of the job whose handler was given ctx.
+// Like a heartbeat, it stops the job if it was taken from this worker or its
+// cancellation was requested, and then returns the context's cause so the
+// handler can return straight away.
+func ReportProgress(ctx context.Context, p Progress) error {
+	r, ok := ctx.Value(runningJobKey{}).(*runningJob)
+	if !ok {
+		return errNoRunningJob
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	held, cancelRequested, err := r.store.UpdateProgress(ctx, r.jobID, r.attempt, p.Percent, p.Message, p.Checkpoint)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !held:
+		r.stop(errJobLost)
+		return context.Cause(ctx)
+	case cancelRequested:
+		r.stop(errCancelRequested)
+		return context.Cause(ctx)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+// MaxResultSize is the largest job result accepted, in bytes.
+const MaxResultSize = 1024 * 1024 // 1 MB
+
+var errNoRunningJob = errors.New("no job is running under this context")
+
+type runningJobKey struct{}
+
+// runningJob is the job a handler's context runs, for the functions handlers
+// call with that context.
+type runningJob struct {
+	store   store.JobStore
+	jobID   uuid.UUID
+	attempt int
+	stop    context.CancelCauseFunc
+
+	mu     sync.Mutex
+	output []byte
+}
+
+func (r *runningJob) result() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.output
+}
+
+// SetResult sets the output of the job whose handler was given ctx. It is
+// stored when the handler returns nil, and returned by GetJobStatus and
+// GetJobResult; a later call replaces it.
+func SetResult(ctx context.Context, result []byte) error {
+	r, ok := ctx.Value(runningJobKey{}).(*runningJob)
+	if !ok {
+		return errNoRunningJob
+	}
+	if len(result) > MaxResultSize {
+		return fmt.Errorf("result size exceeds maximum limit: %d", MaxResultSize)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = result
+	return nil
+}
@@ -0,0 +1,623 @@
+// Package worker consumes job references from the Redis queue and runs the
+// handler registered for each job type, recording the outcome in Postgres.
+//
+// Jobs are dequeued in the queue's reliable mode: each message is leased while
+// its handler runs and acked once the outcome is recorded, so a worker crash
+// never loses a job. Every worker also runs a reaper that returns expired
+// leases to the queue, and listens for cancellation requests for the jobs it
+// is running.
+//
+// Every execution of a job is recorded in its attempt history under the
+// worker's ID, with how the attempt ended.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/turnertastic1/boltq/internal/outbox"
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/retry"
+	"github.com/turnertastic1/boltq/internal/store"
+)
+
+const (
+	defaultPollTimeout       = 2 * time.Second
+	defaultShutdownTimeout   = 30 * time.Second
+	defaultVisibilityTimeout = 5 * time.Minute
+	defaultReapInterval      = 30 * time.Second
+	defaultHeartbeatInterval = 30 * time.Second
+	bookkeepingTimeout       = 5 * time.Second
+	dequeueErrorBackoff      = time.Second
+)
+
+var (
+	// errCancelRequested is the cause of a job context cancelled through CancelJob.
+	errCancelRequested = errors.New("job cancellation requested")
+	// errJobLost is the cause of a job context cancelled because the job was
+	// taken from this worker after its heartbeats went missing.
+	errJobLost = errors.New("job no longer held by this worker")
+)
+
+// HandlerFunc processes a single job. Returning nil marks the job completed.
+// Returning an error schedules a retry per the job's retry policy, or
+// dead-letters the job once attempts run out; wrap the error with Permanent
+// to fail the job straight away when retrying cannot help. Handlers must honor
+// ctx cancellation so in-flight jobs can be handed back to the queue on
+// shutdown, and stopped when the job is cancelled.
+//
+// Long-running handlers can call ReportProgress with ctx. A checkpoint saved
+// that way by an earlier attempt is in job.Checkpoint. Handlers call SetResult
+// to store the job's output, and wrap errors with WithCode to record more
+// than their message.
+type HandlerFunc func(ctx context.Context, job *store.Job) error
+
+// Config controls polling and shutdown behaviour of a Worker.
+type Config struct {
+	// PollTimeout is how long a single Dequeue call blocks waiting for a job.
+	// It also bounds how long it takes a pool to notice shutdown.
+	PollTimeout time.Duration
+	// ShutdownTimeout is how long in-flight jobs are given to finish after
+	// shutdown starts before their contexts are cancelled and they are requeued.
+	ShutdownTimeout time.Duration
+	// VisibilityTimeout is how long a dequeued job is leased to this worker.
+	// If the job is not acked within it, the reaper returns it to the queue.
+	VisibilityTimeout time.Duration
+	// ReapInterval is how often expired leases are returned to the queue.
+	ReapInterval time.Duration
+	// HeartbeatInterval is how often a running job's lease is extended and
+	// its heartbeat recorded, so long jobs are not taken for abandoned. It
+	// should be well below VisibilityTimeout.
+	HeartbeatInterval time.Duration
+	// WorkerID identifies this worker in the attempt history of the jobs it
+	// runs. It defaults to the host name and process ID.
+	WorkerID string
+}
+
+type registration struct {
+	jobType     string
+	handler     HandlerFunc
+	concurrency int
+}
+
+// Worker runs a pool of goroutines per registered job type.
+type Worker struct {
+	logger        *slog.Logger
+	store         store.JobStore
+	queue         queue.JobQueue
+	cfg           Config
+	registrations []registration
+
+	mu      sync.Mutex
+	running map[uuid.UUID]context.CancelCauseFunc // stops each in-flight job
+}
+
+// NewWorker creates a Worker. Zero values in cfg are replaced with defaults.
+func NewWorker(l *slog.Logger, s store.JobStore, q queue.JobQueue, cfg Config) *Worker {
+	if cfg.PollTimeout <= 0 {
+		cfg.PollTimeout = defaultPollTimeout
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = defaultVisibilityTimeout
+	}
+	if cfg.ReapInterval <= 0 {
+		cfg.ReapInterval = defaultReapInterval
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.WorkerID == "" {
+		cfg.WorkerID = defaultWorkerID()
+	}
+
+	return &Worker{
+		logger:  l,
+		store:   s,
+		queue:   q,
+		cfg:     cfg,
+		running: make(map[uuid.UUID]context.CancelCauseFunc),
+	}
+}
+
+// defaultWorkerID returns "<host name>-<pid>".
+func defaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Register installs the handler for a job type and the number of goroutines
+// that consume that type's queue. It must be called before Run.
+func (w *Worker) Register(jobType string, h HandlerFunc, concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	w.registrations = append(w.registrations, registration{
+		jobType:     jobType,
+		handler:     h,
+		concurrency: concurrency,
+	})
+}
+
+// Run consumes jobs until ctx is cancelled. Once cancelled it stops dequeuing,
+// waits up to ShutdownTimeout for in-flight jobs to finish, then cancels the
+// remaining ones and returns them to the queue. Run returns after every
+// goroutine has exited.
+func (w *Worker) Run(ctx context.Context) error {
+	if len(w.registrations) == 0 {
+		return errors.New("no job handlers registered")
+	}
+
+	// Jobs run under their own context so that shutdown does not interrupt them
+	// until the grace period has passed.
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	cancels, err := w.queue.SubscribeCancels(jobCtx)
+	if err != nil {
+		return err
+	}
+	listening := make(chan struct{})
+	go func() {
+		defer close(listening)
+		w.listenForCancels(cancels)
+	}()
+	defer func() {
+		cancelJobs()
+		<-listening
+	}()
+
+	var wg sync.WaitGroup
+	for _, reg := range w.registrations {
+		w.logger.Info("Starting worker pool", "type", reg.jobType, "concurrency", reg.concurrency)
+
+		for i := 0; i < reg.concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.consume(ctx, jobCtx, reg)
+			}()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.reap(ctx, reg.jobType)
+		}()
+	}
+
+	<-ctx.Done()
+	w.logger.Info("Worker shutting down, waiting for in-flight jobs", "timeout", w.cfg.ShutdownTimeout)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.logger.Info("All in-flight jobs finished")
+	case <-time.After(w.cfg.ShutdownTimeout):
+		w.logger.Warn("Shutdown timeout reached, cancelling in-flight jobs")
+		cancelJobs()
+		<-done
+	}
+
+	return nil
+}
+
+// consume is the loop run by each pool goroutine.
+func (w *Worker) consume(ctx, jobCtx context.Context, reg registration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// Dequeue is deliberately not bound to ctx: cancelling a blocking pop
+		// mid-flight can lose a message Redis has already handed us.
+		msg, err := w.queue.DequeueReliable(context.Background(), reg.jobType, w.cfg.PollTimeout, w.cfg.VisibilityTimeout)
+		if err != nil {
+			w.logger.Error("Failed to dequeue job", "error", err, "type", reg.jobType)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(dequeueErrorBackoff):
+			}
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+
+		w.process(jobCtx, reg, msg)
+	}
+}
+
+// process loads the job, runs its handler and records the outcome.
+func (w *Worker) process(jobCtx context.Context, reg registration, msg *queue.JobMessage) {
+	logger := w.logger.With("job_id", msg.JobID.String(), "type", msg.Type)
+
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	job, err := w.store.GetJobByID(ctx, msg.JobID)
+	if errors.Is(err, store.ErrJobNotFound) {
+		logger.Warn("Dropping message for unknown job")
+		w.ack(logger, msg)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to load job, returning it to the queue", "error", err)
+		w.nack(logger, msg)
+		return
+	}
+
+	if !isRunnable(job.Status) {
+		logger.Warn("Skipping job that is already finished", "status", job.Status)
+		w.ack(logger, msg)
+		return
+	}
+
+	// Track the job before claiming it, so a cancellation requested as soon
+	// as it turns processing is not missed
+	runCtx, stop := context.WithCancelCause(jobCtx)
+	defer stop(nil)
+	w.track(job.ID, stop)
+	defer w.untrack(job.ID)
+
+	// A processing job is redelivered when a previous lease expired, e.g. the
+	// worker holding it crashed, and may be claimed again once it has been
+	// running for longer than the visibility timeout.
+	claimed, err := w.store.ClaimJob(ctx, job.ID, w.cfg.WorkerID, w.cfg.VisibilityTimeout)
+	if err != nil {
+		logger.Error("Failed to claim job, returning it to the queue", "error", err)
+		w.nack(logger, msg)
+		return
+	}
+	if !claimed {
+		// Another worker still holds the job. The message is left leased so
+		// the reaper hands it back once more, by which time the job has either
+		// finished or its holder is known to be gone.
+		logger.Warn("Job is held by another worker, leaving message until its lease expires")
+		return
+	}
+	job.Attempts++
+
+	if job.CancelRequestedAt != nil {
+		// Its previous worker was lost before it could stop the job
+		logger.Info("Cancelling redelivered job")
+		w.markCancelled(logger, job)
+		w.finishAttempt(logger, job, store.AttemptEnd{Outcome: store.AttemptCancelled})
+		w.ack(logger, msg)
+		return
+	}
+
+	logger.Info("Processing job", "attempt", job.Attempts)
+	start := time.Now()
+
+	beatCtx, stopBeating := context.WithCancel(runCtx)
+	beating := make(chan struct{})
+	go func() {
+		defer close(beating)
+		w.heartbeat(beatCtx, logger, msg, job.Attempts, stop)
+	}()
+
+	running := &runningJob{store: w.store, jobID: job.ID, attempt: job.Attempts, stop: stop}
+	err = runHandler(context.WithValue(runCtx, runningJobKey{}, running), reg.handler, job)
+	stopBeating()
+	<-beating
+
+	switch {
+	case err == nil:
+		logger.Info("Job completed", "duration", time.Since(start))
+		w.record(logger, "completed", func(ctx context.Context, job *store.Job) (bool, error) {
+			return w.store.MarkJobAsCompleted(ctx, job.ID, job.Attempts, running.result())
+		}, job)
+		w.finishAttempt(logger, job, store.AttemptEnd{Outcome: store.AttemptSucceeded})
+		w.ack(logger, msg)
+	case errors.Is(context.Cause(runCtx), errCancelRequested):
+		logger.Info("Job cancelled", "duration", time.Since(start))
+		w.markCancelled(logger, job)
+		w.finishAttempt(logger, job, store.AttemptEnd{Outcome: store.AttemptCancelled})
+		w.ack(logger, msg)
+	case errors.Is(context.Cause(runCtx), errJobLost):
+		// Its outcome, and that of its attempt, was recorded when it was taken away
+		logger.Warn("Job stopped after being taken from this worker", "duration", time.Since(start))
+		w.ack(logger, msg)
+	case jobCtx.Err() != nil:
+		logger.Warn("Job interrupted by shutdown, returning it to the queue", "error", err)
+		w.finishAttempt(logger, job, store.AttemptEnd{Outcome: store.AttemptInterrupted})
+		w.requeue(logger, job, msg)
+	default:
+		logger.Error("Job failed", "error", err, "retryable", !IsPermanent(err), "duration", time.Since(start))
+		w.fail(logger, job, err)
+		w.finishAttempt(logger, job, store.AttemptEnd{Outcome: store.AttemptFailed, Error: DescribeError(err)})
+		w.ack(logger, msg)
+	}
+}
+
+// heartbeat extends the lease of a running job and records its heartbeat every
+// HeartbeatInterval until ctx is done. It stops the job through stop if the
+// job was taken from this worker or its cancellation was requested, which
+// covers requests published while this worker was not subscribed.
+func (w *Worker) heartbeat(ctx context.Context, logger *slog.Logger, msg *queue.JobMessage, attempt int, stop context.CancelCauseFunc) {
+	ticker := time.NewTicker(w.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		beatCtx, cancel := context.WithTimeout(ctx, bookkeepingTimeout)
+		if _, err := w.queue.ExtendLease(beatCtx, msg, w.cfg.VisibilityTimeout); err != nil {
+			logger.Warn("Failed to extend job lease", "error", err)
+		}
+		held, cancelRequested, err := w.store.Heartbeat(beatCtx, msg.JobID, attempt)
+		cancel()
+
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				logger.Warn("Failed to record job heartbeat", "error", err)
+			}
+		case !held:
+			logger.Warn("Job is no longer held by this worker, stopping it")
+			stop(errJobLost)
+			return
+		case cancelRequested:
+			logger.Info("Cancellation requested for running job")
+			stop(errCancelRequested)
+			return
+		}
+	}
+}
+
+// isRunnable reports whether a job in status may be claimed by a worker.
+func isRunnable(status string) bool {
+	switch status {
+	case store.JobStatusQueued, store.JobStatusScheduled, store.JobStatusRetrying, store.JobStatusProcessing:
+		return true
+	default:
+		return false
+	}
+}
+
+// jobRetryPolicy returns the job's retry policy, with the job type's defaults
+// for anything the job does not set.
+func jobRetryPolicy(job *store.Job) retry.Policy {
+	return retry.Policy{
+		MaxAttempts: job.MaxAttempts,
+		BaseDelay:   job.RetryBaseDelay,
+		MaxDelay:    job.RetryMaxDelay,
+		Jitter:      job.RetryJitter,
+	}.WithDefaults(job.Type)
+}
+
+// fail records a failed attempt; see RecordFailure.
+func (w *Worker) fail(logger *slog.Logger, job *store.Job, jobErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	if _, err := RecordFailure(ctx, logger, w.store, w.queue, job, jobErr, 0); err != nil {
+		logger.Error("Failed to record job outcome", "error", err)
+	}
+}
+
+// RecordFailure records a failed attempt of a processing job and returns the
+// status the job moved to, or "" if the job was no longer processing attempt
+// job.Attempts. A transient failure with attempts left is rescheduled after
+// retryDelay, or the policy's backoff if it is zero; one that exhausted its
+// attempts is dead-lettered, and a permanent failure fails the job. Worker
+// uses it for the jobs it runs, and queue-svc for jobs reported by remote
+// workers.
+func RecordFailure(ctx context.Context, logger *slog.Logger, s store.JobStore, q queue.JobQueue, job *store.Job, jobErr error, retryDelay time.Duration) (string, error) {
+	if IsPermanent(jobErr) {
+		failed, err := s.FailJob(ctx, job.ID, store.JobStatusProcessing, job.Attempts, DescribeError(jobErr))
+		if err != nil || !failed {
+			return "", err
+		}
+		logger.Warn("Job failed permanently", "attempts", job.Attempts)
+		return store.JobStatusFailed, nil
+	}
+
+	policy := jobRetryPolicy(job)
+
+	if policy.CanRetry(job.AttemptsSinceReplay()) {
+		delay := retryDelay
+		if delay <= 0 {
+			delay = policy.Backoff(job.AttemptsSinceReplay())
+		}
+		entry, err := s.ScheduleRetry(ctx, job.ID, job.Attempts, DescribeError(jobErr), delay)
+		if err != nil || entry == nil {
+			return "", err
+		}
+		logger.Info("Scheduled job retry", "attempt", job.Attempts, "max_attempts", policy.MaxAttempts, "delay", delay)
+
+		if entry.SentAt != nil {
+			// Already queued in the transaction that scheduled it
+			return store.JobStatusRetrying, nil
+		}
+		// The retry is durable in the outbox; publishing now just saves the relay a trip
+		if err := outbox.Publish(ctx, q, entry); err != nil {
+			logger.Warn("Failed to publish job retry, leaving it to the outbox relay", "error", err)
+			return store.JobStatusRetrying, nil
+		}
+		if err := s.MarkOutboxSent(ctx, entry.ID); err != nil {
+			logger.Warn("Failed to mark outbox entry as sent", "error", err)
+		}
+		return store.JobStatusRetrying, nil
+	}
+
+	dead, err := s.DeadLetterJob(ctx, job.ID, store.JobStatusProcessing, job.Attempts, DescribeError(jobErr))
+	if err != nil || !dead {
+		return "", err
+	}
+	// Postgres is the source of truth for dead jobs; the Redis set only mirrors it
+	if err := q.DeadLetter(ctx, job.ID, job.Type); err != nil {
+		logger.Warn("Failed to add job to dead-letter queue", "error", err)
+	}
+	logger.Warn("Job dead-lettered after exhausting retries", "attempts", job.Attempts, "max_attempts", policy.MaxAttempts)
+	return store.JobStatusDead, nil
+}
+
+// runHandler invokes h, converting a panic into an error so one bad job
+// cannot take down the pool.
+func runHandler(ctx context.Context, h HandlerFunc, job *store.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &panicError{value: r, stack: debug.Stack()}
+		}
+	}()
+
+	return h(ctx, job)
+}
+
+// record applies a terminal status transition using a fresh context, since
+// the job context may already be cancelled. mark reports false if the job was
+// taken from this worker, in which case the outcome is dropped.
+func (w *Worker) record(logger *slog.Logger, outcome string, mark func(context.Context, *store.Job) (bool, error), job *store.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	held, err := mark(ctx, job)
+	if err != nil {
+		logger.Error("Failed to record job outcome", "error", err, "outcome", outcome)
+		return
+	}
+	if !held {
+		logger.Warn("Job was taken from this worker before its outcome was recorded", "outcome", outcome)
+	}
+}
+
+// markCancelled records that a processing job was stopped by a cancellation request.
+func (w *Worker) markCancelled(logger *slog.Logger, job *store.Job) {
+	w.record(logger, "cancelled", func(ctx context.Context, job *store.Job) (bool, error) {
+		return w.store.MarkJobAsCancelled(ctx, job.ID, job.Attempts)
+	}, job)
+}
+
+// finishAttempt closes the job's current attempt in its attempt history.
+func (w *Worker) finishAttempt(logger *slog.Logger, job *store.Job, end store.AttemptEnd) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	if err := w.store.FinishAttempt(ctx, job.ID, job.Attempts, end); err != nil {
+		logger.Error("Failed to record job attempt", "error", err, "outcome", end.Outcome)
+	}
+}
+
+// track registers stop as the way to cancel an in-flight job.
+func (w *Worker) track(id uuid.UUID, stop context.CancelCauseFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running[id] = stop
+}
+
+func (w *Worker) untrack(id uuid.UUID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.running, id)
+}
+
+// listenForCancels stops in-flight jobs whose cancellation is requested until
+// ids is closed. Requests for jobs this worker is not running are ignored.
+func (w *Worker) listenForCancels(ids <-chan uuid.UUID) {
+	for id := range ids {
+		w.mu.Lock()
+		stop, ok := w.running[id]
+		w.mu.Unlock()
+
+		if ok {
+			w.logger.Info("Cancellation requested for running job", "job_id", id.String())
+			stop(errCancelRequested)
+		}
+	}
+}
+
+// ack releases the job's lease once its outcome has been recorded.
+func (w *Worker) ack(logger *slog.Logger, msg *queue.JobMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	held, err := w.queue.Ack(ctx, msg)
+	if err != nil {
+		logger.Error("Failed to ack job", "error", err)
+		return
+	}
+	if !held {
+		logger.Warn("Job lease expired before ack, it may be processed again")
+	}
+}
+
+// nack returns the message of a job this worker has not claimed to the queue,
+// leaving the job itself alone; whoever dequeues it next claims it as usual.
+func (w *Worker) nack(logger *slog.Logger, msg *queue.JobMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	if _, err := w.queue.Nack(ctx, msg); err != nil {
+		logger.Error("Failed to return job to the queue", "error", err)
+	}
+}
+
+// requeue resets the job's interrupted attempt to queued and returns its
+// message to the queue. If the attempt was taken over in the meantime, the job
+// and its message belong to the new holder and are left alone.
+func (w *Worker) requeue(logger *slog.Logger, job *store.Job, msg *queue.JobMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	requeued, err := w.store.MarkJobAsQueued(ctx, job.ID, job.Attempts)
+	if err != nil {
+		// The lease expires and the reaper returns the message instead
+		logger.Error("Failed to mark job as queued", "error", err)
+		return
+	}
+	if !requeued {
+		logger.Warn("Job was taken from this worker before it could be requeued")
+		return
+	}
+	w.nack(logger, msg)
+}
+
+// reap periodically returns expired leases for jobType to the queue until ctx is cancelled.
+func (w *Worker) reap(ctx context.Context, jobType string) {
+	ticker := time.NewTicker(w.cfg.ReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reaped, err := w.queue.ReapExpired(ctx, jobType, w.cfg.VisibilityTimeout)
+		if err != nil {
+			w.logger.Error("Failed to reap expired leases", "error", err, "type", jobType)
+			continue
+		}
+		if reaped > 0 {
+			w.logger.Warn("Returned jobs with expired leases to the queue", "type", jobType, "count", reaped)
+		}
+	}
+}
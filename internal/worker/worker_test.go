@@ -0,0 +1,638 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turnertastic1/boltq/internal/queue"
+	"github.com/turnertastic1/boltq/internal/store"
+	"github.com/turnertastic1/boltq/internal/testutil"
+)
+
+const testJobType = "JOB_STANDARD"
+
+type testDeps struct {
+	logger *slog.Logger
+	store  *store.PostgresStore
+	queue  *queue.RedisQueue
+}
+
+func setupTestWorker(t *testing.T) *testDeps {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	db := testutil.StartPostgres(t)
+	redisAddr := testutil.StartRedis(t)
+
+	redisQueue, err := queue.NewRedisQueue(redisAddr, "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { redisQueue.Close() })
+
+	return &testDeps{
+		logger: logger,
+		store:  store.NewPostgresStore(db),
+		queue:  redisQueue,
+	}
+}
+
+// enqueueTestJob creates a queued job in Postgres and pushes its reference to Redis.
+func enqueueTestJob(t *testing.T, deps *testDeps) uuid.UUID {
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:      jobID,
+		Type:    testJobType,
+		Payload: []byte("worker test payload"),
+		Status:  store.JobStatusQueued,
+	}))
+	require.NoError(t, deps.queue.Enqueue(ctx, jobID, testJobType, 0))
+
+	return jobID
+}
+
+// startWorker runs w in the background and returns a function that stops it
+// and waits for Run to return.
+func startWorker(t *testing.T, w *Worker) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Run(ctx) }()
+
+	return func() {
+		cancel()
+		select {
+		case err := <-errCh:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("worker did not stop in time")
+		}
+	}
+}
+
+func requireJobStatus(t *testing.T, deps *testDeps, jobID uuid.UUID, want string) {
+	require.Eventually(t, func() bool {
+		job, err := deps.store.GetJobByID(context.Background(), jobID)
+		return err == nil && job.Status == want
+	}, 10*time.Second, 50*time.Millisecond, "job never reached status %s", want)
+}
+
+func TestWorker_ProcessesJob(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+
+	handled := make(chan uuid.UUID, 1)
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		handled <- job.ID
+		return nil
+	}, 2)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	select {
+	case id := <-handled:
+		assert.Equal(t, jobID, id)
+	case <-time.After(10 * time.Second):
+		t.Fatal("handler was never invoked")
+	}
+
+	requireJobStatus(t, deps, jobID, store.JobStatusCompleted)
+
+	job, err := deps.store.GetJobByID(context.Background(), jobID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Attempts)
+	assert.NotNil(t, job.StartedAt)
+	assert.NotNil(t, job.CompletedAt)
+
+	// The lease is released once the outcome is recorded
+	require.Eventually(t, func() bool {
+		n, err := deps.queue.GetProcessingLength(context.Background(), testJobType)
+		return err == nil && n == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWorker_HandlerErrorMarksJobFailed(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		return Permanent(errors.New("boom"))
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusFailed)
+
+	// Permanent failures skip the dead-letter queue
+	n, err := deps.queue.GetDeadLetterLength(context.Background(), testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
+
+func TestWorker_HandlerErrorSchedulesRetry(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		return errors.New("boom")
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusRetrying)
+
+	job, err := deps.store.GetJobByID(context.Background(), jobID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Attempts)
+	require.NotNil(t, job.LastError)
+	assert.Equal(t, "boom", *job.LastError)
+	assert.NotNil(t, job.NextRunAt)
+
+	// The retry waits in the scheduled set until it is due
+	scheduled, err := deps.queue.GetScheduledLength(context.Background(), testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), scheduled)
+
+	pending, err := deps.store.CountPendingOutbox(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), pending)
+}
+
+func TestWorker_HandlerErrorDeadLettersExhaustedJob(t *testing.T) {
+	deps := setupTestWorker(t)
+	ctx := context.Background()
+	jobID := uuid.New()
+
+	require.NoError(t, deps.store.CreateJob(ctx, &store.Job{
+		ID:          jobID,
+		Type:        testJobType,
+		Payload:     []byte("worker test payload"),
+		Status:      store.JobStatusQueued,
+		MaxAttempts: 1,
+	}))
+	require.NoError(t, deps.queue.Enqueue(ctx, jobID, testJobType, 0))
+
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		return errors.New("boom")
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusDead)
+
+	failures, err := deps.store.ListJobFailures(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "boom", failures[0].Error)
+
+	n, err := deps.queue.GetDeadLetterLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestWorker_ShutdownRequeuesInFlightJob(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+
+	started := make(chan struct{})
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{
+		PollTimeout:     100 * time.Millisecond,
+		ShutdownTimeout: 200 * time.Millisecond,
+	})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, 1)
+
+	stop := startWorker(t, w)
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("handler was never invoked")
+	}
+
+	stop()
+
+	job, err := deps.store.GetJobByID(context.Background(), jobID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusQueued, job.Status)
+
+	queueLen, err := deps.queue.GetQueueLength(context.Background(), testJobType)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), queueLen)
+}
+
+func TestWorker_ShutdownLeavesJobTakenOver(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{
+		PollTimeout:       100 * time.Millisecond,
+		ShutdownTimeout:   200 * time.Millisecond,
+		HeartbeatInterval: time.Hour,
+	})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, 1)
+
+	stop := startWorker(t, w)
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("handler was never invoked")
+	}
+
+	// Another worker claims the job, as if this one's lease had expired
+	claimed, err := deps.store.ClaimJob(ctx, jobID, "worker-2", 0)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	stop()
+
+	// The interrupted attempt is not requeued over the new one
+	job, err := deps.store.GetJobByID(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, store.JobStatusProcessing, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+
+	queueLen, err := deps.queue.GetQueueLength(ctx, testJobType)
+	require.NoError(t, err)
+	assert.Zero(t, queueLen)
+}
+
+func TestWorker_CancelsRunningJob(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("handler was never invoked")
+	}
+
+	// What the CancelJob RPC does for a processing job
+	_, err := deps.store.CancelJob(ctx, jobID)
+	require.NoError(t, err)
+	require.NoError(t, deps.queue.RequestCancel(ctx, jobID))
+
+	requireJobStatus(t, deps, jobID, store.JobStatusCancelled)
+
+	require.Eventually(t, func() bool {
+		n, err := deps.queue.GetProcessingLength(ctx, testJobType)
+		return err == nil && n == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWorker_CancelsRedeliveredJobAtClaim(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	// The job was running elsewhere when its cancellation was requested, and
+	// that worker died before seeing it
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+	_, err := deps.store.CancelJob(ctx, jobID)
+	require.NoError(t, err)
+
+	// Let the previous claim go stale
+	time.Sleep(500 * time.Millisecond)
+
+	invoked := make(chan struct{}, 1)
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{
+		PollTimeout:       100 * time.Millisecond,
+		VisibilityTimeout: 300 * time.Millisecond,
+	})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		invoked <- struct{}{}
+		return nil
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusCancelled)
+	assert.Empty(t, invoked)
+}
+
+func TestWorker_RecoversJobFromCrashedWorker(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	// Simulate a worker that leased the job, marked it processing and died
+	msg, err := deps.queue.DequeueReliable(ctx, testJobType, time.Second, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	require.NoError(t, deps.store.MarkJobAsProcessing(ctx, jobID))
+
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{
+		PollTimeout:       100 * time.Millisecond,
+		VisibilityTimeout: 300 * time.Millisecond,
+		ReapInterval:      100 * time.Millisecond,
+	})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		return nil
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusCompleted)
+
+	job, err := deps.store.GetJobByID(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Attempts)
+}
+
+func TestWorker_HeartbeatKeepsLongJobLeased(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	invocations := make(chan struct{}, 2)
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{
+		PollTimeout:       100 * time.Millisecond,
+		VisibilityTimeout: 300 * time.Millisecond,
+		ReapInterval:      100 * time.Millisecond,
+		HeartbeatInterval: 50 * time.Millisecond,
+	})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		invocations <- struct{}{}
+		// Outlives the visibility timeout several times over
+		time.Sleep(time.Second)
+		return nil
+	}, 2)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusCompleted)
+
+	job, err := deps.store.GetJobByID(ctx, jobID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Len(t, invocations, 1)
+	require.NotNil(t, job.LastHeartbeatAt)
+	assert.True(t, job.LastHeartbeatAt.After(*job.StartedAt))
+}
+
+func TestWorker_StopsJobTakenAway(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{
+		PollTimeout:       100 * time.Millisecond,
+		HeartbeatInterval: 50 * time.Millisecond,
+	})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		close(started)
+		<-ctx.Done()
+		stopped <- context.Cause(ctx)
+		return ctx.Err()
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("handler was never invoked")
+	}
+
+	// What the reconciler does once the worker's heartbeats go missing
+	dead, err := deps.store.DeadLetterJob(ctx, jobID, store.JobStatusProcessing, 1, store.JobError{Message: "reconciler: no heartbeat"})
+	require.NoError(t, err)
+	require.True(t, dead)
+
+	select {
+	case cause := <-stopped:
+		assert.ErrorIs(t, cause, errJobLost)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not stopped after being taken away")
+	}
+
+	require.Eventually(t, func() bool {
+		n, err := deps.queue.GetProcessingLength(ctx, testJobType)
+		return err == nil && n == 0
+	}, 5*time.Second, 50*time.Millisecond)
+	requireJobStatus(t, deps, jobID, store.JobStatusDead)
+}
+
+func TestWorker_RetryResumesFromCheckpoint(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	checkpoints := make(chan []byte, 2)
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		checkpoints <- job.Checkpoint
+		if job.Checkpoint != nil {
+			return nil
+		}
+		if err := ReportProgress(ctx, Progress{Percent: 40, Message: "step 4 of 10", Checkpoint: []byte("step-4")}); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusRetrying)
+
+	job, err := deps.store.GetJobByID(ctx, jobID)
+	require.NoError(t, err)
+	require.NotNil(t, job.ProgressPercent)
+	assert.Equal(t, 40, *job.ProgressPercent)
+	require.NotNil(t, job.ProgressMessage)
+	assert.Equal(t, "step 4 of 10", *job.ProgressMessage)
+	assert.Equal(t, []byte("step-4"), job.Checkpoint)
+
+	// What the promoter does once the retry is due
+	require.NoError(t, deps.queue.Enqueue(ctx, jobID, testJobType, 0))
+	requireJobStatus(t, deps, jobID, store.JobStatusCompleted)
+
+	assert.Nil(t, <-checkpoints)
+	assert.Equal(t, []byte("step-4"), <-checkpoints)
+}
+
+func TestWorker_RecordsResultAndErrorCode(t *testing.T) {
+	deps := setupTestWorker(t)
+	succeeded := enqueueTestJob(t, deps)
+	failed := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		if job.ID == failed {
+			return Permanent(WithCode(errors.New("account closed"), "account_closed", "account 42 closed on 2024-01-01"))
+		}
+		return SetResult(ctx, []byte(`{"sent":3}`))
+	}, 2)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, succeeded, store.JobStatusCompleted)
+	requireJobStatus(t, deps, failed, store.JobStatusFailed)
+
+	job, err := deps.store.GetJobByID(ctx, succeeded)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"sent":3}`), job.Result)
+
+	job, err = deps.store.GetJobByID(ctx, failed)
+	require.NoError(t, err)
+	assert.Nil(t, job.Result)
+	require.NotNil(t, job.LastError)
+	assert.Equal(t, "account closed", *job.LastError)
+	require.NotNil(t, job.ErrorCode)
+	assert.Equal(t, "account_closed", *job.ErrorCode)
+	require.NotNil(t, job.ErrorDetails)
+	assert.Equal(t, "account 42 closed on 2024-01-01", *job.ErrorDetails)
+}
+
+func TestWorker_RecordsAttemptHistory(t *testing.T) {
+	deps := setupTestWorker(t)
+	jobID := enqueueTestJob(t, deps)
+	ctx := context.Background()
+
+	w := NewWorker(deps.logger, deps.store, deps.queue, Config{PollTimeout: 100 * time.Millisecond, WorkerID: "worker-a"})
+	w.Register(testJobType, func(ctx context.Context, job *store.Job) error {
+		if job.Attempts == 1 {
+			return WithCode(errors.New("upstream unavailable"), "http_503", "")
+		}
+		return nil
+	}, 1)
+
+	stop := startWorker(t, w)
+	defer stop()
+
+	requireJobStatus(t, deps, jobID, store.JobStatusRetrying)
+	require.NoError(t, deps.queue.Enqueue(ctx, jobID, testJobType, 0))
+	requireJobStatus(t, deps, jobID, store.JobStatusCompleted)
+
+	var attempts []*store.JobAttempt
+	require.Eventually(t, func() bool {
+		var err error
+		attempts, err = deps.store.ListJobAttempts(ctx, jobID)
+		return err == nil && len(attempts) == 2 && attempts[1].Outcome != store.AttemptRunning
+	}, 10*time.Second, 50*time.Millisecond)
+
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.Equal(t, "worker-a", attempts[0].WorkerID)
+	assert.Equal(t, store.AttemptFailed, attempts[0].Outcome)
+	require.NotNil(t, attempts[0].Error)
+	assert.Equal(t, "upstream unavailable", *attempts[0].Error)
+	require.NotNil(t, attempts[0].ErrorCode)
+	assert.Equal(t, "http_503", *attempts[0].ErrorCode)
+	assert.NotNil(t, attempts[0].FinishedAt)
+	assert.NotNil(t, attempts[0].LatencyMs)
+
+	assert.Equal(t, 2, attempts[1].Attempt)
+	assert.Equal(t, store.AttemptSucceeded, attempts[1].Outcome)
+	assert.Nil(t, attempts[1].Error)
+}
+
+func TestReportProgress_OutsideJob(t *testing.T) {
+	err := ReportProgress(context.Background(), Progress{Percent: 10})
+	assert.Error(t, err)
+	assert.Error(t, SetResult(context.Background(), []byte("output")))
+}
+
+func TestProgress_Validate(t *testing.T) {
+	assert.NoError(t, Progress{Percent: 100, Message: "done"}.Validate())
+	assert.Error(t, Progress{Percent: -1}.Validate())
+	assert.Error(t, Progress{Percent: 101}.Validate())
+	assert.Error(t, Progress{Message: string(make([]byte, MaxProgressMessageLen+1))}.Validate())
+	assert.Error(t, Progress{Checkpoint: make([]byte, MaxCheckpointSize+1)}.Validate())
+}
+
+func TestWorker_RunWithoutHandlers(t *testing.T) {
+	w := NewWorker(slog.Default(), nil, nil, Config{})
+
+	err := w.Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestRunHandler_RecoversPanic(t *testing.T) {
+	err := runHandler(context.Background(), func(ctx context.Context, job *store.Job) error {
+		panic("handler exploded")
+	}, &store.Job{ID: uuid.New()})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "handler exploded")
+
+	jobErr := DescribeError(err)
+	assert.Equal(t, "panic", jobErr.Code)
+	assert.Contains(t, jobErr.Details, "TestRunHandler_RecoversPanic")
+}
+
+func TestWithCode(t *testing.T) {
+	base := errors.New("upstream unavailable")
+
+	err := Permanent(WithCode(base, "http_503", "retry-after: 30"))
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, "http_503", ErrorCode(fmt.Errorf("wrapped: %w", err)))
+	assert.Equal(t, store.JobError{Message: "upstream unavailable", Code: "http_503", Details: "retry-after: 30"}, DescribeError(err))
+
+	assert.Equal(t, "", ErrorCode(base))
+	assert.Equal(t, store.JobError{Message: "upstream unavailable"}, DescribeError(base))
+	assert.Nil(t, WithCode(nil, "http_503", ""))
+
+	long := DescribeError(WithCode(base, "dump", string(make([]byte, maxErrorDetailsLen+1))))
+	assert.Len(t, long.Details, maxErrorDetailsLen)
+}
+
+func TestPermanent(t *testing.T) {
+	base := errors.New("bad request")
+
+	err := Permanent(base)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, base)
+	assert.True(t, IsPermanent(fmt.Errorf("wrapped: %w", err)))
+
+	assert.False(t, IsPermanent(base))
+	assert.Nil(t, Permanent(nil))
+}
@@ -0,0 +1,2 @@
+-- Track how many times a job has been picked up by a worker
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
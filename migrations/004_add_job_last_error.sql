@@ -0,0 +1,4 @@
+-- Record why a job was last failed or requeued
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status_started_at ON jobs(status, started_at);
@@ -0,0 +1,4 @@
+-- Record the outcome of the last webhook delivery attempt
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS response_status INTEGER;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS response_latency_ms INTEGER;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS response_body BYTEA;
@@ -0,0 +1,6 @@
+-- Per-job retry policy and scheduling of the next attempt
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS max_attempts INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS retry_base_delay_ms BIGINT NOT NULL DEFAULT 1000;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS retry_max_delay_ms BIGINT NOT NULL DEFAULT 60000;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS retry_jitter VARCHAR(10) NOT NULL DEFAULT 'full';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS next_run_at TIMESTAMP;
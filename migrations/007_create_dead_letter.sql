@@ -0,0 +1,14 @@
+-- Dead-lettered jobs and the failure history used to inspect them
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_dead ON jobs(dead_at DESC, id DESC) WHERE status = 'dead';
+
+CREATE TABLE IF NOT EXISTS job_failures (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    error TEXT NOT NULL,
+    failed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_failures_job_id ON job_failures(job_id, failed_at);
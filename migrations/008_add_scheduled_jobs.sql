@@ -0,0 +1,4 @@
+-- Jobs scheduled to run at a later time
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS run_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_next_run_at ON jobs(next_run_at) WHERE status IN ('scheduled', 'retrying');
@@ -0,0 +1,5 @@
+-- Client-supplied idempotency keys. A key maps to one job until the job is
+-- older than the service's retention window, after which it is released.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_idempotency_key ON jobs(idempotency_key) WHERE idempotency_key IS NOT NULL;
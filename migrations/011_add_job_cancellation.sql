@@ -0,0 +1,3 @@
+-- Set when cancellation of a processing job is requested, so a worker that
+-- picks the job up again after the owning worker died still cancels it
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS cancel_requested_at TIMESTAMP;
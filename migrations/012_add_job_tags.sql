@@ -0,0 +1,11 @@
+-- Free-form key/value labels on jobs, filtered on by ListJobs with @>
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_jobs_tags ON jobs USING GIN (tags jsonb_path_ops);
+
+-- ListJobs pages on (created_at, id), overall and within a status
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at_id ON jobs(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_created_at_id ON jobs(status, created_at DESC, id DESC);
+
+-- Superseded by idx_jobs_created_at_id
+DROP INDEX IF EXISTS idx_jobs_created_at;
@@ -0,0 +1,26 @@
+-- Publishes every job status change on the boltq_job_events channel, so
+-- watchers on any queue-svc replica see changes made by any process. Tags are
+-- left out when large, as a payload over 8000 bytes would fail the write.
+CREATE OR REPLACE FUNCTION notify_job_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('boltq_job_events', json_build_object(
+        'job_id', NEW.id,
+        'type', NEW.type,
+        'status', NEW.status,
+        'tags', CASE WHEN octet_length(NEW.tags::text) <= 4000 THEN NEW.tags END,
+        'at_us', (extract(epoch FROM clock_timestamp()) * 1000000)::bigint
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_notify_insert ON jobs;
+CREATE TRIGGER jobs_notify_insert
+    AFTER INSERT ON jobs
+    FOR EACH ROW EXECUTE FUNCTION notify_job_event();
+
+DROP TRIGGER IF EXISTS jobs_notify_status ON jobs;
+CREATE TRIGGER jobs_notify_status
+    AFTER UPDATE OF status ON jobs
+    FOR EACH ROW WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_job_event();
@@ -0,0 +1,5 @@
+-- Refreshed by the worker running a job. A processing job whose heartbeat has
+-- gone stale is treated as abandoned by its worker
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS last_heartbeat_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_processing_heartbeat ON jobs(last_heartbeat_at) WHERE status = 'processing';
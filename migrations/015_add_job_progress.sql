@@ -0,0 +1,38 @@
+-- Progress reported by the worker running a job, and the checkpoint handed
+-- back to its handler if the job is retried
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS progress_percent SMALLINT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS progress_message TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS progress_updated_at TIMESTAMP;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS checkpoint BYTEA;
+
+-- Progress events carry the reported progress and leave the status unchanged
+CREATE OR REPLACE FUNCTION notify_job_event() RETURNS trigger AS $$
+DECLARE
+    progress json;
+BEGIN
+    IF TG_NAME = 'jobs_notify_progress' THEN
+        progress := json_build_object(
+            'percent', NEW.progress_percent,
+            'message', NEW.progress_message,
+            'updated_at_us', (extract(epoch FROM NEW.progress_updated_at) * 1000000)::bigint
+        );
+    END IF;
+
+    PERFORM pg_notify('boltq_job_events', json_build_object(
+        'job_id', NEW.id,
+        'type', NEW.type,
+        'status', NEW.status,
+        'tags', CASE WHEN octet_length(NEW.tags::text) <= 4000 THEN NEW.tags END,
+        'progress', progress,
+        'at_us', (extract(epoch FROM clock_timestamp()) * 1000000)::bigint
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_notify_progress ON jobs;
+CREATE TRIGGER jobs_notify_progress
+    AFTER UPDATE OF progress_updated_at ON jobs
+    FOR EACH ROW WHEN (OLD.progress_updated_at IS DISTINCT FROM NEW.progress_updated_at
+        AND OLD.status IS NOT DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION notify_job_event();
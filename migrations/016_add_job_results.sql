@@ -0,0 +1,5 @@
+-- Output of a completed job, and what is known about its last failure beyond
+-- the message kept in last_error
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS result BYTEA;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS error_code TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS error_details TEXT;
@@ -0,0 +1,18 @@
+-- One row per execution of a job, opened when a worker claims it and closed
+-- with the attempt's outcome
+CREATE TABLE IF NOT EXISTS job_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    worker_id TEXT NOT NULL,
+    outcome VARCHAR(50) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    latency_ms BIGINT,
+    error TEXT,
+    error_code TEXT,
+    error_details TEXT,
+    response_status INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_attempts_job_id ON job_attempts(job_id, id);
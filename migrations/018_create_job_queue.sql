@@ -0,0 +1,49 @@
+-- Queue state for the Postgres queue backend (QUEUE_BACKEND=postgres). Each
+-- row is one message for a job: ready in its type's queue, scheduled to be
+-- promoted at run_at, or leased to a consumer until lease_expires_at.
+-- Consumers take ready rows with FOR UPDATE SKIP LOCKED, lowest position
+-- first; messages returned to the head of the queue get a negative position.
+CREATE SEQUENCE IF NOT EXISTS job_queue_position_seq;
+
+CREATE TABLE IF NOT EXISTS job_queue (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    level SMALLINT NOT NULL,
+    state VARCHAR(20) NOT NULL,
+    position BIGINT NOT NULL,
+    run_at TIMESTAMPTZ,
+    lease_id UUID,
+    lease_expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_queue_ready ON job_queue(type, level, position) WHERE state = 'ready';
+CREATE INDEX IF NOT EXISTS idx_job_queue_scheduled ON job_queue(type, run_at) WHERE state = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_job_queue_leased ON job_queue(type, lease_expires_at) WHERE state = 'leased';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_job_queue_lease_id ON job_queue(lease_id) WHERE lease_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_job_queue_job_id ON job_queue(job_id);
+
+-- Dead-lettered jobs of each type, like the Redis backend's dead-letter sets
+CREATE TABLE IF NOT EXISTS job_queue_dead (
+    type VARCHAR(50) NOT NULL,
+    job_id UUID NOT NULL,
+    dead_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (type, job_id)
+);
+
+-- Wakes consumers listening on boltq_queue whenever a message becomes ready.
+-- Postgres folds identical notifications sent in one transaction, so a batch
+-- of one type wakes them once.
+CREATE OR REPLACE FUNCTION notify_job_queue() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('boltq_queue', NEW.type);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS job_queue_notify ON job_queue;
+CREATE TRIGGER job_queue_notify
+    AFTER INSERT OR UPDATE OF state ON job_queue
+    FOR EACH ROW WHEN (NEW.state = 'ready')
+    EXECUTE FUNCTION notify_job_queue();
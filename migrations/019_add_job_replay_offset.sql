@@ -0,0 +1,4 @@
+-- Attempts a job made before it was last replayed from the dead-letter queue.
+-- Replays keep counting attempts, so attempt numbers in job_failures and
+-- job_attempts never repeat; the retry budget counts from this offset instead.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS attempts_before_replay INTEGER NOT NULL DEFAULT 0;
@@ -9,6 +9,8 @@ package queuepb
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -26,6 +28,8 @@ type JobType int32
 const (
 	JobType_JOB_TYPE_UNSPECIFIED JobType = 0
 	JobType_JOB_STANDARD         JobType = 1
+	// Payload is a JSON webhook request: {"url", "method", "headers", "body", "timeout_ms"}
+	JobType_JOB_WEBHOOK JobType = 2
 )
 
 // Enum value maps for JobType.
@@ -33,10 +37,12 @@ var (
 	JobType_name = map[int32]string{
 		0: "JOB_TYPE_UNSPECIFIED",
 		1: "JOB_STANDARD",
+		2: "JOB_WEBHOOK",
 	}
 	JobType_value = map[string]int32{
 		"JOB_TYPE_UNSPECIFIED": 0,
 		"JOB_STANDARD":         1,
+		"JOB_WEBHOOK":          2,
 	}
 )
 
@@ -67,17 +73,400 @@ func (JobType) EnumDescriptor() ([]byte, []int) {
 	return file_proto_queue_proto_rawDescGZIP(), []int{0}
 }
 
+type JobStatus int32
+
+const (
+	JobStatus_JOB_STATUS_UNSPECIFIED JobStatus = 0
+	JobStatus_JOB_STATUS_QUEUED      JobStatus = 1
+	JobStatus_JOB_STATUS_PROCESSING  JobStatus = 2
+	JobStatus_JOB_STATUS_COMPLETED   JobStatus = 3
+	JobStatus_JOB_STATUS_FAILED      JobStatus = 4
+	// Failed an attempt and waiting for next_run_at before trying again
+	JobStatus_JOB_STATUS_RETRYING JobStatus = 5
+	// Exhausted its retries; held in the dead-letter queue until replayed or purged
+	JobStatus_JOB_STATUS_DEAD JobStatus = 6
+	// Waiting for its run_at before being queued
+	JobStatus_JOB_STATUS_SCHEDULED JobStatus = 7
+	// Cancelled through CancelJob before it finished
+	JobStatus_JOB_STATUS_CANCELLED JobStatus = 8
+)
+
+// Enum value maps for JobStatus.
+var (
+	JobStatus_name = map[int32]string{
+		0: "JOB_STATUS_UNSPECIFIED",
+		1: "JOB_STATUS_QUEUED",
+		2: "JOB_STATUS_PROCESSING",
+		3: "JOB_STATUS_COMPLETED",
+		4: "JOB_STATUS_FAILED",
+		5: "JOB_STATUS_RETRYING",
+		6: "JOB_STATUS_DEAD",
+		7: "JOB_STATUS_SCHEDULED",
+		8: "JOB_STATUS_CANCELLED",
+	}
+	JobStatus_value = map[string]int32{
+		"JOB_STATUS_UNSPECIFIED": 0,
+		"JOB_STATUS_QUEUED":      1,
+		"JOB_STATUS_PROCESSING":  2,
+		"JOB_STATUS_COMPLETED":   3,
+		"JOB_STATUS_FAILED":      4,
+		"JOB_STATUS_RETRYING":    5,
+		"JOB_STATUS_DEAD":        6,
+		"JOB_STATUS_SCHEDULED":   7,
+		"JOB_STATUS_CANCELLED":   8,
+	}
+)
+
+func (x JobStatus) Enum() *JobStatus {
+	p := new(JobStatus)
+	*p = x
+	return p
+}
+
+func (x JobStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_queue_proto_enumTypes[1].Descriptor()
+}
+
+func (JobStatus) Type() protoreflect.EnumType {
+	return &file_proto_queue_proto_enumTypes[1]
+}
+
+func (x JobStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobStatus.Descriptor instead.
+func (JobStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{1}
+}
+
+type CancelOutcome int32
+
+const (
+	CancelOutcome_CANCEL_OUTCOME_UNSPECIFIED CancelOutcome = 0
+	// The job had not started, or was already cancelled, and will not run
+	CancelOutcome_CANCEL_OUTCOME_CANCELLED CancelOutcome = 1
+	// The job is running; its worker has been asked to stop it
+	CancelOutcome_CANCEL_OUTCOME_REQUESTED CancelOutcome = 2
+	// The job had already finished
+	CancelOutcome_CANCEL_OUTCOME_TOO_LATE CancelOutcome = 3
+)
+
+// Enum value maps for CancelOutcome.
+var (
+	CancelOutcome_name = map[int32]string{
+		0: "CANCEL_OUTCOME_UNSPECIFIED",
+		1: "CANCEL_OUTCOME_CANCELLED",
+		2: "CANCEL_OUTCOME_REQUESTED",
+		3: "CANCEL_OUTCOME_TOO_LATE",
+	}
+	CancelOutcome_value = map[string]int32{
+		"CANCEL_OUTCOME_UNSPECIFIED": 0,
+		"CANCEL_OUTCOME_CANCELLED":   1,
+		"CANCEL_OUTCOME_REQUESTED":   2,
+		"CANCEL_OUTCOME_TOO_LATE":    3,
+	}
+)
+
+func (x CancelOutcome) Enum() *CancelOutcome {
+	p := new(CancelOutcome)
+	*p = x
+	return p
+}
+
+func (x CancelOutcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CancelOutcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_queue_proto_enumTypes[2].Descriptor()
+}
+
+func (CancelOutcome) Type() protoreflect.EnumType {
+	return &file_proto_queue_proto_enumTypes[2]
+}
+
+func (x CancelOutcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CancelOutcome.Descriptor instead.
+func (CancelOutcome) EnumDescriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{2}
+}
+
+type AttemptOutcome int32
+
+const (
+	AttemptOutcome_ATTEMPT_OUTCOME_UNSPECIFIED AttemptOutcome = 0
+	AttemptOutcome_ATTEMPT_OUTCOME_RUNNING     AttemptOutcome = 1
+	AttemptOutcome_ATTEMPT_OUTCOME_SUCCEEDED   AttemptOutcome = 2
+	AttemptOutcome_ATTEMPT_OUTCOME_FAILED      AttemptOutcome = 3
+	AttemptOutcome_ATTEMPT_OUTCOME_CANCELLED   AttemptOutcome = 4
+	// The worker shut down while running the job and handed it back
+	AttemptOutcome_ATTEMPT_OUTCOME_INTERRUPTED AttemptOutcome = 5
+	// The worker stopped sending heartbeats, or the job was claimed again
+	// without the worker reporting an outcome, so it was presumed dead
+	AttemptOutcome_ATTEMPT_OUTCOME_ABANDONED AttemptOutcome = 6
+)
+
+// Enum value maps for AttemptOutcome.
+var (
+	AttemptOutcome_name = map[int32]string{
+		0: "ATTEMPT_OUTCOME_UNSPECIFIED",
+		1: "ATTEMPT_OUTCOME_RUNNING",
+		2: "ATTEMPT_OUTCOME_SUCCEEDED",
+		3: "ATTEMPT_OUTCOME_FAILED",
+		4: "ATTEMPT_OUTCOME_CANCELLED",
+		5: "ATTEMPT_OUTCOME_INTERRUPTED",
+		6: "ATTEMPT_OUTCOME_ABANDONED",
+	}
+	AttemptOutcome_value = map[string]int32{
+		"ATTEMPT_OUTCOME_UNSPECIFIED": 0,
+		"ATTEMPT_OUTCOME_RUNNING":     1,
+		"ATTEMPT_OUTCOME_SUCCEEDED":   2,
+		"ATTEMPT_OUTCOME_FAILED":      3,
+		"ATTEMPT_OUTCOME_CANCELLED":   4,
+		"ATTEMPT_OUTCOME_INTERRUPTED": 5,
+		"ATTEMPT_OUTCOME_ABANDONED":   6,
+	}
+)
+
+func (x AttemptOutcome) Enum() *AttemptOutcome {
+	p := new(AttemptOutcome)
+	*p = x
+	return p
+}
+
+func (x AttemptOutcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AttemptOutcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_queue_proto_enumTypes[3].Descriptor()
+}
+
+func (AttemptOutcome) Type() protoreflect.EnumType {
+	return &file_proto_queue_proto_enumTypes[3]
+}
+
+func (x AttemptOutcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AttemptOutcome.Descriptor instead.
+func (AttemptOutcome) EnumDescriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{3}
+}
+
+type SortOrder int32
+
+const (
+	// Newest first
+	SortOrder_SORT_ORDER_UNSPECIFIED  SortOrder = 0
+	SortOrder_SORT_ORDER_NEWEST_FIRST SortOrder = 1
+	SortOrder_SORT_ORDER_OLDEST_FIRST SortOrder = 2
+)
+
+// Enum value maps for SortOrder.
+var (
+	SortOrder_name = map[int32]string{
+		0: "SORT_ORDER_UNSPECIFIED",
+		1: "SORT_ORDER_NEWEST_FIRST",
+		2: "SORT_ORDER_OLDEST_FIRST",
+	}
+	SortOrder_value = map[string]int32{
+		"SORT_ORDER_UNSPECIFIED":  0,
+		"SORT_ORDER_NEWEST_FIRST": 1,
+		"SORT_ORDER_OLDEST_FIRST": 2,
+	}
+)
+
+func (x SortOrder) Enum() *SortOrder {
+	p := new(SortOrder)
+	*p = x
+	return p
+}
+
+func (x SortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_queue_proto_enumTypes[4].Descriptor()
+}
+
+func (SortOrder) Type() protoreflect.EnumType {
+	return &file_proto_queue_proto_enumTypes[4]
+}
+
+func (x SortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortOrder.Descriptor instead.
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{4}
+}
+
+type JitterStrategy int32
+
+const (
+	JitterStrategy_JITTER_STRATEGY_UNSPECIFIED JitterStrategy = 0
+	// Wait exactly the exponential delay
+	JitterStrategy_JITTER_STRATEGY_NONE JitterStrategy = 1
+	// Wait a random delay between 0 and the exponential delay
+	JitterStrategy_JITTER_STRATEGY_FULL JitterStrategy = 2
+	// Wait half the exponential delay plus a random delay up to the other half
+	JitterStrategy_JITTER_STRATEGY_EQUAL JitterStrategy = 3
+)
+
+// Enum value maps for JitterStrategy.
+var (
+	JitterStrategy_name = map[int32]string{
+		0: "JITTER_STRATEGY_UNSPECIFIED",
+		1: "JITTER_STRATEGY_NONE",
+		2: "JITTER_STRATEGY_FULL",
+		3: "JITTER_STRATEGY_EQUAL",
+	}
+	JitterStrategy_value = map[string]int32{
+		"JITTER_STRATEGY_UNSPECIFIED": 0,
+		"JITTER_STRATEGY_NONE":        1,
+		"JITTER_STRATEGY_FULL":        2,
+		"JITTER_STRATEGY_EQUAL":       3,
+	}
+)
+
+func (x JitterStrategy) Enum() *JitterStrategy {
+	p := new(JitterStrategy)
+	*p = x
+	return p
+}
+
+func (x JitterStrategy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JitterStrategy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_queue_proto_enumTypes[5].Descriptor()
+}
+
+func (JitterStrategy) Type() protoreflect.EnumType {
+	return &file_proto_queue_proto_enumTypes[5]
+}
+
+func (x JitterStrategy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JitterStrategy.Descriptor instead.
+func (JitterStrategy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{5}
+}
+
+// RetryPolicy controls how a failed job is retried. Unset fields fall back
+// to the defaults for the job type.
+type RetryPolicy struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Total number of attempts, including the first; 1 disables retries
+	MaxAttempts   int32                `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	BaseDelay     *durationpb.Duration `protobuf:"bytes,2,opt,name=base_delay,json=baseDelay,proto3" json:"base_delay,omitempty"`
+	MaxDelay      *durationpb.Duration `protobuf:"bytes,3,opt,name=max_delay,json=maxDelay,proto3" json:"max_delay,omitempty"`
+	Jitter        JitterStrategy       `protobuf:"varint,4,opt,name=jitter,proto3,enum=queue.JitterStrategy" json:"jitter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryPolicy) Reset() {
+	*x = RetryPolicy{}
+	mi := &file_proto_queue_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryPolicy) ProtoMessage() {}
+
+func (x *RetryPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryPolicy.ProtoReflect.Descriptor instead.
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RetryPolicy) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetBaseDelay() *durationpb.Duration {
+	if x != nil {
+		return x.BaseDelay
+	}
+	return nil
+}
+
+func (x *RetryPolicy) GetMaxDelay() *durationpb.Duration {
+	if x != nil {
+		return x.MaxDelay
+	}
+	return nil
+}
+
+func (x *RetryPolicy) GetJitter() JitterStrategy {
+	if x != nil {
+		return x.Jitter
+	}
+	return JitterStrategy_JITTER_STRATEGY_UNSPECIFIED
+}
+
 type EnqueueJobRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          JobType                `protobuf:"varint,1,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
-	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Type        JobType                `protobuf:"varint,1,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	Payload     []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	RetryPolicy *RetryPolicy           `protobuf:"bytes,3,opt,name=retry_policy,json=retryPolicy,proto3" json:"retry_policy,omitempty"`
+	// Run at this time instead of straight away; mutually exclusive with delay
+	RunAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=run_at,json=runAt,proto3" json:"run_at,omitempty"`
+	// Run after this delay instead of straight away; mutually exclusive with run_at
+	Delay *durationpb.Duration `protobuf:"bytes,5,opt,name=delay,proto3" json:"delay,omitempty"`
+	// Higher runs sooner, from -100 to 100. Above zero is queued as high
+	// priority, below zero as low; the default 0 is normal.
+	Priority int32 `protobuf:"varint,6,opt,name=priority,proto3" json:"priority,omitempty"`
+	// Deduplicates retried requests: while the job created with this key is
+	// within the retention window, a request with the same key, type, payload,
+	// priority, tags and retry policy returns that job's ID, and one where any
+	// of them differ fails with ALREADY_EXISTS. The schedule is not compared.
+	// At most 255 bytes.
+	IdempotencyKey string `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// Free-form labels to find the job by with ListJobs. At most 16, with keys
+	// up to 64 bytes and values up to 256, and 4000 bytes of JSON in all.
+	Tags          map[string]string `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *EnqueueJobRequest) Reset() {
 	*x = EnqueueJobRequest{}
-	mi := &file_proto_queue_proto_msgTypes[0]
+	mi := &file_proto_queue_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -89,7 +478,7 @@ func (x *EnqueueJobRequest) String() string {
 func (*EnqueueJobRequest) ProtoMessage() {}
 
 func (x *EnqueueJobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_queue_proto_msgTypes[0]
+	mi := &file_proto_queue_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -102,7 +491,7 @@ func (x *EnqueueJobRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EnqueueJobRequest.ProtoReflect.Descriptor instead.
 func (*EnqueueJobRequest) Descriptor() ([]byte, []int) {
-	return file_proto_queue_proto_rawDescGZIP(), []int{0}
+	return file_proto_queue_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *EnqueueJobRequest) GetType() JobType {
@@ -119,6 +508,48 @@ func (x *EnqueueJobRequest) GetPayload() []byte {
 	return nil
 }
 
+func (x *EnqueueJobRequest) GetRetryPolicy() *RetryPolicy {
+	if x != nil {
+		return x.RetryPolicy
+	}
+	return nil
+}
+
+func (x *EnqueueJobRequest) GetRunAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RunAt
+	}
+	return nil
+}
+
+func (x *EnqueueJobRequest) GetDelay() *durationpb.Duration {
+	if x != nil {
+		return x.Delay
+	}
+	return nil
+}
+
+func (x *EnqueueJobRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *EnqueueJobRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *EnqueueJobRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 type EnqueueJobResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
@@ -128,7 +559,7 @@ type EnqueueJobResponse struct {
 
 func (x *EnqueueJobResponse) Reset() {
 	*x = EnqueueJobResponse{}
-	mi := &file_proto_queue_proto_msgTypes[1]
+	mi := &file_proto_queue_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -140,7 +571,7 @@ func (x *EnqueueJobResponse) String() string {
 func (*EnqueueJobResponse) ProtoMessage() {}
 
 func (x *EnqueueJobResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_queue_proto_msgTypes[1]
+	mi := &file_proto_queue_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -153,7 +584,7 @@ func (x *EnqueueJobResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EnqueueJobResponse.ProtoReflect.Descriptor instead.
 func (*EnqueueJobResponse) Descriptor() ([]byte, []int) {
-	return file_proto_queue_proto_rawDescGZIP(), []int{1}
+	return file_proto_queue_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *EnqueueJobResponse) GetJobId() string {
@@ -163,28 +594,32 @@ func (x *EnqueueJobResponse) GetJobId() string {
 	return ""
 }
 
-type GetJobStatusRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+type EnqueueJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At most 1000
+	Jobs []*EnqueueJobRequest `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// Enqueue all of the jobs or none of them. Without it, valid jobs are
+	// enqueued even if others in the batch are rejected.
+	Atomic        bool `protobuf:"varint,2,opt,name=atomic,proto3" json:"atomic,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetJobStatusRequest) Reset() {
-	*x = GetJobStatusRequest{}
-	mi := &file_proto_queue_proto_msgTypes[2]
+func (x *EnqueueJobsRequest) Reset() {
+	*x = EnqueueJobsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetJobStatusRequest) String() string {
+func (x *EnqueueJobsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetJobStatusRequest) ProtoMessage() {}
+func (*EnqueueJobsRequest) ProtoMessage() {}
 
-func (x *GetJobStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_queue_proto_msgTypes[2]
+func (x *EnqueueJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -195,41 +630,51 @@ func (x *GetJobStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetJobStatusRequest.ProtoReflect.Descriptor instead.
-func (*GetJobStatusRequest) Descriptor() ([]byte, []int) {
-	return file_proto_queue_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use EnqueueJobsRequest.ProtoReflect.Descriptor instead.
+func (*EnqueueJobsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *GetJobStatusRequest) GetJobId() string {
+func (x *EnqueueJobsRequest) GetJobs() []*EnqueueJobRequest {
 	if x != nil {
-		return x.JobId
+		return x.Jobs
 	}
-	return ""
+	return nil
 }
 
-type GetJobStatusResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+func (x *EnqueueJobsRequest) GetAtomic() bool {
+	if x != nil {
+		return x.Atomic
+	}
+	return false
+}
+
+type EnqueueJobResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Set when the job was enqueued, or its idempotency key matched an earlier job
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// A google.rpc.Code; 0 (OK) when job_id is set
+	Code          int32  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetJobStatusResponse) Reset() {
-	*x = GetJobStatusResponse{}
-	mi := &file_proto_queue_proto_msgTypes[3]
+func (x *EnqueueJobResult) Reset() {
+	*x = EnqueueJobResult{}
+	mi := &file_proto_queue_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetJobStatusResponse) String() string {
+func (x *EnqueueJobResult) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetJobStatusResponse) ProtoMessage() {}
+func (*EnqueueJobResult) ProtoMessage() {}
 
-func (x *GetJobStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_queue_proto_msgTypes[3]
+func (x *EnqueueJobResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -240,47 +685,3174 @@ func (x *GetJobStatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetJobStatusResponse.ProtoReflect.Descriptor instead.
-func (*GetJobStatusResponse) Descriptor() ([]byte, []int) {
-	return file_proto_queue_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use EnqueueJobResult.ProtoReflect.Descriptor instead.
+func (*EnqueueJobResult) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *GetJobStatusResponse) GetJobId() string {
+func (x *EnqueueJobResult) GetJobId() string {
 	if x != nil {
 		return x.JobId
 	}
 	return ""
 }
 
-func (x *GetJobStatusResponse) GetStatus() string {
+func (x *EnqueueJobResult) GetCode() int32 {
 	if x != nil {
-		return x.Status
+		return x.Code
 	}
-	return ""
+	return 0
 }
 
-var File_proto_queue_proto protoreflect.FileDescriptor
+func (x *EnqueueJobResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type EnqueueJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One per requested job, in request order
+	Results       []*EnqueueJobResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueJobsResponse) Reset() {
+	*x = EnqueueJobsResponse{}
+	mi := &file_proto_queue_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueJobsResponse) ProtoMessage() {}
+
+func (x *EnqueueJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueJobsResponse.ProtoReflect.Descriptor instead.
+func (*EnqueueJobsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *EnqueueJobsResponse) GetResults() []*EnqueueJobResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type EnqueueStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chosen by the client and echoed in the job's acknowledgement
+	Sequence      uint64             `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Job           *EnqueueJobRequest `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueStreamRequest) Reset() {
+	*x = EnqueueStreamRequest{}
+	mi := &file_proto_queue_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueStreamRequest) ProtoMessage() {}
+
+func (x *EnqueueStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueStreamRequest.ProtoReflect.Descriptor instead.
+func (*EnqueueStreamRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EnqueueStreamRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *EnqueueStreamRequest) GetJob() *EnqueueJobRequest {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type EnqueueStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sequence      uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Result        *EnqueueJobResult      `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueStreamResponse) Reset() {
+	*x = EnqueueStreamResponse{}
+	mi := &file_proto_queue_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueStreamResponse) ProtoMessage() {}
+
+func (x *EnqueueStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueStreamResponse.ProtoReflect.Descriptor instead.
+func (*EnqueueStreamResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EnqueueStreamResponse) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *EnqueueStreamResponse) GetResult() *EnqueueJobResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type GetJobStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobStatusRequest) Reset() {
+	*x = GetJobStatusRequest{}
+	mi := &file_proto_queue_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobStatusRequest) ProtoMessage() {}
+
+func (x *GetJobStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetJobStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetJobStatusRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type GetJobStatusResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	JobId       string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Type        JobType                `protobuf:"varint,3,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	CompletedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	// Counts every attempt, so it may exceed max_attempts once a dead-lettered
+	// job is replayed
+	Attempts    int32  `protobuf:"varint,7,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	MaxAttempts int32  `protobuf:"varint,8,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	LastError   string `protobuf:"bytes,9,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	// When a scheduled or retrying job is due to run
+	NextRunAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=next_run_at,json=nextRunAt,proto3" json:"next_run_at,omitempty"`
+	Priority  int32                  `protobuf:"varint,11,opt,name=priority,proto3" json:"priority,omitempty"`
+	// When cancellation was requested while the job was processing
+	CancelRequestedAt *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=cancel_requested_at,json=cancelRequestedAt,proto3" json:"cancel_requested_at,omitempty"`
+	Tags              map[string]string      `protobuf:"bytes,13,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Last sign of life from the worker running the job
+	LastHeartbeatAt *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=last_heartbeat_at,json=lastHeartbeatAt,proto3" json:"last_heartbeat_at,omitempty"`
+	// Time since last_heartbeat_at, set only while the job is processing
+	HeartbeatAge *durationpb.Duration `protobuf:"bytes,15,opt,name=heartbeat_age,json=heartbeatAge,proto3" json:"heartbeat_age,omitempty"`
+	Progress     *JobProgress         `protobuf:"bytes,16,opt,name=progress,proto3" json:"progress,omitempty"`
+	// Saved by the job's handler; handed back to it if the job is retried
+	Checkpoint []byte `protobuf:"bytes,17,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	// Output of the job once completed
+	Result []byte `protobuf:"bytes,18,opt,name=result,proto3" json:"result,omitempty"`
+	// Why the last attempt failed, if one did
+	Error         *JobError `protobuf:"bytes,19,opt,name=error,proto3" json:"error,omitempty"`
+	JobStatus     JobStatus `protobuf:"varint,20,opt,name=job_status,json=jobStatus,proto3,enum=queue.JobStatus" json:"job_status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobStatusResponse) Reset() {
+	*x = GetJobStatusResponse{}
+	mi := &file_proto_queue_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobStatusResponse) ProtoMessage() {}
+
+func (x *GetJobStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetJobStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetJobStatusResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *GetJobStatusResponse) GetType() JobType {
+	if x != nil {
+		return x.Type
+	}
+	return JobType_JOB_TYPE_UNSPECIFIED
+}
+
+func (x *GetJobStatusResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *GetJobStatusResponse) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *GetJobStatusResponse) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *GetJobStatusResponse) GetNextRunAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextRunAt
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *GetJobStatusResponse) GetCancelRequestedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CancelRequestedAt
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetLastHeartbeatAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastHeartbeatAt
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetHeartbeatAge() *durationpb.Duration {
+	if x != nil {
+		return x.HeartbeatAge
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetProgress() *JobProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetCheckpoint() []byte {
+	if x != nil {
+		return x.Checkpoint
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetResult() []byte {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetError() *JobError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *GetJobStatusResponse) GetJobStatus() JobStatus {
+	if x != nil {
+		return x.JobStatus
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+// JobError describes why an attempt of a job failed.
+type JobError struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// Short machine-readable reason, e.g. "http_503" or "panic"
+	Code string `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	// Longer diagnostics such as a stack trace
+	Details       string `protobuf:"bytes,3,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobError) Reset() {
+	*x = JobError{}
+	mi := &file_proto_queue_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobError) ProtoMessage() {}
+
+func (x *JobError) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobError.ProtoReflect.Descriptor instead.
+func (*JobError) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *JobError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *JobError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *JobError) GetDetails() string {
+	if x != nil {
+		return x.Details
+	}
+	return ""
+}
+
+type GetJobResultRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	JobId string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// How long to wait for an unfinished job to finish, at most 30s. Without
+	// it the job's current state is returned straight away
+	Wait          *durationpb.Duration `protobuf:"bytes,2,opt,name=wait,proto3" json:"wait,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobResultRequest) Reset() {
+	*x = GetJobResultRequest{}
+	mi := &file_proto_queue_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobResultRequest) ProtoMessage() {}
+
+func (x *GetJobResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobResultRequest.ProtoReflect.Descriptor instead.
+func (*GetJobResultRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetJobResultRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *GetJobResultRequest) GetWait() *durationpb.Duration {
+	if x != nil {
+		return x.Wait
+	}
+	return nil
+}
+
+type GetJobResultResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	JobId string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	// Not a terminal status if the job had not finished by the end of the wait
+	Status JobStatus `protobuf:"varint,2,opt,name=status,proto3,enum=queue.JobStatus" json:"status,omitempty"`
+	// Output of the job once completed
+	Result []byte `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	// Why the last attempt failed, if one did
+	Error         *JobError              `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	CompletedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobResultResponse) Reset() {
+	*x = GetJobResultResponse{}
+	mi := &file_proto_queue_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobResultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobResultResponse) ProtoMessage() {}
+
+func (x *GetJobResultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobResultResponse.ProtoReflect.Descriptor instead.
+func (*GetJobResultResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetJobResultResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *GetJobResultResponse) GetStatus() JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+func (x *GetJobResultResponse) GetResult() []byte {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *GetJobResultResponse) GetError() *JobError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *GetJobResultResponse) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+type ListJobAttemptsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobAttemptsRequest) Reset() {
+	*x = ListJobAttemptsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobAttemptsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobAttemptsRequest) ProtoMessage() {}
+
+func (x *ListJobAttemptsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobAttemptsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobAttemptsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListJobAttemptsRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type ListJobAttemptsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Oldest first
+	Attempts      []*JobAttempt `protobuf:"bytes,1,rep,name=attempts,proto3" json:"attempts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobAttemptsResponse) Reset() {
+	*x = ListJobAttemptsResponse{}
+	mi := &file_proto_queue_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobAttemptsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobAttemptsResponse) ProtoMessage() {}
+
+func (x *ListJobAttemptsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobAttemptsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobAttemptsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListJobAttemptsResponse) GetAttempts() []*JobAttempt {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
+// JobAttempt is one execution of a job.
+type JobAttempt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keeps increasing when a dead-lettered job is replayed
+	Attempt   int32                  `protobuf:"varint,1,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	WorkerId  string                 `protobuf:"bytes,2,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	Outcome   AttemptOutcome         `protobuf:"varint,3,opt,name=outcome,proto3,enum=queue.AttemptOutcome" json:"outcome,omitempty"`
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// Unset while the attempt is running
+	FinishedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	// From started_at to finished_at
+	Latency *durationpb.Duration `protobuf:"bytes,6,opt,name=latency,proto3" json:"latency,omitempty"`
+	// Why the attempt failed or was abandoned
+	Error *JobError `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	// HTTP status of the response a webhook job got, 0 if it got none
+	ResponseStatus int32 `protobuf:"varint,8,opt,name=response_status,json=responseStatus,proto3" json:"response_status,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *JobAttempt) Reset() {
+	*x = JobAttempt{}
+	mi := &file_proto_queue_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobAttempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobAttempt) ProtoMessage() {}
+
+func (x *JobAttempt) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobAttempt.ProtoReflect.Descriptor instead.
+func (*JobAttempt) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *JobAttempt) GetAttempt() int32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *JobAttempt) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *JobAttempt) GetOutcome() AttemptOutcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return AttemptOutcome_ATTEMPT_OUTCOME_UNSPECIFIED
+}
+
+func (x *JobAttempt) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *JobAttempt) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *JobAttempt) GetLatency() *durationpb.Duration {
+	if x != nil {
+		return x.Latency
+	}
+	return nil
+}
+
+func (x *JobAttempt) GetError() *JobError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *JobAttempt) GetResponseStatus() int32 {
+	if x != nil {
+		return x.ResponseStatus
+	}
+	return 0
+}
+
+// JobProgress is what the worker running a job last reported about it.
+type JobProgress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// From 0 to 100
+	Percent       int32                  `protobuf:"varint,1,opt,name=percent,proto3" json:"percent,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobProgress) Reset() {
+	*x = JobProgress{}
+	mi := &file_proto_queue_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobProgress) ProtoMessage() {}
+
+func (x *JobProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobProgress.ProtoReflect.Descriptor instead.
+func (*JobProgress) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *JobProgress) GetPercent() int32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *JobProgress) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *JobProgress) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CancelJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelJobRequest) Reset() {
+	*x = CancelJobRequest{}
+	mi := &file_proto_queue_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobRequest) ProtoMessage() {}
+
+func (x *CancelJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobRequest.ProtoReflect.Descriptor instead.
+func (*CancelJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CancelJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type CancelJobResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Outcome CancelOutcome          `protobuf:"varint,1,opt,name=outcome,proto3,enum=queue.CancelOutcome" json:"outcome,omitempty"`
+	// The job's status after the call
+	Status        JobStatus `protobuf:"varint,2,opt,name=status,proto3,enum=queue.JobStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelJobResponse) Reset() {
+	*x = CancelJobResponse{}
+	mi := &file_proto_queue_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobResponse) ProtoMessage() {}
+
+func (x *CancelJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobResponse.ProtoReflect.Descriptor instead.
+func (*CancelJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CancelJobResponse) GetOutcome() CancelOutcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return CancelOutcome_CANCEL_OUTCOME_UNSPECIFIED
+}
+
+func (x *CancelJobResponse) GetStatus() JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+type WatchJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobRequest) Reset() {
+	*x = WatchJobRequest{}
+	mi := &file_proto_queue_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobRequest) ProtoMessage() {}
+
+func (x *WatchJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *WatchJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type WatchJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unset matches every type
+	Type JobType `protobuf:"varint,1,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	// Jobs carrying all of these tags
+	Tags          map[string]string `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobsRequest) Reset() {
+	*x = WatchJobsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobsRequest) ProtoMessage() {}
+
+func (x *WatchJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobsRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchJobsRequest) GetType() JobType {
+	if x != nil {
+		return x.Type
+	}
+	return JobType_JOB_TYPE_UNSPECIFIED
+}
+
+func (x *WatchJobsRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type JobEvent struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	JobId  string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Type   JobType                `protobuf:"varint,2,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	Status JobStatus              `protobuf:"varint,3,opt,name=status,proto3,enum=queue.JobStatus" json:"status,omitempty"`
+	// When the status changed; unset on the first WatchJob event, which
+	// reports the status the job had when the watch started
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	// Set on events reporting progress, which leave the status unchanged, and
+	// on the first WatchJob event if the job has reported progress
+	Progress      *JobProgress `protobuf:"bytes,5,opt,name=progress,proto3" json:"progress,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobEvent) Reset() {
+	*x = JobEvent{}
+	mi := &file_proto_queue_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobEvent) ProtoMessage() {}
+
+func (x *JobEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobEvent.ProtoReflect.Descriptor instead.
+func (*JobEvent) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *JobEvent) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobEvent) GetType() JobType {
+	if x != nil {
+		return x.Type
+	}
+	return JobType_JOB_TYPE_UNSPECIFIED
+}
+
+func (x *JobEvent) GetStatus() JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+func (x *JobEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *JobEvent) GetProgress() *JobProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+// JobFilter selects jobs. Unset fields match every job.
+type JobFilter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  JobType                `protobuf:"varint,1,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	// Any of these statuses
+	Statuses []JobStatus `protobuf:"varint,2,rep,packed,name=statuses,proto3,enum=queue.JobStatus" json:"statuses,omitempty"`
+	// Created at or after
+	CreatedAfter *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_after,json=createdAfter,proto3" json:"created_after,omitempty"`
+	// Created before
+	CreatedBefore *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_before,json=createdBefore,proto3" json:"created_before,omitempty"`
+	// Jobs carrying all of these tags
+	Tags          map[string]string `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobFilter) Reset() {
+	*x = JobFilter{}
+	mi := &file_proto_queue_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobFilter) ProtoMessage() {}
+
+func (x *JobFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobFilter.ProtoReflect.Descriptor instead.
+func (*JobFilter) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *JobFilter) GetType() JobType {
+	if x != nil {
+		return x.Type
+	}
+	return JobType_JOB_TYPE_UNSPECIFIED
+}
+
+func (x *JobFilter) GetStatuses() []JobStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *JobFilter) GetCreatedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAfter
+	}
+	return nil
+}
+
+func (x *JobFilter) GetCreatedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return nil
+}
+
+func (x *JobFilter) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type Job struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	JobId  string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Type   JobType                `protobuf:"varint,2,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	Status JobStatus              `protobuf:"varint,3,opt,name=status,proto3,enum=queue.JobStatus" json:"status,omitempty"`
+	// Only set when requested with include_payload
+	Payload     []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	Priority    int32                  `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	Tags        map[string]string      `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartedAt   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	CompletedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	// Counts every attempt, so it may exceed max_attempts once a dead-lettered
+	// job is replayed
+	Attempts    int32                  `protobuf:"varint,10,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	MaxAttempts int32                  `protobuf:"varint,11,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	LastError   string                 `protobuf:"bytes,12,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	NextRunAt   *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=next_run_at,json=nextRunAt,proto3" json:"next_run_at,omitempty"`
+	Progress    *JobProgress           `protobuf:"bytes,14,opt,name=progress,proto3" json:"progress,omitempty"`
+	// Only set when requested with include_payload, and on leased jobs
+	Checkpoint    []byte `protobuf:"bytes,15,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_proto_queue_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Job) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *Job) GetType() JobType {
+	if x != nil {
+		return x.Type
+	}
+	return JobType_JOB_TYPE_UNSPECIFIED
+}
+
+func (x *Job) GetStatus() JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+func (x *Job) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Job) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Job) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Job) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Job) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Job) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *Job) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *Job) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *Job) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *Job) GetNextRunAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextRunAt
+	}
+	return nil
+}
+
+func (x *Job) GetProgress() *JobProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *Job) GetCheckpoint() []byte {
+	if x != nil {
+		return x.Checkpoint
+	}
+	return nil
+}
+
+type ListJobsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *JobFilter             `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Order  SortOrder              `protobuf:"varint,2,opt,name=order,proto3,enum=queue.SortOrder" json:"order,omitempty"`
+	// Defaults to 50, at most 500
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// From a previous response with the same filter and order
+	PageToken      string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	IncludePayload bool   `protobuf:"varint,5,opt,name=include_payload,json=includePayload,proto3" json:"include_payload,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListJobsRequest) GetFilter() *JobFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListJobsRequest) GetOrder() SortOrder {
+	if x != nil {
+		return x.Order
+	}
+	return SortOrder_SORT_ORDER_UNSPECIFIED
+}
+
+func (x *ListJobsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListJobsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetIncludePayload() bool {
+	if x != nil {
+		return x.IncludePayload
+	}
+	return false
+}
+
+type ListJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Ordered by created_at, then job ID
+	Jobs []*Job `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// Empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_proto_queue_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsResponse) ProtoMessage() {}
+
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListJobsResponse) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *ListJobsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// DeadLetterFilter selects dead-lettered jobs. Unset fields match every job.
+type DeadLetterFilter struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Type   JobType                `protobuf:"varint,1,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	JobIds []string               `protobuf:"bytes,2,rep,name=job_ids,json=jobIds,proto3" json:"job_ids,omitempty"`
+	// Dead-lettered at or after
+	DeadAfter *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=dead_after,json=deadAfter,proto3" json:"dead_after,omitempty"`
+	// Dead-lettered before
+	DeadBefore *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=dead_before,json=deadBefore,proto3" json:"dead_before,omitempty"`
+	// Substring of the job's last error
+	ErrorContains string `protobuf:"bytes,5,opt,name=error_contains,json=errorContains,proto3" json:"error_contains,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterFilter) Reset() {
+	*x = DeadLetterFilter{}
+	mi := &file_proto_queue_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterFilter) ProtoMessage() {}
+
+func (x *DeadLetterFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterFilter.ProtoReflect.Descriptor instead.
+func (*DeadLetterFilter) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DeadLetterFilter) GetType() JobType {
+	if x != nil {
+		return x.Type
+	}
+	return JobType_JOB_TYPE_UNSPECIFIED
+}
+
+func (x *DeadLetterFilter) GetJobIds() []string {
+	if x != nil {
+		return x.JobIds
+	}
+	return nil
+}
+
+func (x *DeadLetterFilter) GetDeadAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeadAfter
+	}
+	return nil
+}
+
+func (x *DeadLetterFilter) GetDeadBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeadBefore
+	}
+	return nil
+}
+
+func (x *DeadLetterFilter) GetErrorContains() string {
+	if x != nil {
+		return x.ErrorContains
+	}
+	return ""
+}
+
+type DeadLetterJob struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Type          JobType                `protobuf:"varint,2,opt,name=type,proto3,enum=queue.JobType" json:"type,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Attempts      int32                  `protobuf:"varint,4,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	MaxAttempts   int32                  `protobuf:"varint,5,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	LastError     string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	DeadAt        *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=dead_at,json=deadAt,proto3" json:"dead_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterJob) Reset() {
+	*x = DeadLetterJob{}
+	mi := &file_proto_queue_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterJob) ProtoMessage() {}
+
+func (x *DeadLetterJob) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterJob.ProtoReflect.Descriptor instead.
+func (*DeadLetterJob) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *DeadLetterJob) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *DeadLetterJob) GetType() JobType {
+	if x != nil {
+		return x.Type
+	}
+	return JobType_JOB_TYPE_UNSPECIFIED
+}
+
+func (x *DeadLetterJob) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *DeadLetterJob) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DeadLetterJob) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *DeadLetterJob) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *DeadLetterJob) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *DeadLetterJob) GetDeadAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeadAt
+	}
+	return nil
+}
+
+type JobFailure struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attempt       int32                  `protobuf:"varint,1,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	FailedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=failed_at,json=failedAt,proto3" json:"failed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobFailure) Reset() {
+	*x = JobFailure{}
+	mi := &file_proto_queue_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobFailure) ProtoMessage() {}
+
+func (x *JobFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobFailure.ProtoReflect.Descriptor instead.
+func (*JobFailure) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *JobFailure) GetAttempt() int32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *JobFailure) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *JobFailure) GetFailedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FailedAt
+	}
+	return nil
+}
+
+type ListDeadLetterJobsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *DeadLetterFilter      `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Defaults to 50, at most 500
+	PageSize      int32  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLetterJobsRequest) Reset() {
+	*x = ListDeadLetterJobsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLetterJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLetterJobsRequest) ProtoMessage() {}
+
+func (x *ListDeadLetterJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLetterJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListDeadLetterJobsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListDeadLetterJobsRequest) GetFilter() *DeadLetterFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListDeadLetterJobsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListDeadLetterJobsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListDeadLetterJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Most recently dead-lettered first
+	Jobs []*DeadLetterJob `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// Empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLetterJobsResponse) Reset() {
+	*x = ListDeadLetterJobsResponse{}
+	mi := &file_proto_queue_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLetterJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLetterJobsResponse) ProtoMessage() {}
+
+func (x *ListDeadLetterJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLetterJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListDeadLetterJobsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListDeadLetterJobsResponse) GetJobs() []*DeadLetterJob {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *ListDeadLetterJobsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetDeadLetterJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeadLetterJobRequest) Reset() {
+	*x = GetDeadLetterJobRequest{}
+	mi := &file_proto_queue_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeadLetterJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeadLetterJobRequest) ProtoMessage() {}
+
+func (x *GetDeadLetterJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeadLetterJobRequest.ProtoReflect.Descriptor instead.
+func (*GetDeadLetterJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetDeadLetterJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type GetDeadLetterJobResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Job   *DeadLetterJob         `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	// Every failed attempt, oldest first
+	Failures      []*JobFailure `protobuf:"bytes,2,rep,name=failures,proto3" json:"failures,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeadLetterJobResponse) Reset() {
+	*x = GetDeadLetterJobResponse{}
+	mi := &file_proto_queue_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeadLetterJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeadLetterJobResponse) ProtoMessage() {}
+
+func (x *GetDeadLetterJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeadLetterJobResponse.ProtoReflect.Descriptor instead.
+func (*GetDeadLetterJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetDeadLetterJobResponse) GetJob() *DeadLetterJob {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *GetDeadLetterJobResponse) GetFailures() []*JobFailure {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+type ReplayDeadLetterJobsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *DeadLetterFilter      `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Must be set to act on every dead job when the filter is empty
+	All bool `protobuf:"varint,2,opt,name=all,proto3" json:"all,omitempty"`
+	// Maximum number of jobs to replay; defaults to 100, at most 1000
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLetterJobsRequest) Reset() {
+	*x = ReplayDeadLetterJobsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLetterJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLetterJobsRequest) ProtoMessage() {}
+
+func (x *ReplayDeadLetterJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLetterJobsRequest.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLetterJobsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ReplayDeadLetterJobsRequest) GetFilter() *DeadLetterFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ReplayDeadLetterJobsRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+func (x *ReplayDeadLetterJobsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ReplayDeadLetterJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Replayed jobs are queued again with a fresh attempt budget of
+	// max_attempts; their attempt count carries on from where it was
+	JobIds        []string `protobuf:"bytes,1,rep,name=job_ids,json=jobIds,proto3" json:"job_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayDeadLetterJobsResponse) Reset() {
+	*x = ReplayDeadLetterJobsResponse{}
+	mi := &file_proto_queue_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayDeadLetterJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayDeadLetterJobsResponse) ProtoMessage() {}
+
+func (x *ReplayDeadLetterJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayDeadLetterJobsResponse.ProtoReflect.Descriptor instead.
+func (*ReplayDeadLetterJobsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ReplayDeadLetterJobsResponse) GetJobIds() []string {
+	if x != nil {
+		return x.JobIds
+	}
+	return nil
+}
+
+type PurgeDeadLetterJobsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *DeadLetterFilter      `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Must be set to act on every dead job when the filter is empty
+	All bool `protobuf:"varint,2,opt,name=all,proto3" json:"all,omitempty"`
+	// Maximum number of jobs to purge; defaults to 100, at most 1000
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeDeadLetterJobsRequest) Reset() {
+	*x = PurgeDeadLetterJobsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeDeadLetterJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeDeadLetterJobsRequest) ProtoMessage() {}
+
+func (x *PurgeDeadLetterJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeDeadLetterJobsRequest.ProtoReflect.Descriptor instead.
+func (*PurgeDeadLetterJobsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *PurgeDeadLetterJobsRequest) GetFilter() *DeadLetterFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *PurgeDeadLetterJobsRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+func (x *PurgeDeadLetterJobsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type PurgeDeadLetterJobsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobIds        []string               `protobuf:"bytes,1,rep,name=job_ids,json=jobIds,proto3" json:"job_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeDeadLetterJobsResponse) Reset() {
+	*x = PurgeDeadLetterJobsResponse{}
+	mi := &file_proto_queue_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeDeadLetterJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeDeadLetterJobsResponse) ProtoMessage() {}
+
+func (x *PurgeDeadLetterJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeDeadLetterJobsResponse.ProtoReflect.Descriptor instead.
+func (*PurgeDeadLetterJobsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *PurgeDeadLetterJobsResponse) GetJobIds() []string {
+	if x != nil {
+		return x.JobIds
+	}
+	return nil
+}
+
+type FetchJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The types of job the worker runs; at least one
+	Types []JobType `protobuf:"varint,1,rep,packed,name=types,proto3,enum=queue.JobType" json:"types,omitempty"`
+	// Defaults to 1, at most 100
+	MaxJobs int32 `protobuf:"varint,2,opt,name=max_jobs,json=maxJobs,proto3" json:"max_jobs,omitempty"`
+	// How long to wait for a job if none is ready, at most 30s. Without it the
+	// call returns straight away.
+	Wait *durationpb.Duration `protobuf:"bytes,3,opt,name=wait,proto3" json:"wait,omitempty"`
+	// How long the jobs are leased for; defaults to 5m, at most 1h
+	LeaseDuration *durationpb.Duration `protobuf:"bytes,4,opt,name=lease_duration,json=leaseDuration,proto3" json:"lease_duration,omitempty"`
+	// Identifies the worker in the attempt history of the jobs it runs, at most
+	// 255 bytes. Defaults to the worker's address
+	WorkerId      string `protobuf:"bytes,5,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchJobsRequest) Reset() {
+	*x = FetchJobsRequest{}
+	mi := &file_proto_queue_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchJobsRequest) ProtoMessage() {}
+
+func (x *FetchJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchJobsRequest.ProtoReflect.Descriptor instead.
+func (*FetchJobsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *FetchJobsRequest) GetTypes() []JobType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *FetchJobsRequest) GetMaxJobs() int32 {
+	if x != nil {
+		return x.MaxJobs
+	}
+	return 0
+}
+
+func (x *FetchJobsRequest) GetWait() *durationpb.Duration {
+	if x != nil {
+		return x.Wait
+	}
+	return nil
+}
+
+func (x *FetchJobsRequest) GetLeaseDuration() *durationpb.Duration {
+	if x != nil {
+		return x.LeaseDuration
+	}
+	return nil
+}
+
+func (x *FetchJobsRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+type LeasedJob struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Includes the payload; attempts counts this attempt
+	Job *Job `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	// Passed to AckJob, NackJob and ExtendLease
+	LeaseToken string `protobuf:"bytes,2,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	// Unless extended, the job is handed to another worker after this time
+	LeaseExpiresAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=lease_expires_at,json=leaseExpiresAt,proto3" json:"lease_expires_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LeasedJob) Reset() {
+	*x = LeasedJob{}
+	mi := &file_proto_queue_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeasedJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeasedJob) ProtoMessage() {}
+
+func (x *LeasedJob) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeasedJob.ProtoReflect.Descriptor instead.
+func (*LeasedJob) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *LeasedJob) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *LeasedJob) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+func (x *LeasedJob) GetLeaseExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LeaseExpiresAt
+	}
+	return nil
+}
+
+type FetchJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty if no job was ready within the wait
+	Jobs          []*LeasedJob `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchJobsResponse) Reset() {
+	*x = FetchJobsResponse{}
+	mi := &file_proto_queue_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchJobsResponse) ProtoMessage() {}
+
+func (x *FetchJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchJobsResponse.ProtoReflect.Descriptor instead.
+func (*FetchJobsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *FetchJobsResponse) GetJobs() []*LeasedJob {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type AckJobRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	LeaseToken string                 `protobuf:"bytes,1,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	// Set if the worker stopped the job because ExtendLease reported a
+	// cancellation request, instead of finishing it
+	Cancelled bool `protobuf:"varint,2,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	// Output of the job, at most 1 MB; ignored if cancelled
+	Result        []byte `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckJobRequest) Reset() {
+	*x = AckJobRequest{}
+	mi := &file_proto_queue_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckJobRequest) ProtoMessage() {}
+
+func (x *AckJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckJobRequest.ProtoReflect.Descriptor instead.
+func (*AckJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *AckJobRequest) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+func (x *AckJobRequest) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+func (x *AckJobRequest) GetResult() []byte {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+type AckJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        JobStatus              `protobuf:"varint,1,opt,name=status,proto3,enum=queue.JobStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckJobResponse) Reset() {
+	*x = AckJobResponse{}
+	mi := &file_proto_queue_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckJobResponse) ProtoMessage() {}
+
+func (x *AckJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckJobResponse.ProtoReflect.Descriptor instead.
+func (*AckJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *AckJobResponse) GetStatus() JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+type NackJobRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	LeaseToken string                 `protobuf:"bytes,1,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	// Why the attempt failed, recorded in the job's failure history
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// Overrides the job's retry backoff for this retry
+	RetryDelay *durationpb.Duration `protobuf:"bytes,3,opt,name=retry_delay,json=retryDelay,proto3" json:"retry_delay,omitempty"`
+	// Fail the job without retrying it
+	Permanent bool `protobuf:"varint,4,opt,name=permanent,proto3" json:"permanent,omitempty"`
+	// Short machine-readable reason, e.g. "http_503"
+	ErrorCode string `protobuf:"bytes,5,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	// Longer diagnostics such as a stack trace, truncated to 16 KB
+	ErrorDetails  string `protobuf:"bytes,6,opt,name=error_details,json=errorDetails,proto3" json:"error_details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NackJobRequest) Reset() {
+	*x = NackJobRequest{}
+	mi := &file_proto_queue_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NackJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NackJobRequest) ProtoMessage() {}
+
+func (x *NackJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NackJobRequest.ProtoReflect.Descriptor instead.
+func (*NackJobRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *NackJobRequest) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+func (x *NackJobRequest) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *NackJobRequest) GetRetryDelay() *durationpb.Duration {
+	if x != nil {
+		return x.RetryDelay
+	}
+	return nil
+}
+
+func (x *NackJobRequest) GetPermanent() bool {
+	if x != nil {
+		return x.Permanent
+	}
+	return false
+}
+
+func (x *NackJobRequest) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *NackJobRequest) GetErrorDetails() string {
+	if x != nil {
+		return x.ErrorDetails
+	}
+	return ""
+}
+
+type NackJobResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// RETRYING, FAILED or DEAD
+	Status        JobStatus `protobuf:"varint,1,opt,name=status,proto3,enum=queue.JobStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NackJobResponse) Reset() {
+	*x = NackJobResponse{}
+	mi := &file_proto_queue_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NackJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NackJobResponse) ProtoMessage() {}
+
+func (x *NackJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NackJobResponse.ProtoReflect.Descriptor instead.
+func (*NackJobResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *NackJobResponse) GetStatus() JobStatus {
+	if x != nil {
+		return x.Status
+	}
+	return JobStatus_JOB_STATUS_UNSPECIFIED
+}
+
+type ExtendLeaseRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	LeaseToken string                 `protobuf:"bytes,1,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	// Defaults to 5m, at most 1h
+	LeaseDuration *durationpb.Duration `protobuf:"bytes,2,opt,name=lease_duration,json=leaseDuration,proto3" json:"lease_duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtendLeaseRequest) Reset() {
+	*x = ExtendLeaseRequest{}
+	mi := &file_proto_queue_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtendLeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendLeaseRequest) ProtoMessage() {}
+
+func (x *ExtendLeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendLeaseRequest.ProtoReflect.Descriptor instead.
+func (*ExtendLeaseRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *ExtendLeaseRequest) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+func (x *ExtendLeaseRequest) GetLeaseDuration() *durationpb.Duration {
+	if x != nil {
+		return x.LeaseDuration
+	}
+	return nil
+}
+
+type ExtendLeaseResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	LeaseExpiresAt *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=lease_expires_at,json=leaseExpiresAt,proto3" json:"lease_expires_at,omitempty"`
+	// Set once CancelJob was called for the job; the worker should stop it and
+	// ack it as cancelled
+	CancelRequested bool `protobuf:"varint,2,opt,name=cancel_requested,json=cancelRequested,proto3" json:"cancel_requested,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ExtendLeaseResponse) Reset() {
+	*x = ExtendLeaseResponse{}
+	mi := &file_proto_queue_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtendLeaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendLeaseResponse) ProtoMessage() {}
+
+func (x *ExtendLeaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendLeaseResponse.ProtoReflect.Descriptor instead.
+func (*ExtendLeaseResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ExtendLeaseResponse) GetLeaseExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LeaseExpiresAt
+	}
+	return nil
+}
+
+func (x *ExtendLeaseResponse) GetCancelRequested() bool {
+	if x != nil {
+		return x.CancelRequested
+	}
+	return false
+}
+
+type ReportProgressRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	LeaseToken string                 `protobuf:"bytes,1,opt,name=lease_token,json=leaseToken,proto3" json:"lease_token,omitempty"`
+	// From 0 to 100
+	Percent int32 `protobuf:"varint,2,opt,name=percent,proto3" json:"percent,omitempty"`
+	// At most 256 bytes
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// Replaces the job's checkpoint when set, at most 1 MB. It is handed back
+	// in LeasedJob.job if the job is retried, so the attempt can resume
+	Checkpoint    []byte `protobuf:"bytes,4,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportProgressRequest) Reset() {
+	*x = ReportProgressRequest{}
+	mi := &file_proto_queue_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportProgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportProgressRequest) ProtoMessage() {}
+
+func (x *ReportProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportProgressRequest.ProtoReflect.Descriptor instead.
+func (*ReportProgressRequest) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ReportProgressRequest) GetLeaseToken() string {
+	if x != nil {
+		return x.LeaseToken
+	}
+	return ""
+}
+
+func (x *ReportProgressRequest) GetPercent() int32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *ReportProgressRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReportProgressRequest) GetCheckpoint() []byte {
+	if x != nil {
+		return x.Checkpoint
+	}
+	return nil
+}
+
+type ReportProgressResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Set once CancelJob was called for the job
+	CancelRequested bool `protobuf:"varint,1,opt,name=cancel_requested,json=cancelRequested,proto3" json:"cancel_requested,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ReportProgressResponse) Reset() {
+	*x = ReportProgressResponse{}
+	mi := &file_proto_queue_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportProgressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportProgressResponse) ProtoMessage() {}
+
+func (x *ReportProgressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_queue_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportProgressResponse.ProtoReflect.Descriptor instead.
+func (*ReportProgressResponse) Descriptor() ([]byte, []int) {
+	return file_proto_queue_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ReportProgressResponse) GetCancelRequested() bool {
+	if x != nil {
+		return x.CancelRequested
+	}
+	return false
+}
+
+var File_proto_queue_proto protoreflect.FileDescriptor
 
 const file_proto_queue_proto_rawDesc = "" +
 	"\n" +
-	"\x11proto/queue.proto\x12\x05queue\"Q\n" +
+	"\x11proto/queue.proto\x12\x05queue\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd1\x01\n" +
+	"\vRetryPolicy\x12!\n" +
+	"\fmax_attempts\x18\x01 \x01(\x05R\vmaxAttempts\x128\n" +
+	"\n" +
+	"base_delay\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\tbaseDelay\x126\n" +
+	"\tmax_delay\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\bmaxDelay\x12-\n" +
+	"\x06jitter\x18\x04 \x01(\x0e2\x15.queue.JitterStrategyR\x06jitter\"\xa2\x03\n" +
 	"\x11EnqueueJobRequest\x12\"\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x12\x18\n" +
-	"\apayload\x18\x02 \x01(\fR\apayload\"+\n" +
+	"\apayload\x18\x02 \x01(\fR\apayload\x125\n" +
+	"\fretry_policy\x18\x03 \x01(\v2\x12.queue.RetryPolicyR\vretryPolicy\x121\n" +
+	"\x06run_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x05runAt\x12/\n" +
+	"\x05delay\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x05delay\x12\x1a\n" +
+	"\bpriority\x18\x06 \x01(\x05R\bpriority\x12'\n" +
+	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\x126\n" +
+	"\x04tags\x18\b \x03(\v2\".queue.EnqueueJobRequest.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"+\n" +
 	"\x12EnqueueJobResponse\x12\x15\n" +
-	"\x06job_id\x18\x01 \x01(\tR\x05jobId\",\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"Z\n" +
+	"\x12EnqueueJobsRequest\x12,\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x18.queue.EnqueueJobRequestR\x04jobs\x12\x16\n" +
+	"\x06atomic\x18\x02 \x01(\bR\x06atomic\"S\n" +
+	"\x10EnqueueJobResult\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"H\n" +
+	"\x13EnqueueJobsResponse\x121\n" +
+	"\aresults\x18\x01 \x03(\v2\x17.queue.EnqueueJobResultR\aresults\"^\n" +
+	"\x14EnqueueStreamRequest\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12*\n" +
+	"\x03job\x18\x02 \x01(\v2\x18.queue.EnqueueJobRequestR\x03job\"d\n" +
+	"\x15EnqueueStreamResponse\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12/\n" +
+	"\x06result\x18\x02 \x01(\v2\x17.queue.EnqueueJobResultR\x06result\",\n" +
 	"\x13GetJobStatusRequest\x12\x15\n" +
-	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"E\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\xd2\a\n" +
 	"\x14GetJobStatusResponse\x12\x15\n" +
-	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status*5\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\"\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12=\n" +
+	"\fcompleted_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x12\x1a\n" +
+	"\battempts\x18\a \x01(\x05R\battempts\x12!\n" +
+	"\fmax_attempts\x18\b \x01(\x05R\vmaxAttempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\t \x01(\tR\tlastError\x12:\n" +
+	"\vnext_run_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tnextRunAt\x12\x1a\n" +
+	"\bpriority\x18\v \x01(\x05R\bpriority\x12J\n" +
+	"\x13cancel_requested_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\x11cancelRequestedAt\x129\n" +
+	"\x04tags\x18\r \x03(\v2%.queue.GetJobStatusResponse.TagsEntryR\x04tags\x12F\n" +
+	"\x11last_heartbeat_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\x0flastHeartbeatAt\x12>\n" +
+	"\rheartbeat_age\x18\x0f \x01(\v2\x19.google.protobuf.DurationR\fheartbeatAge\x12.\n" +
+	"\bprogress\x18\x10 \x01(\v2\x12.queue.JobProgressR\bprogress\x12\x1e\n" +
+	"\n" +
+	"checkpoint\x18\x11 \x01(\fR\n" +
+	"checkpoint\x12\x16\n" +
+	"\x06result\x18\x12 \x01(\fR\x06result\x12%\n" +
+	"\x05error\x18\x13 \x01(\v2\x0f.queue.JobErrorR\x05error\x12/\n" +
+	"\n" +
+	"job_status\x18\x14 \x01(\x0e2\x10.queue.JobStatusR\tjobStatus\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01J\x04\b\x02\x10\x03R\x06status\"R\n" +
+	"\bJobError\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x18\n" +
+	"\adetails\x18\x03 \x01(\tR\adetails\"[\n" +
+	"\x13GetJobResultRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12-\n" +
+	"\x04wait\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x04wait\"\xd5\x01\n" +
+	"\x14GetJobResultResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12(\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x10.queue.JobStatusR\x06status\x12\x16\n" +
+	"\x06result\x18\x03 \x01(\fR\x06result\x12%\n" +
+	"\x05error\x18\x04 \x01(\v2\x0f.queue.JobErrorR\x05error\x12=\n" +
+	"\fcompleted_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\"/\n" +
+	"\x16ListJobAttemptsRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"H\n" +
+	"\x17ListJobAttemptsResponse\x12-\n" +
+	"\battempts\x18\x01 \x03(\v2\x11.queue.JobAttemptR\battempts\"\xf1\x02\n" +
+	"\n" +
+	"JobAttempt\x12\x18\n" +
+	"\aattempt\x18\x01 \x01(\x05R\aattempt\x12\x1b\n" +
+	"\tworker_id\x18\x02 \x01(\tR\bworkerId\x12/\n" +
+	"\aoutcome\x18\x03 \x01(\x0e2\x15.queue.AttemptOutcomeR\aoutcome\x129\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x123\n" +
+	"\alatency\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\alatency\x12%\n" +
+	"\x05error\x18\a \x01(\v2\x0f.queue.JobErrorR\x05error\x12'\n" +
+	"\x0fresponse_status\x18\b \x01(\x05R\x0eresponseStatus\"|\n" +
+	"\vJobProgress\x12\x18\n" +
+	"\apercent\x18\x01 \x01(\x05R\apercent\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x129\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\")\n" +
+	"\x10CancelJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"m\n" +
+	"\x11CancelJobResponse\x12.\n" +
+	"\aoutcome\x18\x01 \x01(\x0e2\x14.queue.CancelOutcomeR\aoutcome\x12(\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x10.queue.JobStatusR\x06status\"(\n" +
+	"\x0fWatchJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\xa6\x01\n" +
+	"\x10WatchJobsRequest\x12\"\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x125\n" +
+	"\x04tags\x18\x02 \x03(\v2!.queue.WatchJobsRequest.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdc\x01\n" +
+	"\bJobEvent\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\"\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x12(\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x10.queue.JobStatusR\x06status\x12;\n" +
+	"\voccurred_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12.\n" +
+	"\bprogress\x18\x05 \x01(\v2\x12.queue.JobProgressR\bprogress\"\xca\x02\n" +
+	"\tJobFilter\x12\"\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x12,\n" +
+	"\bstatuses\x18\x02 \x03(\x0e2\x10.queue.JobStatusR\bstatuses\x12?\n" +
+	"\rcreated_after\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\fcreatedAfter\x12A\n" +
+	"\x0ecreated_before\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\rcreatedBefore\x12.\n" +
+	"\x04tags\x18\x05 \x03(\v2\x1a.queue.JobFilter.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa2\x05\n" +
+	"\x03Job\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\"\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x12(\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x10.queue.JobStatusR\x06status\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12\x1a\n" +
+	"\bpriority\x18\x05 \x01(\x05R\bpriority\x12(\n" +
+	"\x04tags\x18\x06 \x03(\v2\x14.queue.Job.TagsEntryR\x04tags\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"started_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12=\n" +
+	"\fcompleted_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x12\x1a\n" +
+	"\battempts\x18\n" +
+	" \x01(\x05R\battempts\x12!\n" +
+	"\fmax_attempts\x18\v \x01(\x05R\vmaxAttempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\f \x01(\tR\tlastError\x12:\n" +
+	"\vnext_run_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tnextRunAt\x12.\n" +
+	"\bprogress\x18\x0e \x01(\v2\x12.queue.JobProgressR\bprogress\x12\x1e\n" +
+	"\n" +
+	"checkpoint\x18\x0f \x01(\fR\n" +
+	"checkpoint\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc8\x01\n" +
+	"\x0fListJobsRequest\x12(\n" +
+	"\x06filter\x18\x01 \x01(\v2\x10.queue.JobFilterR\x06filter\x12&\n" +
+	"\x05order\x18\x02 \x01(\x0e2\x10.queue.SortOrderR\x05order\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\x12'\n" +
+	"\x0finclude_payload\x18\x05 \x01(\bR\x0eincludePayload\"Z\n" +
+	"\x10ListJobsResponse\x12\x1e\n" +
+	"\x04jobs\x18\x01 \x03(\v2\n" +
+	".queue.JobR\x04jobs\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xee\x01\n" +
+	"\x10DeadLetterFilter\x12\"\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x12\x17\n" +
+	"\ajob_ids\x18\x02 \x03(\tR\x06jobIds\x129\n" +
+	"\n" +
+	"dead_after\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tdeadAfter\x12;\n" +
+	"\vdead_before\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"deadBefore\x12%\n" +
+	"\x0eerror_contains\x18\x05 \x01(\tR\rerrorContains\"\xb2\x02\n" +
+	"\rDeadLetterJob\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\"\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x0e.queue.JobTypeR\x04type\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12\x1a\n" +
+	"\battempts\x18\x04 \x01(\x05R\battempts\x12!\n" +
+	"\fmax_attempts\x18\x05 \x01(\x05R\vmaxAttempts\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x123\n" +
+	"\adead_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x06deadAt\"u\n" +
+	"\n" +
+	"JobFailure\x12\x18\n" +
+	"\aattempt\x18\x01 \x01(\x05R\aattempt\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x127\n" +
+	"\tfailed_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bfailedAt\"\x88\x01\n" +
+	"\x19ListDeadLetterJobsRequest\x12/\n" +
+	"\x06filter\x18\x01 \x01(\v2\x17.queue.DeadLetterFilterR\x06filter\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"n\n" +
+	"\x1aListDeadLetterJobsResponse\x12(\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x14.queue.DeadLetterJobR\x04jobs\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"0\n" +
+	"\x17GetDeadLetterJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"q\n" +
+	"\x18GetDeadLetterJobResponse\x12&\n" +
+	"\x03job\x18\x01 \x01(\v2\x14.queue.DeadLetterJobR\x03job\x12-\n" +
+	"\bfailures\x18\x02 \x03(\v2\x11.queue.JobFailureR\bfailures\"v\n" +
+	"\x1bReplayDeadLetterJobsRequest\x12/\n" +
+	"\x06filter\x18\x01 \x01(\v2\x17.queue.DeadLetterFilterR\x06filter\x12\x10\n" +
+	"\x03all\x18\x02 \x01(\bR\x03all\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"7\n" +
+	"\x1cReplayDeadLetterJobsResponse\x12\x17\n" +
+	"\ajob_ids\x18\x01 \x03(\tR\x06jobIds\"u\n" +
+	"\x1aPurgeDeadLetterJobsRequest\x12/\n" +
+	"\x06filter\x18\x01 \x01(\v2\x17.queue.DeadLetterFilterR\x06filter\x12\x10\n" +
+	"\x03all\x18\x02 \x01(\bR\x03all\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"6\n" +
+	"\x1bPurgeDeadLetterJobsResponse\x12\x17\n" +
+	"\ajob_ids\x18\x01 \x03(\tR\x06jobIds\"\xe1\x01\n" +
+	"\x10FetchJobsRequest\x12$\n" +
+	"\x05types\x18\x01 \x03(\x0e2\x0e.queue.JobTypeR\x05types\x12\x19\n" +
+	"\bmax_jobs\x18\x02 \x01(\x05R\amaxJobs\x12-\n" +
+	"\x04wait\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x04wait\x12@\n" +
+	"\x0elease_duration\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\rleaseDuration\x12\x1b\n" +
+	"\tworker_id\x18\x05 \x01(\tR\bworkerId\"\x90\x01\n" +
+	"\tLeasedJob\x12\x1c\n" +
+	"\x03job\x18\x01 \x01(\v2\n" +
+	".queue.JobR\x03job\x12\x1f\n" +
+	"\vlease_token\x18\x02 \x01(\tR\n" +
+	"leaseToken\x12D\n" +
+	"\x10lease_expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x0eleaseExpiresAt\"9\n" +
+	"\x11FetchJobsResponse\x12$\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x10.queue.LeasedJobR\x04jobs\"f\n" +
+	"\rAckJobRequest\x12\x1f\n" +
+	"\vlease_token\x18\x01 \x01(\tR\n" +
+	"leaseToken\x12\x1c\n" +
+	"\tcancelled\x18\x02 \x01(\bR\tcancelled\x12\x16\n" +
+	"\x06result\x18\x03 \x01(\fR\x06result\":\n" +
+	"\x0eAckJobResponse\x12(\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x10.queue.JobStatusR\x06status\"\xe5\x01\n" +
+	"\x0eNackJobRequest\x12\x1f\n" +
+	"\vlease_token\x18\x01 \x01(\tR\n" +
+	"leaseToken\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12:\n" +
+	"\vretry_delay\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"retryDelay\x12\x1c\n" +
+	"\tpermanent\x18\x04 \x01(\bR\tpermanent\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x05 \x01(\tR\terrorCode\x12#\n" +
+	"\rerror_details\x18\x06 \x01(\tR\ferrorDetails\";\n" +
+	"\x0fNackJobResponse\x12(\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x10.queue.JobStatusR\x06status\"w\n" +
+	"\x12ExtendLeaseRequest\x12\x1f\n" +
+	"\vlease_token\x18\x01 \x01(\tR\n" +
+	"leaseToken\x12@\n" +
+	"\x0elease_duration\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\rleaseDuration\"\x86\x01\n" +
+	"\x13ExtendLeaseResponse\x12D\n" +
+	"\x10lease_expires_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x0eleaseExpiresAt\x12)\n" +
+	"\x10cancel_requested\x18\x02 \x01(\bR\x0fcancelRequested\"\x8c\x01\n" +
+	"\x15ReportProgressRequest\x12\x1f\n" +
+	"\vlease_token\x18\x01 \x01(\tR\n" +
+	"leaseToken\x12\x18\n" +
+	"\apercent\x18\x02 \x01(\x05R\apercent\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"checkpoint\x18\x04 \x01(\fR\n" +
+	"checkpoint\"C\n" +
+	"\x16ReportProgressResponse\x12)\n" +
+	"\x10cancel_requested\x18\x01 \x01(\bR\x0fcancelRequested*F\n" +
 	"\aJobType\x12\x18\n" +
 	"\x14JOB_TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
-	"\fJOB_STANDARD\x10\x012\x9a\x01\n" +
+	"\fJOB_STANDARD\x10\x01\x12\x0f\n" +
+	"\vJOB_WEBHOOK\x10\x02*\xec\x01\n" +
+	"\tJobStatus\x12\x1a\n" +
+	"\x16JOB_STATUS_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11JOB_STATUS_QUEUED\x10\x01\x12\x19\n" +
+	"\x15JOB_STATUS_PROCESSING\x10\x02\x12\x18\n" +
+	"\x14JOB_STATUS_COMPLETED\x10\x03\x12\x15\n" +
+	"\x11JOB_STATUS_FAILED\x10\x04\x12\x17\n" +
+	"\x13JOB_STATUS_RETRYING\x10\x05\x12\x13\n" +
+	"\x0fJOB_STATUS_DEAD\x10\x06\x12\x18\n" +
+	"\x14JOB_STATUS_SCHEDULED\x10\a\x12\x18\n" +
+	"\x14JOB_STATUS_CANCELLED\x10\b*\x88\x01\n" +
+	"\rCancelOutcome\x12\x1e\n" +
+	"\x1aCANCEL_OUTCOME_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18CANCEL_OUTCOME_CANCELLED\x10\x01\x12\x1c\n" +
+	"\x18CANCEL_OUTCOME_REQUESTED\x10\x02\x12\x1b\n" +
+	"\x17CANCEL_OUTCOME_TOO_LATE\x10\x03*\xe8\x01\n" +
+	"\x0eAttemptOutcome\x12\x1f\n" +
+	"\x1bATTEMPT_OUTCOME_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17ATTEMPT_OUTCOME_RUNNING\x10\x01\x12\x1d\n" +
+	"\x19ATTEMPT_OUTCOME_SUCCEEDED\x10\x02\x12\x1a\n" +
+	"\x16ATTEMPT_OUTCOME_FAILED\x10\x03\x12\x1d\n" +
+	"\x19ATTEMPT_OUTCOME_CANCELLED\x10\x04\x12\x1f\n" +
+	"\x1bATTEMPT_OUTCOME_INTERRUPTED\x10\x05\x12\x1d\n" +
+	"\x19ATTEMPT_OUTCOME_ABANDONED\x10\x06*a\n" +
+	"\tSortOrder\x12\x1a\n" +
+	"\x16SORT_ORDER_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17SORT_ORDER_NEWEST_FIRST\x10\x01\x12\x1b\n" +
+	"\x17SORT_ORDER_OLDEST_FIRST\x10\x02*\x80\x01\n" +
+	"\x0eJitterStrategy\x12\x1f\n" +
+	"\x1bJITTER_STRATEGY_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14JITTER_STRATEGY_NONE\x10\x01\x12\x18\n" +
+	"\x14JITTER_STRATEGY_FULL\x10\x02\x12\x19\n" +
+	"\x15JITTER_STRATEGY_EQUAL\x10\x032\xed\n" +
+	"\n" +
 	"\fQueueService\x12A\n" +
 	"\n" +
-	"EnqueueJob\x12\x18.queue.EnqueueJobRequest\x1a\x19.queue.EnqueueJobResponse\x12G\n" +
-	"\fGetJobStatus\x12\x1a.queue.GetJobStatusRequest\x1a\x1b.queue.GetJobStatusResponseB,Z*github.com/turnertastic1/boltq/pkg/queuepbb\x06proto3"
+	"EnqueueJob\x12\x18.queue.EnqueueJobRequest\x1a\x19.queue.EnqueueJobResponse\x12D\n" +
+	"\vEnqueueJobs\x12\x19.queue.EnqueueJobsRequest\x1a\x1a.queue.EnqueueJobsResponse\x12N\n" +
+	"\rEnqueueStream\x12\x1b.queue.EnqueueStreamRequest\x1a\x1c.queue.EnqueueStreamResponse(\x010\x01\x12G\n" +
+	"\fGetJobStatus\x12\x1a.queue.GetJobStatusRequest\x1a\x1b.queue.GetJobStatusResponse\x12G\n" +
+	"\fGetJobResult\x12\x1a.queue.GetJobResultRequest\x1a\x1b.queue.GetJobResultResponse\x12P\n" +
+	"\x0fListJobAttempts\x12\x1d.queue.ListJobAttemptsRequest\x1a\x1e.queue.ListJobAttemptsResponse\x12>\n" +
+	"\tCancelJob\x12\x17.queue.CancelJobRequest\x1a\x18.queue.CancelJobResponse\x12;\n" +
+	"\bListJobs\x12\x16.queue.ListJobsRequest\x1a\x17.queue.ListJobsResponse\x125\n" +
+	"\bWatchJob\x12\x16.queue.WatchJobRequest\x1a\x0f.queue.JobEvent0\x01\x127\n" +
+	"\tWatchJobs\x12\x17.queue.WatchJobsRequest\x1a\x0f.queue.JobEvent0\x01\x12>\n" +
+	"\tFetchJobs\x12\x17.queue.FetchJobsRequest\x1a\x18.queue.FetchJobsResponse\x125\n" +
+	"\x06AckJob\x12\x14.queue.AckJobRequest\x1a\x15.queue.AckJobResponse\x128\n" +
+	"\aNackJob\x12\x15.queue.NackJobRequest\x1a\x16.queue.NackJobResponse\x12D\n" +
+	"\vExtendLease\x12\x19.queue.ExtendLeaseRequest\x1a\x1a.queue.ExtendLeaseResponse\x12M\n" +
+	"\x0eReportProgress\x12\x1c.queue.ReportProgressRequest\x1a\x1d.queue.ReportProgressResponse\x12Y\n" +
+	"\x12ListDeadLetterJobs\x12 .queue.ListDeadLetterJobsRequest\x1a!.queue.ListDeadLetterJobsResponse\x12S\n" +
+	"\x10GetDeadLetterJob\x12\x1e.queue.GetDeadLetterJobRequest\x1a\x1f.queue.GetDeadLetterJobResponse\x12_\n" +
+	"\x14ReplayDeadLetterJobs\x12\".queue.ReplayDeadLetterJobsRequest\x1a#.queue.ReplayDeadLetterJobsResponse\x12\\\n" +
+	"\x13PurgeDeadLetterJobs\x12!.queue.PurgeDeadLetterJobsRequest\x1a\".queue.PurgeDeadLetterJobsResponseB,Z*github.com/turnertastic1/boltq/pkg/queuepbb\x06proto3"
 
 var (
 	file_proto_queue_proto_rawDescOnce sync.Once
@@ -294,26 +3866,198 @@ func file_proto_queue_proto_rawDescGZIP() []byte {
 	return file_proto_queue_proto_rawDescData
 }
 
-var file_proto_queue_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_queue_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_queue_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_proto_queue_proto_msgTypes = make([]protoimpl.MessageInfo, 53)
 var file_proto_queue_proto_goTypes = []any{
-	(JobType)(0),                 // 0: queue.JobType
-	(*EnqueueJobRequest)(nil),    // 1: queue.EnqueueJobRequest
-	(*EnqueueJobResponse)(nil),   // 2: queue.EnqueueJobResponse
-	(*GetJobStatusRequest)(nil),  // 3: queue.GetJobStatusRequest
-	(*GetJobStatusResponse)(nil), // 4: queue.GetJobStatusResponse
+	(JobType)(0),                         // 0: queue.JobType
+	(JobStatus)(0),                       // 1: queue.JobStatus
+	(CancelOutcome)(0),                   // 2: queue.CancelOutcome
+	(AttemptOutcome)(0),                  // 3: queue.AttemptOutcome
+	(SortOrder)(0),                       // 4: queue.SortOrder
+	(JitterStrategy)(0),                  // 5: queue.JitterStrategy
+	(*RetryPolicy)(nil),                  // 6: queue.RetryPolicy
+	(*EnqueueJobRequest)(nil),            // 7: queue.EnqueueJobRequest
+	(*EnqueueJobResponse)(nil),           // 8: queue.EnqueueJobResponse
+	(*EnqueueJobsRequest)(nil),           // 9: queue.EnqueueJobsRequest
+	(*EnqueueJobResult)(nil),             // 10: queue.EnqueueJobResult
+	(*EnqueueJobsResponse)(nil),          // 11: queue.EnqueueJobsResponse
+	(*EnqueueStreamRequest)(nil),         // 12: queue.EnqueueStreamRequest
+	(*EnqueueStreamResponse)(nil),        // 13: queue.EnqueueStreamResponse
+	(*GetJobStatusRequest)(nil),          // 14: queue.GetJobStatusRequest
+	(*GetJobStatusResponse)(nil),         // 15: queue.GetJobStatusResponse
+	(*JobError)(nil),                     // 16: queue.JobError
+	(*GetJobResultRequest)(nil),          // 17: queue.GetJobResultRequest
+	(*GetJobResultResponse)(nil),         // 18: queue.GetJobResultResponse
+	(*ListJobAttemptsRequest)(nil),       // 19: queue.ListJobAttemptsRequest
+	(*ListJobAttemptsResponse)(nil),      // 20: queue.ListJobAttemptsResponse
+	(*JobAttempt)(nil),                   // 21: queue.JobAttempt
+	(*JobProgress)(nil),                  // 22: queue.JobProgress
+	(*CancelJobRequest)(nil),             // 23: queue.CancelJobRequest
+	(*CancelJobResponse)(nil),            // 24: queue.CancelJobResponse
+	(*WatchJobRequest)(nil),              // 25: queue.WatchJobRequest
+	(*WatchJobsRequest)(nil),             // 26: queue.WatchJobsRequest
+	(*JobEvent)(nil),                     // 27: queue.JobEvent
+	(*JobFilter)(nil),                    // 28: queue.JobFilter
+	(*Job)(nil),                          // 29: queue.Job
+	(*ListJobsRequest)(nil),              // 30: queue.ListJobsRequest
+	(*ListJobsResponse)(nil),             // 31: queue.ListJobsResponse
+	(*DeadLetterFilter)(nil),             // 32: queue.DeadLetterFilter
+	(*DeadLetterJob)(nil),                // 33: queue.DeadLetterJob
+	(*JobFailure)(nil),                   // 34: queue.JobFailure
+	(*ListDeadLetterJobsRequest)(nil),    // 35: queue.ListDeadLetterJobsRequest
+	(*ListDeadLetterJobsResponse)(nil),   // 36: queue.ListDeadLetterJobsResponse
+	(*GetDeadLetterJobRequest)(nil),      // 37: queue.GetDeadLetterJobRequest
+	(*GetDeadLetterJobResponse)(nil),     // 38: queue.GetDeadLetterJobResponse
+	(*ReplayDeadLetterJobsRequest)(nil),  // 39: queue.ReplayDeadLetterJobsRequest
+	(*ReplayDeadLetterJobsResponse)(nil), // 40: queue.ReplayDeadLetterJobsResponse
+	(*PurgeDeadLetterJobsRequest)(nil),   // 41: queue.PurgeDeadLetterJobsRequest
+	(*PurgeDeadLetterJobsResponse)(nil),  // 42: queue.PurgeDeadLetterJobsResponse
+	(*FetchJobsRequest)(nil),             // 43: queue.FetchJobsRequest
+	(*LeasedJob)(nil),                    // 44: queue.LeasedJob
+	(*FetchJobsResponse)(nil),            // 45: queue.FetchJobsResponse
+	(*AckJobRequest)(nil),                // 46: queue.AckJobRequest
+	(*AckJobResponse)(nil),               // 47: queue.AckJobResponse
+	(*NackJobRequest)(nil),               // 48: queue.NackJobRequest
+	(*NackJobResponse)(nil),              // 49: queue.NackJobResponse
+	(*ExtendLeaseRequest)(nil),           // 50: queue.ExtendLeaseRequest
+	(*ExtendLeaseResponse)(nil),          // 51: queue.ExtendLeaseResponse
+	(*ReportProgressRequest)(nil),        // 52: queue.ReportProgressRequest
+	(*ReportProgressResponse)(nil),       // 53: queue.ReportProgressResponse
+	nil,                                  // 54: queue.EnqueueJobRequest.TagsEntry
+	nil,                                  // 55: queue.GetJobStatusResponse.TagsEntry
+	nil,                                  // 56: queue.WatchJobsRequest.TagsEntry
+	nil,                                  // 57: queue.JobFilter.TagsEntry
+	nil,                                  // 58: queue.Job.TagsEntry
+	(*durationpb.Duration)(nil),          // 59: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),        // 60: google.protobuf.Timestamp
 }
 var file_proto_queue_proto_depIdxs = []int32{
-	0, // 0: queue.EnqueueJobRequest.type:type_name -> queue.JobType
-	1, // 1: queue.QueueService.EnqueueJob:input_type -> queue.EnqueueJobRequest
-	3, // 2: queue.QueueService.GetJobStatus:input_type -> queue.GetJobStatusRequest
-	2, // 3: queue.QueueService.EnqueueJob:output_type -> queue.EnqueueJobResponse
-	4, // 4: queue.QueueService.GetJobStatus:output_type -> queue.GetJobStatusResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	59,  // 0: queue.RetryPolicy.base_delay:type_name -> google.protobuf.Duration
+	59,  // 1: queue.RetryPolicy.max_delay:type_name -> google.protobuf.Duration
+	5,   // 2: queue.RetryPolicy.jitter:type_name -> queue.JitterStrategy
+	0,   // 3: queue.EnqueueJobRequest.type:type_name -> queue.JobType
+	6,   // 4: queue.EnqueueJobRequest.retry_policy:type_name -> queue.RetryPolicy
+	60,  // 5: queue.EnqueueJobRequest.run_at:type_name -> google.protobuf.Timestamp
+	59,  // 6: queue.EnqueueJobRequest.delay:type_name -> google.protobuf.Duration
+	54,  // 7: queue.EnqueueJobRequest.tags:type_name -> queue.EnqueueJobRequest.TagsEntry
+	7,   // 8: queue.EnqueueJobsRequest.jobs:type_name -> queue.EnqueueJobRequest
+	10,  // 9: queue.EnqueueJobsResponse.results:type_name -> queue.EnqueueJobResult
+	7,   // 10: queue.EnqueueStreamRequest.job:type_name -> queue.EnqueueJobRequest
+	10,  // 11: queue.EnqueueStreamResponse.result:type_name -> queue.EnqueueJobResult
+	0,   // 12: queue.GetJobStatusResponse.type:type_name -> queue.JobType
+	60,  // 13: queue.GetJobStatusResponse.created_at:type_name -> google.protobuf.Timestamp
+	60,  // 14: queue.GetJobStatusResponse.started_at:type_name -> google.protobuf.Timestamp
+	60,  // 15: queue.GetJobStatusResponse.completed_at:type_name -> google.protobuf.Timestamp
+	60,  // 16: queue.GetJobStatusResponse.next_run_at:type_name -> google.protobuf.Timestamp
+	60,  // 17: queue.GetJobStatusResponse.cancel_requested_at:type_name -> google.protobuf.Timestamp
+	55,  // 18: queue.GetJobStatusResponse.tags:type_name -> queue.GetJobStatusResponse.TagsEntry
+	60,  // 19: queue.GetJobStatusResponse.last_heartbeat_at:type_name -> google.protobuf.Timestamp
+	59,  // 20: queue.GetJobStatusResponse.heartbeat_age:type_name -> google.protobuf.Duration
+	22,  // 21: queue.GetJobStatusResponse.progress:type_name -> queue.JobProgress
+	16,  // 22: queue.GetJobStatusResponse.error:type_name -> queue.JobError
+	1,   // 23: queue.GetJobStatusResponse.job_status:type_name -> queue.JobStatus
+	59,  // 24: queue.GetJobResultRequest.wait:type_name -> google.protobuf.Duration
+	1,   // 25: queue.GetJobResultResponse.status:type_name -> queue.JobStatus
+	16,  // 26: queue.GetJobResultResponse.error:type_name -> queue.JobError
+	60,  // 27: queue.GetJobResultResponse.completed_at:type_name -> google.protobuf.Timestamp
+	21,  // 28: queue.ListJobAttemptsResponse.attempts:type_name -> queue.JobAttempt
+	3,   // 29: queue.JobAttempt.outcome:type_name -> queue.AttemptOutcome
+	60,  // 30: queue.JobAttempt.started_at:type_name -> google.protobuf.Timestamp
+	60,  // 31: queue.JobAttempt.finished_at:type_name -> google.protobuf.Timestamp
+	59,  // 32: queue.JobAttempt.latency:type_name -> google.protobuf.Duration
+	16,  // 33: queue.JobAttempt.error:type_name -> queue.JobError
+	60,  // 34: queue.JobProgress.updated_at:type_name -> google.protobuf.Timestamp
+	2,   // 35: queue.CancelJobResponse.outcome:type_name -> queue.CancelOutcome
+	1,   // 36: queue.CancelJobResponse.status:type_name -> queue.JobStatus
+	0,   // 37: queue.WatchJobsRequest.type:type_name -> queue.JobType
+	56,  // 38: queue.WatchJobsRequest.tags:type_name -> queue.WatchJobsRequest.TagsEntry
+	0,   // 39: queue.JobEvent.type:type_name -> queue.JobType
+	1,   // 40: queue.JobEvent.status:type_name -> queue.JobStatus
+	60,  // 41: queue.JobEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	22,  // 42: queue.JobEvent.progress:type_name -> queue.JobProgress
+	0,   // 43: queue.JobFilter.type:type_name -> queue.JobType
+	1,   // 44: queue.JobFilter.statuses:type_name -> queue.JobStatus
+	60,  // 45: queue.JobFilter.created_after:type_name -> google.protobuf.Timestamp
+	60,  // 46: queue.JobFilter.created_before:type_name -> google.protobuf.Timestamp
+	57,  // 47: queue.JobFilter.tags:type_name -> queue.JobFilter.TagsEntry
+	0,   // 48: queue.Job.type:type_name -> queue.JobType
+	1,   // 49: queue.Job.status:type_name -> queue.JobStatus
+	58,  // 50: queue.Job.tags:type_name -> queue.Job.TagsEntry
+	60,  // 51: queue.Job.created_at:type_name -> google.protobuf.Timestamp
+	60,  // 52: queue.Job.started_at:type_name -> google.protobuf.Timestamp
+	60,  // 53: queue.Job.completed_at:type_name -> google.protobuf.Timestamp
+	60,  // 54: queue.Job.next_run_at:type_name -> google.protobuf.Timestamp
+	22,  // 55: queue.Job.progress:type_name -> queue.JobProgress
+	28,  // 56: queue.ListJobsRequest.filter:type_name -> queue.JobFilter
+	4,   // 57: queue.ListJobsRequest.order:type_name -> queue.SortOrder
+	29,  // 58: queue.ListJobsResponse.jobs:type_name -> queue.Job
+	0,   // 59: queue.DeadLetterFilter.type:type_name -> queue.JobType
+	60,  // 60: queue.DeadLetterFilter.dead_after:type_name -> google.protobuf.Timestamp
+	60,  // 61: queue.DeadLetterFilter.dead_before:type_name -> google.protobuf.Timestamp
+	0,   // 62: queue.DeadLetterJob.type:type_name -> queue.JobType
+	60,  // 63: queue.DeadLetterJob.created_at:type_name -> google.protobuf.Timestamp
+	60,  // 64: queue.DeadLetterJob.dead_at:type_name -> google.protobuf.Timestamp
+	60,  // 65: queue.JobFailure.failed_at:type_name -> google.protobuf.Timestamp
+	32,  // 66: queue.ListDeadLetterJobsRequest.filter:type_name -> queue.DeadLetterFilter
+	33,  // 67: queue.ListDeadLetterJobsResponse.jobs:type_name -> queue.DeadLetterJob
+	33,  // 68: queue.GetDeadLetterJobResponse.job:type_name -> queue.DeadLetterJob
+	34,  // 69: queue.GetDeadLetterJobResponse.failures:type_name -> queue.JobFailure
+	32,  // 70: queue.ReplayDeadLetterJobsRequest.filter:type_name -> queue.DeadLetterFilter
+	32,  // 71: queue.PurgeDeadLetterJobsRequest.filter:type_name -> queue.DeadLetterFilter
+	0,   // 72: queue.FetchJobsRequest.types:type_name -> queue.JobType
+	59,  // 73: queue.FetchJobsRequest.wait:type_name -> google.protobuf.Duration
+	59,  // 74: queue.FetchJobsRequest.lease_duration:type_name -> google.protobuf.Duration
+	29,  // 75: queue.LeasedJob.job:type_name -> queue.Job
+	60,  // 76: queue.LeasedJob.lease_expires_at:type_name -> google.protobuf.Timestamp
+	44,  // 77: queue.FetchJobsResponse.jobs:type_name -> queue.LeasedJob
+	1,   // 78: queue.AckJobResponse.status:type_name -> queue.JobStatus
+	59,  // 79: queue.NackJobRequest.retry_delay:type_name -> google.protobuf.Duration
+	1,   // 80: queue.NackJobResponse.status:type_name -> queue.JobStatus
+	59,  // 81: queue.ExtendLeaseRequest.lease_duration:type_name -> google.protobuf.Duration
+	60,  // 82: queue.ExtendLeaseResponse.lease_expires_at:type_name -> google.protobuf.Timestamp
+	7,   // 83: queue.QueueService.EnqueueJob:input_type -> queue.EnqueueJobRequest
+	9,   // 84: queue.QueueService.EnqueueJobs:input_type -> queue.EnqueueJobsRequest
+	12,  // 85: queue.QueueService.EnqueueStream:input_type -> queue.EnqueueStreamRequest
+	14,  // 86: queue.QueueService.GetJobStatus:input_type -> queue.GetJobStatusRequest
+	17,  // 87: queue.QueueService.GetJobResult:input_type -> queue.GetJobResultRequest
+	19,  // 88: queue.QueueService.ListJobAttempts:input_type -> queue.ListJobAttemptsRequest
+	23,  // 89: queue.QueueService.CancelJob:input_type -> queue.CancelJobRequest
+	30,  // 90: queue.QueueService.ListJobs:input_type -> queue.ListJobsRequest
+	25,  // 91: queue.QueueService.WatchJob:input_type -> queue.WatchJobRequest
+	26,  // 92: queue.QueueService.WatchJobs:input_type -> queue.WatchJobsRequest
+	43,  // 93: queue.QueueService.FetchJobs:input_type -> queue.FetchJobsRequest
+	46,  // 94: queue.QueueService.AckJob:input_type -> queue.AckJobRequest
+	48,  // 95: queue.QueueService.NackJob:input_type -> queue.NackJobRequest
+	50,  // 96: queue.QueueService.ExtendLease:input_type -> queue.ExtendLeaseRequest
+	52,  // 97: queue.QueueService.ReportProgress:input_type -> queue.ReportProgressRequest
+	35,  // 98: queue.QueueService.ListDeadLetterJobs:input_type -> queue.ListDeadLetterJobsRequest
+	37,  // 99: queue.QueueService.GetDeadLetterJob:input_type -> queue.GetDeadLetterJobRequest
+	39,  // 100: queue.QueueService.ReplayDeadLetterJobs:input_type -> queue.ReplayDeadLetterJobsRequest
+	41,  // 101: queue.QueueService.PurgeDeadLetterJobs:input_type -> queue.PurgeDeadLetterJobsRequest
+	8,   // 102: queue.QueueService.EnqueueJob:output_type -> queue.EnqueueJobResponse
+	11,  // 103: queue.QueueService.EnqueueJobs:output_type -> queue.EnqueueJobsResponse
+	13,  // 104: queue.QueueService.EnqueueStream:output_type -> queue.EnqueueStreamResponse
+	15,  // 105: queue.QueueService.GetJobStatus:output_type -> queue.GetJobStatusResponse
+	18,  // 106: queue.QueueService.GetJobResult:output_type -> queue.GetJobResultResponse
+	20,  // 107: queue.QueueService.ListJobAttempts:output_type -> queue.ListJobAttemptsResponse
+	24,  // 108: queue.QueueService.CancelJob:output_type -> queue.CancelJobResponse
+	31,  // 109: queue.QueueService.ListJobs:output_type -> queue.ListJobsResponse
+	27,  // 110: queue.QueueService.WatchJob:output_type -> queue.JobEvent
+	27,  // 111: queue.QueueService.WatchJobs:output_type -> queue.JobEvent
+	45,  // 112: queue.QueueService.FetchJobs:output_type -> queue.FetchJobsResponse
+	47,  // 113: queue.QueueService.AckJob:output_type -> queue.AckJobResponse
+	49,  // 114: queue.QueueService.NackJob:output_type -> queue.NackJobResponse
+	51,  // 115: queue.QueueService.ExtendLease:output_type -> queue.ExtendLeaseResponse
+	53,  // 116: queue.QueueService.ReportProgress:output_type -> queue.ReportProgressResponse
+	36,  // 117: queue.QueueService.ListDeadLetterJobs:output_type -> queue.ListDeadLetterJobsResponse
+	38,  // 118: queue.QueueService.GetDeadLetterJob:output_type -> queue.GetDeadLetterJobResponse
+	40,  // 119: queue.QueueService.ReplayDeadLetterJobs:output_type -> queue.ReplayDeadLetterJobsResponse
+	42,  // 120: queue.QueueService.PurgeDeadLetterJobs:output_type -> queue.PurgeDeadLetterJobsResponse
+	102, // [102:121] is the sub-list for method output_type
+	83,  // [83:102] is the sub-list for method input_type
+	83,  // [83:83] is the sub-list for extension type_name
+	83,  // [83:83] is the sub-list for extension extendee
+	0,   // [0:83] is the sub-list for field type_name
 }
 
 func init() { file_proto_queue_proto_init() }
@@ -326,8 +4070,8 @@ func file_proto_queue_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_queue_proto_rawDesc), len(file_proto_queue_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   4,
+			NumEnums:      6,
+			NumMessages:   53,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
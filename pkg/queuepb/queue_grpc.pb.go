@@ -19,8 +19,25 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	QueueService_EnqueueJob_FullMethodName   = "/queue.QueueService/EnqueueJob"
-	QueueService_GetJobStatus_FullMethodName = "/queue.QueueService/GetJobStatus"
+	QueueService_EnqueueJob_FullMethodName           = "/queue.QueueService/EnqueueJob"
+	QueueService_EnqueueJobs_FullMethodName          = "/queue.QueueService/EnqueueJobs"
+	QueueService_EnqueueStream_FullMethodName        = "/queue.QueueService/EnqueueStream"
+	QueueService_GetJobStatus_FullMethodName         = "/queue.QueueService/GetJobStatus"
+	QueueService_GetJobResult_FullMethodName         = "/queue.QueueService/GetJobResult"
+	QueueService_ListJobAttempts_FullMethodName      = "/queue.QueueService/ListJobAttempts"
+	QueueService_CancelJob_FullMethodName            = "/queue.QueueService/CancelJob"
+	QueueService_ListJobs_FullMethodName             = "/queue.QueueService/ListJobs"
+	QueueService_WatchJob_FullMethodName             = "/queue.QueueService/WatchJob"
+	QueueService_WatchJobs_FullMethodName            = "/queue.QueueService/WatchJobs"
+	QueueService_FetchJobs_FullMethodName            = "/queue.QueueService/FetchJobs"
+	QueueService_AckJob_FullMethodName               = "/queue.QueueService/AckJob"
+	QueueService_NackJob_FullMethodName              = "/queue.QueueService/NackJob"
+	QueueService_ExtendLease_FullMethodName          = "/queue.QueueService/ExtendLease"
+	QueueService_ReportProgress_FullMethodName       = "/queue.QueueService/ReportProgress"
+	QueueService_ListDeadLetterJobs_FullMethodName   = "/queue.QueueService/ListDeadLetterJobs"
+	QueueService_GetDeadLetterJob_FullMethodName     = "/queue.QueueService/GetDeadLetterJob"
+	QueueService_ReplayDeadLetterJobs_FullMethodName = "/queue.QueueService/ReplayDeadLetterJobs"
+	QueueService_PurgeDeadLetterJobs_FullMethodName  = "/queue.QueueService/PurgeDeadLetterJobs"
 )
 
 // QueueServiceClient is the client API for QueueService service.
@@ -28,7 +45,34 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type QueueServiceClient interface {
 	EnqueueJob(ctx context.Context, in *EnqueueJobRequest, opts ...grpc.CallOption) (*EnqueueJobResponse, error)
+	EnqueueJobs(ctx context.Context, in *EnqueueJobsRequest, opts ...grpc.CallOption) (*EnqueueJobsResponse, error)
+	// Enqueues jobs sent over a long-lived stream. The server writes them in
+	// batches and acknowledges each one with its result, in the order received
+	EnqueueStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EnqueueStreamRequest, EnqueueStreamResponse], error)
 	GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*GetJobStatusResponse, error)
+	// Returns a job's output or why it failed, optionally waiting for it to finish
+	GetJobResult(ctx context.Context, in *GetJobResultRequest, opts ...grpc.CallOption) (*GetJobResultResponse, error)
+	// Returns every execution of a job: which worker ran it, when, and how it ended
+	ListJobAttempts(ctx context.Context, in *ListJobAttemptsRequest, opts ...grpc.CallOption) (*ListJobAttemptsResponse, error)
+	CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error)
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	// Streams a job's status changes and progress, starting with its current
+	// status, and ends once the job reaches a terminal status
+	WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error)
+	// Streams the status changes and progress of every job matching the request
+	WatchJobs(ctx context.Context, in *WatchJobsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error)
+	// Remote workers lease jobs from queue-svc and report their outcome, so
+	// they need no access to Postgres or Redis
+	FetchJobs(ctx context.Context, in *FetchJobsRequest, opts ...grpc.CallOption) (*FetchJobsResponse, error)
+	AckJob(ctx context.Context, in *AckJobRequest, opts ...grpc.CallOption) (*AckJobResponse, error)
+	NackJob(ctx context.Context, in *NackJobRequest, opts ...grpc.CallOption) (*NackJobResponse, error)
+	ExtendLease(ctx context.Context, in *ExtendLeaseRequest, opts ...grpc.CallOption) (*ExtendLeaseResponse, error)
+	ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error)
+	// Dead-letter queue
+	ListDeadLetterJobs(ctx context.Context, in *ListDeadLetterJobsRequest, opts ...grpc.CallOption) (*ListDeadLetterJobsResponse, error)
+	GetDeadLetterJob(ctx context.Context, in *GetDeadLetterJobRequest, opts ...grpc.CallOption) (*GetDeadLetterJobResponse, error)
+	ReplayDeadLetterJobs(ctx context.Context, in *ReplayDeadLetterJobsRequest, opts ...grpc.CallOption) (*ReplayDeadLetterJobsResponse, error)
+	PurgeDeadLetterJobs(ctx context.Context, in *PurgeDeadLetterJobsRequest, opts ...grpc.CallOption) (*PurgeDeadLetterJobsResponse, error)
 }
 
 type queueServiceClient struct {
@@ -49,6 +93,29 @@ func (c *queueServiceClient) EnqueueJob(ctx context.Context, in *EnqueueJobReque
 	return out, nil
 }
 
+func (c *queueServiceClient) EnqueueJobs(ctx context.Context, in *EnqueueJobsRequest, opts ...grpc.CallOption) (*EnqueueJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnqueueJobsResponse)
+	err := c.cc.Invoke(ctx, QueueService_EnqueueJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) EnqueueStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[EnqueueStreamRequest, EnqueueStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QueueService_ServiceDesc.Streams[0], QueueService_EnqueueStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EnqueueStreamRequest, EnqueueStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueueService_EnqueueStreamClient = grpc.BidiStreamingClient[EnqueueStreamRequest, EnqueueStreamResponse]
+
 func (c *queueServiceClient) GetJobStatus(ctx context.Context, in *GetJobStatusRequest, opts ...grpc.CallOption) (*GetJobStatusResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetJobStatusResponse)
@@ -59,12 +126,207 @@ func (c *queueServiceClient) GetJobStatus(ctx context.Context, in *GetJobStatusR
 	return out, nil
 }
 
+func (c *queueServiceClient) GetJobResult(ctx context.Context, in *GetJobResultRequest, opts ...grpc.CallOption) (*GetJobResultResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetJobResultResponse)
+	err := c.cc.Invoke(ctx, QueueService_GetJobResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) ListJobAttempts(ctx context.Context, in *ListJobAttemptsRequest, opts ...grpc.CallOption) (*ListJobAttemptsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobAttemptsResponse)
+	err := c.cc.Invoke(ctx, QueueService_ListJobAttempts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) CancelJob(ctx context.Context, in *CancelJobRequest, opts ...grpc.CallOption) (*CancelJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelJobResponse)
+	err := c.cc.Invoke(ctx, QueueService_CancelJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, QueueService_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) WatchJob(ctx context.Context, in *WatchJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QueueService_ServiceDesc.Streams[1], QueueService_WatchJob_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobRequest, JobEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueueService_WatchJobClient = grpc.ServerStreamingClient[JobEvent]
+
+func (c *queueServiceClient) WatchJobs(ctx context.Context, in *WatchJobsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QueueService_ServiceDesc.Streams[2], QueueService_WatchJobs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchJobsRequest, JobEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueueService_WatchJobsClient = grpc.ServerStreamingClient[JobEvent]
+
+func (c *queueServiceClient) FetchJobs(ctx context.Context, in *FetchJobsRequest, opts ...grpc.CallOption) (*FetchJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchJobsResponse)
+	err := c.cc.Invoke(ctx, QueueService_FetchJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) AckJob(ctx context.Context, in *AckJobRequest, opts ...grpc.CallOption) (*AckJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AckJobResponse)
+	err := c.cc.Invoke(ctx, QueueService_AckJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) NackJob(ctx context.Context, in *NackJobRequest, opts ...grpc.CallOption) (*NackJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NackJobResponse)
+	err := c.cc.Invoke(ctx, QueueService_NackJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) ExtendLease(ctx context.Context, in *ExtendLeaseRequest, opts ...grpc.CallOption) (*ExtendLeaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExtendLeaseResponse)
+	err := c.cc.Invoke(ctx, QueueService_ExtendLease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportProgressResponse)
+	err := c.cc.Invoke(ctx, QueueService_ReportProgress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) ListDeadLetterJobs(ctx context.Context, in *ListDeadLetterJobsRequest, opts ...grpc.CallOption) (*ListDeadLetterJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeadLetterJobsResponse)
+	err := c.cc.Invoke(ctx, QueueService_ListDeadLetterJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) GetDeadLetterJob(ctx context.Context, in *GetDeadLetterJobRequest, opts ...grpc.CallOption) (*GetDeadLetterJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeadLetterJobResponse)
+	err := c.cc.Invoke(ctx, QueueService_GetDeadLetterJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) ReplayDeadLetterJobs(ctx context.Context, in *ReplayDeadLetterJobsRequest, opts ...grpc.CallOption) (*ReplayDeadLetterJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplayDeadLetterJobsResponse)
+	err := c.cc.Invoke(ctx, QueueService_ReplayDeadLetterJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queueServiceClient) PurgeDeadLetterJobs(ctx context.Context, in *PurgeDeadLetterJobsRequest, opts ...grpc.CallOption) (*PurgeDeadLetterJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeDeadLetterJobsResponse)
+	err := c.cc.Invoke(ctx, QueueService_PurgeDeadLetterJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // QueueServiceServer is the server API for QueueService service.
 // All implementations must embed UnimplementedQueueServiceServer
 // for forward compatibility.
 type QueueServiceServer interface {
 	EnqueueJob(context.Context, *EnqueueJobRequest) (*EnqueueJobResponse, error)
+	EnqueueJobs(context.Context, *EnqueueJobsRequest) (*EnqueueJobsResponse, error)
+	// Enqueues jobs sent over a long-lived stream. The server writes them in
+	// batches and acknowledges each one with its result, in the order received
+	EnqueueStream(grpc.BidiStreamingServer[EnqueueStreamRequest, EnqueueStreamResponse]) error
 	GetJobStatus(context.Context, *GetJobStatusRequest) (*GetJobStatusResponse, error)
+	// Returns a job's output or why it failed, optionally waiting for it to finish
+	GetJobResult(context.Context, *GetJobResultRequest) (*GetJobResultResponse, error)
+	// Returns every execution of a job: which worker ran it, when, and how it ended
+	ListJobAttempts(context.Context, *ListJobAttemptsRequest) (*ListJobAttemptsResponse, error)
+	CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error)
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	// Streams a job's status changes and progress, starting with its current
+	// status, and ends once the job reaches a terminal status
+	WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobEvent]) error
+	// Streams the status changes and progress of every job matching the request
+	WatchJobs(*WatchJobsRequest, grpc.ServerStreamingServer[JobEvent]) error
+	// Remote workers lease jobs from queue-svc and report their outcome, so
+	// they need no access to Postgres or Redis
+	FetchJobs(context.Context, *FetchJobsRequest) (*FetchJobsResponse, error)
+	AckJob(context.Context, *AckJobRequest) (*AckJobResponse, error)
+	NackJob(context.Context, *NackJobRequest) (*NackJobResponse, error)
+	ExtendLease(context.Context, *ExtendLeaseRequest) (*ExtendLeaseResponse, error)
+	ReportProgress(context.Context, *ReportProgressRequest) (*ReportProgressResponse, error)
+	// Dead-letter queue
+	ListDeadLetterJobs(context.Context, *ListDeadLetterJobsRequest) (*ListDeadLetterJobsResponse, error)
+	GetDeadLetterJob(context.Context, *GetDeadLetterJobRequest) (*GetDeadLetterJobResponse, error)
+	ReplayDeadLetterJobs(context.Context, *ReplayDeadLetterJobsRequest) (*ReplayDeadLetterJobsResponse, error)
+	PurgeDeadLetterJobs(context.Context, *PurgeDeadLetterJobsRequest) (*PurgeDeadLetterJobsResponse, error)
 	mustEmbedUnimplementedQueueServiceServer()
 }
 
@@ -78,9 +340,60 @@ type UnimplementedQueueServiceServer struct{}
 func (UnimplementedQueueServiceServer) EnqueueJob(context.Context, *EnqueueJobRequest) (*EnqueueJobResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method EnqueueJob not implemented")
 }
+func (UnimplementedQueueServiceServer) EnqueueJobs(context.Context, *EnqueueJobsRequest) (*EnqueueJobsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method EnqueueJobs not implemented")
+}
+func (UnimplementedQueueServiceServer) EnqueueStream(grpc.BidiStreamingServer[EnqueueStreamRequest, EnqueueStreamResponse]) error {
+	return status.Error(codes.Unimplemented, "method EnqueueStream not implemented")
+}
 func (UnimplementedQueueServiceServer) GetJobStatus(context.Context, *GetJobStatusRequest) (*GetJobStatusResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetJobStatus not implemented")
 }
+func (UnimplementedQueueServiceServer) GetJobResult(context.Context, *GetJobResultRequest) (*GetJobResultResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetJobResult not implemented")
+}
+func (UnimplementedQueueServiceServer) ListJobAttempts(context.Context, *ListJobAttemptsRequest) (*ListJobAttemptsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListJobAttempts not implemented")
+}
+func (UnimplementedQueueServiceServer) CancelJob(context.Context, *CancelJobRequest) (*CancelJobResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelJob not implemented")
+}
+func (UnimplementedQueueServiceServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedQueueServiceServer) WatchJob(*WatchJobRequest, grpc.ServerStreamingServer[JobEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchJob not implemented")
+}
+func (UnimplementedQueueServiceServer) WatchJobs(*WatchJobsRequest, grpc.ServerStreamingServer[JobEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchJobs not implemented")
+}
+func (UnimplementedQueueServiceServer) FetchJobs(context.Context, *FetchJobsRequest) (*FetchJobsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method FetchJobs not implemented")
+}
+func (UnimplementedQueueServiceServer) AckJob(context.Context, *AckJobRequest) (*AckJobResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AckJob not implemented")
+}
+func (UnimplementedQueueServiceServer) NackJob(context.Context, *NackJobRequest) (*NackJobResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method NackJob not implemented")
+}
+func (UnimplementedQueueServiceServer) ExtendLease(context.Context, *ExtendLeaseRequest) (*ExtendLeaseResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExtendLease not implemented")
+}
+func (UnimplementedQueueServiceServer) ReportProgress(context.Context, *ReportProgressRequest) (*ReportProgressResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportProgress not implemented")
+}
+func (UnimplementedQueueServiceServer) ListDeadLetterJobs(context.Context, *ListDeadLetterJobsRequest) (*ListDeadLetterJobsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDeadLetterJobs not implemented")
+}
+func (UnimplementedQueueServiceServer) GetDeadLetterJob(context.Context, *GetDeadLetterJobRequest) (*GetDeadLetterJobResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDeadLetterJob not implemented")
+}
+func (UnimplementedQueueServiceServer) ReplayDeadLetterJobs(context.Context, *ReplayDeadLetterJobsRequest) (*ReplayDeadLetterJobsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReplayDeadLetterJobs not implemented")
+}
+func (UnimplementedQueueServiceServer) PurgeDeadLetterJobs(context.Context, *PurgeDeadLetterJobsRequest) (*PurgeDeadLetterJobsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PurgeDeadLetterJobs not implemented")
+}
 func (UnimplementedQueueServiceServer) mustEmbedUnimplementedQueueServiceServer() {}
 func (UnimplementedQueueServiceServer) testEmbeddedByValue()                      {}
 
@@ -120,6 +433,31 @@ func _QueueService_EnqueueJob_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _QueueService_EnqueueJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnqueueJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).EnqueueJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_EnqueueJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).EnqueueJobs(ctx, req.(*EnqueueJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_EnqueueStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(QueueServiceServer).EnqueueStream(&grpc.GenericServerStream[EnqueueStreamRequest, EnqueueStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueueService_EnqueueStreamServer = grpc.BidiStreamingServer[EnqueueStreamRequest, EnqueueStreamResponse]
+
 func _QueueService_GetJobStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetJobStatusRequest)
 	if err := dec(in); err != nil {
@@ -138,6 +476,262 @@ func _QueueService_GetJobStatus_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _QueueService_GetJobResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).GetJobResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_GetJobResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).GetJobResult(ctx, req.(*GetJobResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_ListJobAttempts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobAttemptsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).ListJobAttempts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_ListJobAttempts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).ListJobAttempts(ctx, req.(*ListJobAttemptsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_CancelJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).CancelJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_CancelJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).CancelJob(ctx, req.(*CancelJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_WatchJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueueServiceServer).WatchJob(m, &grpc.GenericServerStream[WatchJobRequest, JobEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueueService_WatchJobServer = grpc.ServerStreamingServer[JobEvent]
+
+func _QueueService_WatchJobs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchJobsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueueServiceServer).WatchJobs(m, &grpc.GenericServerStream[WatchJobsRequest, JobEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueueService_WatchJobsServer = grpc.ServerStreamingServer[JobEvent]
+
+func _QueueService_FetchJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).FetchJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_FetchJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).FetchJobs(ctx, req.(*FetchJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_AckJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).AckJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_AckJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).AckJob(ctx, req.(*AckJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_NackJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NackJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).NackJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_NackJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).NackJob(ctx, req.(*NackJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_ExtendLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtendLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).ExtendLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_ExtendLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).ExtendLease(ctx, req.(*ExtendLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_ReportProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportProgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).ReportProgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_ReportProgress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).ReportProgress(ctx, req.(*ReportProgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_ListDeadLetterJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLetterJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).ListDeadLetterJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_ListDeadLetterJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).ListDeadLetterJobs(ctx, req.(*ListDeadLetterJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_GetDeadLetterJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeadLetterJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).GetDeadLetterJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_GetDeadLetterJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).GetDeadLetterJob(ctx, req.(*GetDeadLetterJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_ReplayDeadLetterJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayDeadLetterJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).ReplayDeadLetterJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_ReplayDeadLetterJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).ReplayDeadLetterJobs(ctx, req.(*ReplayDeadLetterJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueueService_PurgeDeadLetterJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeDeadLetterJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueServiceServer).PurgeDeadLetterJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueService_PurgeDeadLetterJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueServiceServer).PurgeDeadLetterJobs(ctx, req.(*PurgeDeadLetterJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // QueueService_ServiceDesc is the grpc.ServiceDesc for QueueService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -149,11 +743,84 @@ var QueueService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "EnqueueJob",
 			Handler:    _QueueService_EnqueueJob_Handler,
 		},
+		{
+			MethodName: "EnqueueJobs",
+			Handler:    _QueueService_EnqueueJobs_Handler,
+		},
 		{
 			MethodName: "GetJobStatus",
 			Handler:    _QueueService_GetJobStatus_Handler,
 		},
+		{
+			MethodName: "GetJobResult",
+			Handler:    _QueueService_GetJobResult_Handler,
+		},
+		{
+			MethodName: "ListJobAttempts",
+			Handler:    _QueueService_ListJobAttempts_Handler,
+		},
+		{
+			MethodName: "CancelJob",
+			Handler:    _QueueService_CancelJob_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _QueueService_ListJobs_Handler,
+		},
+		{
+			MethodName: "FetchJobs",
+			Handler:    _QueueService_FetchJobs_Handler,
+		},
+		{
+			MethodName: "AckJob",
+			Handler:    _QueueService_AckJob_Handler,
+		},
+		{
+			MethodName: "NackJob",
+			Handler:    _QueueService_NackJob_Handler,
+		},
+		{
+			MethodName: "ExtendLease",
+			Handler:    _QueueService_ExtendLease_Handler,
+		},
+		{
+			MethodName: "ReportProgress",
+			Handler:    _QueueService_ReportProgress_Handler,
+		},
+		{
+			MethodName: "ListDeadLetterJobs",
+			Handler:    _QueueService_ListDeadLetterJobs_Handler,
+		},
+		{
+			MethodName: "GetDeadLetterJob",
+			Handler:    _QueueService_GetDeadLetterJob_Handler,
+		},
+		{
+			MethodName: "ReplayDeadLetterJobs",
+			Handler:    _QueueService_ReplayDeadLetterJobs_Handler,
+		},
+		{
+			MethodName: "PurgeDeadLetterJobs",
+			Handler:    _QueueService_PurgeDeadLetterJobs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EnqueueStream",
+			Handler:       _QueueService_EnqueueStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchJob",
+			Handler:       _QueueService_WatchJob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchJobs",
+			Handler:       _QueueService_WatchJobs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/queue.proto",
 }